@@ -0,0 +1,321 @@
+/*
+Package assets provides template functions emitting the script and stylesheet
+tags of bundled assets. The hashed file names are read from the manifest written
+by the bundler, e.g. Vite with `build.manifest` or webpack with
+webpack-manifest-plugin, and the tags carry subresource integrity attributes.
+
+Example:
+
+	m, err := assets.New(assets.Config{
+	  Manifest: "dist/.vite/manifest.json",
+	  Root:     "dist",
+	  Prefix:   "/static/",
+	  Module:   true,
+	})
+	if err != nil {
+	  e.Logger.Fatal(err)
+	}
+	e.Static("/static", "dist")
+	t := template.Must(template.New("").Funcs(m.FuncMap()).ParseGlob("views/*.html"))
+
+	<!-- views/index.html -->
+	{{ script "src/main.ts" }}
+	<img src="{{ asset "src/logo.svg" }}">
+*/
+package assets
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+type (
+	// Config defines the config for a manifest.
+	Config struct {
+		// Manifest is the path of the manifest file.
+		// Required.
+		Manifest string
+
+		// Root is the directory of the built assets, used to compute the
+		// integrity of assets the manifest has no integrity for.
+		// Optional. Default value is the directory of the manifest.
+		Root string
+
+		// Prefix is the URL path prefix the assets are served at.
+		// Optional. Default value "/".
+		Prefix string
+
+		// Algorithm is the hash algorithm of the integrity attributes, either
+		// "sha256", "sha384" or "sha512".
+		// Optional. Default value "sha384".
+		Algorithm string
+
+		// CrossOrigin is the value of the "crossorigin" attribute of the tags.
+		// Optional. Default value "anonymous".
+		CrossOrigin string
+
+		// Module emits scripts with `type="module"`, as required by Vite.
+		// Optional. Default value false.
+		Module bool
+
+		// DisableIntegrity leaves the integrity attributes out, e.g. in development.
+		// Optional. Default value false.
+		DisableIntegrity bool
+	}
+
+	// Manifest maps the names of the source assets to their built files.
+	Manifest struct {
+		config  Config
+		entries map[string]*Entry
+
+		mu        sync.Mutex
+		integrity map[string]string
+	}
+
+	// Entry is an asset of a manifest.
+	Entry struct {
+		// File is the path of the built file, relative to the root.
+		File string
+
+		// Integrity is the integrity of the file, if the manifest has it.
+		Integrity string
+
+		// CSS lists the stylesheets imported by a script.
+		CSS []string
+	}
+)
+
+var (
+	// DefaultConfig is the default manifest config.
+	DefaultConfig = Config{
+		Prefix:      "/",
+		Algorithm:   "sha384",
+		CrossOrigin: "anonymous",
+	}
+
+	// ErrNotFound is returned for assets missing from the manifest.
+	ErrNotFound = errors.New("assets: asset not found in manifest")
+)
+
+// New reads the manifest with config.
+func New(config Config) (*Manifest, error) {
+	if config.Manifest == "" {
+		panic("echo: assets requires manifest")
+	}
+	// Defaults
+	if config.Root == "" {
+		config.Root = filepath.Dir(config.Manifest)
+	}
+	if config.Prefix == "" {
+		config.Prefix = DefaultConfig.Prefix
+	}
+	if !strings.HasSuffix(config.Prefix, "/") {
+		config.Prefix += "/"
+	}
+	if config.Algorithm == "" {
+		config.Algorithm = DefaultConfig.Algorithm
+	}
+	if newHash(config.Algorithm) == nil {
+		return nil, fmt.Errorf("assets: unsupported algorithm %q", config.Algorithm)
+	}
+	if config.CrossOrigin == "" {
+		config.CrossOrigin = DefaultConfig.CrossOrigin
+	}
+
+	b, err := ioutil.ReadFile(config.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parse(b)
+	if err != nil {
+		return nil, fmt.Errorf("assets: invalid manifest %s: %v", config.Manifest, err)
+	}
+	return &Manifest{
+		config:    config,
+		entries:   entries,
+		integrity: map[string]string{},
+	}, nil
+}
+
+// parse reads manifests mapping names either to file names, as written by
+// webpack-manifest-plugin, or to objects with a "file" (Vite) or "src"
+// (webpack-assets-manifest) key and optional "integrity" and "css" keys.
+func parse(b []byte) (map[string]*Entry, error) {
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, err
+	}
+	entries := make(map[string]*Entry, len(raw))
+	for name, v := range raw {
+		var file string
+		if err := json.Unmarshal(v, &file); err == nil {
+			entries[name] = &Entry{File: strings.TrimPrefix(file, "/")}
+			continue
+		}
+		var obj struct {
+			File      string   `json:"file"`
+			Src       string   `json:"src"`
+			Integrity string   `json:"integrity"`
+			CSS       []string `json:"css"`
+		}
+		if err := json.Unmarshal(v, &obj); err != nil {
+			return nil, fmt.Errorf("entry %q: %v", name, err)
+		}
+		if obj.File == "" {
+			obj.File = obj.Src
+		}
+		if obj.File == "" {
+			return nil, fmt.Errorf("entry %q: missing file", name)
+		}
+		entries[name] = &Entry{
+			File:      strings.TrimPrefix(obj.File, "/"),
+			Integrity: obj.Integrity,
+			CSS:       obj.CSS,
+		}
+	}
+	return entries, nil
+}
+
+// Entry returns the entry of the asset, or `ErrNotFound`.
+func (m *Manifest) Entry(name string) (*Entry, error) {
+	e, ok := m.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return e, nil
+}
+
+// URL returns the URL path of the asset.
+func (m *Manifest) URL(name string) (string, error) {
+	e, err := m.Entry(name)
+	if err != nil {
+		return "", err
+	}
+	return m.config.Prefix + e.File, nil
+}
+
+// Integrity returns the integrity of the asset, computing it from the built
+// file if the manifest doesn't have it.
+func (m *Manifest) Integrity(name string) (string, error) {
+	e, err := m.Entry(name)
+	if err != nil {
+		return "", err
+	}
+	if e.Integrity != "" {
+		return e.Integrity, nil
+	}
+	return m.fileIntegrity(e.File)
+}
+
+// Script returns the script tag of the asset, preceded by the stylesheet tags of
+// the CSS it imports.
+func (m *Manifest) Script(name string) (template.HTML, error) {
+	e, err := m.Entry(name)
+	if err != nil {
+		return "", err
+	}
+	b := new(strings.Builder)
+	for _, css := range e.CSS {
+		if err := m.tag(b, `<link rel="stylesheet" href="%s"%s>`, css, ""); err != nil {
+			return "", err
+		}
+	}
+	format := `<script src="%s"%s></script>`
+	if m.config.Module {
+		format = `<script type="module" src="%s"%s></script>`
+	}
+	if err := m.tag(b, format, e.File, e.Integrity); err != nil {
+		return "", err
+	}
+	return template.HTML(b.String()), nil
+}
+
+// Stylesheet returns the stylesheet tag of the asset.
+func (m *Manifest) Stylesheet(name string) (template.HTML, error) {
+	e, err := m.Entry(name)
+	if err != nil {
+		return "", err
+	}
+	b := new(strings.Builder)
+	if err := m.tag(b, `<link rel="stylesheet" href="%s"%s>`, e.File, e.Integrity); err != nil {
+		return "", err
+	}
+	return template.HTML(b.String()), nil
+}
+
+// FuncMap returns the template functions of the manifest:
+//
+// - "asset" returns the URL path of an asset
+// - "script" returns the script tag of an asset
+// - "stylesheet" returns the stylesheet tag of an asset
+// - "integrity" returns the integrity of an asset
+func (m *Manifest) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"asset":      m.URL,
+		"script":     m.Script,
+		"stylesheet": m.Stylesheet,
+		"integrity":  m.Integrity,
+	}
+}
+
+// tag writes the tag for the file, with the attributes added by format.
+func (m *Manifest) tag(w io.Writer, format, file, integrity string) (err error) {
+	attrs := ""
+	if !m.config.DisableIntegrity {
+		if integrity == "" {
+			if integrity, err = m.fileIntegrity(file); err != nil {
+				return
+			}
+		}
+		attrs = fmt.Sprintf(` integrity="%s" crossorigin="%s"`,
+			template.HTMLEscapeString(integrity), template.HTMLEscapeString(m.config.CrossOrigin))
+	}
+	_, err = fmt.Fprintf(w, format, template.HTMLEscapeString(m.config.Prefix+file), attrs)
+	return
+}
+
+// fileIntegrity computes the integrity of the built file, caching it.
+func (m *Manifest) fileIntegrity(file string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if v, ok := m.integrity[file]; ok {
+		return v, nil
+	}
+	f, err := os.Open(filepath.Join(m.config.Root, filepath.FromSlash(path.Clean("/"+file))))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := newHash(m.config.Algorithm)
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	v := m.config.Algorithm + "-" + base64.StdEncoding.EncodeToString(h.Sum(nil))
+	m.integrity[file] = v
+	return v, nil
+}
+
+func newHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case "sha256":
+		return sha256.New()
+	case "sha384":
+		return sha512.New384()
+	case "sha512":
+		return sha512.New()
+	}
+	return nil
+}
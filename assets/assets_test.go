@@ -0,0 +1,102 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "assets")
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if !assert.NoError(t, ioutil.WriteFile(p, []byte(content), 0644)) {
+			t.FailNow()
+		}
+	}
+	return dir
+}
+
+func sri(content string) string {
+	sum := sha512.Sum384([]byte(content))
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func TestManifestVite(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		".vite/manifest.json": `{
+			"src/main.ts": {"file": "assets/main.4889e940.js", "css": ["assets/main.b82dbe22.css"], "isEntry": true},
+			"src/logo.svg": {"file": "assets/logo.1a2b3c.svg", "integrity": "sha384-logo"}
+		}`,
+		"assets/main.4889e940.js":  "console.log(1)",
+		"assets/main.b82dbe22.css": "body{}",
+	})
+	defer os.RemoveAll(dir)
+
+	m, err := New(Config{Manifest: filepath.Join(dir, ".vite/manifest.json"), Root: dir, Prefix: "/static", Module: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	tpl := template.Must(template.New("page").Funcs(m.FuncMap()).Parse(
+		`{{ script "src/main.ts" }}<img src="{{ asset "src/logo.svg" }}" integrity="{{ integrity "src/logo.svg" }}">`))
+	buf := new(bytes.Buffer)
+	assert.NoError(t, tpl.Execute(buf, nil))
+	assert.Equal(t,
+		`<link rel="stylesheet" href="/static/assets/main.b82dbe22.css" integrity="`+sri("body{}")+`" crossorigin="anonymous">`+
+			`<script type="module" src="/static/assets/main.4889e940.js" integrity="`+sri("console.log(1)")+`" crossorigin="anonymous"></script>`+
+			`<img src="/static/assets/logo.1a2b3c.svg" integrity="sha384-logo">`,
+		buf.String())
+
+	// Missing asset
+	_, err = m.Script("src/missing.ts")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.Error(t, template.Must(template.New("page").Funcs(m.FuncMap()).Parse(`{{ script "src/missing.ts" }}`)).Execute(buf, nil))
+}
+
+func TestManifestWebpack(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"manifest.json": `{"main.js": "/main.123.js", "main.css": {"src": "main.456.css", "integrity": "sha384-css"}}`,
+		"main.123.js":   "alert(1)",
+	})
+	defer os.RemoveAll(dir)
+
+	m, err := New(Config{Manifest: filepath.Join(dir, "manifest.json")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	html, err := m.Script("main.js")
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML(`<script src="/main.123.js" integrity="`+sri("alert(1)")+`" crossorigin="anonymous"></script>`), html)
+	html, err = m.Stylesheet("main.css")
+	assert.NoError(t, err)
+	assert.Equal(t, template.HTML(`<link rel="stylesheet" href="/main.456.css" integrity="sha384-css" crossorigin="anonymous">`), html)
+
+	// Integrity disabled
+	m, err = New(Config{Manifest: filepath.Join(dir, "manifest.json"), DisableIntegrity: true})
+	assert.NoError(t, err)
+	html, _ = m.Stylesheet("main.css")
+	assert.Equal(t, template.HTML(`<link rel="stylesheet" href="/main.456.css">`), html)
+}
+
+func TestManifestInvalid(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"manifest.json": `{"main.js": {"name": "main"}}`})
+	defer os.RemoveAll(dir)
+
+	_, err := New(Config{Manifest: filepath.Join(dir, "manifest.json")})
+	assert.EqualError(t, err, `assets: invalid manifest `+filepath.Join(dir, "manifest.json")+`: entry "main.js": missing file`)
+	_, err = New(Config{Manifest: filepath.Join(dir, "manifest.json"), Algorithm: "md5"})
+	assert.EqualError(t, err, `assets: unsupported algorithm "md5"`)
+	assert.Panics(t, func() { New(Config{}) })
+}
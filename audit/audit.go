@@ -0,0 +1,267 @@
+/*
+Package audit records audit events: who did what to which resource, and with
+which outcome. Unlike access logs, only the actions of the routes opting in are
+recorded, as structured events written to an append-only sink, e.g. a write
+once bucket or a dedicated table, for compliance.
+
+The action of a route is set with its meta, and its target is the path
+parameter named by the meta, or set by the handler with `SetTarget()`.
+
+Example:
+
+	a, err := audit.New(audit.Config{
+	  Sink: audit.NewWriterSink(file),
+	})
+	if err != nil {
+	  e.Logger.Fatal(err)
+	}
+	e.Use(a.Middleware())
+	e.DELETE("/users/:id", deleteUser).
+	  SetMeta(audit.MetaAction, "user.delete").
+	  SetMeta(audit.MetaTarget, "id")
+*/
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type (
+	// Config defines the config for audit events.
+	Config struct {
+		// Skipper defines a function to skip middleware.
+		Skipper middleware.Skipper
+
+		// Sink receives the events.
+		// Required.
+		Sink Sink
+
+		// Actor returns the actor of a request.
+		// Optional. Default value returns the ID of the principal, see
+		// `middleware.Authn()`.
+		Actor func(c echo.Context) string
+	}
+
+	// Event is an audit event.
+	Event struct {
+		Time      time.Time              `json:"time"`
+		Actor     string                 `json:"actor,omitempty"`
+		Action    string                 `json:"action"`
+		Target    string                 `json:"target,omitempty"`
+		Outcome   Outcome                `json:"outcome"`
+		Status    int                    `json:"status,omitempty"`
+		Method    string                 `json:"method,omitempty"`
+		Path      string                 `json:"path,omitempty"`
+		RemoteIP  string                 `json:"remote_ip,omitempty"`
+		RequestID string                 `json:"request_id,omitempty"`
+		Details   map[string]interface{} `json:"details,omitempty"`
+	}
+
+	// Outcome is the outcome of an audited action.
+	Outcome string
+
+	// Sink receives audit events. Write must be safe for concurrent use.
+	Sink interface {
+		Write(e *Event) error
+	}
+
+	// SinkFunc is an adapter to use functions as sinks.
+	SinkFunc func(e *Event) error
+
+	// WriterSink is a `Sink` appending the events to a writer as JSON lines.
+	WriterSink struct {
+		mu sync.Mutex
+		w  io.Writer
+	}
+
+	// Auditor records audit events.
+	Auditor struct {
+		config Config
+	}
+
+	// record holds the target and details of the event of a request.
+	record struct {
+		action  string
+		target  string
+		details map[string]interface{}
+	}
+)
+
+// Route meta keys
+const (
+	// MetaAction is the route meta key of the audited action (string), e.g.
+	// "user.delete". Requests to routes without action aren't audited.
+	MetaAction = "audit.action"
+
+	// MetaTarget is the route meta key of the name (string) of the path
+	// parameter identifying the target of the action, e.g. "id".
+	MetaTarget = "audit.target"
+)
+
+// Outcomes
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+	OutcomeDenied  Outcome = "denied"
+)
+
+const recordKey = "audit.record"
+
+// New returns an `Auditor` with config.
+func New(config Config) (*Auditor, error) {
+	// Defaults
+	if config.Sink == nil {
+		return nil, errors.New("audit: sink is required")
+	}
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+	if config.Actor == nil {
+		config.Actor = defaultActor
+	}
+	return &Auditor{config: config}, nil
+}
+
+// Middleware returns a middleware recording an event for the requests to
+// routes with the `MetaAction` meta, or whose handler called `SetAction()`,
+// after the handler returns. Responses with status 401 and 403 are denied
+// outcomes, errors and other error statuses are failures. Events which can't
+// be written are logged.
+func (a *Auditor) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if a.config.Skipper(c) {
+				return next(c)
+			}
+
+			r := &record{}
+			if route := c.Echo().MatchedRoute(c); route != nil {
+				meta := route.Meta()
+				r.action, _ = meta[MetaAction].(string)
+				if name, ok := meta[MetaTarget].(string); ok {
+					r.target = c.Param(name)
+				}
+			}
+			c.Set(recordKey, r)
+			err := next(c)
+			if r.action == "" {
+				return err
+			}
+
+			status := c.Response().Status
+			if err != nil {
+				// The error handler has not run yet, predict its status code
+				status = http.StatusInternalServerError
+				var he *echo.HTTPError
+				if errors.As(err, &he) {
+					status = he.Code
+				}
+			}
+			outcome := OutcomeSuccess
+			switch {
+			case status == http.StatusUnauthorized || status == http.StatusForbidden:
+				outcome = OutcomeDenied
+			case err != nil || status >= http.StatusBadRequest:
+				outcome = OutcomeFailure
+			}
+			e := a.event(c, r.action, r.target, outcome, r.details)
+			e.Status = status
+			if werr := a.config.Sink.Write(e); werr != nil {
+				c.Logger().Errorf("audit: %v", werr)
+			}
+			return err
+		}
+	}
+}
+
+// Record writes an event for an action outside of the audited routes, e.g. a
+// failed sign in.
+func (a *Auditor) Record(c echo.Context, action, target string, outcome Outcome, details map[string]interface{}) error {
+	return a.config.Sink.Write(a.event(c, action, target, outcome, details))
+}
+
+func (a *Auditor) event(c echo.Context, action, target string, outcome Outcome, details map[string]interface{}) *Event {
+	req := c.Request()
+	id := req.Header.Get(echo.HeaderXRequestID)
+	if id == "" {
+		id = c.Response().Header().Get(echo.HeaderXRequestID)
+	}
+	return &Event{
+		Time:      time.Now().UTC(),
+		Actor:     a.config.Actor(c),
+		Action:    action,
+		Target:    target,
+		Outcome:   outcome,
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		RemoteIP:  c.RealIP(),
+		RequestID: id,
+		Details:   details,
+	}
+}
+
+// SetAction sets the action of the event of the request, overriding the
+// `MetaAction` meta of the route.
+func SetAction(c echo.Context, action string) {
+	if r, ok := c.Get(recordKey).(*record); ok {
+		r.action = action
+	}
+}
+
+// SetTarget sets the target of the event of the request, e.g. the ID of a
+// created resource.
+func SetTarget(c echo.Context, target string) {
+	if r, ok := c.Get(recordKey).(*record); ok {
+		r.target = target
+	}
+}
+
+// AddDetail adds a key/value pair to the details of the event of the request,
+// e.g. the changed fields.
+func AddDetail(c echo.Context, key string, val interface{}) {
+	if r, ok := c.Get(recordKey).(*record); ok {
+		if r.details == nil {
+			r.details = map[string]interface{}{}
+		}
+		r.details[key] = val
+	}
+}
+
+// Write implements `Sink`.
+func (fn SinkFunc) Write(e *Event) error {
+	return fn(e)
+}
+
+// NewWriterSink returns a `WriterSink` appending to `w`, e.g. a file opened
+// with `os.O_APPEND`.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Write implements `Sink`.
+func (s *WriterSink) Write(e *Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
+
+// defaultActor returns the ID of the principal of the request.
+func defaultActor(c echo.Context) string {
+	if p := c.Principal(); p != nil {
+		return p.ID
+	}
+	return ""
+}
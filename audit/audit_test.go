@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditor(t *testing.T) {
+	var events []*Event
+	a, err := New(Config{Sink: SinkFunc(func(e *Event) error {
+		events = append(events, e)
+		return nil
+	})})
+	if !assert.NoError(t, err) {
+		return
+	}
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if user := c.Request().Header.Get("X-User"); user != "" {
+				c.SetPrincipal(&echo.Principal{ID: user})
+			}
+			return next(c)
+		}
+	})
+	e.Use(a.Middleware())
+	e.DELETE("/users/:id", func(c echo.Context) error {
+		if c.Request().Header.Get("X-User") != "admin" {
+			return echo.ErrForbidden
+		}
+		AddDetail(c, "reason", "spam")
+		return c.NoContent(http.StatusNoContent)
+	}).SetMeta(MetaAction, "user.delete").SetMeta(MetaTarget, "id")
+	e.POST("/users", func(c echo.Context) error {
+		SetTarget(c, "42")
+		return errors.New("db down")
+	}).SetMeta(MetaAction, "user.create")
+	e.GET("/users", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	serve := func(method, path, user string) {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-User", user)
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	serve(http.MethodDelete, "/users/7", "admin")
+	serve(http.MethodDelete, "/users/8", "jon")
+	serve(http.MethodPost, "/users", "admin")
+	serve(http.MethodGet, "/users", "admin")
+	if !assert.Len(t, events, 3) {
+		return
+	}
+	assert.Equal(t, "admin", events[0].Actor)
+	assert.Equal(t, "user.delete", events[0].Action)
+	assert.Equal(t, "7", events[0].Target)
+	assert.Equal(t, OutcomeSuccess, events[0].Outcome)
+	assert.Equal(t, http.StatusNoContent, events[0].Status)
+	assert.Equal(t, map[string]interface{}{"reason": "spam"}, events[0].Details)
+	assert.Equal(t, "/users/7", events[0].Path)
+
+	assert.Equal(t, "jon", events[1].Actor)
+	assert.Equal(t, OutcomeDenied, events[1].Outcome)
+	assert.Equal(t, http.StatusForbidden, events[1].Status)
+
+	assert.Equal(t, "user.create", events[2].Action)
+	assert.Equal(t, "42", events[2].Target)
+	assert.Equal(t, OutcomeFailure, events[2].Outcome)
+	assert.Equal(t, http.StatusInternalServerError, events[2].Status)
+
+	// Manual events
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/login", nil), httptest.NewRecorder())
+	assert.NoError(t, a.Record(c, "session.create", "jon", OutcomeDenied, nil))
+	assert.Equal(t, "session.create", events[3].Action)
+
+	_, err = New(Config{})
+	assert.Error(t, err)
+}
+
+func TestWriterSink(t *testing.T) {
+	buf := new(bytes.Buffer)
+	s := NewWriterSink(buf)
+	assert.NoError(t, s.Write(&Event{Action: "user.delete", Outcome: OutcomeSuccess}))
+	assert.NoError(t, s.Write(&Event{Action: "user.create", Outcome: OutcomeFailure}))
+
+	d := json.NewDecoder(buf)
+	var e Event
+	if assert.NoError(t, d.Decode(&e)) {
+		assert.Equal(t, "user.delete", e.Action)
+	}
+	if assert.NoError(t, d.Decode(&e)) {
+		assert.Equal(t, OutcomeFailure, e.Outcome)
+	}
+}
@@ -1,13 +1,19 @@
 package echo
 
 import (
+	"bytes"
 	"encoding"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -19,7 +25,35 @@ type (
 	}
 
 	// DefaultBinder is the default implementation of the Binder interface.
-	DefaultBinder struct{}
+	DefaultBinder struct {
+		// DisallowUnknownFields rejects JSON bodies with fields not matching
+		// the bound type with a 400 listing the unknown fields, e.g. to catch
+		// client typos instead of silently dropping the data. It can be enabled
+		// per call with `Context#BindStrict()`.
+		DisallowUnknownFields bool
+
+		// ListSeparator splits the parameter values bound into slice fields, so
+		// that both `?id=1&id=2` and `?id=1,2` bind into `[]int{1, 2}`.
+		// Optional. Default value "" (values are not split).
+		ListSeparator string
+	}
+
+	// BindingError describes a request value that couldn't be bound into a
+	// field.
+	BindingError struct {
+		// Field is the name of the parameter, or the path of the JSON field, e.g.
+		// "items.qty".
+		Field string `json:"field"`
+
+		// Values holds the request values of the field, if known.
+		Values []string `json:"values,omitempty"`
+
+		Message  string `json:"message"`
+		Internal error  `json:"-"`
+	}
+
+	// BindingErrors is a list of binding errors.
+	BindingErrors []*BindingError
 
 	// BindUnmarshaler is the interface used to wrap the UnmarshalParam method.
 	// Types that don't implement this, but do implement encoding.TextUnmarshaler
@@ -30,9 +64,69 @@ type (
 	}
 )
 
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+func newBindingError(field string, values []string, err error) *BindingError {
+	msg := err.Error()
+	if ne, ok := err.(*strconv.NumError); ok {
+		msg = ne.Err.Error()
+	}
+	return &BindingError{Field: field, Values: values, Message: msg, Internal: err}
+}
+
+// Error makes it compatible with `error` interface.
+func (be *BindingError) Error() string {
+	return fmt.Sprintf("field=%s, message=%s", be.Field, be.Message)
+}
+
+// Unwrap satisfies the Go 1.13 error wrapper interface.
+func (be *BindingError) Unwrap() error {
+	return be.Internal
+}
+
+// Error makes it compatible with `error` interface.
+func (bes BindingErrors) Error() string {
+	msgs := make([]string, len(bes))
+	for i, be := range bes {
+		msgs[i] = be.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// BindingErrorsOf returns the binding errors of a `Binder#Bind()` error, with
+// JSON type mismatches mapped to the JSON field path. It returns nil if `err`
+// is not a binding error.
+func BindingErrorsOf(err error) BindingErrors {
+	var (
+		bes BindingErrors
+		be  *BindingError
+		ute *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &bes):
+		return bes
+	case errors.As(err, &be):
+		return BindingErrors{be}
+	case errors.As(err, &ute):
+		field := ute.Field
+		if field == "" {
+			field = ute.Struct
+		}
+		return BindingErrors{{
+			Field:    field,
+			Message:  fmt.Sprintf("expected %v, got %v", ute.Type, ute.Value),
+			Internal: ute,
+		}}
+	}
+	return nil
+}
+
 // Bind implements the `Binder#Bind` function.
 func (b *DefaultBinder) Bind(i interface{}, c Context) (err error) {
 	req := c.Request()
+	if err = applyDefaults(i); err != nil {
+		return
+	}
 
 	names := c.ParamNames()
 	values := c.ParamValues()
@@ -46,35 +140,42 @@ func (b *DefaultBinder) Bind(i interface{}, c Context) (err error) {
 	if err = b.bindData(i, c.QueryParams(), "query"); err != nil {
 		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 	}
+	if err = b.bindCookies(i, c); err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
 	if req.ContentLength == 0 {
 		return
 	}
 	ctype := req.Header.Get(HeaderContentType)
 	switch {
 	case strings.HasPrefix(ctype, MIMEApplicationJSON):
-		if err = json.NewDecoder(req.Body).Decode(i); err != nil {
-			if ute, ok := err.(*json.UnmarshalTypeError); ok {
-				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unmarshal type error: expected=%v, got=%v, field=%v, offset=%v", ute.Type, ute.Value, ute.Field, ute.Offset)).SetInternal(err)
-			} else if se, ok := err.(*json.SyntaxError); ok {
-				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: offset=%v, error=%v", se.Offset, se.Error())).SetInternal(err)
-			}
+		return b.bindJSON(i, c)
+	case strings.HasPrefix(ctype, MIMEApplicationXML), strings.HasPrefix(ctype, MIMETextXML):
+		return b.bindXML(i, c)
+	case strings.HasPrefix(ctype, MIMEApplicationForm), strings.HasPrefix(ctype, MIMEMultipartForm):
+		return b.bindForm(i, c)
+	case strings.HasPrefix(ctype, MIMEApplicationYAML), strings.HasPrefix(ctype, MIMETextYAML):
+		codec := c.Echo().YAML
+		if codec == nil {
+			return ErrUnsupportedMediaType
+		}
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
 			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
-	case strings.HasPrefix(ctype, MIMEApplicationXML), strings.HasPrefix(ctype, MIMETextXML):
-		if err = xml.NewDecoder(req.Body).Decode(i); err != nil {
-			if ute, ok := err.(*xml.UnsupportedTypeError); ok {
-				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unsupported type error: type=%v, error=%v", ute.Type, ute.Error())).SetInternal(err)
-			} else if se, ok := err.(*xml.SyntaxError); ok {
-				return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: line=%v, error=%v", se.Line, se.Error())).SetInternal(err)
-			}
+		if err = codec.Unmarshal(body, i); err != nil {
 			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
-	case strings.HasPrefix(ctype, MIMEApplicationForm), strings.HasPrefix(ctype, MIMEMultipartForm):
-		params, err := c.FormParams()
+	case strings.HasPrefix(ctype, MIMEApplicationTOML):
+		body, err := ioutil.ReadAll(req.Body)
 		if err != nil {
 			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
-		if err = b.bindData(i, params, "form"); err != nil {
+		if err = unmarshalTOML(body, i); err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+	case strings.HasPrefix(ctype, MIMETextCSV):
+		if err = b.bindCSV(i, req.Body); err != nil {
 			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
 		}
 	default:
@@ -83,6 +184,269 @@ func (b *DefaultBinder) Bind(i interface{}, c Context) (err error) {
 	return
 }
 
+// bindCookies binds the request cookies into the fields of a struct tagged with
+// `cookie`. Cookies are not bound into maps.
+func (b *DefaultBinder) bindCookies(ptr interface{}, c Context) error {
+	typ := reflect.TypeOf(ptr)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	cookies := c.Cookies()
+	data := make(map[string][]string, len(cookies))
+	for _, cookie := range cookies {
+		data[cookie.Name] = append(data[cookie.Name], cookie.Value)
+	}
+	return b.bindData(ptr, data, "cookie")
+}
+
+// BindJSON binds the JSON request body into provided type `i`, regardless of
+// the Content-Type header.
+func (b *DefaultBinder) BindJSON(i interface{}, c Context) error {
+	if err := applyDefaults(i); err != nil {
+		return err
+	}
+	return b.bindJSON(i, c)
+}
+
+func (b *DefaultBinder) bindJSON(i interface{}, c Context) (err error) {
+	req := c.Request()
+	if req.ContentLength == 0 {
+		return
+	}
+	var body []byte
+	dec := json.NewDecoder(req.Body)
+	if b.DisallowUnknownFields {
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+		}
+		dec = json.NewDecoder(bytes.NewReader(body))
+		dec.DisallowUnknownFields()
+	}
+	if err = dec.Decode(i); err != nil {
+		if ute, ok := err.(*json.UnmarshalTypeError); ok {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unmarshal type error: expected=%v, got=%v, field=%v, offset=%v", ute.Type, ute.Value, ute.Field, ute.Offset)).SetInternal(err)
+		} else if se, ok := err.(*json.SyntaxError); ok {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: offset=%v, error=%v", se.Offset, se.Error())).SetInternal(err)
+		} else if strings.HasPrefix(err.Error(), "json: unknown field ") {
+			fields := unknownJSONFields(body, reflect.TypeOf(i), "")
+			bes := make(BindingErrors, len(fields))
+			for i, f := range fields {
+				bes[i] = &BindingError{Field: f, Message: "unknown field", Internal: err}
+			}
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unknown fields: %s", strings.Join(fields, ", "))).SetInternal(bes)
+		}
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return
+}
+
+// unknownJSONFields returns the paths of the fields in JSON `data` that don't
+// match a field of type `t`, e.g. "user.emial" or "items[1].qty".
+func unknownJSONFields(data []byte, t reflect.Type, path string) (unknown []string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(jsonUnmarshalerType) {
+		return
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		var m map[string]json.RawMessage
+		if json.Unmarshal(data, &m) != nil {
+			return
+		}
+		fields := map[string]reflect.Type{}
+		jsonFields(t, fields)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			ft, ok := fields[strings.ToLower(k)]
+			if !ok {
+				unknown = append(unknown, p)
+				continue
+			}
+			unknown = append(unknown, unknownJSONFields(m[k], ft, p)...)
+		}
+	case reflect.Slice, reflect.Array:
+		var a []json.RawMessage
+		if json.Unmarshal(data, &a) != nil {
+			return
+		}
+		for i, v := range a {
+			unknown = append(unknown, unknownJSONFields(v, t.Elem(), fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	case reflect.Map:
+		var m map[string]json.RawMessage
+		if json.Unmarshal(data, &m) != nil {
+			return
+		}
+		for k, v := range m {
+			unknown = append(unknown, unknownJSONFields(v, t.Elem(), path+"."+k)...)
+		}
+		sort.Strings(unknown)
+	}
+	return
+}
+
+// jsonFields collects the lowercase JSON names of the fields of struct type
+// `t`, including promoted fields of embedded structs.
+func jsonFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			jsonFields(ft, fields)
+			continue
+		}
+		if f.PkgPath != "" { // Unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+}
+
+// BindXML binds the XML request body into provided type `i`, regardless of the
+// Content-Type header.
+func (b *DefaultBinder) BindXML(i interface{}, c Context) error {
+	if err := applyDefaults(i); err != nil {
+		return err
+	}
+	return b.bindXML(i, c)
+}
+
+func (b *DefaultBinder) bindXML(i interface{}, c Context) (err error) {
+	req := c.Request()
+	if req.ContentLength == 0 {
+		return
+	}
+	if err = xml.NewDecoder(req.Body).Decode(i); err != nil {
+		if ute, ok := err.(*xml.UnsupportedTypeError); ok {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Unsupported type error: type=%v, error=%v", ute.Type, ute.Error())).SetInternal(err)
+		} else if se, ok := err.(*xml.SyntaxError); ok {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Syntax error: line=%v, error=%v", se.Line, se.Error())).SetInternal(err)
+		}
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return
+}
+
+// BindForm binds the form request body into provided type `i`. Unless the
+// Content-Type header is multipart/form-data, the body is decoded as URL
+// encoded form regardless of the header. Query parameters are bound too, like
+// with `Context#FormParams()`.
+func (b *DefaultBinder) BindForm(i interface{}, c Context) error {
+	if err := applyDefaults(i); err != nil {
+		return err
+	}
+	return b.bindForm(i, c)
+}
+
+func (b *DefaultBinder) bindForm(i interface{}, c Context) error {
+	var (
+		params url.Values
+		err    error
+	)
+	req := c.Request()
+	if strings.HasPrefix(req.Header.Get(HeaderContentType), MIMEMultipartForm) {
+		params, err = c.FormParams()
+	} else {
+		params, err = formParams(req)
+	}
+	if err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	if err = b.bindData(i, params, "form"); err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	return nil
+}
+
+// formParams parses the URL encoded request body, followed by the query
+// parameters.
+func formParams(req *http.Request) (url.Values, error) {
+	params := url.Values{}
+	if req.ContentLength != 0 {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		if params, err = url.ParseQuery(string(body)); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range req.URL.Query() {
+		params[k] = append(params[k], v...)
+	}
+	return params, nil
+}
+
+// bindCSV binds a CSV body into a slice of structs (or maps). The first record
+// holds the column names, which are matched against the `csv` tag of the
+// fields like form parameters.
+func (b *DefaultBinder) bindCSV(ptr interface{}, r io.Reader) error {
+	val := reflect.ValueOf(ptr)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Slice {
+		return errors.New("binding element must be a slice for CSV")
+	}
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return err
+	}
+	slice := val.Elem()
+	slice.Set(slice.Slice(0, 0))
+	if len(records) < 2 {
+		return nil
+	}
+	columns, elemType := records[0], slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	for n, record := range records[1:] {
+		data := make(map[string][]string, len(columns))
+		for j, column := range columns {
+			data[column] = []string{record[j]}
+		}
+		elem := reflect.New(elemType)
+		if elemType.Kind() == reflect.Map {
+			elem.Elem().Set(reflect.MakeMap(elemType))
+		}
+		if err := applyDefaults(elem.Interface()); err != nil {
+			return err
+		}
+		if err := b.bindData(elem.Interface(), data, "csv"); err != nil {
+			if be, ok := err.(*BindingError); ok {
+				be.Field = fmt.Sprintf("[%d].%s", n, be.Field)
+				return be
+			}
+			return fmt.Errorf("record %d: %v", n+1, err)
+		}
+		if isPtr {
+			slice.Set(reflect.Append(slice, elem))
+		} else {
+			slice.Set(reflect.Append(slice, elem.Elem()))
+		}
+	}
+	return nil
+}
+
 func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag string) error {
 	if ptr == nil || len(data) == 0 {
 		return nil
@@ -131,6 +495,7 @@ func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag
 			// case-insensitive search.
 			for k, v := range data {
 				if strings.EqualFold(k, inputFieldName) {
+					inputFieldName = k
 					inputValue = v
 					exists = true
 					break
@@ -145,29 +510,104 @@ func (b *DefaultBinder) bindData(ptr interface{}, data map[string][]string, tag
 		// Call this first, in case we're dealing with an alias to an array type
 		if ok, err := unmarshalField(typeField.Type.Kind(), inputValue[0], structField); ok {
 			if err != nil {
-				return err
+				return newBindingError(inputFieldName, inputValue, err)
 			}
 			continue
 		}
 
+		if structFieldKind == reflect.Slice && b.ListSeparator != "" {
+			if inputValue = splitValues(inputValue, b.ListSeparator); len(inputValue) == 0 {
+				continue
+			}
+		}
 		numElems := len(inputValue)
 		if structFieldKind == reflect.Slice && numElems > 0 {
 			sliceOf := structField.Type().Elem().Kind()
 			slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
 			for j := 0; j < numElems; j++ {
 				if err := setWithProperType(sliceOf, inputValue[j], slice.Index(j)); err != nil {
-					return err
+					return newBindingError(inputFieldName, inputValue, err)
 				}
 			}
 			val.Field(i).Set(slice)
 		} else if err := setWithProperType(typeField.Type.Kind(), inputValue[0], structField); err != nil {
-			return err
+			return newBindingError(inputFieldName, inputValue, err)
+		}
+	}
+	return nil
+}
+
+// applyDefaults sets the zero value fields with a `default` tag of the struct
+// `ptr` points to, including nested structs, to the tag value. Values of slice
+// fields are separated by commas. The request values bound afterwards override
+// the defaults.
+func applyDefaults(ptr interface{}) error {
+	val := reflect.ValueOf(ptr)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return setDefaults(val.Elem())
+}
+
+func setDefaults(val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := val.Field(i)
+		def, ok := typ.Field(i).Tag.Lookup("default")
+		if !ok {
+			// Exported fields of unexported embedded structs are settable too
+			if field.Kind() == reflect.Struct {
+				if err := setDefaults(field); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !field.CanSet() || !field.IsZero() {
+			continue
+		}
+		if err := setDefault(field, def); err != nil {
+			return fmt.Errorf("invalid default value %q of field %s: %v", def, typ.Field(i).Name, err)
+		}
+	}
+	return nil
+}
 
+func setDefault(field reflect.Value, def string) error {
+	if field.Kind() == reflect.Ptr {
+		field.Set(reflect.New(field.Type().Elem()))
+		field = field.Elem()
+	}
+	if ok, err := unmarshalField(field.Kind(), def, field); ok {
+		return err
+	}
+	if field.Kind() != reflect.Slice {
+		return setWithProperType(field.Kind(), def, field)
+	}
+	values := strings.Split(def, ",")
+	slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+	for i, v := range values {
+		if err := setWithProperType(slice.Index(i).Kind(), v, slice.Index(i)); err != nil {
+			return err
 		}
 	}
+	field.Set(slice)
 	return nil
 }
 
+// splitValues splits each of the values by `sep`, dropping empty ones.
+func splitValues(values []string, sep string) []string {
+	split := make([]string, 0, len(values))
+	for _, v := range values {
+		for _, s := range strings.Split(v, sep) {
+			if s != "" {
+				split = append(split, s)
+			}
+		}
+	}
+	return split
+}
+
 func setWithProperType(valueKind reflect.Kind, val string, structField reflect.Value) error {
 	// But also call it here, in case we're dealing with an array of BindUnmarshalers
 	if ok, err := unmarshalField(valueKind, val, structField); ok {
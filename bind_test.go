@@ -180,6 +180,138 @@ func TestBindXML(t *testing.T) {
 	testBindError(assert, strings.NewReader(userXMLUnsupportedTypeError), MIMETextXML, &xml.SyntaxError{})
 }
 
+func TestBindYAML(t *testing.T) {
+	bind := func(e *Echo, body, ctype string) (*user, error) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, ctype)
+		u := new(user)
+		return u, e.NewContext(req, httptest.NewRecorder()).Bind(u)
+	}
+	e := New()
+	_, err := bind(e, userJSON, MIMEApplicationYAML)
+	assert.Equal(t, ErrUnsupportedMediaType, err)
+
+	e.YAML = jsonYAMLCodec{}
+	for _, ctype := range []string{MIMEApplicationYAML, MIMETextYAML} {
+		u, err := bind(e, userJSON, ctype)
+		if assert.NoError(t, err) {
+			assert.Equal(t, &user{1, "Jon Snow"}, u)
+		}
+		_, err = bind(e, invalidContent, ctype)
+		if assert.IsType(t, new(HTTPError), err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+		}
+	}
+}
+
+func TestBindTOML(t *testing.T) {
+	assert := assert.New(t)
+
+	testBindOkay(assert, strings.NewReader("id = 1\nname = \"Jon Snow\"\n"), MIMEApplicationTOML)
+	testBindError(assert, strings.NewReader(invalidContent), MIMEApplicationTOML, errors.New(""))
+	testBindError(assert, strings.NewReader(`id = "1"`), MIMEApplicationTOML, errors.New(""))
+}
+
+func TestBindExplicit(t *testing.T) {
+	e := New()
+	tests := []struct {
+		name  string
+		body  string
+		ctype string
+		bind  func(Context, interface{}) error
+	}{
+		{"JSON", userJSON, "", Context.BindJSON},
+		{"JSON", userJSON, MIMETextPlain, Context.BindJSON},
+		{"XML", userXML, MIMEApplicationJSON, Context.BindXML},
+		{"Form", userForm, "", Context.BindForm},
+		{"Form", userForm, MIMEOctetStream, Context.BindForm},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+		if tt.ctype != "" {
+			req.Header.Set(HeaderContentType, tt.ctype)
+		}
+		c := e.NewContext(req, httptest.NewRecorder())
+		u := new(user)
+		if assert.NoError(t, tt.bind(c, u), tt.name) {
+			assert.Equal(t, &user{1, "Jon Snow"}, u, tt.name)
+		}
+	}
+
+	// Multipart form
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	mw.WriteField("id", "1")
+	mw.WriteField("name", "Jon Snow")
+	mw.Close()
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(HeaderContentType, mw.FormDataContentType())
+	c := e.NewContext(req, httptest.NewRecorder())
+	u := new(user)
+	if assert.NoError(t, c.BindForm(u)) {
+		assert.Equal(t, &user{1, "Jon Snow"}, u)
+	}
+
+	// Invalid
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(invalidContent))
+	c = e.NewContext(req, httptest.NewRecorder())
+	err := c.BindJSON(u)
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+	}
+}
+
+func TestBindStrict(t *testing.T) {
+	type (
+		item struct {
+			SKU string `json:"sku"`
+			Qty int    `json:"qty"`
+		}
+		order struct {
+			user
+			Items []item            `json:"items"`
+			Meta  map[string]item   `json:"meta"`
+			Raw   json.RawMessage   `json:"raw"`
+			Tags  map[string]string `json:"-"`
+		}
+	)
+	body := `{"id":1,"emial":"jon@snow","NAME":"Jon","items":[{"sku":"a"},{"sku":"b","qyt":2}],"meta":{"x":{"sku":"c","color":1}},"raw":{"any":1},"tags":{}}`
+
+	e := New()
+	newContext := func() Context {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		return e.NewContext(req, httptest.NewRecorder())
+	}
+
+	// Unknown fields are dropped by default
+	assert.NoError(t, newContext().Bind(new(order)))
+
+	// Per call
+	err := newContext().BindStrict(new(order))
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+		assert.Equal(t, "Unknown fields: emial, items[1].qyt, meta.x.color, tags", err.(*HTTPError).Message)
+		if bes := BindingErrorsOf(err); assert.Len(t, bes, 4) {
+			assert.Equal(t, "meta.x.color", bes[2].Field)
+			assert.Equal(t, "unknown field", bes[2].Message)
+		}
+	}
+
+	// Global
+	e.Binder = &DefaultBinder{DisallowUnknownFields: true}
+	err = newContext().BindJSON(new(order))
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, "Unknown fields: emial, items[1].qyt, meta.x.color, tags", err.(*HTTPError).Message)
+	}
+	u := new(user)
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	if assert.NoError(t, e.NewContext(req, httptest.NewRecorder()).Bind(u)) {
+		assert.Equal(t, &user{1, "Jon Snow"}, u)
+	}
+}
+
 func TestBindForm(t *testing.T) {
 	assert := assert.New(t)
 
@@ -232,6 +364,87 @@ func TestBindQueryParamsCaseSensitivePrioritized(t *testing.T) {
 	}
 }
 
+func TestBindCSV(t *testing.T) {
+	e := New()
+	body := "id,name,active\n1,\"Snow, Jon\",true\n2,Arya,false\n"
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMETextCSV)
+	c := e.NewContext(req, httptest.NewRecorder())
+	type row struct {
+		ID     int    `csv:"id"`
+		Name   string `csv:"name"`
+		Active bool
+	}
+	var rows []row
+	if assert.NoError(t, c.Bind(&rows)) {
+		assert.Equal(t, []row{{1, "Snow, Jon", true}, {2, "Arya", false}}, rows)
+	}
+
+	// Pointers and maps
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMETextCSVCharsetUTF8)
+	c = e.NewContext(req, httptest.NewRecorder())
+	var ptrs []*row
+	if assert.NoError(t, c.Bind(&ptrs)) && assert.Len(t, ptrs, 2) {
+		assert.Equal(t, "Arya", ptrs[1].Name)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMETextCSV)
+	c = e.NewContext(req, httptest.NewRecorder())
+	var maps []map[string]string
+	if assert.NoError(t, c.Bind(&maps)) && assert.Len(t, maps, 2) {
+		assert.Equal(t, map[string]string{"id": "1", "name": "Snow, Jon", "active": "true"}, maps[0])
+	}
+
+	// Invalid
+	for _, b := range []string{"id\nx\n", "id,name\n1\n"} {
+		req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(b))
+		req.Header.Set(HeaderContentType, MIMETextCSV)
+		c = e.NewContext(req, httptest.NewRecorder())
+		err := c.Bind(&rows)
+		if assert.Error(t, err) {
+			assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+		}
+	}
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMETextCSV)
+	c = e.NewContext(req, httptest.NewRecorder())
+	assert.Error(t, c.Bind(&row{}))
+}
+
+func TestBindCookies(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodGet, "/?page=2", nil)
+	req.AddCookie(&http.Cookie{Name: "session_id", Value: "abc"})
+	req.AddCookie(&http.Cookie{Name: "variant", Value: "3"})
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	result := struct {
+		Page      int    `query:"page"`
+		SessionID string `cookie:"session_id"`
+		Variant   int    `cookie:"variant"`
+	}{}
+	err := c.Bind(&result)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 2, result.Page)
+		assert.Equal(t, "abc", result.SessionID)
+		assert.Equal(t, 3, result.Variant)
+	}
+
+	// Cookies are not bound into maps
+	m := map[string]interface{}{}
+	if assert.NoError(t, c.Bind(&m)) {
+		assert.Equal(t, map[string]interface{}{"page": "2"}, m)
+	}
+
+	// Invalid value
+	req.Header.Set("Cookie", "variant=b")
+	err = c.Bind(&result)
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*HTTPError).Code)
+	}
+}
+
 func TestBindUnmarshalParam(t *testing.T) {
 	e := New()
 	req := httptest.NewRequest(http.MethodGet, "/?ts=2016-12-06T19:09:05Z&sa=one,two,three&ta=2016-12-06T19:09:05Z&ta=2016-12-06T19:09:05Z&ST=baz", nil)
@@ -397,6 +610,158 @@ func TestBindUnmarshalTypeError(t *testing.T) {
 	assert.Equal(t, he, err)
 }
 
+func TestBindDefaults(t *testing.T) {
+	type (
+		filter struct {
+			Status string `query:"status" default:"active"`
+		}
+		page struct {
+			filter
+			Page    int       `query:"page" json:"page" default:"1"`
+			Limit   *int      `query:"limit" json:"limit" default:"20"`
+			Sort    []string  `query:"sort" default:"name,-id"`
+			Beta    bool      `query:"beta" default:"true"`
+			Since   time.Time `default:"2020-01-02T15:04:05Z"`
+			Options struct {
+				Format string `default:"json"`
+			}
+		}
+	)
+	e := New()
+	since, _ := time.Parse(time.RFC3339, "2020-01-02T15:04:05Z")
+	limit := 20
+
+	// Absent
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	p := new(page)
+	if assert.NoError(t, c.Bind(p)) {
+		assert.Equal(t, "active", p.Status)
+		assert.Equal(t, 1, p.Page)
+		assert.Equal(t, &limit, p.Limit)
+		assert.Equal(t, []string{"name", "-id"}, p.Sort)
+		assert.True(t, p.Beta)
+		assert.Equal(t, since, p.Since)
+		assert.Equal(t, "json", p.Options.Format)
+	}
+
+	// Present
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/?page=3&beta=false&sort=id", nil), httptest.NewRecorder())
+	p = new(page)
+	if assert.NoError(t, c.Bind(p)) {
+		assert.Equal(t, 3, p.Page)
+		assert.Equal(t, []string{"id"}, p.Sort)
+		assert.False(t, p.Beta)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"limit":5}`))
+	c = e.NewContext(req, httptest.NewRecorder())
+	p = new(page)
+	if assert.NoError(t, c.BindJSON(p)) {
+		assert.Equal(t, 1, p.Page)
+		assert.Equal(t, 5, *p.Limit)
+	}
+
+	// Preset values are kept
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	p = &page{Page: 2}
+	if assert.NoError(t, c.Bind(p)) {
+		assert.Equal(t, 2, p.Page)
+	}
+
+	// Invalid default
+	invalid := struct {
+		Page int `default:"one"`
+	}{}
+	assert.Error(t, c.Bind(&invalid))
+}
+
+func TestBindListSeparator(t *testing.T) {
+	type filter struct {
+		IDs  []int    `query:"id"`
+		Tags []string `query:"tag"`
+		Name string   `query:"name"`
+	}
+	e := New()
+	bind := func(target string) (*filter, error) {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, target, nil), httptest.NewRecorder())
+		f := new(filter)
+		return f, c.Bind(f)
+	}
+
+	// Repeated parameters
+	f, err := bind("/?id=1&id=2&tag=a,b")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{1, 2}, f.IDs)
+		assert.Equal(t, []string{"a,b"}, f.Tags)
+	}
+	_, err = bind("/?id=1,2")
+	assert.Error(t, err)
+
+	// Separated lists
+	e.Binder = &DefaultBinder{ListSeparator: ","}
+	f, err = bind("/?id=1,2,3&id=4&tag=a,,b&name=x,y")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{1, 2, 3, 4}, f.IDs)
+		assert.Equal(t, []string{"a", "b"}, f.Tags)
+		assert.Equal(t, "x,y", f.Name)
+	}
+	f, err = bind("/?id=,")
+	if assert.NoError(t, err) {
+		assert.Nil(t, f.IDs)
+	}
+	e.Binder = &DefaultBinder{ListSeparator: "|"}
+	f, err = bind("/?id=1|2")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []int{1, 2}, f.IDs)
+	}
+}
+
+func TestBindingErrors(t *testing.T) {
+	e := New()
+
+	// Parameters
+	req := httptest.NewRequest(http.MethodGet, "/?id=a", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+	err := c.Bind(new(user))
+	if assert.IsType(t, new(HTTPError), err) {
+		assert.Equal(t, "field=id, message=invalid syntax", err.(*HTTPError).Message)
+		bes := BindingErrorsOf(err)
+		if assert.Len(t, bes, 1) {
+			assert.Equal(t, "id", bes[0].Field)
+			assert.Equal(t, []string{"a"}, bes[0].Values)
+			assert.Equal(t, "invalid syntax", bes[0].Message)
+			assert.IsType(t, &strconv.NumError{}, errors.Unwrap(bes[0]))
+		}
+	}
+
+	// JSON type mismatch
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":"1"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c = e.NewContext(req, httptest.NewRecorder())
+	err = c.Bind(new(user))
+	assert.Equal(t, BindingErrors{{Field: "id", Message: "expected int, got string", Internal: err.(*HTTPError).Internal}}, BindingErrorsOf(err))
+
+	// CSV
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("id\n1\nx\n"))
+	req.Header.Set(HeaderContentType, MIMETextCSV)
+	c = e.NewContext(req, httptest.NewRecorder())
+	err = c.Bind(&[]user{})
+	if bes := BindingErrorsOf(err); assert.Len(t, bes, 1) {
+		assert.Equal(t, "[1].id", bes[0].Field)
+	}
+
+	assert.Nil(t, BindingErrorsOf(errors.New("error")))
+	assert.Nil(t, BindingErrorsOf(nil))
+
+	// Error handler
+	e.GET("/", func(c Context) error {
+		return c.Bind(new(user))
+	})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?id=a", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"message":"field=id, message=invalid syntax","errors":[{"field":"id","values":["a"],"message":"invalid syntax"}]}`, rec.Body.String())
+}
+
 func TestBindSetWithProperType(t *testing.T) {
 	assert := assert.New(t)
 	ts := new(bindTestStruct)
@@ -541,7 +906,8 @@ func testBindError(assert *assert.Assertions, r io.Reader, ctype string, expecte
 
 	switch {
 	case strings.HasPrefix(ctype, MIMEApplicationJSON), strings.HasPrefix(ctype, MIMEApplicationXML), strings.HasPrefix(ctype, MIMETextXML),
-		strings.HasPrefix(ctype, MIMEApplicationForm), strings.HasPrefix(ctype, MIMEMultipartForm):
+		strings.HasPrefix(ctype, MIMEApplicationForm), strings.HasPrefix(ctype, MIMEMultipartForm),
+		strings.HasPrefix(ctype, MIMEApplicationTOML):
 		if assert.IsType(new(HTTPError), err) {
 			assert.Equal(http.StatusBadRequest, err.(*HTTPError).Code)
 			assert.IsType(expectedInternal, err.(*HTTPError).Internal)
@@ -0,0 +1,152 @@
+/*
+Package client builds HTTP clients for calls to downstream services made while
+handling a request. Outgoing requests inherit the cancellation and deadline of
+the incoming request, its request ID and the trace headers of the
+`middleware.Trace()` span, so that calls are correlated without boilerplate.
+
+Example:
+
+	func getUser(c echo.Context) error {
+	  req, err := client.NewRequest(c, http.MethodGet, "http://users/users/"+c.Param("id"), nil)
+	  if err != nil {
+	    return err
+	  }
+	  res, err := client.For(c).Do(req)
+	  ...
+	}
+*/
+package client
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type (
+	// Config defines the config for outbound clients.
+	Config struct {
+		// Transport is the transport used to make requests.
+		// Optional. Default value `http.DefaultTransport`.
+		Transport http.RoundTripper
+
+		// Timeout limits the time of each outgoing request, in addition to the
+		// deadline of the incoming request.
+		// Optional. Default value 0 (none).
+		Timeout time.Duration
+
+		// Headers lists headers of the incoming request copied to outgoing
+		// requests, e.g. "Authorization" or "Accept-Language". They aren't
+		// copied to requests following a redirect to another host.
+		// Optional.
+		Headers []string
+	}
+
+	// Client creates HTTP clients bound to incoming requests.
+	Client struct {
+		config Config
+	}
+
+	// transport propagates the context of an incoming request to outgoing
+	// requests.
+	transport struct {
+		base    http.RoundTripper
+		c       echo.Context
+		headers []string
+	}
+)
+
+var (
+	// DefaultConfig is the default client config.
+	DefaultConfig = Config{}
+
+	defaultClient = New(DefaultConfig)
+)
+
+// New returns a client with config.
+func New(config Config) *Client {
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+	return &Client{config: config}
+}
+
+// For returns an `*http.Client` for requests made while handling the request of
+// the context, using the default config.
+func For(c echo.Context) *http.Client {
+	return defaultClient.For(c)
+}
+
+// For returns an `*http.Client` for requests made while handling the request of
+// the context. Outgoing requests:
+//
+// - are canceled with the incoming request, unless they have a cancelable
+// context of their own, see `NewRequest()`
+// - carry the request ID of the incoming request in the "X-Request-ID" header
+// - carry the trace headers of the span started by `middleware.Trace()`
+// - carry the headers listed in `Config.Headers`, unless redirected to another
+// host
+//
+// Headers already set on outgoing requests are kept.
+func (cl *Client) For(c echo.Context) *http.Client {
+	return &http.Client{
+		Transport: &transport{base: cl.config.Transport, c: c, headers: cl.config.Headers},
+		Timeout:   cl.config.Timeout,
+	}
+}
+
+// NewRequest returns an outgoing request with the std context of the incoming
+// request of the context, so that it inherits its cancellation and deadline.
+func NewRequest(c echo.Context, method, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(c.Request().Context(), method, url, body)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	in := t.c.Request()
+	// A RoundTripper must not modify the request
+	r := new(http.Request)
+	*r = *req
+	r.Header = req.Header.Clone()
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	if req.Context().Done() == nil {
+		r = r.WithContext(in.Context())
+	}
+
+	set := func(name, value string) {
+		if value != "" && r.Header.Get(name) == "" {
+			r.Header.Set(name, value)
+		}
+	}
+	rid := t.c.Response().Header().Get(echo.HeaderXRequestID)
+	if rid == "" {
+		rid = in.Header.Get(echo.HeaderXRequestID)
+	}
+	set(echo.HeaderXRequestID, rid)
+	if strings.EqualFold(r.URL.Host, origin(req).URL.Host) {
+		for _, h := range t.headers {
+			set(h, in.Header.Get(h))
+		}
+	}
+	if tc, ok := middleware.TraceFromContext(in.Context()); ok {
+		h := http.Header{}
+		tc.Inject(h)
+		for name := range h {
+			set(name, h.Get(name))
+		}
+	}
+	return t.base.RoundTrip(r)
+}
+
+// origin returns the first request of the redirect chain of the request.
+func origin(req *http.Request) *http.Request {
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	return req
+}
@@ -0,0 +1,114 @@
+package client
+
+import (
+	stdContext "context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient(t *testing.T) {
+	var got http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer downstream.Close()
+
+	cl := New(Config{Headers: []string{echo.HeaderAuthorization}})
+	e := echo.New()
+	e.Use(middleware.RequestID(), middleware.Trace())
+	e.GET("/", func(c echo.Context) error {
+		req, err := NewRequest(c, http.MethodGet, downstream.URL, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("X-Custom", "1")
+		res, err := cl.For(c).Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer token")
+	req.Header.Set(middleware.HeaderTraceparent, "00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, rec.Header().Get(echo.HeaderXRequestID), got.Get(echo.HeaderXRequestID))
+	assert.Equal(t, "Bearer token", got.Get(echo.HeaderAuthorization))
+	assert.Equal(t, "1", got.Get("X-Custom"))
+	assert.True(t, strings.HasPrefix(got.Get(middleware.HeaderTraceparent), "00-0af7651916cd43dd8448eb211c80319c-"))
+	assert.NotContains(t, got.Get(middleware.HeaderTraceparent), "b7ad6b7169203331")
+}
+
+func TestClientRedirect(t *testing.T) {
+	var got http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer other.Close()
+	var same http.Header
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/same":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/final":
+			same = r.Header
+		default:
+			http.Redirect(w, r, other.URL, http.StatusFound)
+		}
+	}))
+	defer downstream.Close()
+
+	cl := New(Config{Headers: []string{echo.HeaderAuthorization}})
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer token")
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	// Cross-host redirects don't carry the copied headers
+	res, err := cl.For(c).Get(downstream.URL)
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Empty(t, got.Get(echo.HeaderAuthorization))
+	}
+
+	res, err = cl.For(c).Get(downstream.URL + "/same")
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, "Bearer token", same.Get(echo.HeaderAuthorization))
+	}
+}
+
+func TestClientDeadline(t *testing.T) {
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer downstream.Close()
+
+	e := echo.New()
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 20*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	// Requests without a context of their own inherit the incoming one
+	out, _ := http.NewRequest(http.MethodGet, downstream.URL, nil)
+	_, err := For(c).Do(out)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "deadline exceeded")
+	}
+}
@@ -0,0 +1,197 @@
+package echo
+
+import (
+	stdContext "context"
+	"errors"
+	"fmt"
+	stdLog "log"
+	"net"
+	"net/http"
+	"time"
+)
+
+type (
+	// Config defines the config of an Echo instance created with
+	// `NewWithConfig()`. Zero values leave the defaults of `New()` in place.
+	Config struct {
+		// Debug enables the debug mode: error details are sent in responses and
+		// contexts used after their request completed panic.
+		Debug bool
+
+		// HideBanner hides the startup banner.
+		HideBanner bool
+
+		// HidePort hides the address the server listens on at startup.
+		HidePort bool
+
+		// DisableHTTP2 disables HTTP/2 for TLS servers.
+		DisableHTTP2 bool
+
+		// Logger is the framework logger.
+		// Optional. Default value is a gommon logger with level ERROR.
+		Logger Logger
+
+		// HTTPErrorHandler is the centralized HTTP error handler.
+		// Optional. Default value `Echo#DefaultHTTPErrorHandler`.
+		HTTPErrorHandler HTTPErrorHandler
+
+		// ErrorReporter reports 5xx errors and panics.
+		// Optional.
+		ErrorReporter ErrorReporter
+
+		// Binder binds requests into values.
+		// Optional. Default value `DefaultBinder`.
+		Binder Binder
+
+		// Validator validates bound values.
+		// Optional.
+		Validator Validator
+
+		// Renderer renders templates.
+		// Optional.
+		Renderer Renderer
+
+		// IPExtractor extracts the client IP address from requests.
+		// Optional. Mutually exclusive with TrustedProxies.
+		IPExtractor IPExtractor
+
+		// TrustedProxies lists the CIDRs of the proxies trusted to set the
+		// `X-Forwarded-For` header, e.g. "10.0.0.0/8". When set, the client IP
+		// address is the nearest untrusted address, see `ExtractIPFromXFFHeader()`.
+		// Optional.
+		TrustedProxies []string
+
+		// ReadTimeout, ReadHeaderTimeout, WriteTimeout and IdleTimeout are set on
+		// both `Echo#Server` and `Echo#TLSServer`, see `http.Server`.
+		// Optional.
+		ReadTimeout       time.Duration
+		ReadHeaderTimeout time.Duration
+		WriteTimeout      time.Duration
+		IdleTimeout       time.Duration
+
+		// ConnState is called when a connection changes state, e.g. to count open
+		// connections, see `http.Server#ConnState`.
+		// Optional.
+		ConnState func(net.Conn, http.ConnState)
+
+		// ConnContext returns the base context of the requests of a connection,
+		// e.g. tagged with connection metadata, see `http.Server#ConnContext`.
+		// Optional.
+		ConnContext func(stdContext.Context, net.Conn) stdContext.Context
+
+		// ContextFactory wraps the context of every request before it is passed
+		// to the middleware and handlers, e.g. into an application type embedding
+		// `echo.Context` with typed accessors. See `ContextHandler()`.
+		// Optional.
+		ContextFactory func(Context) Context
+
+		// BodyCaptureLimit is the limit of `Context#Body()` in bytes.
+		// Optional. Default value 4 MB.
+		BodyCaptureLimit int64
+
+		// DeferredWorkers is the number of workers running the functions of
+		// `Context#DeferAfterResponse()`.
+		// Optional. Default value 64.
+		DeferredWorkers int
+
+		// ShutdownDelay is how long `Echo#Shutdown()` keeps serving requests
+		// after readiness probes start failing, so that load balancers stop
+		// routing requests to the instance before it drains, e.g. during
+		// rolling deploys.
+		// Optional. Default value 0.
+		ShutdownDelay time.Duration
+
+		// Cookies protects and defaults the cookies set with
+		// `Context#SetSignedCookie()` and `Context#SetEncryptedCookie()`.
+		// Optional.
+		Cookies CookieConfig
+	}
+)
+
+// NewWithConfig creates an instance of Echo with config, returning an error if
+// the config is invalid.
+func NewWithConfig(config Config) (*Echo, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	e := New()
+	e.Debug = config.Debug
+	e.HideBanner = config.HideBanner
+	e.HidePort = config.HidePort
+	e.DisableHTTP2 = config.DisableHTTP2
+	if config.Logger != nil {
+		e.Logger = config.Logger
+		e.StdLogger = stdLog.New(e.Logger.Output(), e.Logger.Prefix()+": ", 0)
+	}
+	if config.HTTPErrorHandler != nil {
+		e.HTTPErrorHandler = config.HTTPErrorHandler
+	}
+	if config.Binder != nil {
+		e.Binder = config.Binder
+	}
+	e.ErrorReporter = config.ErrorReporter
+	e.Validator = config.Validator
+	e.Renderer = config.Renderer
+	e.IPExtractor = config.IPExtractor
+	if len(config.TrustedProxies) > 0 {
+		options := []TrustOption{TrustLoopback(false), TrustLinkLocal(false), TrustPrivateNet(false)}
+		for _, cidr := range config.TrustedProxies {
+			_, ipRange, _ := net.ParseCIDR(cidr) // Validated
+			options = append(options, TrustIPRange(ipRange))
+		}
+		e.IPExtractor = ExtractIPFromXFFHeader(options...)
+	}
+	for _, s := range [...]*http.Server{e.Server, e.TLSServer} {
+		s.ReadTimeout = config.ReadTimeout
+		s.ReadHeaderTimeout = config.ReadHeaderTimeout
+		s.WriteTimeout = config.WriteTimeout
+		s.IdleTimeout = config.IdleTimeout
+	}
+	e.ConnState = config.ConnState
+	e.ConnContext = config.ConnContext
+	e.ContextFactory = config.ContextFactory
+	e.BodyCaptureLimit = config.BodyCaptureLimit
+	e.DeferredWorkers = config.DeferredWorkers
+	e.Cookies = config.Cookies
+	e.ShutdownDelay = config.ShutdownDelay
+	return e, nil
+}
+
+func (config Config) validate() error {
+	if config.IPExtractor != nil && len(config.TrustedProxies) > 0 {
+		return errors.New("echo: config: IPExtractor and TrustedProxies are mutually exclusive")
+	}
+	for _, cidr := range config.TrustedProxies {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return fmt.Errorf("echo: config: invalid trusted proxy: %v", err)
+		}
+	}
+	timeouts := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"ReadTimeout", config.ReadTimeout},
+		{"ReadHeaderTimeout", config.ReadHeaderTimeout},
+		{"WriteTimeout", config.WriteTimeout},
+		{"IdleTimeout", config.IdleTimeout},
+		{"ShutdownDelay", config.ShutdownDelay},
+	}
+	for _, t := range timeouts {
+		if t.d < 0 {
+			return fmt.Errorf("echo: config: %s must not be negative", t.name)
+		}
+	}
+	if config.BodyCaptureLimit < 0 {
+		return errors.New("echo: config: BodyCaptureLimit must not be negative")
+	}
+	if config.DeferredWorkers < 0 {
+		return errors.New("echo: config: DeferredWorkers must not be negative")
+	}
+	for _, key := range config.Cookies.Keys {
+		if len(key) < 32 {
+			return errors.New("echo: config: cookie keys must be at least 32 bytes")
+		}
+	}
+	return nil
+}
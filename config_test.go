@@ -0,0 +1,108 @@
+package echo
+
+import (
+	stdContext "context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWithConfig(t *testing.T) {
+	logger := log.New("app")
+	binder := new(DefaultBinder)
+	e, err := NewWithConfig(Config{
+		Debug:          true,
+		HideBanner:     true,
+		Logger:         logger,
+		Binder:         binder,
+		TrustedProxies: []string{"203.0.113.0/24"},
+		ReadTimeout:    time.Second,
+		WriteTimeout:   2 * time.Second,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, e.Debug)
+	assert.True(t, e.HideBanner)
+	assert.Equal(t, logger, e.Logger)
+	assert.Equal(t, "app: ", e.StdLogger.Prefix())
+	assert.Equal(t, binder, e.Binder)
+	assert.NotNil(t, e.HTTPErrorHandler)
+	assert.Equal(t, time.Second, e.Server.ReadTimeout)
+	assert.Equal(t, 2*time.Second, e.TLSServer.WriteTimeout)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "203.0.113.1:1234"
+	req.Header.Set(HeaderXForwardedFor, "192.0.2.1, 10.0.0.1")
+	assert.Equal(t, "10.0.0.1", e.IPExtractor(req)) // Private ranges are not trusted
+}
+
+func TestNewWithConfigInvalid(t *testing.T) {
+	tests := []struct {
+		config Config
+		err    string
+	}{
+		{Config{TrustedProxies: []string{"10.0.0.1"}}, "echo: config: invalid trusted proxy: invalid CIDR address: 10.0.0.1"},
+		{Config{TrustedProxies: []string{"10.0.0.0/8"}, IPExtractor: ExtractIPDirect()}, "echo: config: IPExtractor and TrustedProxies are mutually exclusive"},
+		{Config{IdleTimeout: -1}, "echo: config: IdleTimeout must not be negative"},
+		{Config{BodyCaptureLimit: -1}, "echo: config: BodyCaptureLimit must not be negative"},
+		{Config{DeferredWorkers: -1}, "echo: config: DeferredWorkers must not be negative"},
+		{Config{ShutdownDelay: -1}, "echo: config: ShutdownDelay must not be negative"},
+		{Config{Cookies: CookieConfig{Keys: [][]byte{[]byte("short")}}}, "echo: config: cookie keys must be at least 32 bytes"},
+	}
+	for _, tt := range tests {
+		e, err := NewWithConfig(tt.config)
+		assert.Nil(t, e)
+		assert.EqualError(t, err, tt.err)
+	}
+}
+
+func TestEchoConnHooks(t *testing.T) {
+	type connKey struct{}
+	var active int32
+	e, err := NewWithConfig(Config{
+		HideBanner: true,
+		ConnState: func(conn net.Conn, state http.ConnState) {
+			switch state {
+			case http.StateNew:
+				atomic.AddInt32(&active, 1)
+			case http.StateClosed, http.StateHijacked:
+				atomic.AddInt32(&active, -1)
+			}
+		},
+		ConnContext: func(ctx stdContext.Context, conn net.Conn) stdContext.Context {
+			return stdContext.WithValue(ctx, connKey{}, conn.RemoteAddr().String())
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	e.GET("/", func(c Context) error {
+		assert.Equal(t, int32(1), atomic.LoadInt32(&active))
+		return c.String(http.StatusOK, c.Request().Context().Value(connKey{}).(string))
+	})
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.Start("127.0.0.1:0")
+	}()
+	addr, err := waitForServerStart(e, errCh, false)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := http.Get("http://" + addr.String())
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Contains(t, string(b), "127.0.0.1:")
+	}
+	assert.NoError(t, e.Close())
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+}
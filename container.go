@@ -0,0 +1,129 @@
+package echo
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type (
+	// container holds the constructors of the services of an Echo instance or
+	// a group.
+	container struct {
+		mu           sync.RWMutex
+		constructors map[reflect.Type]reflect.Value
+	}
+
+	// resolving marks a service being constructed, to detect cycles.
+	resolving struct{}
+)
+
+const (
+	serviceGroupKey = "_echo_service_group"
+	servicesKey     = "_echo_services"
+)
+
+var (
+	contextType = reflect.TypeOf((*Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Provide registers the constructor of a service, a function of type
+// `func(Context) T` or `func(Context) (T, error)`, resolved by type with
+// `Resolve()` or `Inject()`. Services are constructed at most once per request,
+// so that e.g. the repositories of a request share its transaction. It panics
+// if the constructor is invalid.
+//
+// Example:
+//
+//	e.Provide(func(c echo.Context) (*UserRepo, error) {
+//	  return NewUserRepo(db), nil
+//	})
+func (e *Echo) Provide(constructor interface{}) {
+	e.services.provide(constructor)
+}
+
+// Provide implements `Echo#Provide()` for the routes of the group and its
+// sub-groups. Constructors of the group take precedence over those of its
+// parents and of the Echo instance.
+func (g *Group) Provide(constructor interface{}) {
+	if g.services == nil {
+		g.services = new(container)
+	}
+	g.services.provide(constructor)
+}
+
+// providesServices reports whether the group or one of its parents has
+// constructors.
+func (g *Group) providesServices() bool {
+	for ; g != nil; g = g.parent {
+		if g.services != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *container) provide(constructor interface{}) {
+	v := reflect.ValueOf(constructor)
+	t := v.Type()
+	if t.Kind() != reflect.Func || t.NumIn() != 1 || t.In(0) != contextType ||
+		t.NumOut() == 0 || t.NumOut() > 2 || (t.NumOut() == 2 && t.Out(1) != errorType) {
+		panic(fmt.Sprintf("echo: constructor is %T, not func(echo.Context) T or func(echo.Context) (T, error)", constructor))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.constructors == nil {
+		s.constructors = map[reflect.Type]reflect.Value{}
+	}
+	s.constructors[t.Out(0)] = v
+}
+
+func (s *container) constructor(t reflect.Type) (reflect.Value, bool) {
+	if s == nil {
+		return reflect.Value{}, false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.constructors[t]
+	return v, ok
+}
+
+// resolve returns the service of type `t` for the request, constructing it
+// with the constructor of the group of the route or of the Echo instance.
+func resolve(c Context, t reflect.Type) (interface{}, error) {
+	services, _ := c.Get(servicesKey).(map[reflect.Type]interface{})
+	if v, ok := services[t]; ok {
+		if _, ok := v.(resolving); ok {
+			return nil, fmt.Errorf("echo: service %v depends on itself", t)
+		}
+		return v, nil
+	}
+
+	var constructor reflect.Value
+	found := false
+	g, _ := c.Get(serviceGroupKey).(*Group)
+	for ; g != nil && !found; g = g.parent {
+		constructor, found = g.services.constructor(t)
+	}
+	if !found {
+		constructor, found = c.Echo().services.constructor(t)
+	}
+	if !found {
+		return nil, fmt.Errorf("echo: no constructor of service %v, see Echo#Provide", t)
+	}
+
+	if services == nil {
+		services = map[reflect.Type]interface{}{}
+		c.Set(servicesKey, services)
+	}
+	services[t] = resolving{}
+	out := constructor.Call([]reflect.Value{reflect.ValueOf(c)})
+	if len(out) == 2 && !out[1].IsNil() {
+		delete(services, t)
+		return nil, out[1].Interface().(error)
+	}
+	v := out[0].Interface()
+	services[t] = v
+	return v, nil
+}
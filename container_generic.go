@@ -0,0 +1,84 @@
+//go:build go1.18
+// +build go1.18
+
+package echo
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Resolve returns the service of type T for the request, constructed by the
+// constructor registered with `Echo#Provide()` or `Group#Provide()`.
+// Constructors may resolve the services they depend on.
+//
+// Example:
+//
+//	e.Provide(func(c echo.Context) (*UserRepo, error) {
+//	  tx, err := echo.Resolve[*sql.Tx](c)
+//	  if err != nil {
+//	    return nil, err
+//	  }
+//	  return &UserRepo{tx: tx}, nil
+//	})
+func Resolve[T any](c Context) (T, error) {
+	var zero T
+	v, err := resolve(c, reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return zero, err
+	}
+	if v == nil {
+		return zero, nil
+	}
+	return v.(T), nil
+}
+
+// MustResolve is like `Resolve()` but panics on error.
+func MustResolve[T any](c Context) T {
+	v, err := Resolve[T](c)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Inject adapts a handler declaring its dependencies as the exported fields of
+// the struct `D` into a `HandlerFunc`. The fields are resolved by type, see
+// `Resolve()`. It panics if `D` isn't a struct.
+//
+// Example:
+//
+//	type deps struct {
+//	  Users  *UserRepo
+//	  Mailer Mailer
+//	}
+//
+//	e.POST("/signup", echo.Inject(func(c echo.Context, d deps) error {
+//	  ...
+//	}))
+func Inject[D any](h func(c Context, deps D) error) HandlerFunc {
+	t := reflect.TypeOf((*D)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("echo: dependencies are %v, not a struct", t))
+	}
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			fields = append(fields, i)
+		}
+	}
+	return func(c Context) error {
+		var deps D
+		v := reflect.ValueOf(&deps).Elem()
+		for _, i := range fields {
+			s, err := resolve(c, t.Field(i).Type)
+			if err != nil {
+				return err
+			}
+			if s != nil {
+				v.Field(i).Set(reflect.ValueOf(s))
+			}
+		}
+		return h(c, deps)
+	}
+}
@@ -0,0 +1,107 @@
+//go:build go1.18
+// +build go1.18
+
+package echo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	testTx struct {
+		id int
+	}
+
+	testRepo struct {
+		tx *testTx
+	}
+)
+
+func TestResolve(t *testing.T) {
+	e := New()
+	txs := 0
+	e.Provide(func(c Context) *testTx {
+		txs++
+		return &testTx{id: txs}
+	})
+	e.Provide(func(c Context) (*testRepo, error) {
+		tx, err := Resolve[*testTx](c)
+		if err != nil {
+			return nil, err
+		}
+		return &testRepo{tx: tx}, nil
+	})
+
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	repo, err := Resolve[*testRepo](c)
+	if assert.NoError(t, err) {
+		// Services are constructed once per request
+		assert.Same(t, MustResolve[*testTx](c), repo.tx)
+		assert.Equal(t, 1, txs)
+	}
+
+	_, err = Resolve[string](c)
+	assert.EqualError(t, err, "echo: no constructor of service string, see Echo#Provide")
+
+	e.Provide(func(c Context) (int, error) {
+		return Resolve[int](c)
+	})
+	_, err = Resolve[int](c)
+	assert.EqualError(t, err, "echo: service int depends on itself")
+
+	assert.Panics(t, func() {
+		e.Provide(func() int { return 0 })
+	})
+}
+
+func TestInject(t *testing.T) {
+	e := New()
+	e.Provide(func(c Context) *testTx {
+		return &testTx{id: 1}
+	})
+	e.Provide(func(c Context) (*testRepo, error) {
+		return &testRepo{tx: MustResolve[*testTx](c)}, nil
+	})
+	type deps struct {
+		Tx    *testTx
+		Repo  *testRepo
+		other string
+	}
+	h := Inject(func(c Context, d deps) error {
+		assert.Same(t, d.Tx, d.Repo.tx)
+		return c.String(http.StatusOK, "ok")
+	})
+	e.GET("/", h)
+
+	// Constructors of groups take precedence and are inherited by sub-groups
+	g := e.Group("/admin")
+	g.Provide(func(c Context) *testTx {
+		return &testTx{id: 2}
+	})
+	g.Group("/users").GET("", Inject(func(c Context, d deps) error {
+		return c.JSON(http.StatusOK, d.Repo.tx.id)
+	}))
+	f := e.Group("/fail")
+	f.Provide(func(c Context) (*testRepo, error) {
+		return nil, errors.New("unavailable")
+	})
+	f.GET("", h)
+
+	code, body := request(http.MethodGet, "/", e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", body)
+	code, body = request(http.MethodGet, "/admin/users", e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "2\n", body)
+	code, _ = request(http.MethodGet, "/fail", e)
+	assert.Equal(t, http.StatusInternalServerError, code)
+
+	assert.Panics(t, func() {
+		Inject(func(c Context, d int) error { return nil })
+	})
+}
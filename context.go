@@ -2,18 +2,24 @@ package echo
 
 import (
 	"bytes"
+	stdContext "context"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type (
@@ -97,22 +103,98 @@ type (
 		// Cookies returns the HTTP cookies sent with the request.
 		Cookies() []*http.Cookie
 
-		// Get retrieves data from the context.
+		// SetSignedCookie adds a `Set-Cookie` header with the value signed with
+		// the first of `Echo#Cookies.Keys`, so that clients can read but not
+		// modify it. Attributes left unset are defaulted, see `CookieConfig`.
+		SetSignedCookie(cookie *http.Cookie) error
+
+		// SignedCookie returns the named cookie set with `SetSignedCookie()`,
+		// with its value verified. It returns `ErrInvalidCookie` if the value
+		// was modified. A cookie signed with an older key is set again with
+		// the first key, see `CookieConfig.MaxAge`.
+		SignedCookie(name string) (*http.Cookie, error)
+
+		// SetEncryptedCookie adds a `Set-Cookie` header with the value encrypted
+		// with the first of `Echo#Cookies.Keys`, so that clients can neither
+		// read nor modify it. Attributes left unset are defaulted, see
+		// `CookieConfig`.
+		SetEncryptedCookie(cookie *http.Cookie) error
+
+		// EncryptedCookie returns the named cookie set with
+		// `SetEncryptedCookie()`, with its value decrypted. It returns
+		// `ErrInvalidCookie` if the value was modified. A cookie encrypted with
+		// an older key is encrypted again with the first key.
+		EncryptedCookie(name string) (*http.Cookie, error)
+
+		// Get retrieves data from the context. It is safe for concurrent use, see
+		// `echo.Get()` for typed access.
 		Get(key string) interface{}
 
-		// Set saves data in the context.
+		// Set saves data in the context. It is safe for concurrent use, see
+		// `echo.Key` for typed, namespaced keys.
 		Set(key string, val interface{})
 
+		// Memoize returns the value computed by `fn` for the key the first time
+		// it is called during the request, so that middleware and handlers
+		// share expensive lookups, e.g. of the user record. Errors aren't
+		// memoized. Memoized values are separate from the values of `Set()`,
+		// see `echo.Memoize()` for typed access.
+		Memoize(key string, fn func() (interface{}, error)) (interface{}, error)
+
+		// Body reads and returns the raw request body, up to `Echo#BodyCaptureLimit`
+		// bytes. The body is memoized and `Request().Body` is reset to replay it,
+		// e.g. to verify a webhook signature before calling `Bind()`. It returns
+		// `ErrStatusRequestEntityTooLarge` if the body exceeds the limit.
+		Body() ([]byte, error)
+
+		// SetBody replaces the raw request body returned by `Body()` and read from
+		// `Request().Body`.
+		SetBody(b []byte)
+
 		// Bind binds the request body into provided type `i`. The default binder
 		// does it based on Content-Type header.
 		Bind(i interface{}) error
 
+		// BindStrict binds like `Bind()`, rejecting JSON bodies with fields unknown
+		// to `i` regardless of `DefaultBinder#DisallowUnknownFields`.
+		BindStrict(i interface{}) error
+
+		// BindJSON binds the request body as JSON into provided type `i`,
+		// regardless of the Content-Type header, e.g. for clients sending a wrong
+		// or no Content-Type. See `DefaultBinder#BindJSON()`.
+		BindJSON(i interface{}) error
+
+		// BindXML binds the request body as XML into provided type `i`, regardless
+		// of the Content-Type header. See `DefaultBinder#BindXML()`.
+		BindXML(i interface{}) error
+
+		// BindForm binds the request body as form into provided type `i`,
+		// regardless of the Content-Type header. See `DefaultBinder#BindForm()`.
+		BindForm(i interface{}) error
+
+		// BindPagination binds the "page", "per_page" and "sort" query params
+		// into a `Pagination`, applying and enforcing the limits. Invalid params
+		// result in a "400 - Bad Request" error.
+		BindPagination(limits PaginationLimits) (*Pagination, error)
+
+		// Negotiate returns the media type of `offers` preferred by the "Accept"
+		// request header, the first offer if the header is missing, or "" if
+		// none is acceptable. It adds "Accept" to the "Vary" header.
+		Negotiate(offers ...string) string
+
+		// NegotiateLanguage returns the language of `offers` preferred by the
+		// "Accept-Language" request header, the first offer if the header is
+		// missing, or "" if none is acceptable. It adds "Accept-Language" to the
+		// "Vary" header.
+		NegotiateLanguage(offers ...string) string
+
 		// Validate validates provided `i`. It is usually called after `Context#Bind()`.
 		// Validator must be registered using `Echo#Validator`.
 		Validate(i interface{}) error
 
 		// Render renders a template with data and sends a text/html response with status
-		// code. Renderer must be registered using `Echo.Renderer`.
+		// code. Renderer must be registered using `Echo.Renderer`. The data is
+		// decorated by the decorators of `Echo#RenderDecorator()` first.
 		Render(code int, name string, data interface{}) error
 
 		// HTML sends an HTTP response with status code.
@@ -121,6 +203,16 @@ type (
 		// HTMLBlob sends an HTTP blob response with status code.
 		HTMLBlob(code int, b []byte) error
 
+		// SafeHTML sends an HTML response with status code, sanitized with
+		// `Echo#Sanitizer`, e.g. for fragments of user content. The
+		// `DefaultHTMLPolicy` is used when the sanitizer is nil.
+		SafeHTML(code int, html string) error
+
+		// Markdown sends the Markdown source converted to HTML with status
+		// code, sanitized like `SafeHTML()` and rendered in the layout of
+		// `Echo#Markdown` if any, e.g. for docs and readme pages.
+		Markdown(code int, source []byte) error
+
 		// String sends a string response with status code.
 		String(code int, s string) error
 
@@ -156,6 +248,33 @@ type (
 		// Stream sends a streaming response with status code and content type.
 		Stream(code int, contentType string, r io.Reader) error
 
+		// JSONLines sends a newline delimited JSON (NDJSON) response with status
+		// code, encoding the items of `source` one per line as they are produced.
+		// `source` is a receive channel or an `Iterator`. The response is flushed
+		// periodically and whenever a channel has no item ready.
+		JSONLines(code int, source interface{}) error
+
+		// JSONArrayStream sends a JSON array response with status code, encoding
+		// the items of `source` as they are produced, like `JSONLines()`.
+		JSONArrayStream(code int, source interface{}) error
+
+		// YAML sends a YAML response with status code. The YAML codec must be
+		// registered using `Echo.YAML`.
+		YAML(code int, i interface{}) error
+
+		// CSV sends a CSV response with status code. The `headers` row, if any, is
+		// written before the rows. Fields are quoted as needed.
+		CSV(code int, headers []string, rows [][]string) error
+
+		// CSVAttachment sends a CSV response as attachment, prompting client to
+		// save it as `name`.
+		CSVAttachment(name string, headers []string, rows [][]string) error
+
+		// CSVStream sends a CSV response with status code, writing the rows of
+		// `source` as they are produced. `source` is a receive channel or an
+		// `Iterator` of `[]string` rows, see `JSONLines()`.
+		CSVStream(code int, headers []string, source interface{}) error
+
 		// File sends a response with the content of the file.
 		File(file string) error
 
@@ -169,10 +288,50 @@ type (
 		// NoContent sends a response with no body and a status code.
 		NoContent(code int) error
 
+		// IfNoneMatch sets the "ETag" response header and reports whether it
+		// matches the "If-None-Match" request header, i.e. the client has the
+		// representation and `NotModified()` should be sent. The entity tag is
+		// quoted if needed, e.g. `v1` is sent as `"v1"`, and compared with the
+		// weak comparison of RFC 7232.
+		IfNoneMatch(etag string) bool
+
+		// IfModifiedSince sets the "Last-Modified" response header and reports
+		// whether the representation wasn't modified since the "If-Modified-Since"
+		// request header, i.e. `NotModified()` should be sent. As required by
+		// RFC 7232, it is false for requests with "If-None-Match" and methods
+		// other than `GET` and `HEAD`.
+		IfModifiedSince(modtime time.Time) bool
+
+		// NotModified sends a "304 - Not Modified" response, keeping the
+		// validators and caching headers set and removing the content headers.
+		NotModified() error
+
+		// WriteEarlyHints sends a "103 Early Hints" interim response with a
+		// "Link" header for each of `links`, e.g. "</app.css>; rel=preload; as=style",
+		// so that clients can preload resources while the final response is
+		// prepared. The links are sent with the final response as well.
+		// It returns `ErrEarlyHintsUnsupported` if the response is committed or
+		// the server or protocol of the request doesn't support interim
+		// responses, e.g. HTTP/1.0 or h2c.
+		WriteEarlyHints(links []string) error
+
 		// Redirect redirects the request to a provided URL with status code.
 		Redirect(code int, url string) error
 
+		// Deadline returns the time the request should be abandoned at, as set on
+		// the std context of the request, e.g. by `middleware.Deadline()`.
+		Deadline() (deadline time.Time, ok bool)
+
+		// DeferAfterResponse registers a function run after the response has been
+		// sent, e.g. for audit writes and cache invalidation that shouldn't delay
+		// the response. The functions run on a pool of `Echo#DeferredWorkers`
+		// (default 64) workers, their context is not the request context, and
+		// `Echo#Shutdown()` waits for them to return.
+		// They must not use the Context, which is reused for other requests.
+		DeferAfterResponse(fn TaskFunc)
+
 		// Error invokes the registered HTTP error handler. Generally used by middleware.
+		// The error is passed to the registered ErrorReporter first, if any.
 		Error(err error)
 
 		// Handler returns the matched handler by router.
@@ -181,15 +340,60 @@ type (
 		// SetHandler sets the matched handler by router.
 		SetHandler(h HandlerFunc)
 
-		// Logger returns the `Logger` instance.
+		// Logger returns the `Logger` instance. Unless set with `SetLogger()` it is
+		// a request-scoped logger writing entries as JSON with the request ID,
+		// route, method, remote IP and the fields attached with `LogFields()`.
 		Logger() Logger
 
+		// LogFields attaches the key/value pairs to the request log fields and
+		// returns all attached fields. The fields are included in the entries of
+		// the request-scoped logger and of the Logger middleware.
+		LogFields(keyvals ...interface{}) Map
+
 		// Set the logger
 		SetLogger(l Logger)
 
+		// Principal returns the authenticated identity of the request, or nil.
+		// See `middleware.Authn()`.
+		Principal() *Principal
+
+		// SetPrincipal sets the authenticated identity of the request and adds
+		// its ID to the request log fields.
+		SetPrincipal(p *Principal)
+
+		// FlagEnabled reports whether the feature flag is enabled for the
+		// request. Flags are disabled unless set with `SetFlags()`, see
+		// `middleware.FeatureFlags()`.
+		FlagEnabled(name string) bool
+
+		// Flags returns the feature flags of the request.
+		Flags() Flags
+
+		// SetFlags sets the feature flags of the request.
+		SetFlags(f Flags)
+
+		// CSPNonce returns the Content-Security-Policy nonce of the request,
+		// generating it on first use. `middleware.Secure()` substitutes it for
+		// the "{nonce}" placeholder of the policy, and templates can add it to
+		// inline scripts and styles with the "cspNonce" function of
+		// `TemplateFuncs`.
+		CSPNonce() string
+
+		// UserAgent returns the parsed "User-Agent" header of the request. It is
+		// parsed once per request, so that middleware and handlers share it.
+		UserAgent() *UserAgent
+
 		// Echo returns the `Echo` instance.
 		Echo() *Echo
 
+		// Clone returns a snapshot of the context, safe to use in goroutines
+		// outliving the handler, unlike the context itself which is reused for
+		// other requests. The snapshot holds copies of the request, path
+		// parameters, query, store and log fields. Its request context keeps the
+		// values but isn't canceled when the request completes, and its response
+		// can't be written.
+		Clone() Context
+
 		// Reset resets the context after request completes. It must be called along
 		// with `Echo#AcquireContext()` and `Echo#ReleaseContext()`.
 		// See `Echo#ServeHTTP()`
@@ -197,26 +401,72 @@ type (
 	}
 
 	context struct {
-		request  *http.Request
-		response *Response
-		path     string
-		pnames   []string
-		pvalues  []string
-		query    url.Values
-		handler  HandlerFunc
-		store    Map
-		echo     *Echo
-		logger   Logger
-		lock     sync.RWMutex
+		request      *http.Request
+		response     *Response
+		path         string
+		pnames       []string
+		pvalues      []string
+		pescaped     bool // Whether pvalues are URL-encoded, see `ParamUnescapeLazy`
+		query        url.Values
+		handler      HandlerFunc
+		store        Map
+		echo         *Echo
+		logger       Logger
+		logFields    Map
+		memo         Map
+		body         []byte
+		bodyRead     bool
+		deferred     []TaskFunc
+		principal    *Principal
+		flags        Flags
+		userAgent    *UserAgent
+		cspNonce     string
+		errorHandler HTTPErrorHandler // Error handler of the group of the route
+		lock         sync.RWMutex
+		released     uint32
+	}
+
+	// detachedContext keeps the values of a parent context, without its
+	// deadline and cancelation.
+	detachedContext struct {
+		stdContext.Context
+	}
+
+	// detachedWriter is the response writer of cloned contexts.
+	detachedWriter struct {
+		header http.Header
+	}
+
+	// Iterator returns the next item of a sequence, or false once the sequence
+	// is exhausted. It is used as source of `Context#JSONLines()` and
+	// `Context#JSONArrayStream()`.
+	Iterator func() (item interface{}, ok bool, err error)
+
+	// replayBody replays a memoized request body, closing the original one.
+	replayBody struct {
+		io.Reader
+		io.Closer
 	}
 )
 
 const (
-	defaultMemory = 32 << 20 // 32 MB
-	indexPage     = "index.html"
-	defaultIndent = "  "
+	defaultMemory           = 32 << 20 // 32 MB
+	defaultBodyCaptureLimit = 4 << 20  // 4 MB
+	indexPage               = "index.html"
+	defaultIndent           = "  "
+	streamFlushItems        = 64
 )
 
+// checkReleased panics if the context was released after its request
+// completed, which is only detected in debug mode, see `Echo#ReleaseContext()`.
+func (c *context) checkReleased() {
+	if atomic.LoadUint32(&c.released) != 0 {
+		panic("echo: Context used after its request completed, it must not be captured " +
+			"by goroutines outliving the handler since it is reused for other requests; " +
+			"pass Context#Clone() instead")
+	}
+}
+
 func (c *context) writeContentType(value string) {
 	header := c.Response().Header()
 	if header.Get(HeaderContentType) == "" {
@@ -225,31 +475,38 @@ func (c *context) writeContentType(value string) {
 }
 
 func (c *context) Request() *http.Request {
+	c.checkReleased()
 	return c.request
 }
 
 func (c *context) SetRequest(r *http.Request) {
+	c.checkReleased()
 	c.request = r
 }
 
 func (c *context) Response() *Response {
+	c.checkReleased()
 	return c.response
 }
 
 func (c *context) SetResponse(r *Response) {
+	c.checkReleased()
 	c.response = r
 }
 
 func (c *context) IsTLS() bool {
+	c.checkReleased()
 	return c.request.TLS != nil
 }
 
 func (c *context) IsWebSocket() bool {
+	c.checkReleased()
 	upgrade := c.request.Header.Get(HeaderUpgrade)
 	return strings.ToLower(upgrade) == "websocket"
 }
 
 func (c *context) Scheme() string {
+	c.checkReleased()
 	// Can't use `r.Request.URL.Scheme`
 	// See: https://groups.google.com/forum/#!topic/golang-nuts/pMUkBlQBDF0
 	if c.IsTLS() {
@@ -271,6 +528,7 @@ func (c *context) Scheme() string {
 }
 
 func (c *context) RealIP() string {
+	c.checkReleased()
 	if c.echo != nil && c.echo.IPExtractor != nil {
 		return c.echo.IPExtractor(c.request)
 	}
@@ -286,14 +544,18 @@ func (c *context) RealIP() string {
 }
 
 func (c *context) Path() string {
+	c.checkReleased()
 	return c.path
 }
 
 func (c *context) SetPath(p string) {
+	c.checkReleased()
 	c.path = p
 }
 
 func (c *context) Param(name string) string {
+	c.checkReleased()
+	c.unescapeParams()
 	for i, n := range c.pnames {
 		if i < len(c.pvalues) {
 			if n == name {
@@ -305,23 +567,61 @@ func (c *context) Param(name string) string {
 }
 
 func (c *context) ParamNames() []string {
+	c.checkReleased()
 	return c.pnames
 }
 
 func (c *context) SetParamNames(names ...string) {
+	c.checkReleased()
 	c.pnames = names
-	*c.echo.maxParam = len(names)
+	c.echo.reserveMaxParam(len(names))
+	c.reserveParams(len(names))
 }
 
 func (c *context) ParamValues() []string {
+	c.checkReleased()
+	c.unescapeParams()
 	return c.pvalues[:len(c.pnames)]
 }
 
 func (c *context) SetParamValues(values ...string) {
-	c.pvalues = values
+	c.checkReleased()
+	// Copy into the pooled storage rather than keeping `values`, so that it is
+	// reused by the next requests
+	c.reserveParams(len(values))
+	copy(c.pvalues, values)
+	c.pescaped = false
+}
+
+// unescapeParams URL-decodes the param values in place if they are encoded.
+// Values without escapes are kept as is, without allocation.
+func (c *context) unescapeParams() {
+	if !c.pescaped {
+		return
+	}
+	c.pescaped = false
+	for i := range c.pnames {
+		if i < len(c.pvalues) {
+			if v, err := url.PathUnescape(c.pvalues[i]); err == nil {
+				c.pvalues[i] = v
+			}
+		}
+	}
+}
+
+// reserveParams grows the param values storage to at least `n` values, e.g. when
+// routes with more params were added after the context was created.
+func (c *context) reserveParams(n int) {
+	if len(c.pvalues) >= n {
+		return
+	}
+	pvalues := make([]string, n)
+	copy(pvalues, c.pvalues)
+	c.pvalues = pvalues
 }
 
 func (c *context) QueryParam(name string) string {
+	c.checkReleased()
 	if c.query == nil {
 		c.query = c.request.URL.Query()
 	}
@@ -329,6 +629,7 @@ func (c *context) QueryParam(name string) string {
 }
 
 func (c *context) QueryParams() url.Values {
+	c.checkReleased()
 	if c.query == nil {
 		c.query = c.request.URL.Query()
 	}
@@ -336,14 +637,17 @@ func (c *context) QueryParams() url.Values {
 }
 
 func (c *context) QueryString() string {
+	c.checkReleased()
 	return c.request.URL.RawQuery
 }
 
 func (c *context) FormValue(name string) string {
+	c.checkReleased()
 	return c.request.FormValue(name)
 }
 
 func (c *context) FormParams() (url.Values, error) {
+	c.checkReleased()
 	if strings.HasPrefix(c.request.Header.Get(HeaderContentType), MIMEMultipartForm) {
 		if err := c.request.ParseMultipartForm(defaultMemory); err != nil {
 			return nil, err
@@ -357,6 +661,7 @@ func (c *context) FormParams() (url.Values, error) {
 }
 
 func (c *context) FormFile(name string) (*multipart.FileHeader, error) {
+	c.checkReleased()
 	f, fh, err := c.request.FormFile(name)
 	if err != nil {
 		return nil, err
@@ -366,29 +671,61 @@ func (c *context) FormFile(name string) (*multipart.FileHeader, error) {
 }
 
 func (c *context) MultipartForm() (*multipart.Form, error) {
+	c.checkReleased()
 	err := c.request.ParseMultipartForm(defaultMemory)
 	return c.request.MultipartForm, err
 }
 
 func (c *context) Cookie(name string) (*http.Cookie, error) {
+	c.checkReleased()
 	return c.request.Cookie(name)
 }
 
 func (c *context) SetCookie(cookie *http.Cookie) {
+	c.checkReleased()
 	http.SetCookie(c.Response(), cookie)
 }
 
 func (c *context) Cookies() []*http.Cookie {
+	c.checkReleased()
 	return c.request.Cookies()
 }
 
 func (c *context) Get(key string) interface{} {
+	c.checkReleased()
 	c.lock.RLock()
 	defer c.lock.RUnlock()
 	return c.store[key]
 }
 
+func (c *context) Memoize(key string, fn func() (interface{}, error)) (interface{}, error) {
+	c.checkReleased()
+	c.lock.RLock()
+	v, ok := c.memo[key]
+	c.lock.RUnlock()
+	if ok {
+		return v, nil
+	}
+
+	// fn runs unlocked as it may use the context, e.g. to memoize other values
+	v, err := fn()
+	if err != nil {
+		return nil, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if prev, ok := c.memo[key]; ok {
+		return prev, nil
+	}
+	if c.memo == nil {
+		c.memo = Map{}
+	}
+	c.memo[key] = v
+	return v, nil
+}
+
 func (c *context) Set(key string, val interface{}) {
+	c.checkReleased()
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
@@ -398,11 +735,86 @@ func (c *context) Set(key string, val interface{}) {
 	c.store[key] = val
 }
 
+func (c *context) Body() ([]byte, error) {
+	c.checkReleased()
+	if !c.bodyRead {
+		limit := c.echo.BodyCaptureLimit
+		if limit <= 0 {
+			limit = defaultBodyCaptureLimit
+		}
+		req := c.request
+		if req.Body == nil || req.Body == http.NoBody {
+			c.body, c.bodyRead = []byte{}, true
+			return c.body, nil
+		}
+		b, err := ioutil.ReadAll(io.LimitReader(req.Body, limit+1))
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(b)) > limit {
+			// Leave the body intact for the caller
+			req.Body = replayBody{Reader: io.MultiReader(bytes.NewReader(b), req.Body), Closer: req.Body}
+			return nil, ErrStatusRequestEntityTooLarge
+		}
+		c.body, c.bodyRead = b, true
+		c.request.Body = replayBody{Reader: bytes.NewReader(b), Closer: req.Body}
+		return c.body, nil
+	}
+	c.SetBody(c.body)
+	return c.body, nil
+}
+
+func (c *context) SetBody(b []byte) {
+	c.checkReleased()
+	var closer io.Closer = ioutil.NopCloser(nil)
+	if rb, ok := c.request.Body.(replayBody); ok {
+		closer = rb.Closer
+	} else if c.request.Body != nil {
+		closer = c.request.Body
+	}
+	c.body, c.bodyRead = b, true
+	c.request.Body = replayBody{Reader: bytes.NewReader(b), Closer: closer}
+	c.request.ContentLength = int64(len(b))
+}
+
 func (c *context) Bind(i interface{}) error {
+	c.checkReleased()
 	return c.echo.Binder.Bind(i, c)
 }
 
+func (c *context) BindStrict(i interface{}) error {
+	c.checkReleased()
+	b := *c.defaultBinder()
+	b.DisallowUnknownFields = true
+	return b.Bind(i, c)
+}
+
+func (c *context) BindJSON(i interface{}) error {
+	c.checkReleased()
+	return c.defaultBinder().BindJSON(i, c)
+}
+
+func (c *context) BindXML(i interface{}) error {
+	c.checkReleased()
+	return c.defaultBinder().BindXML(i, c)
+}
+
+func (c *context) BindForm(i interface{}) error {
+	c.checkReleased()
+	return c.defaultBinder().BindForm(i, c)
+}
+
+// defaultBinder returns the registered binder if it is a `DefaultBinder`,
+// otherwise a new one.
+func (c *context) defaultBinder() *DefaultBinder {
+	if b, ok := c.echo.Binder.(*DefaultBinder); ok {
+		return b
+	}
+	return new(DefaultBinder)
+}
+
 func (c *context) Validate(i interface{}) error {
+	c.checkReleased()
 	if c.echo.Validator == nil {
 		return ErrValidatorNotRegistered
 	}
@@ -410,9 +822,13 @@ func (c *context) Validate(i interface{}) error {
 }
 
 func (c *context) Render(code int, name string, data interface{}) (err error) {
+	c.checkReleased()
 	if c.echo.Renderer == nil {
 		return ErrRendererNotRegistered
 	}
+	for _, d := range c.echo.renderDecorators {
+		data = d(c, name, data)
+	}
 	buf := new(bytes.Buffer)
 	if err = c.echo.Renderer.Render(buf, name, data, c); err != nil {
 		return
@@ -421,14 +837,26 @@ func (c *context) Render(code int, name string, data interface{}) (err error) {
 }
 
 func (c *context) HTML(code int, html string) (err error) {
+	c.checkReleased()
 	return c.HTMLBlob(code, []byte(html))
 }
 
 func (c *context) HTMLBlob(code int, b []byte) (err error) {
+	c.checkReleased()
 	return c.Blob(code, MIMETextHTMLCharsetUTF8, b)
 }
 
+func (c *context) SafeHTML(code int, html string) (err error) {
+	c.checkReleased()
+	var s Sanitizer = DefaultHTMLPolicy
+	if c.echo.Sanitizer != nil {
+		s = c.echo.Sanitizer
+	}
+	return c.HTML(code, s.Sanitize(html))
+}
+
 func (c *context) String(code int, s string) (err error) {
+	c.checkReleased()
 	return c.Blob(code, MIMETextPlainCharsetUTF8, []byte(s))
 }
 
@@ -463,6 +891,7 @@ func (c *context) json(code int, i interface{}, indent string) error {
 }
 
 func (c *context) JSON(code int, i interface{}) (err error) {
+	c.checkReleased()
 	indent := ""
 	if _, pretty := c.QueryParams()["pretty"]; c.echo.Debug || pretty {
 		indent = defaultIndent
@@ -471,18 +900,22 @@ func (c *context) JSON(code int, i interface{}) (err error) {
 }
 
 func (c *context) JSONPretty(code int, i interface{}, indent string) (err error) {
+	c.checkReleased()
 	return c.json(code, i, indent)
 }
 
 func (c *context) JSONBlob(code int, b []byte) (err error) {
+	c.checkReleased()
 	return c.Blob(code, MIMEApplicationJSONCharsetUTF8, b)
 }
 
 func (c *context) JSONP(code int, callback string, i interface{}) (err error) {
+	c.checkReleased()
 	return c.jsonPBlob(code, callback, i)
 }
 
 func (c *context) JSONPBlob(code int, callback string, b []byte) (err error) {
+	c.checkReleased()
 	c.writeContentType(MIMEApplicationJavaScriptCharsetUTF8)
 	c.response.WriteHeader(code)
 	if _, err = c.response.Write([]byte(callback + "(")); err != nil {
@@ -509,6 +942,7 @@ func (c *context) xml(code int, i interface{}, indent string) (err error) {
 }
 
 func (c *context) XML(code int, i interface{}) (err error) {
+	c.checkReleased()
 	indent := ""
 	if _, pretty := c.QueryParams()["pretty"]; c.echo.Debug || pretty {
 		indent = defaultIndent
@@ -517,10 +951,12 @@ func (c *context) XML(code int, i interface{}) (err error) {
 }
 
 func (c *context) XMLPretty(code int, i interface{}, indent string) (err error) {
+	c.checkReleased()
 	return c.xml(code, i, indent)
 }
 
 func (c *context) XMLBlob(code int, b []byte) (err error) {
+	c.checkReleased()
 	c.writeContentType(MIMEApplicationXMLCharsetUTF8)
 	c.response.WriteHeader(code)
 	if _, err = c.response.Write([]byte(xml.Header)); err != nil {
@@ -531,6 +967,7 @@ func (c *context) XMLBlob(code int, b []byte) (err error) {
 }
 
 func (c *context) Blob(code int, contentType string, b []byte) (err error) {
+	c.checkReleased()
 	c.writeContentType(contentType)
 	c.response.WriteHeader(code)
 	_, err = c.response.Write(b)
@@ -538,13 +975,183 @@ func (c *context) Blob(code int, contentType string, b []byte) (err error) {
 }
 
 func (c *context) Stream(code int, contentType string, r io.Reader) (err error) {
+	c.checkReleased()
 	c.writeContentType(contentType)
 	c.response.WriteHeader(code)
 	_, err = io.Copy(c.response, r)
 	return
 }
 
+func (c *context) JSONLines(code int, source interface{}) (err error) {
+	c.checkReleased()
+	next, err := c.iterator(source)
+	if err != nil {
+		return
+	}
+	c.writeContentType(MIMEApplicationNDJSON)
+	c.response.WriteHeader(code)
+	enc := json.NewEncoder(c.response)
+	err = c.stream(next, enc.Encode)
+	c.flushStream()
+	return
+}
+
+func (c *context) JSONArrayStream(code int, source interface{}) (err error) {
+	c.checkReleased()
+	next, err := c.iterator(source)
+	if err != nil {
+		return
+	}
+	c.writeContentType(MIMEApplicationJSONCharsetUTF8)
+	c.response.WriteHeader(code)
+	sep := []byte{'['}
+	err = c.stream(next, func(i interface{}) error {
+		b, err := json.Marshal(i)
+		if err != nil {
+			return err
+		}
+		if _, err = c.response.Write(sep); err != nil {
+			return err
+		}
+		sep[0] = ','
+		_, err = c.response.Write(b)
+		return err
+	})
+	if err == nil {
+		if sep[0] == '[' {
+			_, err = c.response.Write([]byte("[]"))
+		} else {
+			_, err = c.response.Write([]byte{']'})
+		}
+	}
+	c.flushStream()
+	return
+}
+
+// iterator returns an Iterator over `source`, a receive channel or an Iterator.
+// Receiving from a channel stops once the request context is done and flushes
+// the response before blocking.
+func (c *context) iterator(source interface{}) (Iterator, error) {
+	switch s := source.(type) {
+	case Iterator:
+		return s, nil
+	case func() (interface{}, bool, error):
+		return s, nil
+	}
+	v := reflect.ValueOf(source)
+	if v.Kind() != reflect.Chan || v.Type().ChanDir()&reflect.RecvDir == 0 {
+		return nil, ErrInvalidStreamSource
+	}
+	ctx := c.request.Context()
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: v},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+		{Dir: reflect.SelectDefault},
+	}
+	return func() (interface{}, bool, error) {
+		chosen, item, ok := reflect.Select(cases)
+		if chosen == 2 {
+			c.flushStream()
+			chosen, item, ok = reflect.Select(cases[:2])
+		}
+		if chosen == 1 {
+			return nil, false, ctx.Err()
+		}
+		if !ok {
+			return nil, false, nil
+		}
+		return item.Interface(), true, nil
+	}, nil
+}
+
+// stream calls `fn` for every item, flushing the response every
+// streamFlushItems items.
+func (c *context) stream(next Iterator, fn func(interface{}) error) error {
+	ctx := c.request.Context()
+	for n := 1; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		item, ok, err := next()
+		if err != nil || !ok {
+			return err
+		}
+		if err = fn(item); err != nil {
+			return err
+		}
+		if n%streamFlushItems == 0 {
+			c.flushStream()
+		}
+	}
+}
+
+func (c *context) flushStream() {
+	if _, ok := c.response.Writer.(http.Flusher); ok {
+		c.response.Flush()
+	}
+}
+
+func (c *context) YAML(code int, i interface{}) error {
+	c.checkReleased()
+	if c.echo.YAML == nil {
+		return ErrYAMLNotRegistered
+	}
+	b, err := c.echo.YAML.Marshal(i)
+	if err != nil {
+		return err
+	}
+	return c.Blob(code, MIMEApplicationYAMLCharsetUTF8, b)
+}
+
+func (c *context) CSV(code int, headers []string, rows [][]string) (err error) {
+	c.checkReleased()
+	c.writeContentType(MIMETextCSVCharsetUTF8)
+	c.response.WriteHeader(code)
+	w := csv.NewWriter(c.response)
+	if headers != nil {
+		if err = w.Write(headers); err != nil {
+			return
+		}
+	}
+	return w.WriteAll(rows)
+}
+
+func (c *context) CSVAttachment(name string, headers []string, rows [][]string) error {
+	c.checkReleased()
+	c.response.Header().Set(HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name))
+	return c.CSV(http.StatusOK, headers, rows)
+}
+
+func (c *context) CSVStream(code int, headers []string, source interface{}) (err error) {
+	c.checkReleased()
+	next, err := c.iterator(source)
+	if err != nil {
+		return
+	}
+	c.writeContentType(MIMETextCSVCharsetUTF8)
+	c.response.WriteHeader(code)
+	w := csv.NewWriter(c.response)
+	write := func(i interface{}) error {
+		row, ok := i.([]string)
+		if !ok {
+			return fmt.Errorf("echo: invalid CSV row type %T, must be []string", i)
+		}
+		w.Write(row)
+		w.Flush()
+		return w.Error()
+	}
+	if headers != nil {
+		if err = write(headers); err != nil {
+			return
+		}
+	}
+	err = c.stream(next, write)
+	c.flushStream()
+	return
+}
+
 func (c *context) File(file string) (err error) {
+	c.checkReleased()
 	f, err := os.Open(file)
 	if err != nil {
 		return NotFoundHandler(c)
@@ -568,10 +1175,12 @@ func (c *context) File(file string) (err error) {
 }
 
 func (c *context) Attachment(file, name string) error {
+	c.checkReleased()
 	return c.contentDisposition(file, name, "attachment")
 }
 
 func (c *context) Inline(file, name string) error {
+	c.checkReleased()
 	return c.contentDisposition(file, name, "inline")
 }
 
@@ -581,11 +1190,80 @@ func (c *context) contentDisposition(file, name, dispositionType string) error {
 }
 
 func (c *context) NoContent(code int) error {
+	c.checkReleased()
 	c.response.WriteHeader(code)
 	return nil
 }
 
+func (c *context) IfNoneMatch(etag string) bool {
+	c.checkReleased()
+	if !strings.HasPrefix(etag, `"`) && !strings.HasPrefix(etag, `W/"`) {
+		etag = `"` + etag + `"`
+	}
+	c.response.Header().Set(HeaderETag, etag)
+	inm := c.request.Header.Get(HeaderIfNoneMatch)
+	if inm == "" {
+		return false
+	}
+	for _, t := range strings.Split(inm, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *context) IfModifiedSince(modtime time.Time) bool {
+	c.checkReleased()
+	if modtime.IsZero() || modtime.Equal(time.Unix(0, 0)) {
+		return false
+	}
+	c.response.Header().Set(HeaderLastModified, modtime.UTC().Format(http.TimeFormat))
+	req := c.request
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	if req.Header.Get(HeaderIfNoneMatch) != "" {
+		return false
+	}
+	ims, err := http.ParseTime(req.Header.Get(HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	// Last-Modified has a precision of seconds
+	return !modtime.Truncate(time.Second).After(ims)
+}
+
+func (c *context) NotModified() error {
+	c.checkReleased()
+	h := c.response.Header()
+	h.Del(HeaderContentType)
+	h.Del(HeaderContentLength)
+	h.Del(HeaderContentEncoding)
+	if h.Get(HeaderETag) != "" {
+		h.Del(HeaderLastModified)
+	}
+	c.response.WriteHeader(http.StatusNotModified)
+	return nil
+}
+
+func (c *context) WriteEarlyHints(links []string) error {
+	c.checkReleased()
+	r := c.request
+	h2 := r.ProtoMajor == 2 && r.TLS != nil // h2c is served by x/net/http2
+	if !informationalSupported || c.response.Committed || !(r.ProtoAtLeast(1, 1) && r.ProtoMajor == 1 || h2) {
+		return ErrEarlyHintsUnsupported
+	}
+	for _, l := range links {
+		c.response.Header().Add(HeaderLink, l)
+	}
+	c.response.Writer.WriteHeader(http.StatusEarlyHints)
+	return nil
+}
+
 func (c *context) Redirect(code int, url string) error {
+	c.checkReleased()
 	if code < 300 || code > 308 {
 		return ErrInvalidRedirectCode
 	}
@@ -594,45 +1272,228 @@ func (c *context) Redirect(code int, url string) error {
 	return nil
 }
 
+func (c *context) Deadline() (deadline time.Time, ok bool) {
+	c.checkReleased()
+	if c.request == nil {
+		return
+	}
+	return c.request.Context().Deadline()
+}
+
+func (c *context) DeferAfterResponse(fn TaskFunc) {
+	c.checkReleased()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.deferred = append(c.deferred, fn)
+}
+
 func (c *context) Error(err error) {
+	c.checkReleased()
+	c.echo.reportError(err, c)
+	if c.errorHandler != nil {
+		c.errorHandler(err, c)
+		return
+	}
 	c.echo.HTTPErrorHandler(err, c)
 }
 
 func (c *context) Echo() *Echo {
+	c.checkReleased()
 	return c.echo
 }
 
+func (c *context) Clone() Context {
+	c.checkReleased()
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	clone := &context{
+		response:     NewResponse(detachedWriter{header: http.Header{}}, c.echo),
+		path:         c.path,
+		pnames:       append([]string(nil), c.pnames...),
+		pvalues:      append([]string(nil), c.pvalues...),
+		pescaped:     c.pescaped,
+		query:        url.Values{},
+		handler:      c.handler,
+		store:        make(Map, len(c.store)),
+		echo:         c.echo,
+		logger:       c.logger,
+		body:         append([]byte(nil), c.body...),
+		bodyRead:     c.bodyRead,
+		principal:    c.principal,
+		flags:        c.flags,
+		userAgent:    c.userAgent,
+		cspNonce:     c.cspNonce,
+		errorHandler: c.errorHandler,
+	}
+	if c.request != nil {
+		clone.request = c.request.Clone(detachedContext{c.request.Context()})
+		clone.request.Body = http.NoBody
+		if c.bodyRead {
+			clone.request.Body = ioutil.NopCloser(bytes.NewReader(clone.body))
+		}
+		for k, v := range c.QueryParams() {
+			clone.query[k] = append([]string(nil), v...)
+		}
+	}
+	for k, v := range c.store {
+		clone.store[k] = v
+	}
+	if c.logFields != nil {
+		clone.logFields = make(Map, len(c.logFields))
+		for k, v := range c.logFields {
+			clone.logFields[k] = v
+		}
+	}
+	if c.memo != nil {
+		clone.memo = make(Map, len(c.memo))
+		for k, v := range c.memo {
+			clone.memo[k] = v
+		}
+	}
+	return clone
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
+
+func (w detachedWriter) Header() http.Header {
+	return w.header
+}
+
+func (detachedWriter) Write([]byte) (int, error) {
+	return 0, ErrDetachedResponse
+}
+
+func (detachedWriter) WriteHeader(int) {}
+
 func (c *context) Handler() HandlerFunc {
+	c.checkReleased()
 	return c.handler
 }
 
 func (c *context) SetHandler(h HandlerFunc) {
+	c.checkReleased()
 	c.handler = h
 }
 
 func (c *context) Logger() Logger {
+	c.checkReleased()
 	res := c.logger
 	if res != nil {
 		return res
 	}
-	return c.echo.Logger
+	return requestLogger{Logger: c.echo.Logger, c: c}
+}
+
+func (c *context) LogFields(keyvals ...interface{}) Map {
+	c.checkReleased()
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if c.logFields == nil {
+			c.logFields = Map{}
+		}
+		c.logFields[fmt.Sprint(keyvals[i])] = keyvals[i+1]
+	}
+	if c.logFields == nil {
+		return nil
+	}
+	fields := make(Map, len(c.logFields))
+	for k, v := range c.logFields {
+		fields[k] = v
+	}
+	return fields
 }
 
 func (c *context) SetLogger(l Logger) {
+	c.checkReleased()
 	c.logger = l
 }
 
+func (c *context) Principal() *Principal {
+	c.checkReleased()
+	return c.principal
+}
+
+func (c *context) SetPrincipal(p *Principal) {
+	c.checkReleased()
+	c.principal = p
+	if p != nil {
+		c.LogFields("principal", p.ID)
+	}
+}
+
+func (c *context) FlagEnabled(name string) bool {
+	c.checkReleased()
+	return c.flags[name]
+}
+
+func (c *context) Flags() Flags {
+	c.checkReleased()
+	return c.flags
+}
+
+func (c *context) SetFlags(f Flags) {
+	c.checkReleased()
+	c.flags = f
+}
+
+func (c *context) UserAgent() *UserAgent {
+	c.checkReleased()
+	if c.userAgent == nil {
+		ua := ""
+		if c.request != nil {
+			ua = c.request.UserAgent()
+		}
+		c.userAgent = ParseUserAgent(ua)
+	}
+	return c.userAgent
+}
+
+func (c *context) CSPNonce() string {
+	c.checkReleased()
+	if c.cspNonce == "" {
+		c.cspNonce = newCSPNonce()
+	}
+	return c.cspNonce
+}
+
 func (c *context) Reset(r *http.Request, w http.ResponseWriter) {
 	c.request = r
 	c.response.reset(w)
 	c.query = nil
 	c.handler = NotFoundHandler
+	c.lock.Lock()
 	c.store = nil
+	c.logFields = nil
+	c.memo = nil
+	c.lock.Unlock()
 	c.path = ""
 	c.pnames = nil
 	c.logger = nil
+	c.body = nil
+	c.bodyRead = false
+	c.deferred = nil
+	c.principal = nil
+	c.flags = nil
+	c.userAgent = nil
+	c.cspNonce = ""
+	c.errorHandler = nil
+	c.pescaped = false
 	// NOTE: Don't reset because it has to have length c.echo.maxParam at all times
-	for i := 0; i < *c.echo.maxParam; i++ {
+	for i := range c.pvalues {
 		c.pvalues[i] = ""
 	}
+	c.reserveParams(c.echo.maxParams())
 }
@@ -2,16 +2,21 @@ package echo
 
 import (
 	"bytes"
+	stdContext "context"
 	"crypto/tls"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	htmlTemplate "html/template"
 	"io"
+	"io/ioutil"
 	"math"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
 	"net/url"
 	"strings"
 	"testing"
@@ -185,6 +190,19 @@ func TestContext(t *testing.T) {
 		assert.Equal(xml.Header+userXML, rec.Body.String())
 	}
 
+	// YAML
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec).(*context)
+	assert.Equal(ErrYAMLNotRegistered, c.YAML(http.StatusOK, user{1, "Jon Snow"}))
+	e.YAML = jsonYAMLCodec{}
+	err = c.YAML(http.StatusOK, user{1, "Jon Snow"})
+	if assert.NoError(err) {
+		assert.Equal(http.StatusOK, rec.Code)
+		assert.Equal(MIMEApplicationYAMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal(userJSON, rec.Body.String())
+	}
+	e.YAML = nil
+
 	// XML with "?pretty"
 	req = httptest.NewRequest(http.MethodGet, "/?pretty", nil)
 	rec = httptest.NewRecorder()
@@ -508,6 +526,45 @@ func TestContextGetAndSetParam(t *testing.T) {
 	})
 }
 
+func TestContextParamStorageReuse(t *testing.T) {
+	e := New()
+	e.GET("/:a/:b/:c", func(Context) error { return nil })
+	c := e.NewContext(nil, nil).(*context)
+
+	// Values are copied, not aliased
+	values := []string{"1", "2"}
+	c.SetParamNames("a", "b")
+	c.SetParamValues(values...)
+	values[0] = "x"
+	testify.Equal(t, "1", c.Param("a"))
+	testify.Equal(t, 3, e.maxParams(), "maxParam is not shrunk")
+
+	// More names than the router params
+	c.SetParamNames("a", "b", "c", "d")
+	c.SetParamValues("1", "2", "3", "4")
+	testify.Equal(t, []string{"1", "2", "3", "4"}, c.ParamValues())
+	testify.NotPanics(t, func() {
+		c.Reset(nil, nil)
+	})
+	testify.Equal(t, []string{"", "", "", ""}, c.pvalues)
+
+	// Routes with more params added after the context was created
+	c = e.NewContext(nil, nil).(*context)
+	e.GET("/:a/:b/:c/:d/:e", func(Context) error { return nil })
+	testify.NotPanics(t, func() {
+		e.router.Find(http.MethodGet, "/1/2/3/4/5", c)
+	})
+	testify.Equal(t, "5", c.Param("e"))
+
+	// No allocations
+	names := []string{"a", "b"}
+	testify.Zero(t, testing.AllocsPerRun(100, func() {
+		c.Reset(nil, nil)
+		c.SetParamNames(names...)
+		c.SetParamValues("1", "2")
+	}))
+}
+
 func TestContextFormValue(t *testing.T) {
 	f := make(url.Values)
 	f.Set("name", "Jon Snow")
@@ -612,6 +669,38 @@ func TestContextStore(t *testing.T) {
 	testify.Equal(t, "Jon Snow", c.Get("name"))
 }
 
+func TestContextMemoize(t *testing.T) {
+	e := New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	calls := 0
+	lookup := func() (interface{}, error) {
+		calls++
+		return "Jon Snow", nil
+	}
+
+	v, err := c.Memoize("user", lookup)
+	testify.NoError(t, err)
+	testify.Equal(t, "Jon Snow", v)
+	v, _ = c.Memoize("user", lookup)
+	testify.Equal(t, "Jon Snow", v)
+	testify.Equal(t, 1, calls)
+	testify.Nil(t, c.Get("user"))
+
+	// Errors aren't memoized
+	_, err = c.Memoize("flags", func() (interface{}, error) {
+		return nil, errors.New("unavailable")
+	})
+	testify.EqualError(t, err, "unavailable")
+	v, _ = c.Memoize("flags", func() (interface{}, error) {
+		return "on", nil
+	})
+	testify.Equal(t, "on", v)
+
+	c.Reset(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Memoize("user", lookup)
+	testify.Equal(t, 2, calls)
+}
+
 func BenchmarkContext_Store(b *testing.B) {
 	e := &Echo{}
 
@@ -818,6 +907,282 @@ func TestContext_Bind(t *testing.T) {
 	testify.Equal(t, &user{1, "Jon Snow"}, u)
 }
 
+func TestContext_Body(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	b, err := c.Body()
+	if testify.NoError(t, err) {
+		testify.Equal(t, userJSON, string(b))
+	}
+	u := new(user)
+	if testify.NoError(t, c.Bind(u)) {
+		testify.Equal(t, &user{1, "Jon Snow"}, u)
+	}
+	// Memoized and replayed again
+	b, err = c.Body()
+	if testify.NoError(t, err) {
+		testify.Equal(t, userJSON, string(b))
+	}
+	rb, _ := ioutil.ReadAll(c.Request().Body)
+	testify.Equal(t, userJSON, string(rb))
+
+	c.SetBody([]byte(`{"id":2,"name":"Arya"}`))
+	u = new(user)
+	if testify.NoError(t, c.Bind(u)) {
+		testify.Equal(t, &user{2, "Arya"}, u)
+	}
+
+	// Limit
+	e.BodyCaptureLimit = 4
+	c.Reset(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(userJSON)), httptest.NewRecorder())
+	_, err = c.Body()
+	testify.Equal(t, ErrStatusRequestEntityTooLarge, err)
+	rb, _ = ioutil.ReadAll(c.Request().Body)
+	testify.Equal(t, userJSON, string(rb))
+
+	// No body
+	c.Reset(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	b, err = c.Body()
+	if testify.NoError(t, err) {
+		testify.Empty(t, b)
+	}
+}
+
+func TestContext_JSONStream(t *testing.T) {
+	e := New()
+	items := func(n int) Iterator {
+		i := 0
+		return func() (interface{}, bool, error) {
+			if i == n {
+				return nil, false, nil
+			}
+			i++
+			return user{i, "Jon Snow"}, true, nil
+		}
+	}
+
+	// Iterator
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if testify.NoError(t, c.JSONLines(http.StatusOK, items(2))) {
+		testify.Equal(t, http.StatusOK, rec.Code)
+		testify.Equal(t, MIMEApplicationNDJSON, rec.Header().Get(HeaderContentType))
+		testify.Equal(t, `{"id":1,"name":"Jon Snow"}`+"\n"+`{"id":2,"name":"Jon Snow"}`+"\n", rec.Body.String())
+		testify.True(t, rec.Flushed)
+	}
+
+	// Channel
+	ch := make(chan user, 2)
+	ch <- user{1, "Jon Snow"}
+	ch <- user{2, "Arya"}
+	close(ch)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if testify.NoError(t, c.JSONArrayStream(http.StatusOK, ch)) {
+		testify.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+		testify.Equal(t, `[{"id":1,"name":"Jon Snow"},{"id":2,"name":"Arya"}]`, rec.Body.String())
+	}
+
+	// Empty
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if testify.NoError(t, c.JSONArrayStream(http.StatusOK, items(0))) {
+		testify.Equal(t, `[]`, rec.Body.String())
+	}
+
+	// Iterator error
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	fail := errors.New("fail")
+	err := c.JSONArrayStream(http.StatusOK, func() (interface{}, bool, error) {
+		return nil, false, fail
+	})
+	testify.Equal(t, fail, err)
+
+	// Invalid source
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	testify.Equal(t, ErrInvalidStreamSource, c.JSONLines(http.StatusOK, []user{}))
+	testify.Equal(t, ErrInvalidStreamSource, c.JSONLines(http.StatusOK, make(chan<- user)))
+	testify.False(t, c.Response().Committed)
+}
+
+func TestContext_CSV(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	err := c.CSV(http.StatusOK, []string{"id", "name"}, [][]string{{"1", "Snow, Jon"}, {"2", `Arya "No One"`}})
+	if testify.NoError(t, err) {
+		testify.Equal(t, http.StatusOK, rec.Code)
+		testify.Equal(t, MIMETextCSVCharsetUTF8, rec.Header().Get(HeaderContentType))
+		testify.Equal(t, "id,name\n1,\"Snow, Jon\"\n2,\"Arya \"\"No One\"\"\"\n", rec.Body.String())
+	}
+
+	// Attachment
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if testify.NoError(t, c.CSVAttachment("users.csv", nil, [][]string{{"1", "Jon"}})) {
+		testify.Equal(t, `attachment; filename="users.csv"`, rec.Header().Get(HeaderContentDisposition))
+		testify.Equal(t, "1,Jon\n", rec.Body.String())
+	}
+
+	// Stream
+	ch := make(chan []string, 2)
+	ch <- []string{"1", "Jon"}
+	ch <- []string{"2", "Arya"}
+	close(ch)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if testify.NoError(t, c.CSVStream(http.StatusOK, []string{"id", "name"}, ch)) {
+		testify.Equal(t, "id,name\n1,Jon\n2,Arya\n", rec.Body.String())
+		testify.True(t, rec.Flushed)
+	}
+
+	// Invalid row
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	ich := make(chan int, 1)
+	ich <- 1
+	close(ich)
+	testify.Error(t, c.CSVStream(http.StatusOK, nil, ich))
+}
+
+func TestContext_Principal(t *testing.T) {
+	e := New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	testify.Nil(t, c.Principal())
+	testify.False(t, c.Principal().HasScope("users:read"))
+
+	p := &Principal{ID: "jon", Method: "jwt", Scopes: []string{"users:read"}}
+	c.SetPrincipal(p)
+	testify.Equal(t, p, c.Principal())
+	testify.True(t, c.Principal().HasScope("users:read"))
+	testify.False(t, c.Principal().HasScope("users:write"))
+	testify.Equal(t, Map{"principal": "jon"}, c.LogFields())
+	testify.Equal(t, p, c.Clone().Principal())
+
+	c.Reset(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	testify.Nil(t, c.Principal())
+}
+
+func TestContext_CSPNonce(t *testing.T) {
+	e := New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	nonce := c.CSPNonce()
+	testify.Len(t, nonce, 22)
+	testify.Equal(t, nonce, c.CSPNonce())
+	testify.Equal(t, nonce, c.Clone().CSPNonce())
+
+	tpl := htmlTemplate.Must(htmlTemplate.New("page").Funcs(TemplateFuncs).Parse(`<script nonce="{{cspNonce .}}"></script>`))
+	buf := new(bytes.Buffer)
+	testify.NoError(t, tpl.Execute(buf, c))
+	testify.Equal(t, `<script nonce="`+nonce+`"></script>`, buf.String())
+
+	c.Reset(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	testify.NotEqual(t, nonce, c.CSPNonce())
+}
+
+func TestContext_ConditionalGET(t *testing.T) {
+	e := New()
+	modtime := time.Date(2020, 5, 1, 10, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name        string
+		method      string
+		header      http.Header
+		notModified bool
+	}{
+		{name: "no conditions", method: http.MethodGet},
+		{name: "etag match", method: http.MethodGet, header: http.Header{HeaderIfNoneMatch: {`"v0", W/"v1"`}}, notModified: true},
+		{name: "etag wildcard", method: http.MethodHead, header: http.Header{HeaderIfNoneMatch: {"*"}}, notModified: true},
+		{name: "etag mismatch", method: http.MethodGet, header: http.Header{
+			HeaderIfNoneMatch:     {`"v0"`},
+			HeaderIfModifiedSince: {modtime.Format(http.TimeFormat)},
+		}},
+		{name: "not modified since", method: http.MethodGet, header: http.Header{HeaderIfModifiedSince: {modtime.Format(http.TimeFormat)}}, notModified: true},
+		{name: "modified since", method: http.MethodGet, header: http.Header{HeaderIfModifiedSince: {modtime.Add(-time.Hour).Format(http.TimeFormat)}}},
+		{name: "invalid date", method: http.MethodGet, header: http.Header{HeaderIfModifiedSince: {"yesterday"}}},
+		{name: "unsafe method", method: http.MethodPost, header: http.Header{HeaderIfModifiedSince: {modtime.Format(http.TimeFormat)}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.Header = tt.header
+			if req.Header == nil {
+				req.Header = http.Header{}
+			}
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Response().Header().Set(HeaderCacheControl, "max-age=60")
+			c.Response().Header().Set(HeaderContentType, MIMETextPlain)
+
+			notModified := c.IfNoneMatch("v1") || c.IfModifiedSince(modtime)
+			testify.Equal(t, tt.notModified, notModified)
+			if !notModified {
+				testify.NoError(t, c.String(http.StatusOK, "body"))
+				testify.Equal(t, `"v1"`, rec.Header().Get(HeaderETag))
+				return
+			}
+			testify.NoError(t, c.NotModified())
+			testify.Equal(t, http.StatusNotModified, rec.Code)
+			testify.Empty(t, rec.Body.String())
+			testify.Equal(t, `"v1"`, rec.Header().Get(HeaderETag))
+			testify.Equal(t, "max-age=60", rec.Header().Get(HeaderCacheControl))
+			testify.Empty(t, rec.Header().Get(HeaderContentType))
+		})
+	}
+
+	// Last-Modified only
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderIfModifiedSince, modtime.Format(http.TimeFormat))
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	testify.True(t, c.IfModifiedSince(modtime))
+	testify.NoError(t, c.NotModified())
+	testify.Equal(t, "Fri, 01 May 2020 10:00:00 GMT", rec.Header().Get(HeaderLastModified))
+	testify.False(t, c.IfModifiedSince(time.Time{}))
+}
+
+func TestContext_Clone(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodPost, "/users/1?page=2", strings.NewReader(userJSON))
+	ctx, cancel := stdContext.WithCancel(stdContext.WithValue(req.Context(), "trace", "abc"))
+	req = req.WithContext(ctx)
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.SetPath("/users/:id")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+	c.Set("user", "Jon Snow")
+	c.LogFields("tenant", "acme")
+	_, err := c.Body()
+	testify.NoError(t, err)
+
+	clone := c.Clone()
+	cancel()
+	c.Reset(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Set("user", "Arya")
+
+	testify.Equal(t, "/users/:id", clone.Path())
+	testify.Equal(t, "1", clone.Param("id"))
+	testify.Equal(t, "2", clone.QueryParam("page"))
+	testify.Equal(t, "Jon Snow", clone.Get("user"))
+	testify.Equal(t, Map{"tenant": "acme"}, clone.LogFields())
+	testify.Equal(t, http.MethodPost, clone.Request().Method)
+	b, err := clone.Body()
+	if testify.NoError(t, err) {
+		testify.Equal(t, userJSON, string(b))
+	}
+
+	// Detached request context
+	testify.NoError(t, clone.Request().Context().Err())
+	testify.Equal(t, "abc", clone.Request().Context().Value("trace"))
+
+	// Response can't be written
+	testify.Equal(t, ErrDetachedResponse, clone.String(http.StatusOK, "OK"))
+}
+
 func TestContext_Logger(t *testing.T) {
 	e := New()
 	c := e.NewContext(nil, nil)
@@ -834,6 +1199,38 @@ func TestContext_Logger(t *testing.T) {
 	testify.Equal(t, log1, c.Logger())
 }
 
+func TestContext_LoggerFields(t *testing.T) {
+	e := New()
+	buf := new(bytes.Buffer)
+	e.Logger.SetOutput(buf)
+	e.Logger.SetHeader(`{"level":"${level}"}`)
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(HeaderXRequestID, "abc")
+	req.RemoteAddr = "192.0.2.1:1234"
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.SetPath("/users/:id")
+
+	fields := c.LogFields("user", "jon", "dangling")
+	testify.Equal(t, Map{"user": "jon"}, fields)
+	c.Logger().Errorf("failed %d", 1)
+
+	var entry map[string]interface{}
+	if testify.NoError(t, json.Unmarshal(buf.Bytes(), &entry)) {
+		testify.Equal(t, map[string]interface{}{
+			"level":     "ERROR",
+			"id":        "abc",
+			"method":    http.MethodGet,
+			"remote_ip": "192.0.2.1",
+			"route":     "/users/:id",
+			"user":      "jon",
+			"message":   "failed 1",
+		}, entry)
+	}
+
+	c.Reset(req, httptest.NewRecorder())
+	testify.Empty(t, c.LogFields())
+}
+
 func TestContext_RealIP(t *testing.T) {
 	tests := []struct {
 		c Context
@@ -871,3 +1268,46 @@ func TestContext_RealIP(t *testing.T) {
 		testify.Equal(t, tt.s, tt.c.RealIP())
 	}
 }
+
+func TestContext_WriteEarlyHints(t *testing.T) {
+	e := New()
+	e.GET("/", func(c Context) error {
+		if err := c.WriteEarlyHints([]string{"</app.css>; rel=preload; as=style"}); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, "OK")
+	})
+	s := httptest.NewServer(e)
+	defer s.Close()
+
+	var hints []textproto.MIMEHeader
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				hints = append(hints, header)
+			}
+			return nil
+		},
+	}
+	req, _ := http.NewRequest(http.MethodGet, s.URL, nil)
+	res, err := http.DefaultClient.Do(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if testify.NoError(t, err) {
+		res.Body.Close()
+		testify.Equal(t, http.StatusOK, res.StatusCode)
+		testify.Equal(t, "</app.css>; rel=preload; as=style", res.Header.Get(HeaderLink))
+		if testify.Len(t, hints, 1) {
+			testify.Equal(t, "</app.css>; rel=preload; as=style", hints[0].Get(HeaderLink))
+		}
+	}
+
+	// HTTP/1.0
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Proto, req.ProtoMinor = "HTTP/1.0", 0
+	c := e.NewContext(req, httptest.NewRecorder())
+	testify.Equal(t, ErrEarlyHintsUnsupported, c.WriteEarlyHints([]string{"</app.css>; rel=preload"}))
+
+	// Committed
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.NoContent(http.StatusNoContent)
+	testify.Equal(t, ErrEarlyHintsUnsupported, c.WriteEarlyHints([]string{"</app.css>; rel=preload"}))
+}
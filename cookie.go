@@ -0,0 +1,237 @@
+package echo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type (
+	// CookieConfig defines how cookies set with `Context#SetSignedCookie()` and
+	// `Context#SetEncryptedCookie()` are protected and defaulted.
+	CookieConfig struct {
+		// Keys sign and encrypt cookies. New cookies use the first key while
+		// cookies protected with any of the keys are accepted, so that keys can
+		// be rotated by prepending a new key and dropping the oldest one later.
+		// Keys should be at least 32 random bytes.
+		// Required to use signed or encrypted cookies.
+		Keys [][]byte
+
+		// SameSite is set on cookies without a SameSite attribute.
+		// Optional. Default value `http.SameSiteLaxMode`.
+		SameSite http.SameSite
+
+		// Insecure allows cookies to be sent over plain HTTP, e.g. during
+		// development. By default the Secure attribute is set.
+		// Optional. Default value false.
+		Insecure bool
+
+		// ScriptAccess allows scripts to read cookies. By default the HttpOnly
+		// attribute is set.
+		// Optional. Default value false.
+		ScriptAccess bool
+
+		// MaxAge is the max age, in seconds, of cookies re-issued under the
+		// first key when read with an older key, since clients don't send the
+		// attributes of cookies.
+		// Optional. Default value 0, re-issued cookies are session cookies.
+		MaxAge int
+	}
+)
+
+var (
+	// ErrCookieKeysMissing is returned when signing or encrypting cookies
+	// without `CookieConfig.Keys`.
+	ErrCookieKeysMissing = errors.New("cookie keys not configured")
+
+	// ErrInvalidCookie is returned for cookies which were tampered with or
+	// weren't protected with any of `CookieConfig.Keys`.
+	ErrInvalidCookie = errors.New("invalid cookie")
+)
+
+// cookieKey derives the key of a purpose from a configured key, so that the
+// same key isn't used both for signing and encryption.
+func cookieKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("echo cookie " + purpose))
+	return mac.Sum(nil)
+}
+
+// signCookie returns the value and its signature, binding the value to the
+// cookie name.
+func signCookie(key []byte, name, value string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(value)) + "." +
+		base64.RawURLEncoding.EncodeToString(cookieMAC(key, name, value))
+}
+
+func cookieMAC(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, cookieKey(key, "signing"))
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// verifyCookie returns the value of a cookie created by `signCookie()` and the
+// index of the key it was signed with.
+func verifyCookie(keys [][]byte, name, signed string) (string, int, error) {
+	i := strings.LastIndexByte(signed, '.')
+	if i == -1 {
+		return "", 0, ErrInvalidCookie
+	}
+	value, err := base64.RawURLEncoding.DecodeString(signed[:i])
+	if err != nil {
+		return "", 0, ErrInvalidCookie
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(signed[i+1:])
+	if err != nil {
+		return "", 0, ErrInvalidCookie
+	}
+	for k, key := range keys {
+		if hmac.Equal(sig, cookieMAC(key, name, string(value))) {
+			return string(value), k, nil
+		}
+	}
+	return "", 0, ErrInvalidCookie
+}
+
+func cookieAEAD(key []byte) cipher.AEAD {
+	block, _ := aes.NewCipher(cookieKey(key, "encryption")) // 32 bytes key
+	aead, _ := cipher.NewGCM(block)
+	return aead
+}
+
+// encryptCookie returns the value encrypted with AES-GCM, authenticating the
+// cookie name.
+func encryptCookie(key []byte, name, value string) (string, error) {
+	aead := cookieAEAD(key)
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(value), []byte(name))), nil
+}
+
+// decryptCookie returns the value of a cookie created by `encryptCookie()` and
+// the index of the key it was encrypted with.
+func decryptCookie(keys [][]byte, name, encrypted string) (string, int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(encrypted)
+	if err != nil {
+		return "", 0, ErrInvalidCookie
+	}
+	for k, key := range keys {
+		aead := cookieAEAD(key)
+		if len(b) < aead.NonceSize() {
+			return "", 0, ErrInvalidCookie
+		}
+		value, err := aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], []byte(name))
+		if err == nil {
+			return string(value), k, nil
+		}
+	}
+	return "", 0, ErrInvalidCookie
+}
+
+// reissued returns the cookie to set again under the first key for a cookie
+// read with an older key.
+func (config CookieConfig) reissued(cookie *http.Cookie) *http.Cookie {
+	return &http.Cookie{Name: cookie.Name, Value: cookie.Value, MaxAge: config.MaxAge}
+}
+
+// withDefaults returns a copy of the cookie with the attributes of the config.
+func (config CookieConfig) withDefaults(cookie *http.Cookie) *http.Cookie {
+	cp := *cookie
+	if cp.Path == "" {
+		cp.Path = "/"
+	}
+	if cp.SameSite == 0 {
+		cp.SameSite = config.SameSite
+		if cp.SameSite == 0 {
+			cp.SameSite = http.SameSiteLaxMode
+		}
+	}
+	cp.Secure = cp.Secure || !config.Insecure
+	cp.HttpOnly = cp.HttpOnly || !config.ScriptAccess
+	return &cp
+}
+
+func (c *context) SetSignedCookie(cookie *http.Cookie) error {
+	c.checkReleased()
+	config := c.echo.Cookies
+	if len(config.Keys) == 0 {
+		return ErrCookieKeysMissing
+	}
+	cookie = config.withDefaults(cookie)
+	cookie.Value = signCookie(config.Keys[0], cookie.Name, cookie.Value)
+	http.SetCookie(c.Response(), cookie)
+	return nil
+}
+
+func (c *context) SignedCookie(name string) (*http.Cookie, error) {
+	c.checkReleased()
+	cookie, err := c.request.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+	keys := c.echo.Cookies.Keys
+	if len(keys) == 0 {
+		return nil, ErrCookieKeysMissing
+	}
+	value, k, err := verifyCookie(keys, name, cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+	cookie.Value = value
+	if k > 0 && !c.response.Committed {
+		if err := c.SetSignedCookie(c.echo.Cookies.reissued(cookie)); err != nil {
+			return nil, err
+		}
+	}
+	return cookie, nil
+}
+
+func (c *context) SetEncryptedCookie(cookie *http.Cookie) error {
+	c.checkReleased()
+	config := c.echo.Cookies
+	if len(config.Keys) == 0 {
+		return ErrCookieKeysMissing
+	}
+	cookie = config.withDefaults(cookie)
+	value, err := encryptCookie(config.Keys[0], cookie.Name, cookie.Value)
+	if err != nil {
+		return err
+	}
+	cookie.Value = value
+	http.SetCookie(c.Response(), cookie)
+	return nil
+}
+
+func (c *context) EncryptedCookie(name string) (*http.Cookie, error) {
+	c.checkReleased()
+	cookie, err := c.request.Cookie(name)
+	if err != nil {
+		return nil, err
+	}
+	keys := c.echo.Cookies.Keys
+	if len(keys) == 0 {
+		return nil, ErrCookieKeysMissing
+	}
+	value, k, err := decryptCookie(keys, name, cookie.Value)
+	if err != nil {
+		return nil, err
+	}
+	cookie.Value = value
+	if k > 0 && !c.response.Committed {
+		if err := c.SetEncryptedCookie(c.echo.Cookies.reissued(cookie)); err != nil {
+			return nil, err
+		}
+	}
+	return cookie, nil
+}
@@ -0,0 +1,139 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cookieRequest(e *Echo, set func(c Context) error) *http.Request {
+	rec := httptest.NewRecorder()
+	if err := set(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)); err != nil {
+		panic(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, cookie := range rec.Result().Cookies() {
+		req.AddCookie(cookie)
+	}
+	return req
+}
+
+func TestContextSignedCookie(t *testing.T) {
+	oldKey := []byte(strings.Repeat("o", 32))
+	e := New()
+	e.Cookies.Keys = [][]byte{oldKey}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	assert.NoError(t, c.SetSignedCookie(&http.Cookie{Name: "user", Value: "jon"}))
+	cookie := rec.Result().Cookies()[0]
+	assert.NotEqual(t, "jon", cookie.Value)
+	assert.Equal(t, "/", cookie.Path)
+	assert.True(t, cookie.Secure)
+	assert.True(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	cookie, err := e.NewContext(req, nil).SignedCookie("user")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "jon", cookie.Value)
+	}
+
+	// Rotated key, re-signed on read
+	e.Cookies.Keys = [][]byte{[]byte(strings.Repeat("n", 32)), oldKey}
+	e.Cookies.MaxAge = 3600
+	rec = httptest.NewRecorder()
+	cookie, err = e.NewContext(req, rec).SignedCookie("user")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "jon", cookie.Value)
+	}
+	reissued := rec.Result().Cookies()
+	if assert.Len(t, reissued, 1) {
+		assert.Equal(t, 3600, reissued[0].MaxAge)
+		assert.True(t, reissued[0].HttpOnly)
+	}
+	e.Cookies.Keys = e.Cookies.Keys[:1]
+	_, err = e.NewContext(req, nil).SignedCookie("user")
+	assert.Equal(t, ErrInvalidCookie, err)
+	req2 := httptest.NewRequest(http.MethodGet, "/", nil)
+	req2.AddCookie(reissued[0])
+	rec = httptest.NewRecorder()
+	cookie, err = e.NewContext(req2, rec).SignedCookie("user")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "jon", cookie.Value)
+	}
+	assert.Empty(t, rec.Result().Cookies(), "current key not re-signed")
+	e.Cookies.MaxAge = 0
+
+	// Tampered
+	e.Cookies.Keys = [][]byte{oldKey}
+	raw, _ := req.Cookie("user")
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "user", Value: "YWRtaW4" + raw.Value[strings.IndexByte(raw.Value, '.'):]})
+	_, err = e.NewContext(req, nil).SignedCookie("user")
+	assert.Equal(t, ErrInvalidCookie, err)
+
+	// Renamed
+	req = cookieRequest(e, func(c Context) error {
+		return c.SetSignedCookie(&http.Cookie{Name: "role", Value: "user"})
+	})
+	value, _ := req.Cookie("role")
+	req.AddCookie(&http.Cookie{Name: "admin", Value: value.Value})
+	_, err = e.NewContext(req, nil).SignedCookie("admin")
+	assert.Equal(t, ErrInvalidCookie, err)
+
+	_, err = e.NewContext(req, nil).SignedCookie("missing")
+	assert.Equal(t, http.ErrNoCookie, err)
+	e.Cookies.Keys = nil
+	assert.Equal(t, ErrCookieKeysMissing, e.NewContext(req, rec).SetSignedCookie(&http.Cookie{Name: "user"}))
+}
+
+func TestContextEncryptedCookie(t *testing.T) {
+	oldKey := []byte(strings.Repeat("o", 32))
+	e := New()
+	e.Cookies = CookieConfig{Keys: [][]byte{oldKey}, SameSite: http.SameSiteStrictMode, Insecure: true, ScriptAccess: true}
+	req := cookieRequest(e, func(c Context) error {
+		return c.SetEncryptedCookie(&http.Cookie{Name: "cart", Value: "42", Path: "/shop"})
+	})
+	raw, _ := req.Cookie("cart")
+	assert.NotContains(t, raw.Value, "42")
+
+	cookie, err := e.NewContext(req, nil).EncryptedCookie("cart")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "42", cookie.Value)
+	}
+	_, err = e.NewContext(req, nil).SignedCookie("cart")
+	assert.Equal(t, ErrInvalidCookie, err)
+
+	// Rotated key, encrypted again on read
+	e.Cookies.Keys = [][]byte{[]byte(strings.Repeat("n", 32)), oldKey}
+	rec := httptest.NewRecorder()
+	cookie, err = e.NewContext(req, rec).EncryptedCookie("cart")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "42", cookie.Value)
+	}
+	reissued := rec.Result().Cookies()
+	e.Cookies.Keys = e.Cookies.Keys[:1]
+	_, err = e.NewContext(req, nil).EncryptedCookie("cart")
+	assert.Equal(t, ErrInvalidCookie, err)
+	if assert.Len(t, reissued, 1) {
+		req2 := httptest.NewRequest(http.MethodGet, "/", nil)
+		req2.AddCookie(reissued[0])
+		cookie, err = e.NewContext(req2, nil).EncryptedCookie("cart")
+		if assert.NoError(t, err) {
+			assert.Equal(t, "42", cookie.Value)
+		}
+	}
+
+	// Attributes
+	rec = httptest.NewRecorder()
+	assert.NoError(t, e.NewContext(req, rec).SetEncryptedCookie(&http.Cookie{Name: "cart", Value: "42", Path: "/shop"}))
+	cookie = rec.Result().Cookies()[0]
+	assert.Equal(t, "/shop", cookie.Path)
+	assert.False(t, cookie.Secure)
+	assert.False(t, cookie.HttpOnly)
+	assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+}
@@ -0,0 +1,32 @@
+package echo
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"html/template"
+)
+
+// TemplateFuncs are template functions for `Renderer` implementations based on
+// html/template. Templates get the context of the request as argument:
+//
+// - "cspNonce" returns the Content-Security-Policy nonce of the request, e.g.
+// `<script nonce="{{cspNonce .Context}}">`
+//
+// - "formInput" renders the label, input and error of a `FormField`, e.g.
+// `{{range .Form.Fields}}{{formInput .}}{{end}}`
+var TemplateFuncs = template.FuncMap{
+	"cspNonce": func(c Context) string {
+		return c.CSPNonce()
+	},
+	"formInput": formInput,
+}
+
+// newCSPNonce returns a random nonce of 128 bits, base64url encoded so that it
+// needs no escaping in HTML attributes.
+func newCSPNonce() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
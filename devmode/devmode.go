@@ -0,0 +1,359 @@
+/*
+Package devmode speeds up the edit-refresh loop during development: templates
+are parsed again when they change and, with live reload, pages open in the
+browser reload when templates or static files change.
+
+Files are polled for changes, so that no platform specific file notification
+API is needed. Without `Config.Dev`, templates are parsed once and nothing is
+watched, so that the same setup serves production.
+
+Example:
+
+	w, err := devmode.New(e, devmode.Config{
+	  Dev:        os.Getenv("APP_ENV") == "development",
+	  Templates:  "views/*.html",
+	  Watch:      []string{"public"},
+	  LiveReload: true,
+	})
+	if err != nil {
+	  e.Logger.Fatal(err)
+	}
+	e.Renderer = w
+	e.Static("/", "public")
+*/
+package devmode
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Config defines the config for development mode.
+	Config struct {
+		// Dev enables watching the files, reloading the templates and live
+		// reload.
+		// Optional. Default value false.
+		Dev bool
+
+		// Templates is the glob pattern of the template files.
+		// Optional.
+		Templates string
+
+		// Funcs are the functions available to the templates.
+		// Optional.
+		Funcs template.FuncMap
+
+		// Watch lists the directories watched for changes in addition to the
+		// template files, e.g. the directories of static files.
+		// Optional.
+		Watch []string
+
+		// Interval is how often files are polled for changes.
+		// Optional. Default value 500ms.
+		Interval time.Duration
+
+		// LiveReload injects a script reloading the page on changes into HTML
+		// responses.
+		// Optional. Default value false.
+		LiveReload bool
+
+		// Path is the path of the server-sent events endpoint the live reload
+		// script listens to.
+		// Optional. Default value "/_devmode/reload".
+		Path string
+	}
+
+	// Watcher renders templates and watches files for changes. It implements
+	// `echo.Renderer`.
+	Watcher struct {
+		config Config
+		e      *echo.Echo
+
+		mu          sync.RWMutex
+		templates   *template.Template
+		fingerprint string
+		version     int
+		changed     chan struct{}
+
+		closed   chan struct{}
+		closeOne sync.Once
+	}
+
+	injectWriter struct {
+		http.ResponseWriter
+		inject bool
+		code   int
+		buf    bytes.Buffer
+	}
+)
+
+var (
+	// DefaultConfig is the default development mode config.
+	DefaultConfig = Config{
+		Interval: 500 * time.Millisecond,
+		Path:     "/_devmode/reload",
+	}
+)
+
+const liveReloadScript = `<script>new EventSource(%q).addEventListener("reload",function(){location.reload()})</script>`
+
+// New returns a watcher with config. In dev mode, it starts polling the files,
+// and with live reload, registers the events endpoint and the middleware
+// injecting the script on `e`. Polling stops when the servers of `e` shut down.
+func New(e *echo.Echo, config Config) (*Watcher, error) {
+	// Defaults
+	if config.Interval == 0 {
+		config.Interval = DefaultConfig.Interval
+	}
+	if config.Path == "" {
+		config.Path = DefaultConfig.Path
+	}
+
+	w := &Watcher{
+		config:  config,
+		e:       e,
+		changed: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	if err := w.parse(); err != nil {
+		return nil, err
+	}
+	if !config.Dev {
+		return w, nil
+	}
+
+	fp, err := w.scan()
+	if err != nil {
+		return nil, err
+	}
+	w.fingerprint = fp
+	if config.LiveReload {
+		e.GET(config.Path, w.events)
+		e.Use(w.inject)
+	}
+	e.Server.RegisterOnShutdown(w.Close)
+	e.TLSServer.RegisterOnShutdown(w.Close)
+	go w.poll()
+	return w, nil
+}
+
+// Render implements `echo.Renderer`. Map and nil data get the built-in
+// variables of `echo.TemplateContext()`, e.g. `{{.CSRF}}`.
+func (w *Watcher) Render(out io.Writer, name string, data interface{}, c echo.Context) error {
+	w.mu.RLock()
+	t := w.templates
+	w.mu.RUnlock()
+	if t == nil {
+		return echo.ErrRendererNotRegistered
+	}
+	return t.ExecuteTemplate(out, name, echo.WithTemplateContext(c, data))
+}
+
+// Version returns the number of changes detected.
+func (w *Watcher) Version() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.version
+}
+
+// Check polls the files once, reloading the templates and notifying the live
+// reload clients if they changed. It reports whether files changed.
+func (w *Watcher) Check() (bool, error) {
+	fp, err := w.scan()
+	if err != nil {
+		return false, err
+	}
+	w.mu.RLock()
+	same := fp == w.fingerprint
+	w.mu.RUnlock()
+	if same {
+		return false, nil
+	}
+
+	// Invalid templates are reported and the previous ones kept, so that the
+	// page reloads once they are fixed
+	perr := w.parse()
+	w.mu.Lock()
+	w.fingerprint = fp
+	w.version++
+	close(w.changed)
+	w.changed = make(chan struct{})
+	w.mu.Unlock()
+	return true, perr
+}
+
+// Close stops polling and closes the live reload connections.
+func (w *Watcher) Close() {
+	w.closeOne.Do(func() {
+		close(w.closed)
+	})
+}
+
+func (w *Watcher) poll() {
+	t := time.NewTicker(w.config.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if _, err := w.Check(); err != nil {
+				w.e.Logger.Errorf("devmode: %v", err)
+			}
+		case <-w.closed:
+			return
+		}
+	}
+}
+
+func (w *Watcher) parse() error {
+	if w.config.Templates == "" {
+		return nil
+	}
+	t, err := template.New("").Funcs(w.config.Funcs).ParseGlob(w.config.Templates)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.templates = t
+	w.mu.Unlock()
+	return nil
+}
+
+// scan returns a fingerprint of the names, sizes and modification times of the
+// watched files.
+func (w *Watcher) scan() (string, error) {
+	var files []string
+	add := func(path string, info os.FileInfo) {
+		files = append(files, path+"\x00"+strconv.FormatInt(info.Size(), 10)+"\x00"+strconv.FormatInt(info.ModTime().UnixNano(), 10))
+	}
+	if w.config.Templates != "" {
+		matches, err := filepath.Glob(w.config.Templates)
+		if err != nil {
+			return "", err
+		}
+		for _, m := range matches {
+			if info, err := os.Stat(m); err == nil {
+				add(m, info)
+			}
+		}
+	}
+	for _, dir := range w.config.Watch {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.IsDir() {
+				add(path, info)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	sort.Strings(files)
+	h := sha256.New()
+	for _, f := range files {
+		io.WriteString(h, f)
+		h.Write([]byte{'\n'})
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// events streams a "reload" event to the live reload script on each change.
+func (w *Watcher) events(c echo.Context) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.WriteHeader(http.StatusOK)
+	res.Flush()
+	for {
+		w.mu.RLock()
+		changed := w.changed
+		w.mu.RUnlock()
+		select {
+		case <-changed:
+			if _, err := fmt.Fprintf(res, "event: reload\ndata: %d\n\n", w.Version()); err != nil {
+				return nil
+			}
+			res.Flush()
+		case <-w.closed:
+			return nil
+		case <-c.Request().Context().Done():
+			return nil
+		}
+	}
+}
+
+// inject is a middleware injecting the live reload script into HTML responses.
+func (w *Watcher) inject(next echo.HandlerFunc) echo.HandlerFunc {
+	script := []byte(fmt.Sprintf(liveReloadScript, w.config.Path))
+	return func(c echo.Context) error {
+		res := c.Response()
+		iw := &injectWriter{ResponseWriter: res.Writer}
+		res.Writer = iw
+		err := next(c)
+		res.Writer = iw.ResponseWriter
+		if !iw.inject {
+			return err
+		}
+
+		b := iw.buf.Bytes()
+		if i := bytes.LastIndex(b, []byte("</body>")); i != -1 {
+			b = append(b[:i:i], append(script, b[i:]...)...)
+		} else {
+			b = append(b, script...)
+		}
+		iw.ResponseWriter.WriteHeader(iw.code)
+		if _, werr := iw.ResponseWriter.Write(b); werr != nil && err == nil {
+			err = werr
+		}
+		return err
+	}
+}
+
+func (w *injectWriter) WriteHeader(code int) {
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get(echo.HeaderContentType))
+	if code == http.StatusOK && mediaType == echo.MIMETextHTML && w.Header().Get(echo.HeaderContentEncoding) == "" {
+		w.inject = true
+		w.code = code
+		w.Header().Del(echo.HeaderContentLength)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *injectWriter) Write(b []byte) (int, error) {
+	if w.inject {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *injectWriter) Flush() {
+	if !w.inject {
+		w.ResponseWriter.(http.Flusher).Flush()
+	}
+}
+
+func (w *injectWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
@@ -0,0 +1,158 @@
+package devmode
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "devmode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, path, content string) {
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Ensure a different modification time on file systems with a coarse
+	// resolution
+	later := time.Now().Add(time.Duration(len(content)) * time.Second)
+	os.Chtimes(path, later, later)
+}
+
+func TestWatcher(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	os.Mkdir(filepath.Join(dir, "public"), 0755)
+	writeFile(t, filepath.Join(dir, "page.html"), `{{define "page"}}<html><body>v1</body></html>{{end}}`)
+	writeFile(t, filepath.Join(dir, "public", "index.html"), `<html><body>static</body></html>`)
+
+	e := echo.New()
+	w, err := New(e, Config{
+		Dev:        true,
+		Templates:  filepath.Join(dir, "*.html"),
+		Watch:      []string{filepath.Join(dir, "public")},
+		Interval:   time.Hour,
+		LiveReload: true,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer w.Close()
+	e.Renderer = w
+	e.GET("/page", func(c echo.Context) error {
+		return c.Render(http.StatusOK, "page", nil)
+	})
+	e.GET("/json", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, "</body>")
+	})
+	e.Static("/static", filepath.Join(dir, "public"))
+	get := func(path string) string {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Body.String()
+	}
+	script := `<script>new EventSource("/_devmode/reload")`
+
+	body := get("/page")
+	assert.True(t, strings.HasPrefix(body, "<html><body>v1"+script), body)
+	assert.True(t, strings.HasSuffix(body, "</body></html>"))
+	assert.Contains(t, get("/static/index.html"), "static"+script)
+	assert.NotContains(t, get("/json"), "script")
+
+	// No changes
+	changed, err := w.Check()
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	// Template changed
+	s := httptest.NewServer(e)
+	defer s.Close()
+	res, err := http.Get(s.URL + "/_devmode/reload")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer res.Body.Close()
+	assert.Equal(t, "text/event-stream", res.Header.Get(echo.HeaderContentType))
+
+	writeFile(t, filepath.Join(dir, "page.html"), `{{define "page"}}<html><body>v2</body></html>{{end}}`)
+	changed, err = w.Check()
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Contains(t, get("/page"), "v2")
+	r := bufio.NewReader(res.Body)
+	line, _ := r.ReadString('\n')
+	assert.Equal(t, "event: reload\n", line)
+
+	// Invalid template keeps the previous one
+	writeFile(t, filepath.Join(dir, "page.html"), `{{define "page"}}{{end`)
+	_, err = w.Check()
+	assert.Error(t, err)
+	assert.Contains(t, get("/page"), "v2")
+	assert.Equal(t, 2, w.Version())
+
+	// Static file changed
+	writeFile(t, filepath.Join(dir, "public", "app.js"), `alert(1)`)
+	changed, _ = w.Check()
+	assert.True(t, changed)
+}
+
+func TestWatcherTemplateContext(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "form.html"), `{{define "form"}}{{.Title}} {{.CSRF}} {{.Locale}}{{end}}`)
+
+	e := echo.New()
+	w, err := New(e, Config{Templates: filepath.Join(dir, "*.html")})
+	if !assert.NoError(t, err) {
+		return
+	}
+	e.Renderer = w
+	e.GET("/", func(c echo.Context) error {
+		c.Set("csrf", "token")
+		c.Set("locale", "de")
+		return c.Render(http.StatusOK, "form", echo.Map{"Title": "Sign up"})
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "Sign up token de", rec.Body.String())
+}
+
+func TestWatcherProduction(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "page.html"), `{{define "page"}}<body>{{.}}</body>{{end}}`)
+
+	e := echo.New()
+	w, err := New(e, Config{Templates: filepath.Join(dir, "*.html"), LiveReload: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	e.Renderer = w
+	e.GET("/page", func(c echo.Context) error {
+		return c.Render(http.StatusOK, "page", "prod")
+	})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/page", nil))
+	assert.Equal(t, "<body>prod</body>", rec.Body.String())
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/_devmode/reload", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	_, err = New(e, Config{Templates: filepath.Join(dir, "missing", "*.html")})
+	assert.Error(t, err)
+}
@@ -53,6 +53,7 @@ import (
 	"reflect"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/labstack/gommon/color"
@@ -71,7 +72,8 @@ type (
 		colorer          *color.Color
 		premiddleware    []MiddlewareFunc
 		middleware       []MiddlewareFunc
-		maxParam         *int
+		renderDecorators []RenderDecoratorFunc
+		maxParam         *int32
 		router           *Router
 		routers          map[string]*Router
 		notFoundHandler  HandlerFunc
@@ -86,11 +88,33 @@ type (
 		HideBanner       bool
 		HidePort         bool
 		HTTPErrorHandler HTTPErrorHandler
+		ErrorReporter    ErrorReporter
 		Binder           Binder
+		BodyCaptureLimit int64
 		Validator        Validator
 		Renderer         Renderer
+		YAML             YAMLCodec
+		Sanitizer        Sanitizer
+		Markdown         MarkdownConfig
 		Logger           Logger
 		IPExtractor      IPExtractor
+		ParamUnescape    ParamUnescapeMode
+		PathMatch        PathMatchMode
+		RequestGuard     RequestGuardMode
+		OverrideRoutes   bool
+		Cookies          CookieConfig
+		ShutdownDelay    time.Duration
+		DeferredWorkers  int
+		ConnState        func(net.Conn, http.ConnState)
+		ConnContext      func(stdContext.Context, net.Conn) stdContext.Context
+		ContextFactory   func(Context) Context
+		scheduler        scheduler
+		health           health
+		reloader         reloader
+		services         container
+		routeSites       sync.Map
+		startupMutex     sync.RWMutex
+		jobs             jobPool
 	}
 
 	// Route contains a handler and information for matching against requests.
@@ -98,6 +122,10 @@ type (
 		Method string `json:"method"`
 		Path   string `json:"path"`
 		Name   string `json:"name"`
+
+		metaLock   sync.RWMutex
+		meta       Map
+		middleware []string
 	}
 
 	// HTTPError represents an error that occurred while handling a request.
@@ -107,9 +135,24 @@ type (
 		Internal error       `json:"-"` // Stores the error returned by an external dependency
 	}
 
+	// PanicError represents a panic recovered while handling a request, see
+	// `middleware.Recover()`.
+	PanicError struct {
+		Value interface{} // Value passed to panic()
+		Stack []byte      // Stack trace of the panicking goroutine
+	}
+
 	// MiddlewareFunc defines a function to process middleware.
 	MiddlewareFunc func(HandlerFunc) HandlerFunc
 
+	// ParamUnescapeMode controls the URL-decoding of path param values, see
+	// `Echo#ParamUnescape`.
+	ParamUnescapeMode uint8
+
+	// PathMatchMode selects the form of the request path matched against the
+	// routes, see `Echo#PathMatch`.
+	PathMatchMode uint8
+
 	// HandlerFunc defines a function to serve HTTP requests.
 	HandlerFunc func(Context) error
 
@@ -152,6 +195,7 @@ const (
 const (
 	MIMEApplicationJSON                  = "application/json"
 	MIMEApplicationJSONCharsetUTF8       = MIMEApplicationJSON + "; " + charsetUTF8
+	MIMEApplicationNDJSON                = "application/x-ndjson"
 	MIMEApplicationJavaScript            = "application/javascript"
 	MIMEApplicationJavaScriptCharsetUTF8 = MIMEApplicationJavaScript + "; " + charsetUTF8
 	MIMEApplicationXML                   = "application/xml"
@@ -165,6 +209,12 @@ const (
 	MIMETextHTMLCharsetUTF8              = MIMETextHTML + "; " + charsetUTF8
 	MIMETextPlain                        = "text/plain"
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + charsetUTF8
+	MIMEApplicationYAML                  = "application/x-yaml"
+	MIMEApplicationYAMLCharsetUTF8       = MIMEApplicationYAML + "; " + charsetUTF8
+	MIMETextYAML                         = "text/yaml"
+	MIMEApplicationTOML                  = "application/toml"
+	MIMETextCSV                          = "text/csv"
+	MIMETextCSVCharsetUTF8               = MIMETextCSV + "; " + charsetUTF8
 	MIMEMultipartForm                    = "multipart/form-data"
 	MIMEOctetStream                      = "application/octet-stream"
 )
@@ -177,21 +227,64 @@ const (
 	REPORT = "REPORT"
 )
 
+// Param unescape modes
+const (
+	// ParamUnescapeNone keeps path param values as routed: URL-encoded when the
+	// request path has escapes routed on its raw form, e.g. "%2F", decoded
+	// otherwise.
+	ParamUnescapeNone ParamUnescapeMode = iota
+
+	// ParamUnescapeEager URL-decodes the param values of requests routed on
+	// their raw path right after routing, so that an encoded "/" ("%2F")
+	// matches inside a param and is returned as "/".
+	ParamUnescapeEager
+
+	// ParamUnescapeLazy URL-decodes like `ParamUnescapeEager`, on first access
+	// to the params, so that routes which never read them don't pay for it.
+	ParamUnescapeLazy
+)
+
+// Path match modes
+const (
+	// PathMatchAuto matches the raw path when the request path has escapes
+	// which are not the default encoding of the decoded path, e.g. "%2F", and
+	// the decoded path otherwise. See `GetPath()`.
+	PathMatchAuto PathMatchMode = iota
+
+	// PathMatchDecoded always matches the decoded path, so an encoded "/"
+	// ("%2F") splits path segments like "/".
+	PathMatchDecoded
+
+	// PathMatchRaw always matches the escaped path, e.g. for APIs whose IDs
+	// contain encoded slashes. Routes with characters which are escaped in
+	// paths must be registered escaped, e.g. "/a%20b".
+	PathMatchRaw
+)
+
 // Headers
 const (
 	HeaderAccept              = "Accept"
 	HeaderAcceptEncoding      = "Accept-Encoding"
+	HeaderAcceptLanguage      = "Accept-Language"
 	HeaderAllow               = "Allow"
 	HeaderAuthorization       = "Authorization"
+	HeaderCacheControl        = "Cache-Control"
+	HeaderConnection          = "Connection"
 	HeaderContentDisposition  = "Content-Disposition"
 	HeaderContentEncoding     = "Content-Encoding"
 	HeaderContentLength       = "Content-Length"
 	HeaderContentType         = "Content-Type"
 	HeaderCookie              = "Cookie"
 	HeaderSetCookie           = "Set-Cookie"
+	HeaderTrailer             = "Trailer"
+	HeaderETag                = "ETag"
 	HeaderIfModifiedSince     = "If-Modified-Since"
+	HeaderIfNoneMatch         = "If-None-Match"
+	HeaderLastEventID         = "Last-Event-ID"
 	HeaderLastModified        = "Last-Modified"
+	HeaderLink                = "Link"
 	HeaderLocation            = "Location"
+	HeaderRetryAfter          = "Retry-After"
 	HeaderUpgrade             = "Upgrade"
 	HeaderVary                = "Vary"
 	HeaderWWWAuthenticate     = "WWW-Authenticate"
@@ -202,8 +295,12 @@ const (
 	HeaderXUrlScheme          = "X-Url-Scheme"
 	HeaderXHTTPMethodOverride = "X-HTTP-Method-Override"
 	HeaderXRealIP             = "X-Real-IP"
+	HeaderXRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderXRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderXRateLimitReset     = "X-RateLimit-Reset"
 	HeaderXRequestID          = "X-Request-ID"
 	HeaderXRequestedWith      = "X-Requested-With"
+	HeaderXTotalCount         = "X-Total-Count"
 	HeaderServer              = "Server"
 	HeaderOrigin              = "Origin"
 
@@ -275,11 +372,25 @@ var (
 	ErrInternalServerError         = NewHTTPError(http.StatusInternalServerError)
 	ErrRequestTimeout              = NewHTTPError(http.StatusRequestTimeout)
 	ErrServiceUnavailable          = NewHTTPError(http.StatusServiceUnavailable)
+	ErrNotAcceptable               = NewHTTPError(http.StatusNotAcceptable)
+	ErrConflict                    = NewHTTPError(http.StatusConflict)
+	ErrGone                        = NewHTTPError(http.StatusGone)
+	ErrPreconditionFailed          = NewHTTPError(http.StatusPreconditionFailed)
+	ErrUnprocessableEntity         = NewHTTPError(http.StatusUnprocessableEntity)
+	ErrLocked                      = NewHTTPError(http.StatusLocked)
+	ErrPreconditionRequired        = NewHTTPError(http.StatusPreconditionRequired)
+	ErrNotImplemented              = NewHTTPError(http.StatusNotImplemented)
+	ErrGatewayTimeout              = NewHTTPError(http.StatusGatewayTimeout)
 	ErrValidatorNotRegistered      = errors.New("validator not registered")
 	ErrRendererNotRegistered       = errors.New("renderer not registered")
+	ErrMarkdownNotRegistered       = errors.New("markdown renderer not registered")
+	ErrYAMLNotRegistered           = errors.New("yaml codec not registered")
 	ErrInvalidRedirectCode         = errors.New("invalid redirect status code")
 	ErrCookieNotFound              = errors.New("cookie not found")
 	ErrInvalidCertOrKeyType        = errors.New("invalid cert or key type, must be string or []byte")
+	ErrInvalidStreamSource         = errors.New("invalid stream source, must be a receive channel or an Iterator")
+	ErrDetachedResponse            = errors.New("response of a cloned context can't be written")
+	ErrEarlyHintsUnsupported       = errors.New("early hints not supported for this request")
 )
 
 // Error handlers
@@ -303,7 +414,7 @@ func New() (e *Echo) {
 		},
 		Logger:   log.New("echo"),
 		colorer:  color.New(),
-		maxParam: new(int),
+		maxParam: new(int32),
 	}
 	e.Server.Handler = e
 	e.TLSServer.Handler = e
@@ -326,7 +437,7 @@ func (e *Echo) NewContext(r *http.Request, w http.ResponseWriter) Context {
 		response: NewResponse(w, e),
 		store:    make(Map),
 		echo:     e,
-		pvalues:  make([]string, *e.maxParam),
+		pvalues:  make([]string, e.maxParams()),
 		handler:  NotFoundHandler,
 	}
 }
@@ -343,9 +454,12 @@ func (e *Echo) Routers() map[string]*Router {
 
 // DefaultHTTPErrorHandler is the default HTTP error handler. It sends a JSON response
 // with status code.
+//
+// The first `*HTTPError` found in the error chain (see `errors.As()`) determines
+// the response, any other error results in "500 - Internal Server Error".
 func (e *Echo) DefaultHTTPErrorHandler(err error, c Context) {
-	he, ok := err.(*HTTPError)
-	if ok {
+	var he *HTTPError
+	if errors.As(err, &he) {
 		if he.Internal != nil {
 			if herr, ok := he.Internal.(*HTTPError); ok {
 				he = herr
@@ -364,7 +478,11 @@ func (e *Echo) DefaultHTTPErrorHandler(err error, c Context) {
 	if e.Debug {
 		message = err.Error()
 	} else if m, ok := message.(string); ok {
-		message = Map{"message": m}
+		if bes := BindingErrorsOf(he.Internal); bes != nil {
+			message = Map{"message": m, "errors": bes}
+		} else {
+			message = Map{"message": m}
+		}
 	}
 
 	// Send response
@@ -475,9 +593,12 @@ func (e *Echo) Static(prefix, root string) *Route {
 
 func (common) static(prefix, root string, get func(string, HandlerFunc, ...MiddlewareFunc) *Route) *Route {
 	h := func(c Context) error {
-		p, err := url.PathUnescape(c.Param("*"))
-		if err != nil {
-			return err
+		p := c.Param("*")
+		if c.Echo().ParamUnescape == ParamUnescapeNone {
+			var err error
+			if p, err = url.PathUnescape(p); err != nil {
+				return err
+			}
 		}
 		name := filepath.Join(root, path.Clean("/"+p)) // "/"+ for security
 		return c.File(name)
@@ -501,22 +622,43 @@ func (e *Echo) File(path, file string, m ...MiddlewareFunc) *Route {
 }
 
 func (e *Echo) add(host, method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
+	return e.addRoute(nil, host, method, path, handler, middleware)
+}
+
+// addRoute registers a route of the group `g`, nil for routes of the instance.
+func (e *Echo) addRoute(g *Group, host, method, path string, handler HandlerFunc, middleware []MiddlewareFunc) *Route {
 	name := handlerName(handler)
 	router := e.findRouter(host)
+	var names []string
+	if len(middleware) > 0 {
+		names = middlewareNames(middleware)
+	}
+	r := &Route{
+		Method:     method,
+		Path:       path,
+		Name:       name,
+		middleware: names,
+	}
+	e.checkRoute(host, r)
 	router.Add(method, path, func(c Context) error {
-		h := handler
-		// Chain middleware
-		for i := len(middleware) - 1; i >= 0; i-- {
-			h = middleware[i](h)
+		h := e.applyGuardedMiddleware(handler, middleware, names)
+		if g.providesServices() {
+			c.Set(serviceGroupKey, g)
+		}
+		eh := g.errorHandler()
+		if eh == nil {
+			return serveRoute(r, c, h)
+		}
+		if ctx, ok := c.(*context); ok {
+			ctx.errorHandler = eh
+		}
+		if err := serveRoute(r, c, h); err != nil {
+			e.reportError(err, c)
+			eh(err, c)
 		}
-		return h(c)
+		return nil
 	})
-	r := &Route{
-		Method: method,
-		Path:   path,
-		Name:   name,
-	}
-	e.router.routes[method+path] = r
+	router.addRoute(method+path, r)
 	return r
 }
 
@@ -557,7 +699,7 @@ func (e *Echo) Reverse(name string, params ...interface{}) string {
 	uri := new(bytes.Buffer)
 	ln := len(params)
 	n := 0
-	for _, r := range e.router.routes {
+	for _, r := range e.Routes() {
 		if r.Name == name {
 			for i, l := 0, len(r.Path); i < l; i++ {
 				if r.Path[i] == ':' && n < ln {
@@ -576,15 +718,59 @@ func (e *Echo) Reverse(name string, params ...interface{}) string {
 	return uri.String()
 }
 
-// Routes returns the registered routes.
+// maxParams returns the maximum number of params of the routes.
+func (e *Echo) maxParams() int {
+	return int(atomic.LoadInt32(e.maxParam))
+}
+
+// reserveMaxParam raises the maximum number of params of the routes to `n`.
+func (e *Echo) reserveMaxParam(n int) {
+	for {
+		max := atomic.LoadInt32(e.maxParam)
+		if int32(n) <= max || atomic.CompareAndSwapInt32(e.maxParam, max, int32(n)) {
+			return
+		}
+	}
+}
+
+// Routes returns the registered routes, including the routes of hosts.
 func (e *Echo) Routes() []*Route {
-	routes := make([]*Route, 0, len(e.router.routes))
-	for _, v := range e.router.routes {
+	s := e.router.snapshot()
+	routes := make([]*Route, 0, len(s.routes))
+	for _, v := range s.routes {
 		routes = append(routes, v)
 	}
+	for _, router := range e.routers {
+		for _, v := range router.snapshot().routes {
+			routes = append(routes, v)
+		}
+	}
 	return routes
 }
 
+// Meta returns the metadata attached to the route. The returned map must not be
+// modified, use `Route#SetMeta()` instead.
+func (r *Route) Meta() Map {
+	r.metaLock.RLock()
+	defer r.metaLock.RUnlock()
+	return r.meta
+}
+
+// SetMeta attaches a value to the route under the provided key, e.g. for
+// documentation generators, and returns the route.
+func (r *Route) SetMeta(key string, val interface{}) *Route {
+	r.metaLock.Lock()
+	defer r.metaLock.Unlock()
+	// Copy on write so that readers never see a map being modified
+	m := Map{}
+	for k, v := range r.meta {
+		m[k] = v
+	}
+	m[key] = val
+	r.meta = m
+	return r
+}
+
 // AcquireContext returns an empty `Context` instance from the pool.
 // You must return the context by calling `ReleaseContext()`.
 func (e *Echo) AcquireContext() Context {
@@ -593,7 +779,13 @@ func (e *Echo) AcquireContext() Context {
 
 // ReleaseContext returns the `Context` instance back to the pool.
 // You must call it after `AcquireContext()`.
+// In debug mode the context is discarded instead, and any later use of it
+// panics, to catch contexts captured by goroutines outliving the request.
 func (e *Echo) ReleaseContext(c Context) {
+	if ctx, ok := c.(*context); ok && e.Debug {
+		atomic.StoreUint32(&ctx.released, 1)
+		return
+	}
 	e.pool.Put(c)
 }
 
@@ -602,30 +794,44 @@ func (e *Echo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// Acquire context
 	c := e.pool.Get().(*context)
 	c.Reset(r, w)
+	var ctx Context = c
+	if e.ContextFactory != nil {
+		ctx = e.ContextFactory(c)
+	}
 
 	h := NotFoundHandler
 
 	if e.premiddleware == nil {
-		e.findRouter(r.Host).Find(r.Method, GetPath(r), c)
+		path := e.matchPath(r)
+		e.findRouter(r.Host).Find(r.Method, path, c)
+		e.markEscapedParams(c, r, path)
 		h = c.Handler()
-		h = applyMiddleware(h, e.middleware...)
+		h = e.applyGuardedMiddleware(h, e.middleware, nil)
 	} else {
-		h = func(c Context) error {
-			e.findRouter(r.Host).Find(r.Method, GetPath(r), c)
-			h := c.Handler()
-			h = applyMiddleware(h, e.middleware...)
-			return h(c)
+		h = func(ctx Context) error {
+			path := e.matchPath(r)
+			e.findRouter(r.Host).Find(r.Method, path, c)
+			e.markEscapedParams(c, r, path)
+			h := ctx.Handler()
+			h = e.applyGuardedMiddleware(h, e.middleware, nil)
+			return h(ctx)
 		}
 		h = applyMiddleware(h, e.premiddleware...)
 	}
 
 	// Execute chain
-	if err := h(c); err != nil {
-		e.HTTPErrorHandler(err, c)
+	if err := h(ctx); err != nil {
+		e.reportError(err, ctx)
+		e.HTTPErrorHandler(err, ctx)
+	}
+
+	// Run deferred functions
+	if len(c.deferred) > 0 {
+		e.jobs.submit(e, c.deferred)
 	}
 
 	// Release context
-	e.pool.Put(c)
+	e.ReleaseContext(c)
 }
 
 // Start starts an HTTP server.
@@ -638,6 +844,62 @@ func (e *Echo) Start(address string) error {
 // If `certFile` or `keyFile` is `string` the values are treated as file paths.
 // If `certFile` or `keyFile` is `[]byte` the values are treated as the certificate or key as-is.
 func (e *Echo) StartTLS(address string, certFile, keyFile interface{}) (err error) {
+	if err = e.configureTLS(certFile, keyFile); err != nil {
+		return
+	}
+	return e.startTLS(address)
+}
+
+// StartBoth starts an HTTP server on `httpAddress` and an HTTPS server on
+// `httpsAddress` serving the same routes, e.g. to redirect port 80 to 443 with
+// `middleware.HTTPSRedirect()`. See `StartTLS()` for `certFile` and `keyFile`.
+// It returns when both servers are stopped, e.g. by `Shutdown()`, closing the
+// other server if one of them fails.
+func (e *Echo) StartBoth(httpAddress, httpsAddress string, certFile, keyFile interface{}) (err error) {
+	if err = e.configureTLS(certFile, keyFile); err != nil {
+		return
+	}
+	e.Server.Addr = httpAddress
+	e.TLSServer.Addr = httpsAddress
+	if !e.DisableHTTP2 {
+		e.TLSServer.TLSConfig.NextProtos = append(e.TLSServer.TLSConfig.NextProtos, "h2")
+	}
+
+	servers := []*http.Server{e.Server, e.TLSServer}
+	for _, s := range servers {
+		e.setupServer(s)
+	}
+	if !e.HideBanner {
+		e.colorer.Printf(banner, e.colorer.Red("v"+Version), e.colorer.Blue(website))
+	}
+	listeners := make([]net.Listener, len(servers))
+	for i, s := range servers {
+		if listeners[i], err = e.serverListener(s); err != nil {
+			for _, l := range listeners[:i] {
+				l.Close()
+			}
+			return
+		}
+	}
+	e.scheduler.start(e)
+
+	errCh := make(chan error, len(servers))
+	for i, s := range servers {
+		go func(s *http.Server, l net.Listener) {
+			errCh <- s.Serve(l)
+		}(s, listeners[i])
+	}
+	err = <-errCh
+	if err != http.ErrServerClosed {
+		for _, s := range servers {
+			s.Close()
+		}
+	}
+	<-errCh
+	return
+}
+
+func (e *Echo) configureTLS(certFile, keyFile interface{}) (err error) {
 	var cert []byte
 	if cert, err = filepathOrContent(certFile); err != nil {
 		return
@@ -651,11 +913,8 @@ func (e *Echo) StartTLS(address string, certFile, keyFile interface{}) (err erro
 	s := e.TLSServer
 	s.TLSConfig = new(tls.Config)
 	s.TLSConfig.Certificates = make([]tls.Certificate, 1)
-	if s.TLSConfig.Certificates[0], err = tls.X509KeyPair(cert, key); err != nil {
-		return
-	}
-
-	return e.startTLS(address)
+	s.TLSConfig.Certificates[0], err = tls.X509KeyPair(cert, key)
+	return
 }
 
 func filepathOrContent(fileOrContent interface{}) (content []byte, err error) {
@@ -689,41 +948,69 @@ func (e *Echo) startTLS(address string) error {
 
 // StartServer starts a custom http server.
 func (e *Echo) StartServer(s *http.Server) (err error) {
-	// Setup
+	e.setupServer(s)
+	if !e.HideBanner {
+		e.colorer.Printf(banner, e.colorer.Red("v"+Version), e.colorer.Blue(website))
+	}
+	l, err := e.serverListener(s)
+	if err != nil {
+		return err
+	}
+	e.scheduler.start(e)
+	return s.Serve(l)
+}
+
+func (e *Echo) setupServer(s *http.Server) {
 	e.colorer.SetOutput(e.Logger.Output())
 	s.ErrorLog = e.StdLogger
 	s.Handler = e
+	e.setupConnHooks(s)
 	if e.Debug {
 		e.Logger.SetLevel(log.DEBUG)
 	}
+}
 
-	if !e.HideBanner {
-		e.colorer.Printf(banner, e.colorer.Red("v"+Version), e.colorer.Blue(website))
+// setupConnHooks sets `Echo#ConnState` and `Echo#ConnContext`, if any, on the
+// server, e.g. to count open connections or to tag the request context with
+// connection metadata.
+func (e *Echo) setupConnHooks(s *http.Server) {
+	if e.ConnState != nil {
+		s.ConnState = e.ConnState
 	}
+	if e.ConnContext != nil {
+		s.ConnContext = e.ConnContext
+	}
+}
 
+// serverListener returns `Echo#Listener`, or `Echo#TLSListener` for a TLS
+// server, creating it if needed.
+func (e *Echo) serverListener(s *http.Server) (l net.Listener, err error) {
+	e.startupMutex.Lock()
+	defer e.startupMutex.Unlock()
 	if s.TLSConfig == nil {
 		if e.Listener == nil {
-			e.Listener, err = newListener(s.Addr)
+			l, err := newListener(s.Addr)
 			if err != nil {
-				return err
+				return nil, err
 			}
+			e.Listener = l
 		}
 		if !e.HidePort {
 			e.colorer.Printf("⇨ http server started on %s\n", e.colorer.Green(e.Listener.Addr()))
 		}
-		return s.Serve(e.Listener)
+		return e.Listener, nil
 	}
 	if e.TLSListener == nil {
 		l, err := newListener(s.Addr)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		e.TLSListener = tls.NewListener(l, s.TLSConfig)
 	}
 	if !e.HidePort {
 		e.colorer.Printf("⇨ https server started on %s\n", e.colorer.Green(e.TLSListener.Addr()))
 	}
-	return s.Serve(e.TLSListener)
+	return e.TLSListener, nil
 }
 
 // StartH2CServer starts a custom http/2 server with h2c (HTTP/2 Cleartext).
@@ -734,6 +1021,7 @@ func (e *Echo) StartH2CServer(address string, h2s *http2.Server) (err error) {
 	e.colorer.SetOutput(e.Logger.Output())
 	s.ErrorLog = e.StdLogger
 	s.Handler = h2c.NewHandler(e, h2s)
+	e.setupConnHooks(s)
 	if e.Debug {
 		e.Logger.SetLevel(log.DEBUG)
 	}
@@ -741,35 +1029,78 @@ func (e *Echo) StartH2CServer(address string, h2s *http2.Server) (err error) {
 	if !e.HideBanner {
 		e.colorer.Printf(banner, e.colorer.Red("v"+Version), e.colorer.Blue(website))
 	}
-
+	e.startupMutex.Lock()
 	if e.Listener == nil {
-		e.Listener, err = newListener(s.Addr)
+		l, err := newListener(s.Addr)
 		if err != nil {
+			e.startupMutex.Unlock()
 			return err
 		}
+		e.Listener = l
 	}
+	l := e.Listener
+	e.startupMutex.Unlock()
 	if !e.HidePort {
-		e.colorer.Printf("⇨ http server started on %s\n", e.colorer.Green(e.Listener.Addr()))
+		e.colorer.Printf("⇨ http server started on %s\n", e.colorer.Green(l.Addr()))
+	}
+	e.scheduler.start(e)
+	return s.Serve(l)
+}
+
+// ListenerAddr returns the address of `Echo#Listener`, or nil if the server
+// isn't listening yet, e.g. to wait for a server started in a goroutine.
+func (e *Echo) ListenerAddr() net.Addr {
+	e.startupMutex.RLock()
+	defer e.startupMutex.RUnlock()
+	if e.Listener == nil {
+		return nil
 	}
-	return s.Serve(e.Listener)
+	return e.Listener.Addr()
 }
 
-// Close immediately stops the server.
+// TLSListenerAddr returns the address of `Echo#TLSListener`, or nil if the
+// server isn't listening yet.
+func (e *Echo) TLSListenerAddr() net.Addr {
+	e.startupMutex.RLock()
+	defer e.startupMutex.RUnlock()
+	if e.TLSListener == nil {
+		return nil
+	}
+	return e.TLSListener.Addr()
+}
+
+// Close immediately stops the server and cancels the background tasks and the
+// functions deferred after responses.
 // It internally calls `http.Server#Close()`.
 func (e *Echo) Close() error {
+	e.scheduler.cancel()
+	e.jobs.cancel()
 	if err := e.TLSServer.Close(); err != nil {
 		return err
 	}
 	return e.Server.Close()
 }
 
-// Shutdown stops the server gracefully.
+// Shutdown stops the server gracefully and cancels the background tasks,
+// waiting for them and for the functions deferred after responses to return.
+// Readiness probes fail from the start, and the server keeps serving requests
+// for `Echo#ShutdownDelay`, see `Echo#ReadinessHandler()`.
 // It internally calls `http.Server#Shutdown()`.
 func (e *Echo) Shutdown(ctx stdContext.Context) error {
+	if err := e.drain(ctx); err != nil {
+		return err
+	}
+	e.scheduler.cancel()
 	if err := e.TLSServer.Shutdown(ctx); err != nil {
 		return err
 	}
-	return e.Server.Shutdown(ctx)
+	if err := e.Server.Shutdown(ctx); err != nil {
+		return err
+	}
+	if err := e.jobs.wait(ctx); err != nil {
+		return err
+	}
+	return e.scheduler.wait(ctx)
 }
 
 // NewHTTPError creates a new HTTPError instance.
@@ -795,6 +1126,37 @@ func (he *HTTPError) SetInternal(err error) *HTTPError {
 	return he
 }
 
+// WithInternal returns a copy of the HTTPError with Internal set to `err`.
+// Unlike `SetInternal()` it is safe to use with the predefined errors, e.g.
+// `echo.ErrBadRequest.WithInternal(err)`.
+func (he *HTTPError) WithInternal(err error) *HTTPError {
+	return &HTTPError{Code: he.Code, Message: he.Message, Internal: err}
+}
+
+// Unwrap returns HTTPError.Internal, so that `errors.Is()` and `errors.As()`
+// inspect the internal error as well.
+func (he *HTTPError) Unwrap() error {
+	return he.Internal
+}
+
+// Is reports whether target is an HTTPError with the same status code, so that
+// e.g. `errors.Is(err, echo.ErrNotFound)` matches any "404 - Not Found" error.
+func (he *HTTPError) Is(target error) bool {
+	t, ok := target.(*HTTPError)
+	return ok && t.Code == he.Code
+}
+
+// Error makes it compatible with `error` interface.
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", pe.Value)
+}
+
+// Unwrap returns the value passed to panic() if it is an error.
+func (pe *PanicError) Unwrap() error {
+	err, _ := pe.Value.(error)
+	return err
+}
+
 // WrapHandler wraps `http.Handler` into `echo.HandlerFunc`.
 func WrapHandler(h http.Handler) HandlerFunc {
 	return func(c Context) error {
@@ -817,6 +1179,31 @@ func WrapMiddleware(m func(http.Handler) http.Handler) MiddlewareFunc {
 	}
 }
 
+// matchPath returns the request path matched against the routes, as configured
+// by `Echo#PathMatch`.
+func (e *Echo) matchPath(r *http.Request) string {
+	switch e.PathMatch {
+	case PathMatchDecoded:
+		return r.URL.Path
+	case PathMatchRaw:
+		return r.URL.EscapedPath()
+	}
+	return GetPath(r)
+}
+
+// markEscapedParams marks the param values of the context as URL-encoded when
+// the request was routed on an escaped path, as configured by
+// `Echo#ParamUnescape`.
+func (e *Echo) markEscapedParams(c *context, r *http.Request, path string) {
+	if e.ParamUnescape == ParamUnescapeNone || path == r.URL.Path {
+		return
+	}
+	c.pescaped = true
+	if e.ParamUnescape == ParamUnescapeEager {
+		c.unescapeParams()
+	}
+}
+
 // GetPath returns RawPath, if it's empty returns Path from URL
 func GetPath(r *http.Request) string {
 	path := r.URL.RawPath
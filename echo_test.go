@@ -3,8 +3,11 @@ package echo
 import (
 	"bytes"
 	stdContext "context"
+	"crypto/tls"
 	"errors"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
@@ -58,8 +61,87 @@ func TestEcho(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 }
 
+func TestEchoParamUnescape(t *testing.T) {
+	tests := []struct {
+		mode     ParamUnescapeMode
+		path     string
+		expected string
+	}{
+		{ParamUnescapeNone, "/files/a%2Fb", "a%2Fb"},
+		{ParamUnescapeNone, "/files/a%20b", "a b"},
+		{ParamUnescapeEager, "/files/a%2Fb", "a/b"},
+		{ParamUnescapeEager, "/files/a%20b", "a b"},
+		{ParamUnescapeEager, "/files/100%25%2F", "100%/"},
+		{ParamUnescapeLazy, "/files/a%2Fb", "a/b"},
+		{ParamUnescapeLazy, "/files/a%2Fb%20c", "a/b c"},
+		{ParamUnescapeLazy, "/static/a%2Fb/c", "a/b/c"},
+	}
+	for _, tt := range tests {
+		e := New()
+		e.ParamUnescape = tt.mode
+		h := func(c Context) error {
+			return c.String(http.StatusOK, c.ParamValues()[0])
+		}
+		e.GET("/files/:name", h)
+		e.GET("/static/*", h)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		assert.Equal(t, tt.expected, rec.Body.String(), tt.path)
+	}
+
+	// Lazy decoding on first access
+	e := New()
+	e.ParamUnescape = ParamUnescapeLazy
+	e.GET("/files/:name", func(c Context) error {
+		assert.Equal(t, "a%2Fb", c.(*context).pvalues[0])
+		assert.Equal(t, "a/b", c.Clone().Param("name"))
+		assert.Equal(t, "a/b", c.Param("name"))
+		c.SetParamValues("x%2Fy")
+		assert.Equal(t, "x%2Fy", c.Param("name"), "set values are kept as is")
+		return nil
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/files/a%2Fb", nil))
+}
+
+func TestEchoPathMatch(t *testing.T) {
+	tests := []struct {
+		mode     PathMatchMode
+		unescape ParamUnescapeMode
+		path     string
+		expected string
+	}{
+		{PathMatchAuto, ParamUnescapeNone, "/ids/a%2Fb", "id=a%2Fb"},
+		{PathMatchAuto, ParamUnescapeNone, "/ids/a%20b", "id=a b"},
+		{PathMatchDecoded, ParamUnescapeNone, "/ids/a%2Fb", "id=a sub=b"},
+		{PathMatchDecoded, ParamUnescapeEager, "/ids/a%2Fb", "id=a sub=b"},
+		{PathMatchRaw, ParamUnescapeNone, "/ids/a%2Fb", "id=a%2Fb"},
+		{PathMatchRaw, ParamUnescapeNone, "/ids/a%20b", "id=a%20b"},
+		{PathMatchRaw, ParamUnescapeLazy, "/ids/a%20b", "id=a b"},
+		{PathMatchRaw, ParamUnescapeLazy, "/ids/a%2Fb%20c", "id=a/b c"},
+		{PathMatchRaw, ParamUnescapeNone, "/a%20b", "static"},
+	}
+	for _, tt := range tests {
+		e := New()
+		e.PathMatch = tt.mode
+		e.ParamUnescape = tt.unescape
+		e.GET("/ids/:id", func(c Context) error {
+			return c.String(http.StatusOK, "id="+c.Param("id"))
+		})
+		e.GET("/ids/:id/:sub", func(c Context) error {
+			return c.String(http.StatusOK, "id="+c.Param("id")+" sub="+c.Param("sub"))
+		})
+		e.GET("/a%20b", func(c Context) error {
+			return c.String(http.StatusOK, "static")
+		})
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		assert.Equal(t, tt.expected, rec.Body.String(), tt.path)
+	}
+}
+
 func TestEchoStatic(t *testing.T) {
 	e := New()
+	e.OverrideRoutes = true
 
 	assert := assert.New(t)
 
@@ -287,10 +369,10 @@ func TestEchoURL(t *testing.T) {
 func TestEchoRoutes(t *testing.T) {
 	e := New()
 	routes := []*Route{
-		{http.MethodGet, "/users/:user/events", ""},
-		{http.MethodGet, "/users/:user/events/public", ""},
-		{http.MethodPost, "/repos/:owner/:repo/git/refs", ""},
-		{http.MethodPost, "/repos/:owner/:repo/git/tags", ""},
+		{Method: http.MethodGet, Path: "/users/:user/events"},
+		{Method: http.MethodGet, Path: "/users/:user/events/public"},
+		{Method: http.MethodPost, Path: "/repos/:owner/:repo/git/refs"},
+		{Method: http.MethodPost, Path: "/repos/:owner/:repo/git/tags"},
 	}
 	for _, r := range routes {
 		e.Add(r.Method, r.Path, func(c Context) error {
@@ -409,6 +491,27 @@ func TestEchoContext(t *testing.T) {
 	e.ReleaseContext(c)
 }
 
+func TestEchoReleasedContext(t *testing.T) {
+	e := New()
+	var captured Context
+	e.GET("/", func(c Context) error {
+		captured = c
+		return c.String(http.StatusOK, "OK")
+	})
+
+	// Released contexts are reused
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotPanics(t, func() { captured.Request() })
+
+	// Debug mode
+	e.Debug = true
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Panics(t, func() { captured.Request() })
+	assert.Panics(t, func() { captured.Param("id") })
+	assert.Panics(t, func() { captured.JSON(http.StatusOK, nil) })
+	assert.NotPanics(t, func() { captured.Reset(nil, nil) })
+}
+
 func TestEchoStart(t *testing.T) {
 	e := New()
 	go func() {
@@ -431,6 +534,70 @@ func TestEchoStartTLS(t *testing.T) {
 	e.Close()
 }
 
+// waitForServerStart waits for the server started in a goroutine sending its
+// error to errCh to listen, and returns its address.
+func waitForServerStart(e *Echo, errCh <-chan error, isTLS bool) (net.Addr, error) {
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 2*time.Second)
+	defer cancel()
+	ticker := time.NewTicker(5 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case err := <-errCh:
+			return nil, err
+		case <-ticker.C:
+			addr := e.ListenerAddr()
+			if isTLS {
+				addr = e.TLSListenerAddr()
+			}
+			if addr != nil {
+				return addr, nil
+			}
+		}
+	}
+}
+
+func TestEchoStartBoth(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.GET("/", func(c Context) error {
+		return c.String(http.StatusOK, c.Scheme())
+	})
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.StartBoth(":0", ":0", "_fixture/certs/cert.pem", "_fixture/certs/key.pem")
+	}()
+	tlsAddr, err := waitForServerStart(e, errCh, true)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	res, err := http.Get("http://" + e.ListenerAddr().String())
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "http", string(b))
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	res, err = client.Get("https://" + tlsAddr.String())
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "https", string(b))
+	}
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, e.Shutdown(ctx))
+	assert.Equal(t, http.ErrServerClosed, <-errCh)
+
+	// Invalid certificate
+	e = New()
+	assert.Error(t, e.StartBoth(":0", ":0", []byte("cert"), []byte("key")))
+}
+
 func TestEchoStartTLSByteString(t *testing.T) {
 	cert, err := ioutil.ReadFile("_fixture/certs/cert.pem")
 	require.NoError(t, err)
@@ -558,6 +725,32 @@ func TestHTTPError(t *testing.T) {
 		err.SetInternal(errors.New("internal error"))
 		assert.Equal(t, "code=400, message=map[code:12], internal=internal error", err.Error())
 	})
+	t.Run("with internal", func(t *testing.T) {
+		internal := errors.New("internal error")
+		err := ErrBadRequest.WithInternal(internal)
+		assert.Equal(t, "code=400, message=Bad Request, internal=internal error", err.Error())
+		assert.Nil(t, ErrBadRequest.Internal)
+		assert.True(t, errors.Is(err, internal))
+		assert.True(t, errors.Is(err, ErrBadRequest))
+		assert.False(t, errors.Is(err, ErrNotFound))
+		assert.True(t, errors.Is(fmt.Errorf("user: %w", NewHTTPError(http.StatusNotFound, "no user")), ErrNotFound))
+	})
+}
+
+func TestDefaultHTTPErrorHandlerWrapped(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	e.DefaultHTTPErrorHandler(fmt.Errorf("find user: %w", ErrNotFound), c)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Equal(t, `{"message":"Not Found"}`+"\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	e.DefaultHTTPErrorHandler(ErrUnprocessableEntity.WithInternal(errors.New("invalid")), c)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
 }
 
 func TestEchoClose(t *testing.T) {
@@ -601,3 +794,26 @@ func TestEchoShutdown(t *testing.T) {
 	err := <-errCh
 	assert.Equal(t, err.Error(), "http: Server closed")
 }
+
+func TestEchoRouteMeta(t *testing.T) {
+	e := New()
+	r := e.GET("/users", func(c Context) error { return nil })
+	assert.Nil(t, r.Meta())
+
+	assert.Equal(t, r, r.SetMeta("summary", "List users").SetMeta("tags", []string{"users"}))
+	m := r.Meta()
+	assert.Equal(t, "List users", m["summary"])
+	assert.Equal(t, []string{"users"}, m["tags"])
+
+	// Copy on write
+	r.SetMeta("summary", "Users")
+	assert.Equal(t, "List users", m["summary"])
+	assert.Equal(t, "Users", r.Meta()["summary"])
+
+	// Replaced routes and other instances don't share metadata
+	e.OverrideRoutes = true
+	r2 := e.GET("/users", func(c Context) error { return nil })
+	assert.Nil(t, r2.Meta())
+	assert.Equal(t, r2, e.Routes()[0])
+	assert.Nil(t, New().GET("/users", func(c Context) error { return nil }).Meta())
+}
@@ -0,0 +1,124 @@
+package echotest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// AssertStatus reports a test failure if the recorded status code differs from
+// `code`.
+func AssertStatus(t testing.TB, rec *httptest.ResponseRecorder, code int) bool {
+	t.Helper()
+	if rec.Code != code {
+		t.Errorf("echotest: unexpected status code: expected=%d, got=%d, body=%s", code, rec.Code, rec.Body.String())
+		return false
+	}
+	return true
+}
+
+// AssertHeader reports a test failure if the recorded response header `name`
+// differs from `value`.
+func AssertHeader(t testing.TB, rec *httptest.ResponseRecorder, name, value string) bool {
+	t.Helper()
+	if got := rec.Header().Get(name); got != value {
+		t.Errorf("echotest: unexpected header %s: expected=%q, got=%q", name, value, got)
+		return false
+	}
+	return true
+}
+
+// AssertJSON reports a test failure if the recorded response body is not JSON
+// semantically equal to `expected`. Key order and whitespace are ignored.
+//
+// Expected can be a `string` or `[]byte` holding raw JSON, or any other value
+// which is encoded as JSON before comparing.
+func AssertJSON(t testing.TB, rec *httptest.ResponseRecorder, expected interface{}) bool {
+	t.Helper()
+	var raw []byte
+	switch v := expected.(type) {
+	case string:
+		raw = []byte(v)
+	case []byte:
+		raw = v
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Errorf("echotest: unable to encode expected value: %v", err)
+			return false
+		}
+		raw = b
+	}
+
+	var want, got interface{}
+	if err := json.Unmarshal(raw, &want); err != nil {
+		t.Errorf("echotest: invalid expected JSON: %v", err)
+		return false
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Errorf("echotest: invalid response JSON: %v, body=%s", err, rec.Body.String())
+		return false
+	}
+	if diff := jsonDiff("$", want, got); len(diff) > 0 {
+		t.Errorf("echotest: JSON body mismatch:\n%s", strings.Join(diff, "\n"))
+		return false
+	}
+	return true
+}
+
+// jsonDiff returns a line per difference between two decoded JSON values.
+func jsonDiff(path string, want, got interface{}) (diff []string) {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := path + "." + k
+			wv, wok := w[k]
+			gv, gok := g[k]
+			switch {
+			case !gok:
+				diff = append(diff, fmt.Sprintf("- %s: %s", p, encode(wv)))
+			case !wok:
+				diff = append(diff, fmt.Sprintf("+ %s: %s", p, encode(gv)))
+			default:
+				diff = append(diff, jsonDiff(p, wv, gv)...)
+			}
+		}
+		return
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok || len(g) != len(w) {
+			break
+		}
+		for i := range w {
+			diff = append(diff, jsonDiff(fmt.Sprintf("%s[%d]", path, i), w[i], g[i])...)
+		}
+		return
+	}
+	if !reflect.DeepEqual(want, got) {
+		diff = append(diff, fmt.Sprintf("~ %s: expected=%s, got=%s", path, encode(want), encode(got)))
+	}
+	return
+}
+
+func encode(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,66 @@
+package echotest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertStatus(t *testing.T) {
+	c, rec := NewContext(http.MethodGet, "/", nil)
+	c.NoContent(http.StatusAccepted)
+
+	rt := &recordingT{TB: t}
+	assert.True(t, AssertStatus(rt, rec, http.StatusAccepted))
+	assert.False(t, AssertStatus(rt, rec, http.StatusOK))
+	if assert.Len(t, rt.errors, 1) {
+		assert.Contains(t, rt.errors[0], "expected=200, got=202")
+	}
+}
+
+func TestAssertHeader(t *testing.T) {
+	c, rec := NewContext(http.MethodGet, "/", nil)
+	c.Response().Header().Set(echo.HeaderServer, "echo")
+	c.NoContent(http.StatusOK)
+
+	rt := &recordingT{TB: t}
+	assert.True(t, AssertHeader(rt, rec, echo.HeaderServer, "echo"))
+	assert.False(t, AssertHeader(rt, rec, echo.HeaderServer, "other"))
+	assert.Len(t, rt.errors, 1)
+}
+
+func TestAssertJSON(t *testing.T) {
+	c, rec := NewContext(http.MethodGet, "/", nil)
+	c.JSON(http.StatusOK, echo.Map{"id": 1, "name": "Jon Snow", "tags": []string{"a", "b"}})
+
+	rt := &recordingT{TB: t}
+	assert.True(t, AssertJSON(rt, rec, `{"tags":["a","b"], "name":"Jon Snow","id":1}`))
+	assert.True(t, AssertJSON(rt, rec, echo.Map{"id": 1, "name": "Jon Snow", "tags": []string{"a", "b"}}))
+	assert.Empty(t, rt.errors)
+
+	assert.False(t, AssertJSON(rt, rec, `{"id":2,"tags":["a","c"],"email":"jon@labstack.com"}`))
+	if assert.Len(t, rt.errors, 1) {
+		assert.Contains(t, rt.errors[0], "- $.email: \"jon@labstack.com\"")
+		assert.Contains(t, rt.errors[0], "~ $.id: expected=2, got=1")
+		assert.Contains(t, rt.errors[0], "+ $.name: \"Jon Snow\"")
+		assert.Contains(t, rt.errors[0], "~ $.tags[1]: expected=\"c\", got=\"b\"")
+	}
+
+	rt = &recordingT{TB: t}
+	assert.False(t, AssertJSON(rt, rec, `{invalid`))
+	assert.Len(t, rt.errors, 1)
+}
@@ -0,0 +1,151 @@
+package echotest
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Spec describes a request and the response it is expected to produce.
+	Spec struct {
+		// Name identifies the spec in failure messages.
+		// Optional. Default value "<Method> <Target>".
+		Name string
+
+		// Method and Target of the request, e.g. "GET" and "/users/1?page=2".
+		// Required.
+		Method string
+		Target string
+
+		// Body of the request, see `NewContext()` for the accepted values.
+		// Optional.
+		Body interface{}
+
+		// Header holds the request headers.
+		// Optional.
+		Header http.Header
+
+		// Status is the expected response status code.
+		// Optional. Default value 200.
+		Status int
+
+		// JSON is the expected response body, see `AssertJSON()`.
+		// Optional.
+		JSON interface{}
+
+		// ResponseHeader holds the expected response headers.
+		// Optional.
+		ResponseHeader map[string]string
+	}
+
+	// Coverage reports which registered routes were exercised by a set of specs.
+	Coverage struct {
+		Matched   []*echo.Route
+		Unmatched []*echo.Route
+	}
+)
+
+// RunSpecs executes the specs in order against the full middleware and router
+// stack of `e` without starting a listener, reporting a test failure for every
+// response not matching its spec. It returns the route coverage of the specs.
+//
+// Routes registered with `echo.NotFoundHandler` (e.g. the catch-all routes added
+// by `Group#Use()`) are left out of the coverage.
+func RunSpecs(t testing.TB, e *echo.Echo, specs []Spec) *Coverage {
+	t.Helper()
+	hit := map[*echo.Route]bool{}
+	for _, s := range specs {
+		name := s.Name
+		if name == "" {
+			name = s.Method + " " + s.Target
+		}
+		c, rec := NewContext(s.Method, s.Target, s.Body, WithEcho(e))
+		req := c.Request()
+		for k, v := range s.Header {
+			req.Header[k] = v
+		}
+		if r := e.FindRoute(req); r != nil {
+			hit[r] = true
+		}
+		e.ServeHTTP(rec, req)
+
+		status := s.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		st := &specT{TB: t, name: name}
+		AssertStatus(st, rec, status)
+		for k, v := range s.ResponseHeader {
+			AssertHeader(st, rec, k, v)
+		}
+		if s.JSON != nil {
+			AssertJSON(st, rec, s.JSON)
+		}
+	}
+
+	cov := new(Coverage)
+	notFound := runtime.FuncForPC(reflect.ValueOf(echo.NotFoundHandler).Pointer()).Name()
+	for _, r := range e.Routes() {
+		if r.Name == notFound {
+			continue
+		}
+		if hit[r] {
+			cov.Matched = append(cov.Matched, r)
+		} else {
+			cov.Unmatched = append(cov.Unmatched, r)
+		}
+	}
+	sortRoutes(cov.Matched)
+	sortRoutes(cov.Unmatched)
+	return cov
+}
+
+// Percent returns the percentage of routes matched by at least one spec.
+func (c *Coverage) Percent() float64 {
+	total := len(c.Matched) + len(c.Unmatched)
+	if total == 0 {
+		return 100
+	}
+	return float64(len(c.Matched)) * 100 / float64(total)
+}
+
+// AssertFullCoverage reports a test failure listing the routes no spec matched.
+func AssertFullCoverage(t testing.TB, c *Coverage) bool {
+	t.Helper()
+	if len(c.Unmatched) == 0 {
+		return true
+	}
+	lines := make([]string, len(c.Unmatched))
+	for i, r := range c.Unmatched {
+		lines[i] = r.Method + " " + r.Path
+	}
+	t.Errorf("echotest: %d route(s) not covered by specs (%.1f%% coverage):\n%s",
+		len(c.Unmatched), c.Percent(), strings.Join(lines, "\n"))
+	return false
+}
+
+func sortRoutes(routes []*echo.Route) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Path < routes[j].Path
+	})
+}
+
+// specT prefixes failure messages with the spec name.
+type specT struct {
+	testing.TB
+	name string
+}
+
+func (t *specT) Errorf(format string, args ...interface{}) {
+	t.TB.Helper()
+	t.TB.Errorf("%s: "+format, append([]interface{}{t.name}, args...)...)
+}
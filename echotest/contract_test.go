@@ -0,0 +1,93 @@
+package echotest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunSpecs(t *testing.T) {
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set(echo.HeaderServer, "echo")
+			return next(c)
+		}
+	})
+	e.GET("/users/:id", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, echo.Map{"id": c.Param("id")})
+	})
+	e.POST("/users", func(c echo.Context) error {
+		u := echo.Map{}
+		if err := c.Bind(&u); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusCreated, u)
+	})
+	e.DELETE("/users/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	g := e.Group("/admin", func(next echo.HandlerFunc) echo.HandlerFunc {
+		return next
+	})
+	g.GET("/stats", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	rt := &recordingT{TB: t}
+	cov := RunSpecs(rt, e, []Spec{
+		{Method: http.MethodGet, Target: "/users/1", JSON: `{"id":"1"}`, ResponseHeader: map[string]string{echo.HeaderServer: "echo"}},
+		{Method: http.MethodPost, Target: "/users", Body: echo.Map{"name": "Jon"}, Status: http.StatusCreated, JSON: `{"name":"Jon"}`},
+		{Name: "missing", Method: http.MethodGet, Target: "/missing", Status: http.StatusNotFound},
+	})
+	assert.Empty(t, rt.errors)
+	if assert.Len(t, cov.Matched, 2) {
+		assert.Equal(t, "/users", cov.Matched[0].Path)
+		assert.Equal(t, "/users/:id", cov.Matched[1].Path)
+	}
+	if assert.Len(t, cov.Unmatched, 2) {
+		assert.Equal(t, "/admin/stats", cov.Unmatched[0].Path)
+		assert.Equal(t, http.MethodDelete, cov.Unmatched[1].Method)
+	}
+	assert.Equal(t, float64(50), cov.Percent())
+
+	assert.False(t, AssertFullCoverage(rt, cov))
+	if assert.Len(t, rt.errors, 1) {
+		assert.Contains(t, rt.errors[0], "GET /admin/stats\nDELETE /users/:id")
+	}
+
+	// Failing spec
+	rt = &recordingT{TB: t}
+	RunSpecs(rt, e, []Spec{
+		{Name: "get user", Method: http.MethodGet, Target: "/users/1", Status: http.StatusAccepted},
+	})
+	if assert.Len(t, rt.errors, 1) {
+		assert.Contains(t, rt.errors[0], "get user: echotest: unexpected status code")
+	}
+}
+
+func TestRunSpecsPathMatch(t *testing.T) {
+	e := echo.New()
+	e.PathMatch = echo.PathMatchDecoded
+	e.GET("/files/a/b", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	e.GET("/files/:name", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	cov := RunSpecs(t, e, []Spec{
+		{Method: http.MethodGet, Target: "/files/a%2Fb"},
+	})
+	if assert.Len(t, cov.Matched, 1) {
+		assert.Equal(t, "/files/a/b", cov.Matched[0].Path)
+	}
+}
+
+func TestCoverageEmpty(t *testing.T) {
+	cov := RunSpecs(t, echo.New(), nil)
+	assert.Equal(t, float64(100), cov.Percent())
+	assert.True(t, AssertFullCoverage(t, cov))
+}
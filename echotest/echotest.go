@@ -0,0 +1,155 @@
+/*
+Package echotest provides utilities for unit testing Echo handlers and middleware.
+
+Example:
+
+  func TestGetUser(t *testing.T) {
+    c, rec := echotest.NewContext(http.MethodGet, "/users/1", nil,
+      echotest.WithPath("/users/:id"),
+      echotest.WithParam("id", "1"),
+    )
+    echotest.Run(c, getUser, middleware.RequestID())
+
+    echotest.AssertStatus(t, rec, http.StatusOK)
+    echotest.AssertJSON(t, rec, `{"id":1,"name":"Jon Snow"}`)
+  }
+*/
+package echotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Option configures the request and context created by `NewContext()`.
+	Option func(*config)
+
+	config struct {
+		echo    *echo.Echo
+		header  http.Header
+		cookies []*http.Cookie
+		path    string
+		pnames  []string
+		pvalues []string
+	}
+)
+
+// WithEcho sets the Echo instance the context is created from.
+// Default value is a new instance created with `echo.New()`.
+func WithEcho(e *echo.Echo) Option {
+	return func(c *config) {
+		c.echo = e
+	}
+}
+
+// WithHeader adds a request header.
+func WithHeader(name, value string) Option {
+	return func(c *config) {
+		c.header.Add(name, value)
+	}
+}
+
+// WithContentType sets the request `Content-Type` header.
+func WithContentType(ctype string) Option {
+	return func(c *config) {
+		c.header.Set(echo.HeaderContentType, ctype)
+	}
+}
+
+// WithCookie adds a request cookie.
+func WithCookie(cookie *http.Cookie) Option {
+	return func(c *config) {
+		c.cookies = append(c.cookies, cookie)
+	}
+}
+
+// WithPath sets the registered route path, e.g. "/users/:id".
+func WithPath(path string) Option {
+	return func(c *config) {
+		c.path = path
+	}
+}
+
+// WithParam adds a path parameter.
+func WithParam(name, value string) Option {
+	return func(c *config) {
+		c.pnames = append(c.pnames, name)
+		c.pvalues = append(c.pvalues, value)
+	}
+}
+
+// NewContext returns a context for a request with the provided method, target
+// and body, along with the recorder the response is written to.
+//
+// Body can be nil, a `string`, a `[]byte` or an `io.Reader`. Any other value is
+// encoded as JSON and the `Content-Type` header is set to `application/json`
+// unless provided with `WithContentType()`.
+func NewContext(method, target string, body interface{}, opts ...Option) (echo.Context, *httptest.ResponseRecorder) {
+	cfg := &config{header: http.Header{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.echo == nil {
+		cfg.echo = echo.New()
+	}
+
+	var r io.Reader
+	switch b := body.(type) {
+	case nil:
+	case string:
+		r = strings.NewReader(b)
+	case []byte:
+		r = bytes.NewReader(b)
+	case io.Reader:
+		r = b
+	default:
+		buf := new(bytes.Buffer)
+		if err := json.NewEncoder(buf).Encode(b); err != nil {
+			panic("echotest: unable to encode body: " + err.Error())
+		}
+		r = buf
+		if cfg.header.Get(echo.HeaderContentType) == "" {
+			cfg.header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+	}
+
+	req := httptest.NewRequest(method, target, r)
+	for k, v := range cfg.header {
+		req.Header[k] = v
+	}
+	for _, cookie := range cfg.cookies {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	c := cfg.echo.NewContext(req, rec)
+	if cfg.path != "" {
+		c.SetPath(cfg.path)
+	}
+	if len(cfg.pnames) > 0 {
+		c.SetParamNames(cfg.pnames...)
+		c.SetParamValues(cfg.pvalues...)
+	}
+	return c, rec
+}
+
+// Run executes handler `h` wrapped with middleware `m` against context `c`, the
+// first middleware being the outermost. Just like `Echo#ServeHTTP()` the error
+// returned by the chain is passed to `Echo#HTTPErrorHandler`; it is also returned
+// so tests can inspect it.
+func Run(c echo.Context, h echo.HandlerFunc, m ...echo.MiddlewareFunc) error {
+	for i := len(m) - 1; i >= 0; i-- {
+		h = m[i](h)
+	}
+	err := h(c)
+	if err != nil {
+		c.Error(err)
+	}
+	return err
+}
@@ -0,0 +1,83 @@
+package echotest
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewContext(t *testing.T) {
+	c, rec := NewContext(http.MethodPost, "/users/1?name=jon", `{"id":1}`,
+		WithContentType(echo.MIMEApplicationJSON),
+		WithHeader("X-Test", "test"),
+		WithCookie(&http.Cookie{Name: "session", Value: "abc"}),
+		WithPath("/users/:id"),
+		WithParam("id", "1"),
+	)
+	assert.NotNil(t, rec)
+	assert.Equal(t, http.MethodPost, c.Request().Method)
+	assert.Equal(t, echo.MIMEApplicationJSON, c.Request().Header.Get(echo.HeaderContentType))
+	assert.Equal(t, "test", c.Request().Header.Get("X-Test"))
+	assert.Equal(t, "jon", c.QueryParam("name"))
+	assert.Equal(t, "/users/:id", c.Path())
+	assert.Equal(t, "1", c.Param("id"))
+	cookie, err := c.Cookie("session")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "abc", cookie.Value)
+	}
+	b, _ := ioutil.ReadAll(c.Request().Body)
+	assert.Equal(t, `{"id":1}`, string(b))
+}
+
+func TestNewContextJSONBody(t *testing.T) {
+	e := echo.New()
+	c, _ := NewContext(http.MethodPost, "/", echo.Map{"id": 1}, WithEcho(e))
+	assert.Equal(t, e, c.Echo())
+	assert.Equal(t, echo.MIMEApplicationJSON, c.Request().Header.Get(echo.HeaderContentType))
+	b, _ := ioutil.ReadAll(c.Request().Body)
+	assert.Equal(t, "{\"id\":1}\n", string(b))
+
+	c, _ = NewContext(http.MethodPost, "/", []byte("raw"))
+	assert.Empty(t, c.Request().Header.Get(echo.HeaderContentType))
+	b, _ = ioutil.ReadAll(c.Request().Body)
+	assert.Equal(t, "raw", string(b))
+}
+
+func TestRun(t *testing.T) {
+	c, rec := NewContext(http.MethodGet, "/", nil)
+	order := ""
+	mw := func(name string) echo.MiddlewareFunc {
+		return func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				order += name
+				return next(c)
+			}
+		}
+	}
+	err := Run(c, func(c echo.Context) error {
+		order += "h"
+		return c.String(http.StatusOK, "OK")
+	}, mw("1"), mw("2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "12h", order)
+	assert.Equal(t, "OK", rec.Body.String())
+
+	// Error is handled by the error handler
+	c, rec = NewContext(http.MethodGet, "/", nil)
+	err = Run(c, func(c echo.Context) error {
+		return echo.ErrForbidden
+	})
+	assert.Equal(t, echo.ErrForbidden, err)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	c, rec = NewContext(http.MethodGet, "/", nil)
+	err = Run(c, func(c echo.Context) error {
+		return errors.New("error")
+	})
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
@@ -0,0 +1,7 @@
+package echo
+
+type (
+	// Flags are the feature flags of a request by name, see
+	// `Context#FlagEnabled()`.
+	Flags map[string]bool
+)
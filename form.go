@@ -0,0 +1,329 @@
+package echo
+
+import (
+	"encoding"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+type (
+	// Form is a struct bound from a form submission, for server-rendered
+	// templates: the fields to render with their submitted values and the
+	// errors of binding and validation, see `BindForm()` and the "formInput"
+	// function of `TemplateFuncs`.
+	Form struct {
+		// Fields are the fields of the struct, in declaration order.
+		Fields []*FormField
+
+		// Errors maps the names of the fields to their error. The error of
+		// the form as a whole, e.g. a validation error which isn't a
+		// `FieldErrorer`, has the key "".
+		Errors map[string]string
+	}
+
+	// FormField is a field of a `Form`. Fields are declared with struct tags:
+	//
+	//	type SignUp struct {
+	//	  Email    string `form:"email" label:"E-mail" validate:"required,email"`
+	//	  Password string `form:"password" input:"password" validate:"required"`
+	//	  Plan     string `form:"plan" options:"free,pro"`
+	//	  Terms    bool   `form:"terms"`
+	//	}
+	//
+	// Fields tagged `form:"-"`, and slice, map and struct fields which aren't
+	// `encoding.TextMarshaler`, are left out.
+	FormField struct {
+		// Name is the `form` tag of the field, or its name.
+		Name string
+
+		// Label is the `label` tag of the field, or its name.
+		Label string
+
+		// Type is the input type: the `input` tag of the field, e.g.
+		// "password", "textarea" or "hidden", "checkbox" for bools, "number"
+		// for numbers, "select" for fields with an `options` tag, "email" for
+		// fields validated as emails and "text" otherwise.
+		Type string
+
+		// Value is the submitted value of the field, or its value.
+		Value string
+
+		// Options are the values of a "select" field, from its comma
+		// separated `options` tag.
+		Options []string
+
+		// Required reports whether the field is validated as required.
+		Required bool
+
+		// Error is the error of the field.
+		Error string
+
+		field string
+	}
+
+	// FieldErrorer is implemented by validation errors reporting the errors of
+	// single fields, keyed by the form names or the struct field names of the
+	// fields, e.g. by a `Validator` adapting the errors of a validation
+	// library.
+	FieldErrorer interface {
+		FieldErrors() map[string]string
+	}
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// NewForm returns the form of the struct `v` points to, with its values.
+func NewForm(v interface{}) *Form {
+	f := &Form{Errors: map[string]string{}}
+	t := reflect.TypeOf(v)
+	val := reflect.ValueOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t, val = t.Elem(), val.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return f
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		if field := newFormField(sf, val.Field(i)); field != nil {
+			f.Fields = append(f.Fields, field)
+		}
+	}
+	return f
+}
+
+// BindForm binds the form submission of the request into the struct `ptr`
+// points to, like `DefaultBinder#BindForm()`, and validates it with
+// `Echo#Validator`. It returns the form to render again, with the submitted
+// values and the errors, when `Form#Valid()` is false:
+//
+//	func signUp(c echo.Context) error {
+//	  u := new(SignUp)
+//	  form, err := echo.BindForm(c, u)
+//	  if err != nil {
+//	    return err
+//	  }
+//	  if !form.Valid() {
+//	    return c.Render(http.StatusUnprocessableEntity, "signup.html", echo.Map{"Form": form})
+//	  }
+//	  ...
+//	}
+//
+// It returns an error if the form can't be parsed.
+func BindForm(c Context, ptr interface{}) (*Form, error) {
+	params, err := c.FormParams()
+	if err != nil {
+		return nil, NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+	}
+	if err := applyDefaults(ptr); err != nil {
+		return nil, err
+	}
+	b, ok := c.Echo().Binder.(*DefaultBinder)
+	if !ok {
+		b = new(DefaultBinder)
+	}
+	bindErr := b.bindData(ptr, params, "form")
+
+	f := NewForm(ptr)
+	f.setValues(params)
+	if bindErr != nil {
+		bes := BindingErrorsOf(bindErr)
+		if bes == nil {
+			return nil, bindErr
+		}
+		for _, be := range bes {
+			f.setError(be.Field, be.Message)
+		}
+	}
+	if c.Echo().Validator != nil {
+		if err := c.Validate(ptr); err != nil {
+			fe, ok := err.(FieldErrorer)
+			if !ok {
+				f.setError("", err.Error())
+			} else {
+				for name, msg := range fe.FieldErrors() {
+					f.setError(name, msg)
+				}
+			}
+		}
+	}
+	return f, nil
+}
+
+// Valid reports whether the form has no errors.
+func (f *Form) Valid() bool {
+	return len(f.Errors) == 0
+}
+
+// Field returns the field named `name`, or nil.
+func (f *Form) Field(name string) *FormField {
+	for _, field := range f.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// setValues sets the values of the fields to the submitted values, so that
+// values which failed binding are rendered as submitted.
+func (f *Form) setValues(params url.Values) {
+	for _, field := range f.Fields {
+		if field.Type == "checkbox" {
+			continue
+		}
+		for k, v := range params {
+			if strings.EqualFold(k, field.Name) && len(v) > 0 {
+				field.Value = v[0]
+				break
+			}
+		}
+	}
+}
+
+// setError sets the error of the field with the form name or struct field
+// name `name`, keeping the first error of a field.
+func (f *Form) setError(name, msg string) {
+	for _, field := range f.Fields {
+		if strings.EqualFold(field.Name, name) || field.field == name {
+			name = field.Name
+			if field.Error == "" {
+				field.Error = msg
+			}
+			break
+		}
+	}
+	if _, ok := f.Errors[name]; !ok {
+		f.Errors[name] = msg
+	}
+}
+
+// newFormField returns the field of struct field `sf` with value `v`, or nil if
+// it is left out of forms.
+func newFormField(sf reflect.StructField, v reflect.Value) *FormField {
+	name := sf.Tag.Get("form")
+	if name == "-" {
+		return nil
+	}
+	if name == "" {
+		name = sf.Name
+	}
+	f := &FormField{Name: name, Label: sf.Tag.Get("label"), Type: sf.Tag.Get("input"), field: sf.Name}
+	if f.Label == "" {
+		f.Label = sf.Name
+	}
+	validate := sf.Tag.Get("validate")
+	for _, rule := range strings.Split(validate, ",") {
+		if rule == "required" {
+			f.Required = true
+		}
+	}
+
+	t := sf.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if v.IsNil() {
+			v = reflect.Value{}
+			break
+		}
+		v = v.Elem()
+	}
+	switch {
+	case reflect.PtrTo(t).Implements(textMarshalerType) || t.Implements(textMarshalerType):
+		if v.CanAddr() {
+			v = v.Addr()
+		}
+		if v.IsValid() {
+			if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+				b, _ := m.MarshalText()
+				f.Value = string(b)
+			}
+		}
+	case t.Kind() == reflect.Slice, t.Kind() == reflect.Map, t.Kind() == reflect.Struct:
+		return nil
+	case v.IsValid():
+		f.Value = fmt.Sprint(v.Interface())
+	}
+
+	if options := sf.Tag.Get("options"); options != "" {
+		f.Options = strings.Split(options, ",")
+	}
+	if f.Type != "" {
+		return f
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		f.Type = "checkbox"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		f.Type = "number"
+	default:
+		f.Type = "text"
+		if len(f.Options) > 0 {
+			f.Type = "select"
+		} else if strings.Contains(","+validate+",", ",email,") {
+			f.Type = "email"
+		}
+	}
+	return f
+}
+
+// formInput renders the label, input and error of a form field.
+func formInput(f *FormField) template.HTML {
+	b := new(strings.Builder)
+	id := "form-" + html.EscapeString(f.Name)
+	name := html.EscapeString(f.Name)
+	value := html.EscapeString(f.Value)
+	if f.Type == "password" {
+		value = "" // Never render passwords back
+	}
+	if f.Type == "hidden" {
+		fmt.Fprintf(b, `<input type="hidden" name="%s" value="%s">`, name, value)
+		return template.HTML(b.String())
+	}
+
+	attrs := ""
+	if f.Required {
+		attrs += " required"
+	}
+	if f.Error != "" {
+		attrs += ` aria-invalid="true" aria-describedby="` + id + `-error"`
+	}
+	b.WriteString(`<div class="field">`)
+	fmt.Fprintf(b, `<label for="%s">%s</label>`, id, html.EscapeString(f.Label))
+	switch f.Type {
+	case "textarea":
+		fmt.Fprintf(b, `<textarea id="%s" name="%s"%s>%s</textarea>`, id, name, attrs, value)
+	case "select":
+		fmt.Fprintf(b, `<select id="%s" name="%s"%s>`, id, name, attrs)
+		for _, o := range f.Options {
+			selected := ""
+			if o == f.Value {
+				selected = " selected"
+			}
+			fmt.Fprintf(b, `<option value="%s"%s>%s</option>`, html.EscapeString(o), selected, html.EscapeString(o))
+		}
+		b.WriteString(`</select>`)
+	case "checkbox":
+		if f.Value == "true" {
+			attrs += " checked"
+		}
+		fmt.Fprintf(b, `<input id="%s" type="checkbox" name="%s" value="true"%s>`, id, name, attrs)
+	default:
+		fmt.Fprintf(b, `<input id="%s" type="%s" name="%s" value="%s"%s>`, id, html.EscapeString(f.Type), name, value, attrs)
+	}
+	if f.Error != "" {
+		fmt.Fprintf(b, `<p id="%s-error" class="error">%s</p>`, id, html.EscapeString(f.Error))
+	}
+	b.WriteString(`</div>`)
+	return template.HTML(b.String())
+}
@@ -0,0 +1,126 @@
+package echo
+
+import (
+	"errors"
+	htmlTemplate "html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	formSignUp struct {
+		Email    string `form:"email" label:"E-mail" validate:"required,email"`
+		Password string `form:"password" input:"password" validate:"required"`
+		Age      int    `form:"age"`
+		Plan     string `form:"plan" options:"free,pro"`
+		Terms    bool   `form:"terms"`
+		Bio      string `form:"bio" input:"textarea"`
+		Internal string `form:"-"`
+	}
+
+	formValidator struct{}
+
+	formFieldErrors map[string]string
+)
+
+func (formValidator) Validate(i interface{}) error {
+	s := i.(*formSignUp)
+	errs := formFieldErrors{}
+	if !strings.Contains(s.Email, "@") {
+		errs["Email"] = "must be an e-mail address"
+	}
+	if !s.Terms {
+		errs["terms"] = "must be accepted"
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (e formFieldErrors) Error() string {
+	return "invalid form"
+}
+
+func (e formFieldErrors) FieldErrors() map[string]string {
+	return e
+}
+
+func TestNewForm(t *testing.T) {
+	f := NewForm(&formSignUp{Email: "jon@labstack.com", Age: 30, Plan: "pro", Terms: true})
+	if assert.Len(t, f.Fields, 6) {
+		assert.Equal(t, &FormField{Name: "email", Label: "E-mail", Type: "email", Value: "jon@labstack.com", Required: true, field: "Email"}, f.Fields[0])
+		assert.Equal(t, "password", f.Fields[1].Type)
+		assert.Equal(t, "number", f.Field("age").Type)
+		assert.Equal(t, "30", f.Field("age").Value)
+		assert.Equal(t, "select", f.Field("plan").Type)
+		assert.Equal(t, []string{"free", "pro"}, f.Field("plan").Options)
+		assert.Equal(t, "checkbox", f.Field("terms").Type)
+		assert.Equal(t, "true", f.Field("terms").Value)
+		assert.Equal(t, "textarea", f.Field("bio").Type)
+	}
+	assert.True(t, f.Valid())
+}
+
+func TestFormBind(t *testing.T) {
+	e := New()
+	e.Validator = formValidator{}
+	bind := func(form url.Values) (*formSignUp, *Form) {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+		req.Header.Set(HeaderContentType, MIMEApplicationForm)
+		c := e.NewContext(req, httptest.NewRecorder())
+		s := new(formSignUp)
+		f, err := BindForm(c, s)
+		assert.NoError(t, err)
+		return s, f
+	}
+
+	s, f := bind(url.Values{"email": {"jon@labstack.com"}, "password": {"secret"}, "age": {"30"}, "terms": {"true"}})
+	assert.True(t, f.Valid())
+	assert.Equal(t, &formSignUp{Email: "jon@labstack.com", Password: "secret", Age: 30, Terms: true}, s)
+
+	// Failed binding and validation
+	_, f = bind(url.Values{"email": {"jon"}, "password": {"secret"}, "age": {"thirty"}})
+	assert.False(t, f.Valid())
+	assert.Equal(t, map[string]string{
+		"email": "must be an e-mail address",
+		"age":   "invalid syntax",
+		"terms": "must be accepted",
+	}, f.Errors)
+	assert.Equal(t, "jon", f.Field("email").Value)
+	assert.Equal(t, "thirty", f.Field("age").Value)
+	assert.Equal(t, "invalid syntax", f.Field("age").Error)
+
+	// Form level errors
+	e.Validator = &formErrorValidator{err: errors.New("try again later")}
+	_, f = bind(url.Values{"email": {"jon@labstack.com"}})
+	assert.Equal(t, map[string]string{"": "try again later"}, f.Errors)
+}
+
+type formErrorValidator struct {
+	err error
+}
+
+func (v *formErrorValidator) Validate(interface{}) error {
+	return v.err
+}
+
+func TestFormInput(t *testing.T) {
+	tpl := htmlTemplate.Must(htmlTemplate.New("form").Funcs(TemplateFuncs).Parse(`{{range .Fields}}{{formInput .}}{{end}}`))
+	f := NewForm(&formSignUp{Email: `"jon"`, Password: "secret", Plan: "pro"})
+	f.setError("email", "must be an e-mail address")
+	buf := new(strings.Builder)
+	assert.NoError(t, tpl.Execute(buf, f))
+	out := buf.String()
+
+	assert.Contains(t, out, `<div class="field"><label for="form-email">E-mail</label><input id="form-email" type="email" name="email" value="&#34;jon&#34;" required aria-invalid="true" aria-describedby="form-email-error"><p id="form-email-error" class="error">must be an e-mail address</p></div>`)
+	assert.Contains(t, out, `<input id="form-password" type="password" name="password" value="" required>`)
+	assert.Contains(t, out, `<option value="free">free</option><option value="pro" selected>pro</option>`)
+	assert.Contains(t, out, `<input id="form-terms" type="checkbox" name="terms" value="true">`)
+	assert.Contains(t, out, `<textarea id="form-bio" name="bio"></textarea>`)
+}
@@ -0,0 +1,221 @@
+/*
+Package graphql mounts GraphQL handlers, e.g. of gqlgen or graphql-go, on Echo
+routes.
+
+The handler adds the Echo context to the std context of the request, so that
+resolvers can access it with `FromContext()`. It optionally implements the
+GraphQL multipart request spec for file uploads and serves subscriptions over
+WebSocket on the same route.
+
+Example:
+
+	h := graphql.Handler(graphql.Config{
+	  Handler:    &relay.Handler{Schema: schema},
+	  Subscriber: subscriber,
+	  Uploads:    true,
+	})
+	e.Match([]string{http.MethodGet, http.MethodPost}, "/graphql", h)
+*/
+package graphql
+
+import (
+	"bytes"
+	stdContext "context"
+	"encoding/json"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Config defines the config for the GraphQL handler.
+	Config struct {
+		// Handler serves queries and mutations.
+		// Required.
+		Handler http.Handler
+
+		// Subscriber serves subscriptions over WebSocket, using the
+		// "graphql-transport-ws" or the legacy "graphql-ws" protocol.
+		// Optional. By default WebSocket requests are passed to `Handler`, e.g.
+		// for gqlgen servers with a WebSocket transport.
+		Subscriber Subscriber
+
+		// OnConnect is called with the payload of the "connection_init" message
+		// of WebSocket connections, e.g. to authenticate the connection. An
+		// error closes the connection.
+		// Optional.
+		OnConnect func(c echo.Context, payload map[string]interface{}) error
+
+		// Uploads enables the GraphQL multipart request spec. Multipart requests
+		// are passed to `Handler` as JSON requests, the variables holding files
+		// are set to IDs resolvers pass to `Upload()`.
+		// Optional. Default value false.
+		Uploads bool
+
+		// MaxUploadSize is the maximum size of the files of a request kept in
+		// memory, the rest is stored in temporary files.
+		// Optional. Default value 32MB.
+		MaxUploadSize int64
+	}
+
+	// Request is a GraphQL request.
+	Request struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName,omitempty"`
+		Variables     map[string]interface{} `json:"variables,omitempty"`
+		Extensions    map[string]interface{} `json:"extensions,omitempty"`
+	}
+
+	// Subscriber executes subscriptions. It sends a result, e.g. a value
+	// marshaling to `{"data": ...}`, for each event and closes the channel when
+	// the subscription ends. The context is canceled when the client stops the
+	// subscription or disconnects.
+	Subscriber interface {
+		Subscribe(ctx stdContext.Context, r *Request) (<-chan interface{}, error)
+	}
+
+	// SubscriberFunc is an adapter to use functions as subscribers.
+	SubscriberFunc func(ctx stdContext.Context, r *Request) (<-chan interface{}, error)
+
+	contextKey struct{}
+	uploadsKey struct{}
+)
+
+var (
+	// DefaultConfig is the default GraphQL handler config.
+	DefaultConfig = Config{
+		MaxUploadSize: 32 << 20,
+	}
+)
+
+// Handler returns a handler serving GraphQL requests with config.
+func Handler(config Config) echo.HandlerFunc {
+	// Defaults
+	if config.Handler == nil {
+		panic("echo: graphql handler requires a handler")
+	}
+	if config.MaxUploadSize == 0 {
+		config.MaxUploadSize = DefaultConfig.MaxUploadSize
+	}
+
+	return func(c echo.Context) error {
+		req := c.Request()
+		ctx := stdContext.WithValue(req.Context(), contextKey{}, c)
+		if c.IsWebSocket() && config.Subscriber != nil {
+			c.SetRequest(req.WithContext(ctx))
+			return serveSubscriptions(c, config)
+		}
+		if config.Uploads && req.Method == http.MethodPost && strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+			uploads, err := rewriteMultipart(req, config.MaxUploadSize)
+			if err != nil {
+				return echo.NewHTTPError(http.StatusBadRequest, "invalid multipart request").SetInternal(err)
+			}
+			ctx = stdContext.WithValue(ctx, uploadsKey{}, uploads)
+		}
+		c.SetRequest(req.WithContext(ctx))
+		config.Handler.ServeHTTP(c.Response(), c.Request())
+		return nil
+	}
+}
+
+// FromContext returns the Echo context of the request a resolver is called for.
+func FromContext(ctx stdContext.Context) (echo.Context, bool) {
+	c, ok := ctx.Value(contextKey{}).(echo.Context)
+	return c, ok
+}
+
+// Upload returns the file a variable of a multipart request was set to. See
+// `Config.Uploads`.
+func Upload(ctx stdContext.Context, id string) (*multipart.FileHeader, bool) {
+	uploads, _ := ctx.Value(uploadsKey{}).(map[string]*multipart.FileHeader)
+	f, ok := uploads[id]
+	return f, ok
+}
+
+// Subscribe calls f(ctx, r).
+func (f SubscriberFunc) Subscribe(ctx stdContext.Context, r *Request) (<-chan interface{}, error) {
+	return f(ctx, r)
+}
+
+// rewriteMultipart turns a GraphQL multipart request into a JSON request,
+// returning its files by ID.
+// See: https://github.com/jaydenseric/graphql-multipart-request-spec
+func rewriteMultipart(req *http.Request, maxMemory int64) (map[string]*multipart.FileHeader, error) {
+	if err := req.ParseMultipartForm(maxMemory); err != nil {
+		return nil, err
+	}
+	form := req.MultipartForm
+	var operations interface{}
+	if err := json.Unmarshal([]byte(formValue(form, "operations")), &operations); err != nil {
+		return nil, err
+	}
+	var files map[string][]string
+	if err := json.Unmarshal([]byte(formValue(form, "map")), &files); err != nil {
+		return nil, err
+	}
+
+	uploads := map[string]*multipart.FileHeader{}
+	for id, paths := range files {
+		fhs := form.File[id]
+		if len(fhs) == 0 {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, "missing file "+id)
+		}
+		uploads[id] = fhs[0]
+		for _, path := range paths {
+			if !setPath(operations, strings.Split(path, "."), id) {
+				return nil, echo.NewHTTPError(http.StatusBadRequest, "invalid file path "+path)
+			}
+		}
+	}
+
+	b, err := json.Marshal(operations)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(b))
+	req.ContentLength = int64(len(b))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderContentLength, strconv.Itoa(len(b)))
+	return uploads, nil
+}
+
+func formValue(form *multipart.Form, key string) string {
+	if v := form.Value[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// setPath sets the value at the dot separated path of object keys and array
+// indexes.
+func setPath(v interface{}, path []string, value interface{}) bool {
+	if len(path) == 0 {
+		return false
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			if _, ok := v[path[0]]; !ok {
+				return false
+			}
+			v[path[0]] = value
+			return true
+		}
+		return setPath(v[path[0]], path[1:], value)
+	case []interface{}:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= len(v) {
+			return false
+		}
+		if len(path) == 1 {
+			v[i] = value
+			return true
+		}
+		return setPath(v[i], path[1:], value)
+	}
+	return false
+}
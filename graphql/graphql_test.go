@@ -0,0 +1,190 @@
+package graphql
+
+import (
+	"bytes"
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+// echoHandler is a GraphQL handler responding with the request it got and the
+// uploaded files.
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	c, ok := FromContext(r.Context())
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	var req Request
+	json.NewDecoder(r.Body).Decode(&req)
+	files := map[string]string{}
+	for _, v := range req.Variables {
+		if id, ok := v.(string); ok {
+			if f, ok := Upload(r.Context(), id); ok {
+				files[id] = f.Filename
+			}
+		}
+	}
+	c.JSON(http.StatusOK, echo.Map{"query": req.Query, "variables": req.Variables, "files": files, "path": c.Path()})
+})
+
+func TestHandler(t *testing.T) {
+	e := echo.New()
+	e.POST("/graphql", Handler(Config{Handler: echoHandler, Uploads: true}))
+
+	// JSON
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ users { id } }"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"query":"{ users { id } }","variables":null,"files":{},"path":"/graphql"}`, rec.Body.String())
+
+	// Multipart
+	body := new(bytes.Buffer)
+	mw := multipart.NewWriter(body)
+	mw.WriteField("operations", `{"query":"mutation ($file: Upload!) { upload(file: $file) }","variables":{"file":null}}`)
+	mw.WriteField("map", `{"0":["variables.file"]}`)
+	fw, _ := mw.CreateFormFile("0", "avatar.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/graphql", body)
+	req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"query":"mutation ($file: Upload!) { upload(file: $file) }","variables":{"file":"0"},"files":{"0":"avatar.png"},"path":"/graphql"}`, rec.Body.String())
+
+	// Invalid file path
+	body = new(bytes.Buffer)
+	mw = multipart.NewWriter(body)
+	mw.WriteField("operations", `{"query":"","variables":{}}`)
+	mw.WriteField("map", `{"0":["variables.missing.0"]}`)
+	fw, _ = mw.CreateFormFile("0", "avatar.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/graphql", body)
+	req.Header.Set(echo.HeaderContentType, mw.FormDataContentType())
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestSetPath(t *testing.T) {
+	var v interface{}
+	json.Unmarshal([]byte(`[{"variables":{"files":[null,null]}}]`), &v)
+	assert.True(t, setPath(v, strings.Split("0.variables.files.1", "."), "1"))
+	assert.False(t, setPath(v, strings.Split("0.variables.files.2", "."), "2"))
+	assert.False(t, setPath(v, strings.Split("1.variables", "."), "1"))
+	b, _ := json.Marshal(v)
+	assert.Equal(t, `[{"variables":{"files":[null,"1"]}}]`, string(b))
+}
+
+func TestSubscriptions(t *testing.T) {
+	subscriber := SubscriberFunc(func(ctx stdContext.Context, r *Request) (<-chan interface{}, error) {
+		if r.Query == "" {
+			return nil, errors.New("empty query")
+		}
+		ch := make(chan interface{})
+		go func() {
+			defer close(ch)
+			for i := 1; i <= 2; i++ {
+				select {
+				case ch <- echo.Map{"data": echo.Map{"count": i}}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+		return ch, nil
+	})
+	e := echo.New()
+	e.GET("/graphql", Handler(Config{
+		Handler:    echoHandler,
+		Subscriber: subscriber,
+		OnConnect: func(c echo.Context, payload map[string]interface{}) error {
+			if payload["token"] != "secret" {
+				return errors.New("unauthorized")
+			}
+			return nil
+		},
+	}))
+	s := httptest.NewServer(e)
+	defer s.Close()
+
+	dial := func(protocol string) *websocket.Conn {
+		cfg, _ := websocket.NewConfig("ws"+strings.TrimPrefix(s.URL, "http")+"/graphql", s.URL)
+		cfg.Protocol = []string{protocol}
+		ws, err := websocket.DialConfig(cfg)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return ws
+	}
+	exchange := func(ws *websocket.Conn, send string, n int) (got []string) {
+		if send != "" {
+			websocket.Message.Send(ws, send)
+		}
+		for i := 0; i < n; i++ {
+			var msg string
+			if err := websocket.Message.Receive(ws, &msg); err != nil {
+				break
+			}
+			got = append(got, msg)
+		}
+		return
+	}
+
+	// graphql-transport-ws
+	ws := dial(ProtocolGraphQLTransportWS)
+	assert.Equal(t, []string{`{"type":"connection_ack"}`}, exchange(ws, `{"type":"connection_init","payload":{"token":"secret"}}`, 1))
+	assert.Equal(t, []string{`{"type":"pong"}`}, exchange(ws, `{"type":"ping"}`, 1))
+	assert.Equal(t, []string{
+		`{"id":"1","type":"next","payload":{"data":{"count":1}}}`,
+		`{"id":"1","type":"next","payload":{"data":{"count":2}}}`,
+		`{"id":"1","type":"complete"}`,
+	}, exchange(ws, `{"id":"1","type":"subscribe","payload":{"query":"subscription { count }"}}`, 3))
+	assert.Equal(t, []string{
+		`{"id":"2","type":"error","payload":[{"message":"empty query"}]}`,
+	}, exchange(ws, `{"id":"2","type":"subscribe","payload":{}}`, 1))
+	ws.Close()
+
+	// graphql-ws
+	ws = dial(ProtocolGraphQLWS)
+	assert.Equal(t, []string{`{"type":"connection_ack"}`, `{"type":"ka"}`}, exchange(ws, `{"type":"connection_init","payload":{"token":"secret"}}`, 2))
+	assert.Equal(t, []string{
+		`{"id":"1","type":"data","payload":{"data":{"count":1}}}`,
+		`{"id":"1","type":"data","payload":{"data":{"count":2}}}`,
+		`{"id":"1","type":"complete"}`,
+	}, exchange(ws, `{"id":"1","type":"start","payload":{"query":"subscription { count }"}}`, 3))
+	ws.Close()
+
+	// Rejected connection
+	ws = dial(ProtocolGraphQLWS)
+	assert.Equal(t, []string{`{"type":"connection_error","payload":{"message":"unauthorized"}}`}, exchange(ws, `{"type":"connection_init"}`, 2))
+	ws.Close()
+
+	// Unsupported protocol
+	cfg, _ := websocket.NewConfig("ws"+strings.TrimPrefix(s.URL, "http")+"/graphql", s.URL)
+	cfg.Protocol = []string{"chat"}
+	_, err := websocket.DialConfig(cfg)
+	assert.Error(t, err)
+
+	// Queries over HTTP on the same route
+	res, err := http.Get(s.URL + "/graphql")
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Contains(t, string(b), `"path":"/graphql"`)
+	}
+}
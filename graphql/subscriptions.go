@@ -0,0 +1,233 @@
+package graphql
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+type (
+	// conn is a WebSocket connection serving subscriptions.
+	conn struct {
+		ws     *websocket.Conn
+		c      echo.Context
+		config Config
+		legacy bool // "graphql-ws" protocol
+
+		mu   sync.Mutex
+		subs map[string]stdContext.CancelFunc
+		init bool
+	}
+
+	message struct {
+		ID      string          `json:"id,omitempty"`
+		Type    string          `json:"type"`
+		Payload json.RawMessage `json:"payload,omitempty"`
+	}
+)
+
+// WebSocket subprotocols
+const (
+	ProtocolGraphQLTransportWS = "graphql-transport-ws"
+	ProtocolGraphQLWS          = "graphql-ws"
+)
+
+// Message types of the "graphql-transport-ws" protocol
+const (
+	msgConnectionInit = "connection_init"
+	msgConnectionAck  = "connection_ack"
+	msgPing           = "ping"
+	msgPong           = "pong"
+	msgSubscribe      = "subscribe"
+	msgNext           = "next"
+	msgError          = "error"
+	msgComplete       = "complete"
+)
+
+// Message types of the legacy "graphql-ws" protocol, in addition to
+// "connection_init", "connection_ack", "error" and "complete"
+const (
+	msgConnectionError     = "connection_error"
+	msgConnectionTerminate = "connection_terminate"
+	msgKeepAlive           = "ka"
+	msgStart               = "start"
+	msgData                = "data"
+	msgStop                = "stop"
+)
+
+// keepAlive is the interval of keep-alive messages of the legacy protocol.
+var keepAlive = 30 * time.Second
+
+func serveSubscriptions(c echo.Context, config Config) error {
+	s := websocket.Server{
+		Handshake: func(cfg *websocket.Config, r *http.Request) error {
+			for _, p := range cfg.Protocol {
+				if p == ProtocolGraphQLTransportWS || p == ProtocolGraphQLWS {
+					cfg.Protocol = []string{p}
+					return nil
+				}
+			}
+			return errors.New("unsupported websocket protocol")
+		},
+		Handler: func(ws *websocket.Conn) {
+			cn := &conn{
+				ws:     ws,
+				c:      c,
+				config: config,
+				legacy: ws.Config().Protocol[0] == ProtocolGraphQLWS,
+				subs:   map[string]stdContext.CancelFunc{},
+			}
+			cn.serve()
+		},
+	}
+	s.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+func (cn *conn) serve() {
+	ctx, cancel := stdContext.WithCancel(cn.c.Request().Context())
+	defer func() {
+		cancel()
+		cn.ws.Close()
+	}()
+
+	for {
+		var m message
+		if err := websocket.JSON.Receive(cn.ws, &m); err != nil {
+			return
+		}
+		switch m.Type {
+		case msgConnectionInit:
+			if cn.init {
+				return
+			}
+			var payload map[string]interface{}
+			json.Unmarshal(m.Payload, &payload)
+			if cn.config.OnConnect != nil {
+				if err := cn.config.OnConnect(cn.c, payload); err != nil {
+					if cn.legacy {
+						cn.send(&message{Type: msgConnectionError, Payload: errorPayload(err, false)})
+					}
+					return
+				}
+			}
+			cn.init = true
+			cn.send(&message{Type: msgConnectionAck})
+			if cn.legacy {
+				cn.send(&message{Type: msgKeepAlive})
+				go cn.keepAlive(ctx)
+			}
+		case msgPing:
+			cn.send(&message{Type: msgPong, Payload: m.Payload})
+		case msgPong:
+		case msgSubscribe, msgStart:
+			if !cn.init {
+				return
+			}
+			cn.subscribe(ctx, m)
+		case msgComplete, msgStop:
+			if cn.done(m.ID) && m.Type == msgStop {
+				cn.send(&message{ID: m.ID, Type: msgComplete})
+			}
+		case msgConnectionTerminate:
+			return
+		default:
+			return
+		}
+	}
+}
+
+func (cn *conn) subscribe(ctx stdContext.Context, m message) {
+	r := new(Request)
+	if err := json.Unmarshal(m.Payload, r); err != nil {
+		cn.send(&message{ID: m.ID, Type: msgError, Payload: errorPayload(err, !cn.legacy)})
+		return
+	}
+	cn.mu.Lock()
+	if _, ok := cn.subs[m.ID]; ok {
+		cn.mu.Unlock()
+		cn.send(&message{ID: m.ID, Type: msgError, Payload: errorPayload(errors.New("subscription "+m.ID+" already exists"), !cn.legacy)})
+		return
+	}
+	ctx, stop := stdContext.WithCancel(ctx)
+	cn.subs[m.ID] = stop
+	cn.mu.Unlock()
+
+	results, err := cn.config.Subscriber.Subscribe(ctx, r)
+	if err != nil {
+		cn.done(m.ID)
+		cn.send(&message{ID: m.ID, Type: msgError, Payload: errorPayload(err, !cn.legacy)})
+		return
+	}
+	next := msgNext
+	if cn.legacy {
+		next = msgData
+	}
+	go func() {
+		for {
+			select {
+			case res, ok := <-results:
+				if !ok {
+					if cn.done(m.ID) {
+						cn.send(&message{ID: m.ID, Type: msgComplete})
+					}
+					return
+				}
+				b, err := json.Marshal(res)
+				if err != nil {
+					b = errorPayload(err, false)
+				}
+				cn.send(&message{ID: m.ID, Type: next, Payload: b})
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// done removes a subscription, reporting whether it was still active.
+func (cn *conn) done(id string) bool {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	stop, ok := cn.subs[id]
+	if ok {
+		stop()
+		delete(cn.subs, id)
+	}
+	return ok
+}
+
+func (cn *conn) keepAlive(ctx stdContext.Context) {
+	t := time.NewTicker(keepAlive)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			cn.send(&message{Type: msgKeepAlive})
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (cn *conn) send(m *message) {
+	websocket.JSON.Send(cn.ws, m)
+}
+
+// errorPayload returns the payload of error messages, a list of GraphQL errors
+// or, for the legacy protocol and connection errors, a single error.
+func errorPayload(err error, list bool) json.RawMessage {
+	e := map[string]string{"message": err.Error()}
+	if list {
+		b, _ := json.Marshal([]interface{}{e})
+		return b
+	}
+	b, _ := json.Marshal(e)
+	return b
+}
@@ -13,7 +13,15 @@ type (
 		host       string
 		prefix     string
 		middleware []MiddlewareFunc
+		parent     *Group
+		services   *container
 		echo       *Echo
+
+		// HTTPErrorHandler handles the errors of the routes of the group and
+		// its sub-groups, e.g. rendering HTML error pages for a web group while
+		// an API group responds with JSON. Falls back to the handler of the
+		// parent group, then to `Echo#HTTPErrorHandler`, when nil.
+		HTTPErrorHandler HTTPErrorHandler
 	}
 )
 
@@ -97,8 +105,8 @@ func (g *Group) Group(prefix string, middleware ...MiddlewareFunc) (sg *Group) {
 	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	sg = g.echo.Group(g.prefix+prefix, m...)
-	sg.host = g.host
+	sg = &Group{host: g.host, prefix: g.prefix + prefix, parent: g, echo: g.echo}
+	sg.Use(m...)
 	return
 }
 
@@ -120,5 +128,16 @@ func (g *Group) Add(method, path string, handler HandlerFunc, middleware ...Midd
 	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	return g.echo.add(g.host, method, g.prefix+path, handler, m...)
+	return g.echo.addRoute(g, g.host, method, g.prefix+path, handler, m)
+}
+
+// errorHandler returns the error handler of the group or of its closest
+// parent having one, nil if none has.
+func (g *Group) errorHandler() HTTPErrorHandler {
+	for ; g != nil; g = g.parent {
+		if g.HTTPErrorHandler != nil {
+			return g.HTTPErrorHandler
+		}
+	}
+	return nil
 }
@@ -1,6 +1,7 @@
 package echo
 
 import (
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -11,7 +12,9 @@ import (
 
 // TODO: Fix me
 func TestGroup(t *testing.T) {
-	g := New().Group("/group")
+	e := New()
+	e.OverrideRoutes = true
+	g := e.Group("/group")
 	h := func(Context) error { return nil }
 	g.CONNECT("/", h)
 	g.DELETE("/", h)
@@ -119,3 +122,38 @@ func TestGroupRouteMiddlewareWithMatchAny(t *testing.T) {
 	assert.Equal(t, "/*", m)
 
 }
+
+func TestGroupHTTPErrorHandler(t *testing.T) {
+	e := New()
+	api := e.Group("/api")
+	api.HTTPErrorHandler = func(err error, c Context) {
+		c.String(http.StatusTeapot, "api: "+err.Error())
+	}
+	api.GET("/fail", func(Context) error {
+		return errors.New("handler")
+	})
+	v1 := api.Group("/v1", func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.Error(errors.New("middleware"))
+			return nil
+		}
+	})
+	v1.GET("/users", func(Context) error { return nil })
+	web := e.Group("/web")
+	web.GET("/fail", func(Context) error {
+		return ErrForbidden
+	})
+
+	code, body := request(http.MethodGet, "/api/fail", e)
+	assert.Equal(t, http.StatusTeapot, code)
+	assert.Equal(t, "api: handler", body)
+
+	// Sub-groups inherit the handler
+	code, body = request(http.MethodGet, "/api/v1/users", e)
+	assert.Equal(t, http.StatusTeapot, code)
+	assert.Equal(t, "api: middleware", body)
+
+	// Falls back to the global handler
+	code, _ = request(http.MethodGet, "/web/fail", e)
+	assert.Equal(t, http.StatusForbidden, code)
+}
@@ -0,0 +1,79 @@
+//go:build go1.18
+// +build go1.18
+
+package echo
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Handle adapts a typed function into a `HandlerFunc`. The request is bound into
+// a new `Req` value (see `Context#Bind()`), validated if `Echo#Validator` is
+// registered and passed to `fn`. The returned value is sent as JSON with status
+// code 200, unless `fn` already committed the response.
+//
+// Example:
+//
+//	e.POST("/users", echo.Handle(func(c echo.Context, req CreateUser) (*User, error) {
+//		return store.Create(req.Name)
+//	}))
+func Handle[Req, Res any](fn func(c Context, req Req) (Res, error)) HandlerFunc {
+	return HandleWithStatus(http.StatusOK, fn)
+}
+
+// HandleWithStatus is like `Handle()` but sends the result with status `code`.
+func HandleWithStatus[Req, Res any](code int, fn func(c Context, req Req) (Res, error)) HandlerFunc {
+	return func(c Context) error {
+		req := new(Req)
+		if err := c.Bind(req); err != nil {
+			return err
+		}
+		if c.Echo().Validator != nil {
+			if err := c.Validate(req); err != nil {
+				if _, ok := err.(*HTTPError); ok {
+					return err
+				}
+				return NewHTTPError(http.StatusBadRequest, err.Error()).SetInternal(err)
+			}
+		}
+		res, err := fn(c, *req)
+		if err != nil {
+			return err
+		}
+		if c.Response().Committed {
+			return nil
+		}
+		return c.JSON(code, res)
+	}
+}
+
+// ContextHandler adapts a handler taking the application context type `C`,
+// created by `Echo#ContextFactory`, into a `HandlerFunc`. It panics if the
+// context isn't of type `C`.
+//
+// Example:
+//
+//	type AppContext struct {
+//		echo.Context
+//	}
+//
+//	func (c *AppContext) CurrentUser() *User {
+//		return c.Get("user").(*User)
+//	}
+//
+//	e.ContextFactory = func(c echo.Context) echo.Context {
+//		return &AppContext{Context: c}
+//	}
+//	e.GET("/me", echo.ContextHandler(func(c *AppContext) error {
+//		return c.JSON(http.StatusOK, c.CurrentUser())
+//	}))
+func ContextHandler[C Context](h func(c C) error) HandlerFunc {
+	return func(c Context) error {
+		cc, ok := c.(C)
+		if !ok {
+			panic(fmt.Sprintf("echo: context is %T, not %T, see Echo#ContextFactory", c, cc))
+		}
+		return h(cc)
+	}
+}
@@ -0,0 +1,141 @@
+//go:build go1.18
+// +build go1.18
+
+package echo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type userValidator struct{}
+
+func (userValidator) Validate(i interface{}) error {
+	if u, ok := i.(*user); ok && u.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+func TestHandle(t *testing.T) {
+	e := New()
+	e.POST("/users/:id", Handle(func(c Context, u user) (user, error) {
+		u.Name = strings.ToUpper(u.Name)
+		return u, nil
+	}))
+	e.PUT("/users/:id", HandleWithStatus(http.StatusAccepted, func(c Context, u user) (*user, error) {
+		return &u, nil
+	}))
+	e.DELETE("/users/:id", Handle(func(c Context, u user) (interface{}, error) {
+		if u.ID == 2 {
+			return nil, ErrForbidden
+		}
+		return nil, c.NoContent(http.StatusNoContent)
+	}))
+
+	// OK
+	req := httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(`{"name":"Jon Snow"}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"id":1,"name":"JON SNOW"}`+"\n", rec.Body.String())
+
+	// Status
+	req = httptest.NewRequest(http.MethodPut, "/users/1", strings.NewReader(userJSON))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, userJSON+"\n", rec.Body.String())
+
+	// Bind error
+	req = httptest.NewRequest(http.MethodPost, "/users/1", strings.NewReader(invalidContent))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Committed by the function
+	req = httptest.NewRequest(http.MethodDelete, "/users/1", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// Function error
+	req = httptest.NewRequest(http.MethodDelete, "/users/2", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestHandleValidate(t *testing.T) {
+	e := New()
+	e.Validator = userValidator{}
+	called := false
+	e.POST("/users", Handle(func(c Context, u user) (user, error) {
+		called = true
+		return u, nil
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"id":1}`))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "name is required")
+	assert.False(t, called)
+}
+
+type appContext struct {
+	Context
+}
+
+func (c *appContext) Tenant() string {
+	return c.Request().Header.Get("X-Tenant")
+}
+
+func TestContextHandlerFactory(t *testing.T) {
+	e := New()
+	e.ContextFactory = func(c Context) Context {
+		return &appContext{Context: c}
+	}
+	e.Pre(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			assert.IsType(t, new(appContext), c)
+			return next(c)
+		}
+	})
+	e.GET("/users/:id", ContextHandler(func(c *appContext) error {
+		return c.String(http.StatusOK, c.Tenant()+"/"+c.Param("id"))
+	}))
+	e.GET("/fail", ContextHandler(func(c *appContext) error {
+		return ErrForbidden
+	}))
+	e.HTTPErrorHandler = func(err error, c Context) {
+		assert.IsType(t, new(appContext), c)
+		e.DefaultHTTPErrorHandler(err, c)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("X-Tenant", "acme")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "acme/1", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Missing factory
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.Panics(t, func() {
+		ContextHandler(func(c *appContext) error { return nil })(c)
+	})
+}
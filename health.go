@@ -0,0 +1,108 @@
+package echo
+
+import (
+	stdContext "context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// health holds the readiness of an Echo instance.
+	health struct {
+		mu       sync.RWMutex
+		notReady bool
+		draining bool
+		checks   []readinessCheck
+	}
+
+	readinessCheck struct {
+		name string
+		fn   func(ctx stdContext.Context) error
+	}
+)
+
+// Health statuses of probe responses, named after the serving statuses of the
+// gRPC health checking protocol.
+const (
+	HealthServing    = "SERVING"
+	HealthNotServing = "NOT_SERVING"
+)
+
+// SetReady sets whether the instance is ready to serve traffic, e.g. false
+// until caches are warmed up. Instances are ready by default.
+func (e *Echo) SetReady(ready bool) {
+	e.health.mu.Lock()
+	defer e.health.mu.Unlock()
+	e.health.notReady = !ready
+}
+
+// Ready reports whether the instance is ready and not shutting down. The
+// readiness checks aren't run.
+func (e *Echo) Ready() bool {
+	e.health.mu.RLock()
+	defer e.health.mu.RUnlock()
+	return !e.health.notReady && !e.health.draining
+}
+
+// AddReadinessCheck adds a check run by `ReadinessHandler()`, e.g. pinging the
+// database. The instance isn't ready while a check returns an error.
+func (e *Echo) AddReadinessCheck(name string, check func(ctx stdContext.Context) error) {
+	e.health.mu.Lock()
+	defer e.health.mu.Unlock()
+	e.health.checks = append(e.health.checks, readinessCheck{name: name, fn: check})
+}
+
+// ReadinessHandler returns a handler for readiness probes, e.g. of Kubernetes.
+// It responds with status 200 and `{"status":"SERVING"}` when the instance is
+// ready and its readiness checks pass, and with status 503 and
+// `{"status":"NOT_SERVING"}` otherwise, listing the errors of the failed
+// checks. It fails as soon as `Shutdown()` is called, see
+// `Echo#ShutdownDelay`.
+func (e *Echo) ReadinessHandler() HandlerFunc {
+	return func(c Context) error {
+		if !e.Ready() {
+			return c.JSON(http.StatusServiceUnavailable, Map{"status": HealthNotServing})
+		}
+		e.health.mu.RLock()
+		checks := e.health.checks
+		e.health.mu.RUnlock()
+		failed := Map{}
+		for _, check := range checks {
+			if err := check.fn(c.Request().Context()); err != nil {
+				failed[check.name] = err.Error()
+			}
+		}
+		if len(failed) > 0 {
+			return c.JSON(http.StatusServiceUnavailable, Map{"status": HealthNotServing, "checks": failed})
+		}
+		return c.JSON(http.StatusOK, Map{"status": HealthServing})
+	}
+}
+
+// LivenessHandler returns a handler for liveness probes, responding with
+// status 200 and `{"status":"SERVING"}` as long as the server handles requests.
+func (e *Echo) LivenessHandler() HandlerFunc {
+	return func(c Context) error {
+		return c.JSON(http.StatusOK, Map{"status": HealthServing})
+	}
+}
+
+// drain makes the instance not ready and waits for `Echo#ShutdownDelay`, so
+// that load balancers stop routing requests before the servers shut down.
+func (e *Echo) drain(ctx stdContext.Context) error {
+	e.health.mu.Lock()
+	e.health.draining = true
+	e.health.mu.Unlock()
+	if e.ShutdownDelay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(e.ShutdownDelay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,64 @@
+package echo
+
+import (
+	stdContext "context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEchoReadiness(t *testing.T) {
+	e := New()
+	e.GET("/readyz", e.ReadinessHandler())
+	e.GET("/livez", e.LivenessHandler())
+	probe := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	rec := probe("/readyz")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"SERVING"}`, rec.Body.String())
+
+	e.SetReady(false)
+	assert.Equal(t, http.StatusServiceUnavailable, probe("/readyz").Code)
+	e.SetReady(true)
+
+	var dbErr error
+	e.AddReadinessCheck("db", func(ctx stdContext.Context) error {
+		return dbErr
+	})
+	assert.Equal(t, http.StatusOK, probe("/readyz").Code)
+	dbErr = errors.New("connection refused")
+	rec = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status":"NOT_SERVING","checks":{"db":"connection refused"}}`, rec.Body.String())
+	dbErr = nil
+
+	// Shutdown
+	e.ShutdownDelay = 50 * time.Millisecond
+	done := make(chan error)
+	start := time.Now()
+	go func() {
+		done <- e.Shutdown(stdContext.Background())
+	}()
+	assert.Eventually(t, func() bool { return !e.Ready() }, time.Second, time.Millisecond)
+	rec = probe("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status":"NOT_SERVING"}`, rec.Body.String())
+	assert.Equal(t, http.StatusOK, probe("/livez").Code)
+	assert.NoError(t, <-done)
+	assert.True(t, time.Since(start) >= e.ShutdownDelay)
+
+	// Shutdown deadline during the delay
+	e = New()
+	e.ShutdownDelay = time.Minute
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, stdContext.DeadlineExceeded, e.Shutdown(ctx))
+}
@@ -0,0 +1,419 @@
+/*
+Package hub manages WebSocket connections: it groups them in rooms, broadcasts
+messages, queues outgoing messages per connection and closes connections
+gracefully when the server shuts down.
+
+Example:
+
+	h := hub.New(e, hub.Config{
+	  OnConnect: func(conn *hub.Conn) error {
+	    conn.Join("room:" + conn.Context().Param("room"))
+	    return nil
+	  },
+	  OnMessage: func(conn *hub.Conn, msg []byte) {
+	    conn.Hub().Broadcast("room:"+conn.Context().Param("room"), msg)
+	  },
+	})
+	e.GET("/rooms/:room", h.Handler())
+*/
+package hub
+
+import (
+	stdContext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/net/websocket"
+)
+
+type (
+	// Config defines the config for a hub.
+	Config struct {
+		// OnConnect is called when a connection is established, before messages
+		// are read. An error closes the connection.
+		// Optional.
+		OnConnect func(conn *Conn) error
+
+		// OnMessage is called for each message received on a connection.
+		// Optional.
+		OnMessage func(conn *Conn, msg []byte)
+
+		// OnDisconnect is called when a connection is closed.
+		// Optional.
+		OnDisconnect func(conn *Conn)
+
+		// CheckOrigin decides whether to accept a connection from a browser
+		// with the "Origin" header.
+		// Optional. By default only same host origins are accepted.
+		CheckOrigin func(r *http.Request) bool
+
+		// SendQueue is the number of outgoing messages queued per connection.
+		// Optional. Default value 64.
+		SendQueue int
+
+		// DropWhenFull drops messages sent to connections with a full queue.
+		// By default such slow connections are closed.
+		// Optional. Default value false.
+		DropWhenFull bool
+
+		// WriteTimeout is the maximum duration of a write to a connection.
+		// Optional. Default value 10s.
+		WriteTimeout time.Duration
+
+		// Binary sends messages as binary frames instead of text frames.
+		// Optional. Default value false.
+		Binary bool
+	}
+
+	// Hub manages WebSocket connections.
+	Hub struct {
+		config Config
+
+		mu     sync.RWMutex
+		conns  map[*Conn]struct{}
+		rooms  map[string]map[*Conn]struct{}
+		closed bool
+		wg     sync.WaitGroup
+	}
+
+	// Conn is a WebSocket connection of a hub.
+	Conn struct {
+		// ID identifies the connection.
+		ID string
+
+		hub     *Hub
+		ws      *websocket.Conn
+		c       echo.Context
+		send    chan []byte
+		closing chan struct{}
+		once    sync.Once
+		rooms   map[string]struct{} // Guarded by hub.mu
+	}
+)
+
+var (
+	// DefaultConfig is the default hub config.
+	DefaultConfig = Config{
+		SendQueue:    64,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	// ErrClosed is returned when sending to a closed connection.
+	ErrClosed = errors.New("hub: connection closed")
+
+	// ErrQueueFull is returned when sending to a connection with a full queue.
+	ErrQueueFull = errors.New("hub: send queue full")
+)
+
+// New returns a hub with config. Its connections are closed when the servers of
+// `e` shut down, see `Hub#Close()`.
+func New(e *echo.Echo, config Config) *Hub {
+	// Defaults
+	if config.SendQueue == 0 {
+		config.SendQueue = DefaultConfig.SendQueue
+	}
+	if config.WriteTimeout == 0 {
+		config.WriteTimeout = DefaultConfig.WriteTimeout
+	}
+	if config.CheckOrigin == nil {
+		config.CheckOrigin = sameOrigin
+	}
+
+	h := &Hub{
+		config: config,
+		conns:  map[*Conn]struct{}{},
+		rooms:  map[string]map[*Conn]struct{}{},
+	}
+	if e != nil {
+		e.Server.RegisterOnShutdown(h.Close)
+		e.TLSServer.RegisterOnShutdown(h.Close)
+	}
+	return h
+}
+
+// Handler returns a handler upgrading requests to WebSocket connections of the
+// hub. It returns when the connection is closed.
+func (h *Hub) Handler() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if !c.IsWebSocket() {
+			return echo.NewHTTPError(http.StatusBadRequest, "websocket upgrade required")
+		}
+		if !h.config.CheckOrigin(c.Request()) {
+			return echo.ErrForbidden
+		}
+		conn := &Conn{
+			ID:      randomID(),
+			hub:     h,
+			c:       c,
+			send:    make(chan []byte, h.config.SendQueue),
+			closing: make(chan struct{}),
+			rooms:   map[string]struct{}{},
+		}
+		h.mu.Lock()
+		if h.closed {
+			h.mu.Unlock()
+			return echo.ErrServiceUnavailable
+		}
+		h.conns[conn] = struct{}{}
+		h.wg.Add(1)
+		h.mu.Unlock()
+		defer h.wg.Done()
+
+		websocket.Server{Handler: func(ws *websocket.Conn) {
+			conn.ws = ws
+			conn.serve()
+		}}.ServeHTTP(c.Response(), c.Request())
+		h.remove(conn)
+		return nil
+	}
+}
+
+// Broadcast sends the message to the connections in the room.
+func (h *Hub) Broadcast(room string, msg []byte) {
+	h.mu.RLock()
+	conns := make([]*Conn, 0, len(h.rooms[room]))
+	for conn := range h.rooms[room] {
+		conns = append(conns, conn)
+	}
+	h.mu.RUnlock()
+	for _, conn := range conns {
+		conn.Send(msg)
+	}
+}
+
+// BroadcastAll sends the message to all connections.
+func (h *Hub) BroadcastAll(msg []byte) {
+	for _, conn := range h.Conns() {
+		conn.Send(msg)
+	}
+}
+
+// Conns returns the connections of the hub.
+func (h *Hub) Conns() []*Conn {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	conns := make([]*Conn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// Count returns the number of connections in the room.
+func (h *Hub) Count(room string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.rooms[room])
+}
+
+// Close stops accepting connections and closes the connections of the hub,
+// after sending the messages already queued.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	conns := make([]*Conn, 0, len(h.conns))
+	for conn := range h.conns {
+		conns = append(conns, conn)
+	}
+	h.mu.Unlock()
+	for _, conn := range conns {
+		conn.Close()
+	}
+}
+
+// Shutdown closes the hub and waits for its connections to be closed.
+func (h *Hub) Shutdown(ctx stdContext.Context) error {
+	h.Close()
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *Hub) remove(conn *Conn) {
+	h.mu.Lock()
+	delete(h.conns, conn)
+	for room := range conn.rooms {
+		h.leave(conn, room)
+	}
+	h.mu.Unlock()
+}
+
+// leave removes the connection from the room. The caller must hold the lock.
+func (h *Hub) leave(conn *Conn, room string) {
+	delete(conn.rooms, room)
+	if conns, ok := h.rooms[room]; ok {
+		delete(conns, conn)
+		if len(conns) == 0 {
+			delete(h.rooms, room)
+		}
+	}
+}
+
+// Hub returns the hub of the connection.
+func (conn *Conn) Hub() *Hub {
+	return conn.hub
+}
+
+// Context returns the context of the request the connection was upgraded from.
+// It is valid until the connection is closed.
+func (conn *Conn) Context() echo.Context {
+	return conn.c
+}
+
+// Join adds the connection to the room.
+func (conn *Conn) Join(room string) {
+	h := conn.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.conns[conn]; !ok {
+		return
+	}
+	conns, ok := h.rooms[room]
+	if !ok {
+		conns = map[*Conn]struct{}{}
+		h.rooms[room] = conns
+	}
+	conns[conn] = struct{}{}
+	conn.rooms[room] = struct{}{}
+}
+
+// Leave removes the connection from the room.
+func (conn *Conn) Leave(room string) {
+	conn.hub.mu.Lock()
+	defer conn.hub.mu.Unlock()
+	conn.hub.leave(conn, room)
+}
+
+// Rooms returns the rooms the connection is in.
+func (conn *Conn) Rooms() []string {
+	conn.hub.mu.RLock()
+	defer conn.hub.mu.RUnlock()
+	rooms := make([]string, 0, len(conn.rooms))
+	for room := range conn.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// Send queues the message to be sent on the connection. If the queue is full,
+// the message is dropped and the connection closed, unless
+// `Config.DropWhenFull` is set.
+func (conn *Conn) Send(msg []byte) error {
+	select {
+	case <-conn.closing:
+		return ErrClosed
+	default:
+	}
+	select {
+	case conn.send <- msg:
+		return nil
+	default:
+		if !conn.hub.config.DropWhenFull {
+			conn.Close()
+		}
+		return ErrQueueFull
+	}
+}
+
+// Close closes the connection after sending the messages already queued.
+func (conn *Conn) Close() {
+	conn.once.Do(func() {
+		close(conn.closing)
+	})
+}
+
+func (conn *Conn) serve() {
+	config := conn.hub.config
+	defer conn.Close()
+	if config.OnConnect != nil {
+		if err := config.OnConnect(conn); err != nil {
+			conn.ws.Close()
+			return
+		}
+	}
+	if config.OnDisconnect != nil {
+		defer config.OnDisconnect(conn)
+	}
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+		conn.write()
+	}()
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(conn.ws, &msg); err != nil {
+			break
+		}
+		if config.OnMessage != nil {
+			config.OnMessage(conn, msg)
+		}
+	}
+	conn.Close()
+	<-written
+}
+
+// write sends the queued messages until the connection is closed.
+func (conn *Conn) write() {
+	defer conn.ws.Close()
+	for {
+		select {
+		case msg := <-conn.send:
+			if conn.writeMessage(msg) != nil {
+				return
+			}
+		case <-conn.closing:
+			for {
+				select {
+				case msg := <-conn.send:
+					if conn.writeMessage(msg) != nil {
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (conn *Conn) writeMessage(msg []byte) error {
+	conn.ws.SetWriteDeadline(time.Now().Add(conn.hub.config.WriteTimeout))
+	if conn.hub.config.Binary {
+		return websocket.Message.Send(conn.ws, msg)
+	}
+	return websocket.Message.Send(conn.ws, string(msg))
+}
+
+// sameOrigin accepts requests without the "Origin" header or with an origin of
+// the same host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get(echo.HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,138 @@
+package hub
+
+import (
+	stdContext "context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/websocket"
+)
+
+func dial(t *testing.T, s *httptest.Server, path, origin string) *websocket.Conn {
+	ws, err := websocket.Dial("ws"+strings.TrimPrefix(s.URL, "http")+path, "", origin)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return ws
+}
+
+func receive(t *testing.T, ws *websocket.Conn) string {
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	var msg string
+	assert.NoError(t, websocket.Message.Receive(ws, &msg))
+	return msg
+}
+
+func TestHub(t *testing.T) {
+	e := echo.New()
+	connected := make(chan *Conn, 3)
+	h := New(e, Config{
+		OnConnect: func(conn *Conn) error {
+			if conn.Context().Param("room") == "forbidden" {
+				return echo.ErrForbidden
+			}
+			conn.Join(conn.Context().Param("room"))
+			connected <- conn
+			return nil
+		},
+		OnMessage: func(conn *Conn, msg []byte) {
+			conn.Hub().Broadcast(conn.Context().Param("room"), msg)
+		},
+	})
+	e.GET("/rooms/:room", h.Handler())
+	s := httptest.NewServer(e)
+	defer s.Close()
+
+	origin := s.URL
+	a := dial(t, s, "/rooms/go", origin)
+	b := dial(t, s, "/rooms/go", origin)
+	c := dial(t, s, "/rooms/rust", origin)
+	var conns []*Conn
+	for i := 0; i < 3; i++ {
+		conns = append(conns, <-connected)
+	}
+	assert.Equal(t, 2, h.Count("go"))
+	assert.Equal(t, 1, h.Count("rust"))
+	assert.Len(t, h.Conns(), 3)
+
+	// Broadcast to room
+	websocket.Message.Send(a, "hello gophers")
+	assert.Equal(t, "hello gophers", receive(t, a))
+	assert.Equal(t, "hello gophers", receive(t, b))
+
+	// Broadcast to all
+	h.BroadcastAll([]byte("hello all"))
+	for _, ws := range []*websocket.Conn{a, b, c} {
+		assert.Equal(t, "hello all", receive(t, ws))
+	}
+
+	// Rooms
+	for _, conn := range conns {
+		if conn.Context().Param("room") == "rust" {
+			conn.Join("lobby")
+			rooms := conn.Rooms()
+			sort.Strings(rooms)
+			assert.Equal(t, []string{"lobby", "rust"}, rooms)
+			conn.Leave("rust")
+			assert.Equal(t, []string{"lobby"}, conn.Rooms())
+		}
+	}
+	assert.Equal(t, 0, h.Count("rust"))
+
+	// Disconnect
+	c.Close()
+	assert.Eventually(t, func() bool { return h.Count("lobby") == 0 && len(h.Conns()) == 2 }, time.Second, 5*time.Millisecond)
+
+	// Rejected connection
+	rejected := dial(t, s, "/rooms/forbidden", origin)
+	var msg string
+	assert.Error(t, websocket.Message.Receive(rejected, &msg))
+
+	// Shutdown sends queued messages before closing
+	h.BroadcastAll([]byte("bye"))
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Second)
+	defer cancel()
+	assert.NoError(t, h.Shutdown(ctx))
+	for _, ws := range []*websocket.Conn{a, b} {
+		assert.Equal(t, "bye", receive(t, ws))
+		assert.Error(t, websocket.Message.Receive(ws, &msg))
+	}
+	_, err := websocket.Dial("ws"+strings.TrimPrefix(s.URL, "http")+"/rooms/go", "", origin)
+	assert.Error(t, err)
+}
+
+func TestHubBackpressure(t *testing.T) {
+	h := New(nil, Config{SendQueue: 1, DropWhenFull: true})
+	conn := &Conn{hub: h, send: make(chan []byte, 1), closing: make(chan struct{})}
+	assert.NoError(t, conn.Send([]byte("1")))
+	assert.Equal(t, ErrQueueFull, conn.Send([]byte("2")))
+	assert.Equal(t, ErrQueueFull, conn.Send([]byte("3")), "connection is kept open")
+
+	h = New(nil, Config{SendQueue: 1})
+	conn = &Conn{hub: h, send: make(chan []byte, 1), closing: make(chan struct{})}
+	assert.NoError(t, conn.Send([]byte("1")))
+	assert.Equal(t, ErrQueueFull, conn.Send([]byte("2")))
+	assert.Equal(t, ErrClosed, conn.Send([]byte("3")))
+}
+
+func TestHubOrigin(t *testing.T) {
+	e := echo.New()
+	e.GET("/ws", New(e, Config{}).Handler())
+
+	req := httptest.NewRequest(http.MethodGet, "/ws", nil)
+	req.Header.Set(echo.HeaderUpgrade, "websocket")
+	req.Header.Set(echo.HeaderOrigin, "https://evil.com")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
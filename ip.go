@@ -44,6 +44,22 @@ func TrustIPRange(ipRange *net.IPNet) TrustOption {
 	}
 }
 
+// TrustCIDR add trustable IP ranges given in CIDR notation, e.g. "203.0.113.0/24".
+// It panics if a range can't be parsed.
+func TrustCIDR(cidrs ...string) TrustOption {
+	ranges := make([]*net.IPNet, len(cidrs))
+	for i, cidr := range cidrs {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic("echo: invalid trusted CIDR: " + err.Error())
+		}
+		ranges[i] = ipRange
+	}
+	return func(c *ipChecker) {
+		c.trustExtraRanges = append(c.trustExtraRanges, ranges...)
+	}
+}
+
 func newIPChecker(configs []TrustOption) *ipChecker {
 	checker := &ipChecker{trustLoopback: true, trustLinkLocal: true, trustPrivateNet: true}
 	for _, configure := range configs {
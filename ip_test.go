@@ -207,6 +207,19 @@ func TestExtractIP(t *testing.T) {
 				ipTestReqKeyBrokenXFF:            ipForRemoteAddrLoopback,
 			},
 		},
+		"ExtractIPFromRealIPHeader(trust only direct-facing proxy by CIDR)": {
+			ExtractIPFromRealIPHeader(TrustLoopback(false), TrustLinkLocal(false), TrustPrivateNet(false), TrustCIDR(ipForRemoteAddrExternal+"/24")),
+			map[string]string{
+				ipTestReqKeyNoHeader:             ipForRemoteAddrExternal,
+				ipTestReqKeyRealIPExternal:       ipForRealIP,
+				ipTestReqKeyRealIPInternal:       ipForRemoteAddrLoopback,
+				ipTestReqKeyRealIPAndXFFExternal: ipForRealIP,
+				ipTestReqKeyRealIPAndXFFInternal: ipForRemoteAddrLoopback,
+				ipTestReqKeyXFFExternal:          ipForRemoteAddrExternal,
+				ipTestReqKeyXFFInternal:          ipForRemoteAddrLoopback,
+				ipTestReqKeyBrokenXFF:            ipForRemoteAddrLoopback,
+			},
+		},
 		"ExtractIPFromXFFHeader(trust ipForXFF3External)": {
 			// This trusts private network also after "additional" trust ranges unlike `TrustNProxies(1)` doesn't
 			ExtractIPFromXFFHeader(TrustIPRange(ipForXFF3ExternalRange)),
@@ -233,3 +246,12 @@ func TestExtractIP(t *testing.T) {
 		})
 	}
 }
+
+func TestTrustCIDR(t *testing.T) {
+	testify.Panics(t, func() { TrustCIDR("203.0.113.0") })
+
+	checker := newIPChecker([]TrustOption{TrustCIDR("203.0.113.0/24", "2001:db8::/32")})
+	testify.True(t, checker.trust(net.ParseIP("203.0.113.7")))
+	testify.True(t, checker.trust(net.ParseIP("2001:db8::1")))
+	testify.False(t, checker.trust(net.ParseIP("198.51.100.1")))
+}
@@ -1,6 +1,7 @@
 package echo
 
 import (
+	"fmt"
 	"io"
 
 	"github.com/labstack/gommon/log"
@@ -38,4 +39,129 @@ type (
 		Panicj(j log.JSON)
 		Panicf(format string, args ...interface{})
 	}
+
+	// requestLogger is a request-scoped Logger writing every entry as JSON
+	// with the request fields and the fields attached with `Context#LogFields()`.
+	// Other methods are delegated to the wrapped Logger.
+	requestLogger struct {
+		Logger
+		c *context
+	}
 )
+
+func (l requestLogger) fields(j log.JSON) log.JSON {
+	f := log.JSON{}
+	if req := l.c.request; req != nil {
+		id := req.Header.Get(HeaderXRequestID)
+		if id == "" && l.c.response != nil && l.c.response.Writer != nil {
+			id = l.c.response.Header().Get(HeaderXRequestID)
+		}
+		if id != "" {
+			f["id"] = id
+		}
+		f["method"] = req.Method
+		f["remote_ip"] = l.c.RealIP()
+	}
+	if l.c.path != "" {
+		f["route"] = l.c.path
+	}
+	for k, v := range l.c.LogFields() {
+		f[k] = v
+	}
+	for k, v := range j {
+		f[k] = v
+	}
+	return f
+}
+
+func (l requestLogger) message(i []interface{}) log.JSON {
+	return l.fields(log.JSON{"message": fmt.Sprint(i...)})
+}
+
+func (l requestLogger) messagef(format string, args []interface{}) log.JSON {
+	return l.fields(log.JSON{"message": fmt.Sprintf(format, args...)})
+}
+
+func (l requestLogger) Print(i ...interface{}) {
+	l.Logger.Printj(l.message(i))
+}
+
+func (l requestLogger) Printf(format string, args ...interface{}) {
+	l.Logger.Printj(l.messagef(format, args))
+}
+
+func (l requestLogger) Printj(j log.JSON) {
+	l.Logger.Printj(l.fields(j))
+}
+
+func (l requestLogger) Debug(i ...interface{}) {
+	l.Logger.Debugj(l.message(i))
+}
+
+func (l requestLogger) Debugf(format string, args ...interface{}) {
+	l.Logger.Debugj(l.messagef(format, args))
+}
+
+func (l requestLogger) Debugj(j log.JSON) {
+	l.Logger.Debugj(l.fields(j))
+}
+
+func (l requestLogger) Info(i ...interface{}) {
+	l.Logger.Infoj(l.message(i))
+}
+
+func (l requestLogger) Infof(format string, args ...interface{}) {
+	l.Logger.Infoj(l.messagef(format, args))
+}
+
+func (l requestLogger) Infoj(j log.JSON) {
+	l.Logger.Infoj(l.fields(j))
+}
+
+func (l requestLogger) Warn(i ...interface{}) {
+	l.Logger.Warnj(l.message(i))
+}
+
+func (l requestLogger) Warnf(format string, args ...interface{}) {
+	l.Logger.Warnj(l.messagef(format, args))
+}
+
+func (l requestLogger) Warnj(j log.JSON) {
+	l.Logger.Warnj(l.fields(j))
+}
+
+func (l requestLogger) Error(i ...interface{}) {
+	l.Logger.Errorj(l.message(i))
+}
+
+func (l requestLogger) Errorf(format string, args ...interface{}) {
+	l.Logger.Errorj(l.messagef(format, args))
+}
+
+func (l requestLogger) Errorj(j log.JSON) {
+	l.Logger.Errorj(l.fields(j))
+}
+
+func (l requestLogger) Fatal(i ...interface{}) {
+	l.Logger.Fatalj(l.message(i))
+}
+
+func (l requestLogger) Fatalf(format string, args ...interface{}) {
+	l.Logger.Fatalj(l.messagef(format, args))
+}
+
+func (l requestLogger) Fatalj(j log.JSON) {
+	l.Logger.Fatalj(l.fields(j))
+}
+
+func (l requestLogger) Panic(i ...interface{}) {
+	l.Logger.Panicj(l.message(i))
+}
+
+func (l requestLogger) Panicf(format string, args ...interface{}) {
+	l.Logger.Panicj(l.messagef(format, args))
+}
+
+func (l requestLogger) Panicj(j log.JSON) {
+	l.Logger.Panicj(l.fields(j))
+}
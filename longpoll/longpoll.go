@@ -0,0 +1,234 @@
+/*
+Package longpoll implements long-poll endpoints. Requests are held until events
+are published on their topic or a timeout elapses, and resume from a cursor so
+that clients don't miss events published between polls.
+
+Example:
+
+	b := longpoll.New(e, longpoll.Config{})
+	e.GET("/rooms/:room/events", func(c echo.Context) error {
+	  return b.Poll(c, c.Param("room"))
+	})
+	e.POST("/rooms/:room/messages", func(c echo.Context) error {
+	  ...
+	  b.Publish(c.Param("room"), msg)
+	  return c.NoContent(http.StatusAccepted)
+	})
+*/
+package longpoll
+
+import (
+	stdContext "context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Config defines the config for a broker.
+	Config struct {
+		// Timeout is how long a poll waits for events.
+		// Optional. Default value 30s.
+		Timeout time.Duration
+
+		// BufferSize is the number of events retained per topic for clients
+		// resuming from a cursor.
+		// Optional. Default value 100.
+		BufferSize int
+
+		// CursorParam is the query param holding the cursor. The
+		// "Last-Event-ID" header is used when it is missing.
+		// Optional. Default value "cursor".
+		CursorParam string
+	}
+
+	// Broker holds poll requests until events are published.
+	Broker struct {
+		config Config
+
+		mu       sync.Mutex
+		seq      uint64
+		topics   map[string]*topic
+		created  chan struct{} // Closed when a topic is created
+		closed   chan struct{}
+		closeOne sync.Once
+	}
+
+	// Event is an event published on a topic.
+	Event struct {
+		ID   string      `json:"id"`
+		Data interface{} `json:"data"`
+
+		seq uint64
+	}
+
+	// Response is the response of a poll.
+	Response struct {
+		// Events published after the cursor of the request, oldest first.
+		Events []*Event `json:"events"`
+
+		// Cursor to send with the next poll.
+		Cursor string `json:"cursor"`
+
+		// Missed reports that events after the cursor of the request were
+		// dropped from the buffer before being polled.
+		Missed bool `json:"missed,omitempty"`
+	}
+
+	topic struct {
+		events  []*Event
+		dropped uint64 // Sequence of the last event dropped from the buffer
+		notify  chan struct{}
+	}
+)
+
+var (
+	// DefaultConfig is the default broker config.
+	DefaultConfig = Config{
+		Timeout:     30 * time.Second,
+		BufferSize:  100,
+		CursorParam: "cursor",
+	}
+
+	// ErrInvalidCursor is returned for a malformed cursor.
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// New returns a broker with config. Waiting polls are answered when the servers
+// of `e` shut down, see `Broker#Close()`.
+func New(e *echo.Echo, config Config) *Broker {
+	// Defaults
+	if config.Timeout == 0 {
+		config.Timeout = DefaultConfig.Timeout
+	}
+	if config.BufferSize == 0 {
+		config.BufferSize = DefaultConfig.BufferSize
+	}
+	if config.CursorParam == "" {
+		config.CursorParam = DefaultConfig.CursorParam
+	}
+
+	b := &Broker{
+		config: config,
+		topics:  map[string]*topic{},
+		created: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+	if e != nil {
+		e.Server.RegisterOnShutdown(b.Close)
+		e.TLSServer.RegisterOnShutdown(b.Close)
+	}
+	return b
+}
+
+// Publish publishes an event on the topic and returns it.
+func (b *Broker) Publish(name string, data interface{}) *Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	ev := &Event{ID: strconv.FormatUint(b.seq, 10), Data: data, seq: b.seq}
+	t, ok := b.topics[name]
+	if !ok {
+		// Topics are created by their first event, polls of unknown topics
+		// wait for any topic to be created
+		t = &topic{notify: make(chan struct{})}
+		b.topics[name] = t
+		close(b.created)
+		b.created = make(chan struct{})
+	}
+	t.events = append(t.events, ev)
+	if n := len(t.events) - b.config.BufferSize; n > 0 {
+		t.dropped = t.events[n-1].seq
+		t.events = append(t.events[:0:0], t.events[n:]...)
+	}
+	close(t.notify)
+	t.notify = make(chan struct{})
+	return ev
+}
+
+// Poll responds with the events of the topic published after the cursor of the
+// request, waiting for events if there are none. It responds with no events
+// after the timeout, when the broker is closed and doesn't respond if the client
+// disconnects. Requests without a cursor wait for new events.
+func (b *Broker) Poll(c echo.Context, topic string) error {
+	cursor := c.QueryParam(b.config.CursorParam)
+	if cursor == "" {
+		cursor = c.Request().Header.Get(echo.HeaderLastEventID)
+	}
+	ctx, cancel := stdContext.WithTimeout(c.Request().Context(), b.config.Timeout)
+	defer cancel()
+	res, err := b.Wait(ctx, topic, cursor)
+	if err != nil {
+		if err == stdContext.DeadlineExceeded {
+			return c.JSON(http.StatusOK, res)
+		}
+		if err == ErrInvalidCursor {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		return nil // Client gone
+	}
+	return c.JSON(http.StatusOK, res)
+}
+
+// Wait returns the events of the topic published after the cursor, waiting
+// until there are some, `ctx` is done or the broker is closed. An empty cursor
+// waits for new events. The response is valid even if an error is returned.
+func (b *Broker) Wait(ctx stdContext.Context, name, cursor string) (*Response, error) {
+	var after uint64
+	b.mu.Lock()
+	if cursor == "" {
+		after = b.seq
+	} else {
+		var err error
+		if after, err = strconv.ParseUint(cursor, 10, 64); err != nil {
+			b.mu.Unlock()
+			return &Response{Events: []*Event{}, Cursor: cursor}, ErrInvalidCursor
+		}
+	}
+	b.mu.Unlock()
+
+	for {
+		b.mu.Lock()
+		res := &Response{Events: []*Event{}, Cursor: strconv.FormatUint(after, 10)}
+		notify := b.created
+		if t, ok := b.topics[name]; ok {
+			res = t.after(after)
+			notify = t.notify
+		}
+		b.mu.Unlock()
+		if len(res.Events) > 0 {
+			return res, nil
+		}
+		select {
+		case <-notify:
+		case <-b.closed:
+			return res, nil
+		case <-ctx.Done():
+			return res, ctx.Err()
+		}
+	}
+}
+
+// Close answers the waiting polls and makes new polls return immediately.
+func (b *Broker) Close() {
+	b.closeOne.Do(func() {
+		close(b.closed)
+	})
+}
+
+// after returns the events published after `seq`.
+func (t *topic) after(seq uint64) *Response {
+	res := &Response{Events: []*Event{}, Cursor: strconv.FormatUint(seq, 10), Missed: seq < t.dropped}
+	for i, ev := range t.events {
+		if ev.seq > seq {
+			res.Events = t.events[i:len(t.events):len(t.events)]
+			res.Cursor = t.events[len(t.events)-1].ID
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,100 @@
+package longpoll
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func poll(e *echo.Echo, target string, header http.Header) (int, *Response) {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	res := new(Response)
+	json.Unmarshal(rec.Body.Bytes(), res)
+	return rec.Code, res
+}
+
+func TestBroker(t *testing.T) {
+	e := echo.New()
+	b := New(e, Config{Timeout: 50 * time.Millisecond, BufferSize: 2})
+	e.GET("/:topic", func(c echo.Context) error {
+		return b.Poll(c, c.Param("topic"))
+	})
+
+	// Timeout
+	code, res := poll(e, "/news", nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, res.Events)
+	assert.Equal(t, "0", res.Cursor)
+	assert.Empty(t, b.topics, "polls don't create topics")
+
+	// Wait for new events
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.Publish("sports", "ignored")
+		b.Publish("news", "hello")
+	}()
+	_, res = poll(e, "/news?cursor=0", nil)
+	if assert.Len(t, res.Events, 1) {
+		assert.Equal(t, "hello", res.Events[0].Data)
+		assert.Equal(t, "2", res.Events[0].ID)
+	}
+	assert.Equal(t, "2", res.Cursor)
+
+	// Resume
+	b.Publish("news", "a")
+	b.Publish("news", "b")
+	_, res = poll(e, "/news?cursor=2", nil)
+	assert.Equal(t, []*Event{{ID: "3", Data: "a"}, {ID: "4", Data: "b"}}, res.Events)
+	assert.False(t, res.Missed)
+	_, res = poll(e, "/news", http.Header{echo.HeaderLastEventID: {"3"}})
+	assert.Equal(t, "4", res.Cursor)
+
+	// Missed events
+	b.Publish("news", "c")
+	_, res = poll(e, "/news?cursor=2", nil)
+	assert.True(t, res.Missed)
+	assert.Len(t, res.Events, 2)
+
+	// Invalid cursor
+	code, _ = poll(e, "/news?cursor=x", nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestBrokerClose(t *testing.T) {
+	b := New(nil, Config{Timeout: time.Minute})
+
+	// Client disconnect
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, err := b.Wait(ctx, "news", "")
+	assert.Equal(t, stdContext.Canceled, err)
+
+	// Shutdown
+	done := make(chan *Response)
+	go func() {
+		res, _ := b.Wait(stdContext.Background(), "news", "")
+		done <- res
+	}()
+	time.Sleep(10 * time.Millisecond)
+	b.Close()
+	select {
+	case res := <-done:
+		assert.Empty(t, res.Events)
+	case <-time.After(time.Second):
+		t.Fatal("poll not answered on close")
+	}
+}
@@ -0,0 +1,92 @@
+package echo
+
+import (
+	"bytes"
+	"html/template"
+	"io"
+	"strings"
+)
+
+type (
+	// MarkdownRenderer converts Markdown to HTML, see `Context#Markdown()` and
+	// package markdown for a goldmark adapter.
+	MarkdownRenderer interface {
+		Convert(source []byte, w io.Writer) error
+	}
+
+	// MarkdownRendererFunc is an adapter to use functions as Markdown
+	// renderers.
+	MarkdownRendererFunc func(source []byte, w io.Writer) error
+
+	// MarkdownConfig defines the config for Markdown responses, see
+	// `Echo#Markdown`.
+	MarkdownConfig struct {
+		// Renderer converts Markdown to HTML.
+		// Required.
+		Renderer MarkdownRenderer
+
+		// Layout is the name of the template the HTML is rendered in with
+		// `Echo#Renderer`, with a `MarkdownPage` as data.
+		// Optional. By default the HTML is sent as is.
+		Layout string
+	}
+
+	// MarkdownPage is the data of the layout of Markdown responses.
+	MarkdownPage struct {
+		// Title is the text of the first level one heading of the source.
+		Title string
+
+		// Content is the sanitized HTML of the source.
+		Content template.HTML
+
+		// Context is the context of the request.
+		Context Context
+	}
+)
+
+// Convert implements `MarkdownRenderer`.
+func (fn MarkdownRendererFunc) Convert(source []byte, w io.Writer) error {
+	return fn(source, w)
+}
+
+func (c *context) Markdown(code int, source []byte) error {
+	c.checkReleased()
+	config := c.echo.Markdown
+	if config.Renderer == nil {
+		return ErrMarkdownNotRegistered
+	}
+	buf := new(bytes.Buffer)
+	if err := config.Renderer.Convert(source, buf); err != nil {
+		return err
+	}
+	var s Sanitizer = DefaultHTMLPolicy
+	if c.echo.Sanitizer != nil {
+		s = c.echo.Sanitizer
+	}
+	content := s.Sanitize(buf.String())
+	if config.Layout == "" {
+		return c.HTML(code, content)
+	}
+	return c.Render(code, config.Layout, &MarkdownPage{
+		Title:   markdownTitle(source),
+		Content: template.HTML(content),
+		Context: c,
+	})
+}
+
+// markdownTitle returns the text of the first ATX level one heading of the
+// source, e.g. "Install" for "# Install".
+func markdownTitle(source []byte) string {
+	fence := false
+	for _, line := range strings.Split(string(source), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+			fence = !fence
+			continue
+		}
+		if !fence && strings.HasPrefix(line, "# ") {
+			return strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[2:]), "#"))
+		}
+	}
+	return ""
+}
@@ -0,0 +1,11 @@
+module github.com/labstack/echo/v4/markdown
+
+go 1.14
+
+require (
+	github.com/labstack/echo/v4 v4.1.16
+	github.com/stretchr/testify v1.4.0
+	github.com/yuin/goldmark v1.4.13
+)
+
+replace github.com/labstack/echo/v4 => ../
@@ -0,0 +1,36 @@
+/*
+Package markdown adapts goldmark as the Markdown renderer of `Context#Markdown()`.
+It is a module of its own so that only applications using it depend on goldmark:
+
+	go get github.com/labstack/echo/v4/markdown
+
+Example:
+
+	e.Markdown = echo.MarkdownConfig{
+	  Renderer: markdown.Goldmark(nil),
+	  Layout:   "docs.html",
+	}
+	e.GET("/readme", func(c echo.Context) error {
+	  return c.Markdown(http.StatusOK, readme)
+	})
+*/
+package markdown
+
+import (
+	"io"
+
+	"github.com/labstack/echo/v4"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Goldmark returns a Markdown renderer converting with `md`, or with GitHub
+// Flavored Markdown if `md` is nil.
+func Goldmark(md goldmark.Markdown) echo.MarkdownRenderer {
+	if md == nil {
+		md = goldmark.New(goldmark.WithExtensions(extension.GFM))
+	}
+	return echo.MarkdownRendererFunc(func(source []byte, w io.Writer) error {
+		return md.Convert(source, w)
+	})
+}
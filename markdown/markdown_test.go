@@ -0,0 +1,14 @@
+package markdown
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoldmark(t *testing.T) {
+	buf := new(bytes.Buffer)
+	assert.NoError(t, Goldmark(nil).Convert([]byte("# Title\n\n~~old~~ *new*"), buf))
+	assert.Equal(t, "<h1>Title</h1>\n<p><del>old</del> <em>new</em></p>\n", buf.String())
+}
@@ -0,0 +1,64 @@
+package echo
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type markdownLayout struct {
+	tpl *template.Template
+}
+
+func (l markdownLayout) Render(w io.Writer, name string, data interface{}, c Context) error {
+	return l.tpl.ExecuteTemplate(w, name, data)
+}
+
+func TestContextMarkdown(t *testing.T) {
+	// Converts "# " lines to headings and other lines to paragraphs
+	renderer := MarkdownRendererFunc(func(source []byte, w io.Writer) error {
+		for _, line := range strings.Split(string(source), "\n") {
+			if strings.HasPrefix(line, "# ") {
+				io.WriteString(w, "<h1>"+line[2:]+"</h1>")
+			} else if line != "" {
+				io.WriteString(w, "<p>"+line+"</p>")
+			}
+		}
+		return nil
+	})
+	e := New()
+	markdown := func(source string) (*httptest.ResponseRecorder, error) {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+		return rec, c.Markdown(http.StatusOK, []byte(source))
+	}
+
+	_, err := markdown("# Title")
+	assert.Equal(t, ErrMarkdownNotRegistered, err)
+
+	e.Markdown = MarkdownConfig{Renderer: renderer}
+	rec, err := markdown("# Title\nHello <script>alert(1)</script>")
+	if assert.NoError(t, err) {
+		assert.Equal(t, MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal(t, "<h1>Title</h1><p>Hello </p>", rec.Body.String())
+	}
+
+	// Layout
+	e.Renderer = markdownLayout{template.Must(template.New("docs").Parse(`<title>{{.Title}}</title><main>{{.Content}}</main>`))}
+	e.Markdown.Layout = "docs"
+	rec, err = markdown("Intro\n# Install\nRun it")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "<title>Install</title><main><p>Intro</p><h1>Install</h1><p>Run it</p></main>", rec.Body.String())
+	}
+}
+
+func TestMarkdownTitle(t *testing.T) {
+	assert.Equal(t, "Install", markdownTitle([]byte("Intro\n# Install #\n# Usage")))
+	assert.Equal(t, "Usage", markdownTitle([]byte("```\n# not a title\n```\n# Usage\r\n")))
+	assert.Equal(t, "", markdownTitle([]byte("## Sub")))
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// ACMEChallengeConfig defines the config for ACMEChallenge middleware.
+	// Exactly one of `Root`, `Handler` and `Target` is required.
+	ACMEChallengeConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Root is the directory the challenge files are written to, e.g. by
+		// certbot with the "webroot" plugin and "-w <root>/..". The files are
+		// served from "<root>/<token>".
+		Root string `yaml:"root"`
+
+		// Handler responds to the challenges, e.g. the `HTTPHandler()` of an
+		// `autocert.Manager` wrapped with `echo.WrapHandler()`.
+		Handler echo.HandlerFunc
+
+		// Target is the URL of the server the challenges are proxied to, e.g.
+		// the solver of cert-manager.
+		Target *url.URL `yaml:"target"`
+	}
+)
+
+// ACMEChallengePrefix is the path prefix of ACME HTTP-01 challenges.
+const ACMEChallengePrefix = "/.well-known/acme-challenge/"
+
+// ACMEChallenge returns a middleware serving ACME HTTP-01 challenges, requests
+// to "/.well-known/acme-challenge/<token>", from the directory `root`. Other
+// requests are passed on.
+//
+// Register it with `Echo#Pre()` before redirects, e.g. `HTTPSRedirect()`,
+// since certificate authorities validate challenges over plain HTTP. Requests
+// with invalid tokens get a "404 - Not Found" response.
+func ACMEChallenge(root string) echo.MiddlewareFunc {
+	return ACMEChallengeWithConfig(ACMEChallengeConfig{Root: root})
+}
+
+// ACMEChallengeWithConfig returns an ACMEChallenge middleware with config.
+// See: `ACMEChallenge()`.
+func ACMEChallengeWithConfig(config ACMEChallengeConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultSkipper
+	}
+	var handler echo.HandlerFunc
+	switch {
+	case config.Handler != nil:
+		handler = config.Handler
+	case config.Target != nil:
+		proxy := httputil.NewSingleHostReverseProxy(config.Target)
+		handler = func(c echo.Context) (err error) {
+			rp := *proxy
+			rp.ErrorHandler = func(_ http.ResponseWriter, _ *http.Request, perr error) {
+				err = echo.NewHTTPError(http.StatusBadGateway, "acme challenge target unreachable").SetInternal(perr)
+			}
+			rp.ServeHTTP(c.Response(), c.Request())
+			return
+		}
+	case config.Root != "":
+		handler = func(c echo.Context) error {
+			token := strings.TrimPrefix(c.Request().URL.Path, ACMEChallengePrefix)
+			return c.File(filepath.Join(config.Root, token))
+		}
+	default:
+		panic("echo: acme challenge middleware requires a root, handler or target")
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+			p := c.Request().URL.Path
+			if !strings.HasPrefix(p, ACMEChallengePrefix) {
+				return next(c)
+			}
+			if !validACMEToken(strings.TrimPrefix(p, ACMEChallengePrefix)) {
+				return echo.ErrNotFound
+			}
+			return handler(c)
+		}
+	}
+}
+
+// IsACMEChallenge reports whether the request is an ACME HTTP-01 challenge. It
+// can be used as the `Skipper` of middleware which must not handle them.
+func IsACMEChallenge(c echo.Context) bool {
+	return strings.HasPrefix(c.Request().URL.Path, ACMEChallengePrefix)
+}
+
+// validACMEToken reports whether token only has base64url characters, which
+// also rules out path traversal.
+func validACMEToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, r := range token {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestACMEChallenge(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acme")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "tok_en-1"), []byte("tok_en-1.key"), 0644))
+
+	e := echo.New()
+	e.Pre(ACMEChallenge(dir))
+	e.Pre(HTTPSRedirectWithConfig(RedirectConfig{Code: http.StatusMovedPermanently}))
+	request := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com"+path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := request("/.well-known/acme-challenge/tok_en-1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "tok_en-1.key", rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, request("/.well-known/acme-challenge/missing").Code)
+	assert.Equal(t, http.StatusNotFound, request("/.well-known/acme-challenge/..%2f..%2fetc%2fpasswd").Code)
+	assert.Equal(t, http.StatusMovedPermanently, request("/login").Code)
+
+	// Proxied challenges
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxied " + r.URL.Path))
+	}))
+	defer upstream.Close()
+	target, _ := url.Parse(upstream.URL)
+	e = echo.New()
+	e.Pre(ACMEChallengeWithConfig(ACMEChallengeConfig{Target: target}))
+	rec = request("/.well-known/acme-challenge/abc")
+	assert.Equal(t, "proxied /.well-known/acme-challenge/abc", rec.Body.String())
+
+	e = echo.New()
+	e.Pre(ACMEChallengeWithConfig(ACMEChallengeConfig{Target: &url.URL{Scheme: "http", Host: "127.0.0.1:1"}}))
+	assert.Equal(t, http.StatusBadGateway, request("/.well-known/acme-challenge/abc").Code)
+
+	assert.Panics(t, func() {
+		ACMEChallengeWithConfig(ACMEChallengeConfig{})
+	})
+}
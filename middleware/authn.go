@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// AuthnConfig defines the config for Authn middleware.
+	AuthnConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Authenticators are tried in order until one finds credentials in the
+		// request.
+		// Required.
+		Authenticators []Authenticator
+
+		// Optional lets requests without credentials through without a principal.
+		// Requests with invalid credentials are still rejected.
+		// Optional. Default value false.
+		Optional bool
+	}
+
+	// Authenticator authenticates requests. It returns `ErrNoCredentials` if
+	// the request carries no credentials it handles, so that the next
+	// authenticator is tried.
+	Authenticator interface {
+		Authenticate(c echo.Context) (*echo.Principal, error)
+	}
+
+	// Challenger is implemented by authenticators sending a challenge in the
+	// "WWW-Authenticate" header of "401 - Unauthorized" responses.
+	Challenger interface {
+		Challenge() string
+	}
+
+	// AuthenticatorFunc is an adapter to use functions as authenticators.
+	AuthenticatorFunc func(c echo.Context) (*echo.Principal, error)
+
+	// JWTAuthenticator authenticates requests with a JWT bearer token. The
+	// principal ID is the "sub" claim and the scopes are the space separated
+	// "scope" or "scp" claims.
+	JWTAuthenticator struct {
+		// SigningKey is the key to validate tokens.
+		// Required.
+		SigningKey interface{}
+
+		// SigningMethod is the algorithm used to sign tokens.
+		// Optional. Default value HS256.
+		SigningMethod string
+
+		// Realm is the realm of the challenge.
+		// Optional.
+		Realm string
+	}
+
+	// APIKeyAuthenticator authenticates requests with an API key.
+	APIKeyAuthenticator struct {
+		// Header the key is read from.
+		// Optional. Default value "X-API-Key".
+		Header string
+
+		// Query is the query param the key is read from when the header is
+		// missing.
+		// Optional. Default value "" (disabled).
+		Query string
+
+		// Validate returns the principal of a key, or nil for an invalid key.
+		// Required.
+		Validate func(c echo.Context, key string) (*echo.Principal, error)
+	}
+
+	// CertAuthenticator authenticates requests with a verified TLS client
+	// certificate. The server must be configured to verify client certificates,
+	// see `tls.Config#ClientAuth`.
+	CertAuthenticator struct {
+		// Validate returns the principal of a certificate, or nil for a rejected
+		// certificate.
+		// Optional. By default the principal ID is the subject common name.
+		Validate func(c echo.Context, cert *x509.Certificate) (*echo.Principal, error)
+	}
+)
+
+const (
+	bearer = "Bearer"
+)
+
+var (
+	// DefaultAuthnConfig is the default Authn middleware config.
+	DefaultAuthnConfig = AuthnConfig{
+		Skipper: DefaultSkipper,
+	}
+
+	// ErrNoCredentials is returned by authenticators if the request carries no
+	// credentials they handle.
+	ErrNoCredentials = errors.New("no credentials")
+
+	// ErrInvalidCredentials is returned by authenticators if the credentials of
+	// the request are rejected.
+	ErrInvalidCredentials = errors.New("invalid credentials")
+)
+
+// Authn returns an authentication middleware trying the authenticators in
+// order. The principal of the first authenticator finding credentials in the
+// request is available with `Context#Principal()`.
+//
+// For missing or invalid credentials, it sends "401 - Unauthorized" response
+// with a "WWW-Authenticate" challenge for each authenticator implementing
+// `Challenger`.
+func Authn(authenticators ...Authenticator) echo.MiddlewareFunc {
+	c := DefaultAuthnConfig
+	c.Authenticators = authenticators
+	return AuthnWithConfig(c)
+}
+
+// AuthnWithConfig returns an Authn middleware with config.
+// See: `Authn()`.
+func AuthnWithConfig(config AuthnConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultAuthnConfig.Skipper
+	}
+	if len(config.Authenticators) == 0 {
+		panic("echo: authn middleware requires an authenticator")
+	}
+
+	// Initialize
+	var challenges []string
+	for _, a := range config.Authenticators {
+		if ch, ok := a.(Challenger); ok {
+			challenges = append(challenges, ch.Challenge())
+		}
+	}
+
+	unauthorized := func(c echo.Context, err error) error {
+		for _, ch := range challenges {
+			c.Response().Header().Add(echo.HeaderWWWAuthenticate, ch)
+		}
+		he := echo.NewHTTPError(http.StatusUnauthorized)
+		if err != ErrNoCredentials {
+			he.Message = "invalid credentials"
+			he.Internal = err
+		}
+		return he
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			for _, a := range config.Authenticators {
+				p, err := a.Authenticate(c)
+				if err == ErrNoCredentials {
+					continue
+				}
+				if err != nil {
+					if _, ok := err.(*echo.HTTPError); ok {
+						return err
+					}
+					return unauthorized(c, err)
+				}
+				if p == nil {
+					return unauthorized(c, ErrInvalidCredentials)
+				}
+				c.SetPrincipal(p)
+				return next(c)
+			}
+			if config.Optional {
+				return next(c)
+			}
+			return unauthorized(c, ErrNoCredentials)
+		}
+	}
+}
+
+// Authenticate calls f(c).
+func (f AuthenticatorFunc) Authenticate(c echo.Context) (*echo.Principal, error) {
+	return f(c)
+}
+
+// Authenticate implements `Authenticator`.
+func (a *JWTAuthenticator) Authenticate(c echo.Context) (*echo.Principal, error) {
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	if len(auth) <= len(bearer) || !strings.EqualFold(auth[:len(bearer)+1], bearer+" ") {
+		return nil, ErrNoCredentials
+	}
+	method := a.SigningMethod
+	if method == "" {
+		method = AlgorithmHS256
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(auth[len(bearer)+1:], claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != method {
+			return nil, fmt.Errorf("unexpected jwt signing method=%v", t.Header["alg"])
+		}
+		return a.SigningKey, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	p := &echo.Principal{Method: "jwt", Claims: echo.Map(claims)}
+	p.ID, _ = claims["sub"].(string)
+	for _, name := range []string{"scope", "scp"} {
+		switch s := claims[name].(type) {
+		case string:
+			p.Scopes = strings.Fields(s)
+		case []interface{}:
+			for _, v := range s {
+				if v, ok := v.(string); ok {
+					p.Scopes = append(p.Scopes, v)
+				}
+			}
+		}
+		if p.Scopes != nil {
+			break
+		}
+	}
+	return p, nil
+}
+
+// Challenge implements `Challenger`.
+func (a *JWTAuthenticator) Challenge() string {
+	if a.Realm == "" {
+		return bearer
+	}
+	return fmt.Sprintf("%s realm=%q", bearer, a.Realm)
+}
+
+// Authenticate implements `Authenticator`.
+func (a *APIKeyAuthenticator) Authenticate(c echo.Context) (*echo.Principal, error) {
+	if a.Validate == nil {
+		panic("echo: api key authenticator requires a validator function")
+	}
+	header := a.Header
+	if header == "" {
+		header = "X-API-Key"
+	}
+	key := c.Request().Header.Get(header)
+	if key == "" && a.Query != "" {
+		key = c.QueryParam(a.Query)
+	}
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+	p, err := a.Validate(c, key)
+	if err != nil || p == nil {
+		return p, err
+	}
+	if p.Method == "" {
+		p.Method = "api_key"
+	}
+	return p, nil
+}
+
+// Authenticate implements `Authenticator`.
+func (a *CertAuthenticator) Authenticate(c echo.Context) (*echo.Principal, error) {
+	tls := c.Request().TLS
+	if tls == nil || len(tls.VerifiedChains) == 0 || len(tls.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+	cert := tls.VerifiedChains[0][0]
+	if a.Validate == nil {
+		return &echo.Principal{ID: cert.Subject.CommonName, Method: "mtls"}, nil
+	}
+	p, err := a.Validate(c, cert)
+	if err != nil || p == nil {
+		return p, err
+	}
+	if p.Method == "" {
+		p.Method = "mtls"
+	}
+	return p, nil
+}
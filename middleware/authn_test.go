@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthn(t *testing.T) {
+	key := []byte("secret")
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":   "jon",
+		"scope": "users:read users:write",
+	}).SignedString(key)
+	assert.NoError(t, err)
+
+	mw := Authn(
+		&JWTAuthenticator{SigningKey: key, Realm: "api"},
+		&APIKeyAuthenticator{Query: "api_key", Validate: func(c echo.Context, key string) (*echo.Principal, error) {
+			if key != "valid-key" {
+				return nil, nil
+			}
+			return &echo.Principal{ID: "service"}, nil
+		}},
+		&CertAuthenticator{},
+	)
+	handler := mw(func(c echo.Context) error {
+		p := c.Principal()
+		return c.String(http.StatusOK, p.Method+":"+p.ID)
+	})
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		target string
+		tls    *tls.ConnectionState
+		code   int
+		body   string
+	}{
+		{name: "jwt", header: echo.HeaderAuthorization, value: "Bearer " + token, code: http.StatusOK, body: "jwt:jon"},
+		{name: "invalid jwt", header: echo.HeaderAuthorization, value: "Bearer invalid", code: http.StatusUnauthorized},
+		{name: "api key", header: "X-API-Key", value: "valid-key", code: http.StatusOK, body: "api_key:service"},
+		{name: "api key query", target: "/?api_key=valid-key", code: http.StatusOK, body: "api_key:service"},
+		{name: "invalid api key", header: "X-API-Key", value: "invalid", code: http.StatusUnauthorized},
+		{name: "cert", tls: &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "client"}}}}}, code: http.StatusOK, body: "mtls:client"},
+		{name: "unverified cert", tls: &tls.ConnectionState{}, code: http.StatusUnauthorized},
+		{name: "no credentials", code: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			target := tt.target
+			if target == "" {
+				target = "/"
+			}
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			req.TLS = tt.tls
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			err := handler(c)
+			if tt.code != http.StatusOK {
+				he, ok := err.(*echo.HTTPError)
+				if assert.True(t, ok) {
+					assert.Equal(t, tt.code, he.Code)
+				}
+				assert.Equal(t, []string{`Bearer realm="api"`}, rec.Header().Values(echo.HeaderWWWAuthenticate))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, rec.Body.String())
+		})
+	}
+}
+
+func TestAuthnScopes(t *testing.T) {
+	key := []byte("secret")
+	token, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "jon",
+		"scp": []string{"users:read"},
+	}).SignedString(key)
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(echo.HeaderAuthorization, "bearer "+token)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	var p *echo.Principal
+	h := Authn(&JWTAuthenticator{SigningKey: key})(func(c echo.Context) error {
+		p = c.Principal()
+		return nil
+	})
+	assert.NoError(t, h(c))
+	if assert.NotNil(t, p) {
+		assert.True(t, p.HasScope("users:read"))
+		assert.False(t, p.HasScope("users:write"))
+		assert.Equal(t, "jon", p.Claims["sub"])
+	}
+}
+
+func TestAuthnOptional(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	c := e.NewContext(req, httptest.NewRecorder())
+
+	h := AuthnWithConfig(AuthnConfig{
+		Authenticators: []Authenticator{AuthenticatorFunc(func(c echo.Context) (*echo.Principal, error) {
+			return nil, ErrNoCredentials
+		})},
+		Optional: true,
+	})(func(c echo.Context) error {
+		assert.Nil(t, c.Principal())
+		return nil
+	})
+	assert.NoError(t, h(c))
+
+	assert.Panics(t, func() {
+		Authn()
+	})
+}
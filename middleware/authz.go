@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// AuthorizeConfig defines the config for Authorize middleware.
+	AuthorizeConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Authorize decides whether the principal may access the route. It
+		// returns an error, typically `echo.ErrForbidden`, to deny access.
+		// The principal is nil for unauthenticated requests.
+		// Optional. Default value `AuthorizeScopes`.
+		Authorize AuthorizeFunc
+	}
+
+	// AuthorizeFunc defines a policy function deciding whether a principal may
+	// access a route.
+	AuthorizeFunc func(c echo.Context, p *echo.Principal, r *echo.Route) error
+)
+
+var (
+	// DefaultAuthorizeConfig is the default Authorize middleware config.
+	DefaultAuthorizeConfig = AuthorizeConfig{
+		Skipper:   DefaultSkipper,
+		Authorize: AuthorizeScopes,
+	}
+)
+
+// Authorize returns an authorization middleware calling the policy function
+// `authorize` with the principal set by `Authn()` and the matched route, so that
+// access rules can be declared with route metadata at registration, e.g.
+//
+//	e.Use(middleware.Authn(authenticators...), middleware.Authorize(nil))
+//	e.POST("/users", createUser).RequireScopes("users:write")
+//
+// A nil `authorize` enforces the scopes set with `echo.Route#RequireScopes()`.
+// The middleware must be registered with `Echo#Use()` or on a group, as the
+// route is unknown in middleware registered with `Echo#Pre()`. Requests which
+// can't be resolved to a registered route, e.g. to unknown paths, get a
+// "403 - Forbidden" response rather than being allowed without checks.
+func Authorize(authorize AuthorizeFunc) echo.MiddlewareFunc {
+	c := DefaultAuthorizeConfig
+	if authorize != nil {
+		c.Authorize = authorize
+	}
+	return AuthorizeWithConfig(c)
+}
+
+// AuthorizeWithConfig returns an Authorize middleware with config.
+// See: `Authorize()`.
+func AuthorizeWithConfig(config AuthorizeConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultAuthorizeConfig.Skipper
+	}
+	if config.Authorize == nil {
+		config.Authorize = DefaultAuthorizeConfig.Authorize
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			var r *echo.Route
+			if e := c.Echo(); e != nil {
+				r = e.MatchedRoute(c)
+			}
+			if r == nil {
+				return echo.ErrForbidden
+			}
+			if err := config.Authorize(c, c.Principal(), r); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+// AuthorizeScopes is an `AuthorizeFunc` enforcing the scopes set with
+// `echo.Route#RequireScopes()`.
+func AuthorizeScopes(c echo.Context, p *echo.Principal, r *echo.Route) error {
+	if r == nil {
+		return echo.ErrForbidden
+	}
+	scopes, _ := r.Meta()[echo.RouteMetaScopes].([]string)
+	return checkScopes(p, scopes)
+}
+
+// RequireScopes returns a middleware allowing only requests whose principal,
+// set by `Authn()`, has been granted all of the scopes, e.g.
+//
+//	e.POST("/users", createUser, middleware.RequireScopes("users:write"))
+//
+// For unauthenticated requests, it sends "401 - Unauthorized" response.
+// For missing scopes, it sends "403 - Forbidden" response.
+func RequireScopes(scopes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := checkScopes(c.Principal(), scopes); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	}
+}
+
+func checkScopes(p *echo.Principal, scopes []string) error {
+	if len(scopes) == 0 {
+		return nil
+	}
+	if p == nil {
+		return echo.ErrUnauthorized
+	}
+	var missing []string
+	for _, s := range scopes {
+		if !p.HasScope(s) {
+			missing = append(missing, s)
+		}
+	}
+	if len(missing) > 0 {
+		return echo.NewHTTPError(http.StatusForbidden, "insufficient scope").
+			SetInternal(errors.New("missing scopes: " + strings.Join(missing, ", ")))
+	}
+	return nil
+}
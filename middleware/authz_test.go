@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireScopes(t *testing.T) {
+	h := RequireScopes("users:read", "users:write")(func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name      string
+		principal *echo.Principal
+		code      int
+	}{
+		{name: "granted", principal: &echo.Principal{ID: "jon", Scopes: []string{"users:write", "users:read"}}, code: http.StatusNoContent},
+		{name: "missing scope", principal: &echo.Principal{ID: "jon", Scopes: []string{"users:read"}}, code: http.StatusForbidden},
+		{name: "unauthenticated", code: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodPost, "/users", nil), rec)
+			c.SetPrincipal(tt.principal)
+
+			err := h(c)
+			if tt.code == http.StatusNoContent {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.code, rec.Code)
+				return
+			}
+			he, ok := err.(*echo.HTTPError)
+			if assert.True(t, ok) {
+				assert.Equal(t, tt.code, he.Code)
+			}
+		})
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if id := c.Request().Header.Get("X-User"); id != "" {
+				c.SetPrincipal(&echo.Principal{ID: id, Scopes: []string{"users:read"}})
+			}
+			return next(c)
+		}
+	})
+	e.Use(Authorize(nil))
+	ok := func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}
+	e.GET("/users", ok).RequireScopes("users:read")
+	e.POST("/users", ok).RequireScopes("users:write")
+	e.GET("/public", ok)
+	api := e.Host("api.example.com")
+	api.GET("/users", ok).RequireScopes("users:read")
+	api.POST("/users", ok).RequireScopes("users:write")
+
+	tests := []struct {
+		method string
+		target string
+		host   string
+		user   string
+		code   int
+	}{
+		{method: http.MethodGet, target: "/users", user: "jon", code: http.StatusNoContent},
+		{method: http.MethodGet, target: "/users", code: http.StatusUnauthorized},
+		{method: http.MethodPost, target: "/users", user: "jon", code: http.StatusForbidden},
+		{method: http.MethodGet, target: "/public", code: http.StatusNoContent},
+		{method: http.MethodGet, target: "/missing", code: http.StatusForbidden},
+		{method: http.MethodDelete, target: "/users", user: "jon", code: http.StatusForbidden},
+		{method: http.MethodGet, target: "/users", host: "api.example.com", user: "jon", code: http.StatusNoContent},
+		{method: http.MethodPost, target: "/users", host: "api.example.com", user: "jon", code: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, nil)
+		if tt.host != "" {
+			req.Host = tt.host
+		}
+		if tt.user != "" {
+			req.Header.Set("X-User", tt.user)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tt.code, rec.Code, tt.method+" "+tt.target)
+	}
+}
+
+func TestAuthorizePolicy(t *testing.T) {
+	e := echo.New()
+	e.Use(Authorize(func(c echo.Context, p *echo.Principal, r *echo.Route) error {
+		if role, _ := r.Meta()["role"].(string); role != "" && (p == nil || p.Claims["role"] != role) {
+			return errors.New("denied")
+		}
+		return nil
+	}))
+	e.GET("/admin", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}).SetMeta("role", "admin")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
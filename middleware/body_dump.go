@@ -20,6 +20,12 @@ type (
 		// Handler receives request and response payload.
 		// Required.
 		Handler BodyDumpHandler
+
+		// Redact defines the form parameters and members of JSON payloads
+		// replaced in the payloads passed to the handler, see
+		// `DefaultRedaction`.
+		// Optional. By default nothing is redacted.
+		Redact Redaction
 	}
 
 	// BodyDumpHandler receives the request and response payload.
@@ -83,7 +89,12 @@ func BodyDumpWithConfig(config BodyDumpConfig) echo.MiddlewareFunc {
 			}
 
 			// Callback
-			config.Handler(c, reqBody, resBody.Bytes())
+			dumpedReq, dumpedRes := reqBody, resBody.Bytes()
+			if config.Redact.Enabled() {
+				dumpedReq = config.Redact.body(c.Request().Header.Get(echo.HeaderContentType), dumpedReq)
+				dumpedRes = config.Redact.body(c.Response().Header().Get(echo.HeaderContentType), dumpedRes)
+			}
+			config.Handler(c, dumpedReq, dumpedRes)
 
 			return
 		}
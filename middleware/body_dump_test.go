@@ -52,6 +52,32 @@ func TestBodyDump(t *testing.T) {
 	})
 }
 
+func TestBodyDumpRedact(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("user=jon&password=secret"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	var requestBody, responseBody string
+	mw := BodyDumpWithConfig(BodyDumpConfig{
+		Handler: func(c echo.Context, reqBody, resBody []byte) {
+			requestBody = string(reqBody)
+			responseBody = string(resBody)
+		},
+		Redact: Redaction{Params: []string{"password"}, JSONPaths: []string{"token"}},
+	})
+
+	err := mw(func(c echo.Context) error {
+		assert.Equal(t, "secret", c.FormValue("password"))
+		return c.JSON(http.StatusOK, echo.Map{"token": "secret"})
+	})(c)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "user=jon&password=[REDACTED]", requestBody)
+		assert.Equal(t, `{"token":"[REDACTED]"}`, responseBody)
+		assert.Equal(t, `{"token":"secret"}`+"\n", rec.Body.String())
+	}
+}
+
 func TestBodyDumpFails(t *testing.T) {
 	e := echo.New()
 	hw := "Hello, World!"
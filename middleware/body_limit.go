@@ -44,6 +44,7 @@ var (
 // header and actual content read, which makes it super secure.
 // Limit can be specified as `4x` or `4xB`, where x is one of the multiple from K, M,
 // G, T or P.
+// Routes with their own limit, see `echo.Route#BodyLimit()`, are not limited.
 func BodyLimit(limit string) echo.MiddlewareFunc {
 	c := DefaultBodyLimitConfig
 	c.Limit = limit
@@ -71,6 +72,10 @@ func BodyLimitWithConfig(config BodyLimitConfig) echo.MiddlewareFunc {
 				return next(c)
 			}
 
+			if _, ok := routeOverride(c, echo.RouteMetaBodyLimit); ok {
+				return next(c) // Enforced by the route
+			}
+
 			req := c.Request()
 
 			// Based on content length
@@ -83,3 +83,34 @@ func TestBodyLimitReader(t *testing.T) {
 	assert.Equal(t, 2, n)
 	assert.Equal(t, nil, err)
 }
+
+func TestBodyLimitRouteOverride(t *testing.T) {
+	e := echo.New()
+	e.Use(BodyLimit("2B"))
+	h := func(c echo.Context) error {
+		b, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(b))
+	}
+	e.POST("/small", h)
+	e.POST("/upload", h).BodyLimit("1K")
+	e.Host("api.example.com").POST("/small", h).BodyLimit("1K")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/small", bytes.NewReader([]byte("abc"))))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader([]byte("abc"))))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "abc", rec.Body.String())
+
+	// Host route
+	req := httptest.NewRequest(http.MethodPost, "/small", bytes.NewReader([]byte("abc")))
+	req.Host = "api.example.com"
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
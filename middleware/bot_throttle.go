@@ -0,0 +1,282 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// BotThrottleConfig defines the config for BotThrottle middleware.
+	BotThrottleConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Limit is the limit of the requests of unverified bots, counted by
+		// client IP.
+		// Optional. Default value 30 requests per minute.
+		Limit RateLimit `yaml:"limit"`
+
+		// VerifiedLimit is the limit of the requests of verified search engine
+		// crawlers, counted by engine. A limit of -1 requests doesn't limit
+		// them.
+		// Optional. Default value 300 requests per minute.
+		VerifiedLimit RateLimit `yaml:"verified_limit"`
+
+		// Engines lists the search engines whose crawlers are verified by
+		// reverse DNS.
+		// Optional. Default value `DefaultBotEngines`.
+		Engines []BotEngine `yaml:"engines"`
+
+		// BlockImpostors sends "403 - Forbidden" response to bots claiming to
+		// be the crawler of an engine which fail verification.
+		// Optional. Default value false, impostors are limited as unverified
+		// bots.
+		BlockImpostors bool `yaml:"block_impostors"`
+
+		// Lite handles the requests of bots over their limit, e.g. serving a
+		// cached or reduced page. If nil, it sends "429 - Too Many Requests"
+		// response with the "Retry-After" header.
+		// Optional.
+		Lite echo.HandlerFunc
+
+		// Resolver looks up the hosts of the client IPs and their addresses.
+		// Optional. Default value `net.DefaultResolver`.
+		Resolver BotResolver
+
+		// Store counts the requests of the bots.
+		// Optional. Default value `NewRateLimiterMemoryStore()`.
+		Store RateLimiterStore
+
+		// VerifyTTL is how long verifications of client IPs are cached.
+		// Optional. Default value 1 hour.
+		VerifyTTL time.Duration `yaml:"verify_ttl"`
+	}
+
+	// BotEngine is a search engine whose crawler is verified by reverse DNS:
+	// the host of the client IP must end in one of its domains and resolve
+	// back to the IP.
+	BotEngine struct {
+		// Name is the name of the engine, e.g. "google".
+		Name string `yaml:"name"`
+
+		// Tokens are lower case substrings of the user agents of its
+		// crawlers, e.g. "googlebot".
+		Tokens []string `yaml:"tokens"`
+
+		// Domains are the domains of the hosts of its crawlers, e.g.
+		// ".googlebot.com".
+		Domains []string `yaml:"domains"`
+	}
+
+	// BotResolver looks up hosts and addresses, `*net.Resolver` implements it.
+	BotResolver interface {
+		LookupAddr(ctx context.Context, addr string) ([]string, error)
+		LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	}
+
+	botVerification struct {
+		verified bool
+		expires  time.Time
+	}
+
+	botVerifier struct {
+		mu        sync.Mutex
+		cache     map[string]botVerification
+		lastSweep time.Time
+		resolver  BotResolver
+		ttl       time.Duration
+	}
+)
+
+var (
+	// DefaultBotEngines are the default engines of BotThrottle middleware.
+	DefaultBotEngines = []BotEngine{
+		{Name: "google", Tokens: []string{"googlebot", "google-inspectiontool", "adsbot-google"}, Domains: []string{".googlebot.com", ".google.com"}},
+		{Name: "bing", Tokens: []string{"bingbot", "msnbot", "bingpreview"}, Domains: []string{".search.msn.com"}},
+		{Name: "yandex", Tokens: []string{"yandexbot", "yandeximages"}, Domains: []string{".yandex.ru", ".yandex.net", ".yandex.com"}},
+		{Name: "baidu", Tokens: []string{"baiduspider"}, Domains: []string{".baidu.com", ".baidu.jp"}},
+		{Name: "apple", Tokens: []string{"applebot"}, Domains: []string{".applebot.apple.com"}},
+	}
+
+	// DefaultBotThrottleConfig is the default BotThrottle middleware config.
+	DefaultBotThrottleConfig = BotThrottleConfig{
+		Skipper:       DefaultSkipper,
+		Limit:         RateLimit{Requests: 30, Window: time.Minute},
+		VerifiedLimit: RateLimit{Requests: 300, Window: time.Minute},
+		VerifyTTL:     time.Hour,
+	}
+)
+
+// BotThrottle returns a middleware limiting the requests of bots, detected by
+// their user agent (see `Context#UserAgent()`), separately from the requests
+// of browsers. Crawlers of the major search engines verified by reverse DNS
+// get a higher limit, see `BotThrottleConfig`.
+//
+// The bot is added to the request log fields as "bot", with the name of the
+// engine for verified crawlers and "unverified" otherwise.
+func BotThrottle() echo.MiddlewareFunc {
+	return BotThrottleWithConfig(DefaultBotThrottleConfig)
+}
+
+// BotThrottleWithConfig returns a BotThrottle middleware with config.
+// See: `BotThrottle()`.
+func BotThrottleWithConfig(config BotThrottleConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultBotThrottleConfig.Skipper
+	}
+	if config.Limit.Requests == 0 {
+		config.Limit = DefaultBotThrottleConfig.Limit
+	}
+	if config.VerifiedLimit.Requests == 0 {
+		config.VerifiedLimit = DefaultBotThrottleConfig.VerifiedLimit
+	}
+	if config.Engines == nil {
+		config.Engines = DefaultBotEngines
+	}
+	if config.Resolver == nil {
+		config.Resolver = net.DefaultResolver
+	}
+	if config.Store == nil {
+		config.Store = NewRateLimiterMemoryStore()
+	}
+	if config.VerifyTTL == 0 {
+		config.VerifyTTL = DefaultBotThrottleConfig.VerifyTTL
+	}
+	verifier := &botVerifier{
+		cache:    map[string]botVerification{},
+		resolver: config.Resolver,
+		ttl:      config.VerifyTTL,
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+			ua := c.UserAgent()
+			if !ua.Bot {
+				return next(c)
+			}
+
+			ip := c.RealIP()
+			id, limit := "bot:ip:"+ip, config.Limit
+			name := "unverified"
+			if engine := botEngine(config.Engines, strings.ToLower(ua.Raw)); engine != nil {
+				if verifier.verify(c.Request().Context(), ip, engine) {
+					id, limit = "bot:engine:"+engine.Name, config.VerifiedLimit
+					name = engine.Name
+				} else if config.BlockImpostors {
+					c.LogFields("bot", "impostor")
+					return echo.ErrForbidden
+				}
+			}
+			c.LogFields("bot", name)
+			if limit.Requests <= 0 {
+				return next(c)
+			}
+
+			count, reset, err := config.Store.Take(id, limit)
+			if err != nil {
+				return err
+			}
+			if count > limit.Requests {
+				if config.Lite != nil {
+					return config.Lite(c)
+				}
+				c.Response().Header().Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter(reset)))
+				return echo.ErrTooManyRequests
+			}
+			return next(c)
+		}
+	}
+}
+
+// botEngine returns the engine whose crawler the lower case user agent claims
+// to be, or nil.
+func botEngine(engines []BotEngine, ua string) *BotEngine {
+	for i := range engines {
+		for _, t := range engines[i].Tokens {
+			if strings.Contains(ua, t) {
+				return &engines[i]
+			}
+		}
+	}
+	return nil
+}
+
+// verify reports whether the host of ip is in the domains of the engine and
+// resolves back to ip. Results are cached, lookup errors aren't.
+func (v *botVerifier) verify(ctx context.Context, ip string, engine *BotEngine) bool {
+	key := engine.Name + "|" + ip
+	now := time.Now()
+	v.mu.Lock()
+	if r, ok := v.cache[key]; ok && now.Before(r.expires) {
+		v.mu.Unlock()
+		return r.verified
+	}
+	v.mu.Unlock()
+
+	verified, err := v.lookup(ctx, ip, engine)
+	if err != nil {
+		return false
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if now.Sub(v.lastSweep) > v.ttl {
+		for k, r := range v.cache {
+			if !now.Before(r.expires) {
+				delete(v.cache, k)
+			}
+		}
+		v.lastSweep = now
+	}
+	v.cache[key] = botVerification{verified: verified, expires: now.Add(v.ttl)}
+	return verified
+}
+
+func (v *botVerifier) lookup(ctx context.Context, ip string, engine *BotEngine) (bool, error) {
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false, nil
+	}
+	hosts, err := v.resolver.LookupAddr(ctx, ip)
+	if err != nil {
+		if dnsErr, ok := err.(*net.DNSError); ok && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if !botHostInDomains(host, engine.Domains) {
+			continue
+		}
+		addrs, err := v.resolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return false, err
+		}
+		for _, a := range addrs {
+			if a.IP.Equal(addr) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func botHostInDomains(host string, domains []string) bool {
+	for _, d := range domains {
+		if strings.HasSuffix(host, strings.ToLower(d)) {
+			return true
+		}
+	}
+	return false
+}
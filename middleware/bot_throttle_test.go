@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type botTestResolver struct {
+	hosts   map[string][]string
+	addrs   map[string][]net.IPAddr
+	lookups int
+}
+
+func (r *botTestResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	r.lookups++
+	if hosts, ok := r.hosts[addr]; ok {
+		return hosts, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: addr, IsNotFound: true}
+}
+
+func (r *botTestResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	return r.addrs[host], nil
+}
+
+func TestBotThrottle(t *testing.T) {
+	const googlebot = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+	resolver := &botTestResolver{
+		hosts: map[string][]string{
+			"66.249.66.1": {"crawl-66-249-66-1.googlebot.com."},
+			"6.6.6.6":     {"crawl.googlebot.com.evil.example."},
+		},
+		addrs: map[string][]net.IPAddr{
+			"crawl-66-249-66-1.googlebot.com": {{IP: net.ParseIP("66.249.66.1")}},
+		},
+	}
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, "full")
+	}
+	request := func(e *echo.Echo, ua, ip string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("User-Agent", ua)
+		req.RemoteAddr = ip + ":1234"
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	e := echo.New()
+	e.Use(BotThrottleWithConfig(BotThrottleConfig{
+		Limit:         RateLimit{Requests: 1, Window: time.Hour},
+		VerifiedLimit: RateLimit{Requests: 2, Window: time.Hour},
+		Resolver:      resolver,
+	}))
+	e.GET("/", handler)
+
+	// Browsers aren't limited
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, request(e, "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0", "1.1.1.1").Code)
+	}
+
+	// Unverified bots
+	assert.Equal(t, http.StatusOK, request(e, "curl/8.4.0", "1.1.1.1").Code)
+	rec := request(e, "curl/8.4.0", "1.1.1.1")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderRetryAfter))
+
+	// Verified crawler, verification is cached
+	assert.Equal(t, http.StatusOK, request(e, googlebot, "66.249.66.1").Code)
+	assert.Equal(t, http.StatusOK, request(e, googlebot, "66.249.66.1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(e, googlebot, "66.249.66.1").Code)
+	assert.Equal(t, 1, resolver.lookups)
+
+	// Impostors are limited as unverified bots
+	assert.Equal(t, http.StatusOK, request(e, googlebot, "6.6.6.6").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request(e, googlebot, "6.6.6.6").Code)
+
+	// Lite responses and blocked impostors
+	e = echo.New()
+	e.Use(BotThrottleWithConfig(BotThrottleConfig{
+		Limit:          RateLimit{Requests: 1, Window: time.Hour},
+		VerifiedLimit:  RateLimit{Requests: -1},
+		BlockImpostors: true,
+		Resolver:       resolver,
+		Lite: func(c echo.Context) error {
+			return c.String(http.StatusOK, "lite")
+		},
+	}))
+	e.GET("/", handler)
+	assert.Equal(t, "full", request(e, "curl/8.4.0", "1.1.1.1").Body.String())
+	assert.Equal(t, "lite", request(e, "curl/8.4.0", "1.1.1.1").Body.String())
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, "full", request(e, googlebot, "66.249.66.1").Body.String())
+	}
+	assert.Equal(t, http.StatusForbidden, request(e, googlebot, "7.7.7.7").Code)
+}
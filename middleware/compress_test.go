@@ -106,6 +106,19 @@ func TestGzipNoContent(t *testing.T) {
 	}
 }
 
+func TestGzipVary(t *testing.T) {
+	e := echo.New()
+	e.Use(Gzip(), Gzip())
+	e.GET("/", func(c echo.Context) error {
+		c.Negotiate(echo.MIMETextPlain)
+		return c.String(http.StatusOK, "test")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, []string{echo.HeaderAcceptEncoding, echo.HeaderAccept}, rec.Header().Values(echo.HeaderVary))
+}
+
 func TestGzipErrorReturned(t *testing.T) {
 	e := echo.New()
 	e.Use(Gzip())
@@ -146,3 +159,32 @@ func TestGzipWithStatic(t *testing.T) {
 		}
 	}
 }
+
+func TestGzipTrailer(t *testing.T) {
+	e := echo.New()
+	e.Use(Gzip())
+	e.GET("/", func(c echo.Context) error {
+		res := c.Response()
+		res.DeclareTrailer("X-Checksum")
+		c.String(http.StatusOK, "test")
+		res.Flush()
+		res.SetTrailer("X-Checksum", "abc")
+		return nil
+	})
+	srv := httptest.NewServer(e)
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set(echo.HeaderAcceptEncoding, gzipScheme)
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer res.Body.Close()
+	r, err := gzip.NewReader(res.Body)
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(r)
+		assert.Equal(t, "test", string(b))
+	}
+	assert.Equal(t, "abc", res.Trailer.Get("X-Checksum"))
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+// If returns a middleware running the middleware `m` only for requests for
+// which `predicate` returns true, e.g. compressing only the responses of
+// clients accepting gzip.
+func If(predicate func(echo.Context) bool, m ...echo.MiddlewareFunc) echo.MiddlewareFunc {
+	chain := Chain(m...)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		h := chain(next)
+		return func(c echo.Context) error {
+			if predicate(c) {
+				return h(c)
+			}
+			return next(c)
+		}
+	}
+}
+
+// Unless returns a middleware running the middleware `m` except for requests
+// for which `predicate` returns true, e.g. authenticating all requests but the
+// ones to public pages.
+func Unless(predicate func(echo.Context) bool, m ...echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return If(func(c echo.Context) bool {
+		return !predicate(c)
+	}, m...)
+}
+
+// Chain returns a middleware running the middleware `m` in order, so that a
+// stack of middleware can be passed around as one.
+func Chain(m ...echo.MiddlewareFunc) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		h := next
+		for i := len(m) - 1; i >= 0; i-- {
+			h = m[i](h)
+		}
+		return h
+	}
+}
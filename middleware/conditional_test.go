@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func tag(name string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Add("X-Trace", name)
+			return next(c)
+		}
+	}
+}
+
+func TestConditional(t *testing.T) {
+	public := func(c echo.Context) bool {
+		return strings.HasPrefix(c.Request().URL.Path, "/public")
+	}
+	tests := []struct {
+		name     string
+		mw       echo.MiddlewareFunc
+		path     string
+		expected []string
+	}{
+		{name: "chain", mw: Chain(tag("a"), tag("b")), path: "/", expected: []string{"a", "b"}},
+		{name: "empty chain", mw: Chain(), path: "/"},
+		{name: "if true", mw: If(public, tag("a"), tag("b")), path: "/public/logo.png", expected: []string{"a", "b"}},
+		{name: "if false", mw: If(public, tag("a")), path: "/account"},
+		{name: "unless true", mw: Unless(public, tag("auth")), path: "/public/logo.png"},
+		{name: "unless false", mw: Unless(public, tag("auth")), path: "/account", expected: []string{"auth"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, tt.path, nil), rec)
+			h := tt.mw(func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			})
+			assert.NoError(t, h(c))
+			assert.Equal(t, tt.expected, rec.Header()["X-Trace"])
+		})
+	}
+}
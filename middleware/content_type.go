@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// RequireContentTypeConfig defines the config for RequireContentType middleware.
+	RequireContentTypeConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Types lists the accepted media types, e.g. "application/json". A type
+		// may end with "/*" to accept all its subtypes, e.g. "image/*".
+		// Required.
+		Types []string
+
+		// ExemptMethods lists the methods of the requests accepted regardless
+		// of their "Content-Type".
+		// Optional. Default value []string{"GET", "HEAD", "DELETE", "OPTIONS"}.
+		ExemptMethods []string
+	}
+)
+
+var (
+	// DefaultRequireContentTypeConfig is the default RequireContentType middleware config.
+	DefaultRequireContentTypeConfig = RequireContentTypeConfig{
+		Skipper:       DefaultSkipper,
+		ExemptMethods: []string{http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions},
+	}
+)
+
+// RequireContentType returns a middleware accepting requests only with a
+// "Content-Type" of the provided types, e.g. "application/json". Parameters
+// such as "charset" are ignored and requests with the `GET`, `HEAD`, `DELETE`
+// and `OPTIONS` methods are exempted.
+//
+// For invalid requests, it sends "415 - Unsupported Media Type" response.
+func RequireContentType(types ...string) echo.MiddlewareFunc {
+	c := DefaultRequireContentTypeConfig
+	c.Types = types
+	return RequireContentTypeWithConfig(c)
+}
+
+// RequireContentTypeWithConfig returns a RequireContentType middleware with config.
+// See: `RequireContentType()`.
+func RequireContentTypeWithConfig(config RequireContentTypeConfig) echo.MiddlewareFunc {
+	// Defaults
+	if len(config.Types) == 0 {
+		panic("echo: require-content-type middleware requires types")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultRequireContentTypeConfig.Skipper
+	}
+	if config.ExemptMethods == nil {
+		config.ExemptMethods = DefaultRequireContentTypeConfig.ExemptMethods
+	}
+	expected := strings.Join(config.Types, ", ")
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			for _, m := range config.ExemptMethods {
+				if req.Method == m {
+					return next(c)
+				}
+			}
+			ct := req.Header.Get(echo.HeaderContentType)
+			if ct == "" {
+				return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+					fmt.Sprintf("missing Content-Type, expected %s", expected))
+			}
+			mediaType, _, err := mime.ParseMediaType(ct)
+			if err == nil && matchMediaType(mediaType, config.Types) {
+				return next(c)
+			}
+			return echo.NewHTTPError(http.StatusUnsupportedMediaType,
+				fmt.Sprintf("unsupported Content-Type %q, expected %s", ct, expected))
+		}
+	}
+}
+
+func matchMediaType(mediaType string, types []string) bool {
+	for _, t := range types {
+		t = strings.ToLower(t)
+		if t == mediaType {
+			return true
+		}
+		if strings.HasSuffix(t, "/*") && strings.HasPrefix(mediaType, t[:len(t)-1]) {
+			return true
+		}
+	}
+	return false
+}
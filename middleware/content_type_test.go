@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		message     string
+	}{
+		{name: "json", method: http.MethodPost, contentType: "application/json"},
+		{name: "json with charset", method: http.MethodPut, contentType: "Application/JSON; charset=utf-8"},
+		{name: "wildcard", method: http.MethodPost, contentType: "image/png"},
+		{name: "exempt method", method: http.MethodGet},
+		{name: "exempt delete", method: http.MethodDelete, contentType: "text/plain"},
+		{name: "missing", method: http.MethodPost, message: "missing Content-Type, expected application/json, image/*"},
+		{name: "mismatch", method: http.MethodPatch, contentType: "text/plain",
+			message: `unsupported Content-Type "text/plain", expected application/json, image/*`},
+		{name: "malformed", method: http.MethodPost, contentType: "application/json; =",
+			message: `unsupported Content-Type "application/json; =", expected application/json, image/*`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(tt.method, "/", nil)
+			if tt.contentType != "" {
+				req.Header.Set(echo.HeaderContentType, tt.contentType)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+			h := RequireContentType(echo.MIMEApplicationJSON, "image/*")(func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			})
+
+			err := h(c)
+			if tt.message == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, echo.NewHTTPError(http.StatusUnsupportedMediaType, tt.message), err)
+		})
+	}
+
+	assert.Panics(t, func() { RequireContentType() })
+	h := RequireContentTypeWithConfig(RequireContentTypeConfig{Types: []string{"application/xml"}, ExemptMethods: []string{}})(func(c echo.Context) error {
+		return nil
+	})
+	err := h(echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder()))
+	assert.Error(t, err, "no exempt methods")
+}
@@ -18,6 +18,12 @@ type (
 		// Optional. Default value []string{"*"}.
 		AllowOrigins []string `yaml:"allow_origins"`
 
+		// AllowOriginsFunc returns the origins that may access the resource for
+		// each request, e.g. from an `echo.Setting` updated on `Echo#Reload()`.
+		// It overrides AllowOrigins.
+		// Optional.
+		AllowOriginsFunc func() []string `yaml:"-"`
+
 		// AllowMethods defines a list methods allowed when accessing the resource.
 		// This is used in response to a preflight request.
 		// Optional. Default value DefaultCORSConfig.AllowMethods.
@@ -93,7 +99,11 @@ func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc {
 			allowOrigin := ""
 
 			// Check allowed origins
-			for _, o := range config.AllowOrigins {
+			allowOrigins := config.AllowOrigins
+			if config.AllowOriginsFunc != nil {
+				allowOrigins = config.AllowOriginsFunc()
+			}
+			for _, o := range allowOrigins {
 				if o == "*" && config.AllowCredentials {
 					allowOrigin = origin
 					break
@@ -110,7 +120,7 @@ func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc {
 
 			// Simple request
 			if req.Method != http.MethodOptions {
-				res.Header().Add(echo.HeaderVary, echo.HeaderOrigin)
+				res.AddVary(echo.HeaderOrigin)
 				res.Header().Set(echo.HeaderAccessControlAllowOrigin, allowOrigin)
 				if config.AllowCredentials {
 					res.Header().Set(echo.HeaderAccessControlAllowCredentials, "true")
@@ -122,9 +132,7 @@ func CORSWithConfig(config CORSConfig) echo.MiddlewareFunc {
 			}
 
 			// Preflight request
-			res.Header().Add(echo.HeaderVary, echo.HeaderOrigin)
-			res.Header().Add(echo.HeaderVary, echo.HeaderAccessControlRequestMethod)
-			res.Header().Add(echo.HeaderVary, echo.HeaderAccessControlRequestHeaders)
+			res.AddVary(echo.HeaderOrigin, echo.HeaderAccessControlRequestMethod, echo.HeaderAccessControlRequestHeaders)
 			res.Header().Set(echo.HeaderAccessControlAllowOrigin, allowOrigin)
 			res.Header().Set(echo.HeaderAccessControlAllowMethods, allowMethods)
 			if config.AllowCredentials {
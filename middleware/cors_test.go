@@ -31,6 +31,21 @@ func TestCORS(t *testing.T) {
 	h(c)
 	assert.Equal(t, "localhost", rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
 
+	// Allow origins func
+	origins := []string{"https://a.com"}
+	h = CORSWithConfig(CORSConfig{
+		AllowOrigins:     []string{"localhost"},
+		AllowOriginsFunc: func() []string { return origins },
+	})(echo.NotFoundHandler)
+	for _, origin := range []string{"https://a.com", "https://b.com"} {
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(echo.HeaderOrigin, origin)
+		rec = httptest.NewRecorder()
+		h(e.NewContext(req, rec))
+		assert.Equal(t, origin, rec.Header().Get(echo.HeaderAccessControlAllowOrigin))
+		origins = []string{"https://b.com"}
+	}
+
 	// Preflight request
 	req = httptest.NewRequest(http.MethodOptions, "/", nil)
 	rec = httptest.NewRecorder()
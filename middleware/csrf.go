@@ -166,7 +166,7 @@ func CSRFWithConfig(config CSRFConfig) echo.MiddlewareFunc {
 			c.Set(config.ContextKey, token)
 
 			// Protect clients from caching the response
-			c.Response().Header().Add(echo.HeaderVary, echo.HeaderCookie)
+			c.Response().AddVary(echo.HeaderCookie)
 
 			return next(c)
 		}
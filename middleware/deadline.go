@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"bufio"
+	stdContext "context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+)
+
+type (
+	// DeadlineConfig defines the config for Deadline middleware.
+	DeadlineConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Timeout after which the std context of the request is canceled.
+		// Optional. Default value 0 (canceled only when the client goes away).
+		Timeout time.Duration
+
+		// DisableLateWriteWarning disables the warning logged with the route and
+		// duration when the handler keeps writing after the std context of the
+		// request is done, i.e. the client went away or the timeout elapsed.
+		// Optional. Default value false.
+		DisableLateWriteWarning bool
+	}
+
+	deadlineResponseWriter struct {
+		http.ResponseWriter
+		ctx   stdContext.Context
+		c     echo.Context
+		start time.Time
+		once  sync.Once
+	}
+)
+
+var (
+	// DefaultDeadlineConfig is the default Deadline middleware config.
+	DefaultDeadlineConfig = DeadlineConfig{
+		Skipper: DefaultSkipper,
+	}
+)
+
+// Deadline returns a middleware that cancels the std context of the request,
+// see `Context#Deadline()`, after the timeout or when the client goes away, so
+// that handlers passing it on stop working for nothing. Handler errors caused
+// by the timeout result in a 503 - Service Unavailable.
+func Deadline(timeout time.Duration) echo.MiddlewareFunc {
+	c := DefaultDeadlineConfig
+	c.Timeout = timeout
+	return DeadlineWithConfig(c)
+}
+
+// DeadlineWithConfig returns a Deadline middleware with config.
+// See: `Deadline()`.
+func DeadlineWithConfig(config DeadlineConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultDeadlineConfig.Skipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			ctx := req.Context()
+			if _, ok := routeOverride(c, echo.RouteMetaTimeout); !ok && config.Timeout > 0 {
+				var cancel stdContext.CancelFunc
+				ctx, cancel = stdContext.WithTimeout(ctx, config.Timeout)
+				defer cancel()
+				c.SetRequest(req.WithContext(ctx))
+			}
+			if !config.DisableLateWriteWarning {
+				res := c.Response()
+				writer := res.Writer
+				res.Writer = &deadlineResponseWriter{ResponseWriter: writer, ctx: ctx, c: c, start: time.Now()}
+				defer func() {
+					res.Writer = writer
+				}()
+			}
+
+			err := next(c)
+			if err != nil && errors.Is(err, stdContext.DeadlineExceeded) && ctx.Err() == stdContext.DeadlineExceeded {
+				return echo.ErrServiceUnavailable.WithInternal(err)
+			}
+			return err
+		}
+	}
+}
+
+func (w *deadlineResponseWriter) warn() {
+	if err := w.ctx.Err(); err != nil {
+		w.once.Do(func() {
+			w.c.Logger().Warnj(log.JSON{
+				"message":  "handler kept writing after the request was done",
+				"route":    w.c.Path(),
+				"duration": time.Since(w.start).String(),
+				"reason":   err.Error(),
+			})
+		})
+	}
+}
+
+func (w *deadlineResponseWriter) WriteHeader(code int) {
+	w.warn()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *deadlineResponseWriter) Write(b []byte) (int, error) {
+	w.warn()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *deadlineResponseWriter) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *deadlineResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
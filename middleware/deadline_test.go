@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	stdContext "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadline(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	h := Deadline(time.Minute)(func(c echo.Context) error {
+		deadline, ok := c.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+		return c.String(http.StatusOK, "test")
+	})
+	if assert.NoError(t, h(c)) {
+		assert.Equal(t, "test", rec.Body.String())
+	}
+
+	// Timeout
+	h = Deadline(time.Millisecond)(func(c echo.Context) error {
+		<-c.Request().Context().Done()
+		return c.Request().Context().Err()
+	})
+	err := h(e.NewContext(req, httptest.NewRecorder()))
+	if assert.Error(t, err) {
+		he, ok := err.(*echo.HTTPError)
+		if assert.True(t, ok) {
+			assert.Equal(t, http.StatusServiceUnavailable, he.Code)
+			assert.Equal(t, stdContext.DeadlineExceeded, he.Internal)
+		}
+	}
+}
+
+func TestDeadlineLateWriteWarning(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	e.Logger.SetOutput(buf)
+	e.Logger.SetLevel(log.WARN)
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	c := e.NewContext(req, httptest.NewRecorder())
+	c.SetPath("/users/:id")
+
+	h := DeadlineWithConfig(DeadlineConfig{})(func(c echo.Context) error {
+		cancel() // Client went away
+		c.Response().Write([]byte("a"))
+		c.Response().Write([]byte("b"))
+		return nil
+	})
+	assert.NoError(t, h(c))
+	assert.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("handler kept writing")))
+	assert.Contains(t, buf.String(), `"route":"/users/:id"`)
+	assert.Contains(t, buf.String(), `"reason":"context canceled"`)
+
+	// Disabled
+	buf.Reset()
+	ctx, cancel = stdContext.WithCancel(stdContext.Background())
+	c = e.NewContext(req.WithContext(ctx), httptest.NewRecorder())
+	h = DeadlineWithConfig(DeadlineConfig{DisableLateWriteWarning: true})(func(c echo.Context) error {
+		cancel()
+		return c.String(http.StatusOK, "test")
+	})
+	assert.NoError(t, h(c))
+	assert.Empty(t, buf.String())
+}
@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// EnvelopeConfig defines the config for Envelope middleware.
+	EnvelopeConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Meta returns the meta of the envelope of a response, merged with the
+		// meta added by the handler with `AddEnvelopeMeta()`.
+		// Optional.
+		Meta func(c echo.Context) echo.Map
+	}
+
+	// ResponseEnvelope is the standard shape of JSON responses wrapped by
+	// Envelope middleware.
+	ResponseEnvelope struct {
+		Data      json.RawMessage `json:"data"`
+		Meta      echo.Map        `json:"meta,omitempty"`
+		RequestID string          `json:"request_id,omitempty"`
+	}
+
+	envelopeWriter struct {
+		http.ResponseWriter
+		buf  *bytes.Buffer
+		code int
+		wrap bool
+	}
+)
+
+// RouteMetaNoEnvelope is the route meta key opting a route out of Envelope
+// middleware, e.g. `e.GET("/raw", h).SetMeta(middleware.RouteMetaNoEnvelope, true)`.
+const RouteMetaNoEnvelope = "middleware.no_envelope"
+
+// envelopeMetaKey is the context key of the meta added by handlers.
+const envelopeMetaKey = "middleware.envelope_meta"
+
+var (
+	// DefaultEnvelopeConfig is the default Envelope middleware config.
+	DefaultEnvelopeConfig = EnvelopeConfig{
+		Skipper: DefaultSkipper,
+	}
+)
+
+// Envelope returns a middleware wrapping successful JSON responses in an
+// `ResponseEnvelope`, e.g. `{"data": {...}, "meta": {...}, "request_id": "..."}`.
+// Error responses, responses of other content types and routes with the
+// `RouteMetaNoEnvelope` meta are left as is.
+func Envelope() echo.MiddlewareFunc {
+	return EnvelopeWithConfig(DefaultEnvelopeConfig)
+}
+
+// EnvelopeWithConfig returns an Envelope middleware with config.
+// See: `Envelope()`.
+func EnvelopeWithConfig(config EnvelopeConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultEnvelopeConfig.Skipper
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+			if r := c.Echo().MatchedRoute(c); r != nil && r.Meta()[RouteMetaNoEnvelope] == true {
+				return next(c)
+			}
+
+			res := c.Response()
+			w := &envelopeWriter{ResponseWriter: res.Writer, buf: new(bytes.Buffer)}
+			res.Writer = w
+			err := next(c)
+			res.Writer = w.ResponseWriter
+			if !w.wrap {
+				return err
+			}
+
+			env := &ResponseEnvelope{Data: w.buf.Bytes(), RequestID: res.Header().Get(echo.HeaderXRequestID)}
+			if len(env.Data) == 0 {
+				env.Data = json.RawMessage("null")
+			}
+			if env.RequestID == "" {
+				env.RequestID = c.Request().Header.Get(echo.HeaderXRequestID)
+			}
+			if config.Meta != nil {
+				env.Meta = config.Meta(c)
+			}
+			if m, ok := c.Get(envelopeMetaKey).(echo.Map); ok {
+				if env.Meta == nil {
+					env.Meta = echo.Map{}
+				}
+				for k, v := range m {
+					env.Meta[k] = v
+				}
+			}
+			b, merr := json.Marshal(env)
+			if merr != nil {
+				// Invalid JSON from the handler, sent as is
+				b = w.buf.Bytes()
+			}
+			w.ResponseWriter.WriteHeader(w.code)
+			if _, werr := w.ResponseWriter.Write(b); werr != nil && err == nil {
+				err = werr
+			}
+			return err
+		}
+	}
+}
+
+// AddEnvelopeMeta adds a key/value pair to the meta of the envelope of the
+// response, e.g. pagination info.
+func AddEnvelopeMeta(c echo.Context, key string, val interface{}) {
+	m, ok := c.Get(envelopeMetaKey).(echo.Map)
+	if !ok {
+		m = echo.Map{}
+		c.Set(envelopeMetaKey, m)
+	}
+	m[key] = val
+}
+
+func (w *envelopeWriter) WriteHeader(code int) {
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get(echo.HeaderContentType))
+	if code >= 200 && code < 300 && code != http.StatusNoContent && mediaType == echo.MIMEApplicationJSON {
+		w.wrap = true
+		w.code = code
+		w.Header().Del(echo.HeaderContentLength)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *envelopeWriter) Write(b []byte) (int, error) {
+	if w.wrap {
+		return w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *envelopeWriter) Flush() {
+	if !w.wrap {
+		w.ResponseWriter.(http.Flusher).Flush()
+	}
+}
+
+func (w *envelopeWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
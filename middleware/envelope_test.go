@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	e := echo.New()
+	e.Use(EnvelopeWithConfig(EnvelopeConfig{
+		Meta: func(c echo.Context) echo.Map {
+			return echo.Map{"version": "v1"}
+		},
+	}))
+	e.GET("/users", func(c echo.Context) error {
+		AddEnvelopeMeta(c, "total", 2)
+		return c.JSON(http.StatusOK, []string{"jon", "arya"})
+	})
+	e.POST("/users", func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, echo.Map{"name": "jon"})
+	})
+	e.GET("/text", func(c echo.Context) error {
+		return c.String(http.StatusOK, "text")
+	})
+	e.GET("/error", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusNotFound, "not found")
+	})
+	e.GET("/raw", func(c echo.Context) error {
+		return c.JSON(http.StatusOK, []string{"raw"})
+	}).SetMeta(RouteMetaNoEnvelope, true)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{http.MethodGet, "/users", http.StatusOK, `{"data":["jon","arya"],"meta":{"total":2,"version":"v1"},"request_id":"abc"}`},
+		{http.MethodPost, "/users", http.StatusCreated, `{"data":{"name":"jon"},"meta":{"version":"v1"},"request_id":"abc"}`},
+		{http.MethodGet, "/text", http.StatusOK, "text"},
+		{http.MethodGet, "/error", http.StatusNotFound, `{"message":"not found"}` + "\n"},
+		{http.MethodGet, "/raw", http.StatusOK, `["raw"]` + "\n"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set(echo.HeaderXRequestID, "abc")
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tt.code, rec.Code, tt.path)
+		assert.Equal(t, tt.body, rec.Body.String(), tt.path)
+	}
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"hash/fnv"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/random"
+)
+
+type (
+	// ExperimentConfig defines the config for Experiment middleware.
+	ExperimentConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Name is the name of the experiment, e.g. "checkout-button".
+		// Required.
+		Name string
+
+		// Variants are the variants requests are assigned to, in proportion to
+		// their weights.
+		// Required.
+		Variants []ExperimentVariant
+
+		// Subject returns the ID requests are assigned by, so that the same
+		// subject is always assigned the same variant.
+		// Optional. Default value returns the ID of the principal, see
+		// `Authn()`, or else the visitor ID of the cookie.
+		Subject func(c echo.Context) string
+
+		// CookieName is the name of the cookie holding the visitor ID of
+		// anonymous requests, shared by the experiments.
+		// Optional. Default value "_experiment_visitor".
+		CookieName string
+
+		// CookieMaxAge is the max age (in seconds) of the cookie.
+		// Optional. Default value 31536000 (1 year).
+		CookieMaxAge int
+
+		// Header is the response header listing the assignments as
+		// "<experiment>=<variant>".
+		// Optional. Default value "X-Experiment".
+		Header string
+	}
+
+	// ExperimentVariant is a variant of an experiment.
+	ExperimentVariant struct {
+		Name string
+
+		// Weight is the share of requests assigned to the variant, relative to
+		// the weights of the other variants.
+		Weight int
+	}
+)
+
+const experimentVisitorKey = "_experiment_visitor"
+
+var (
+	// DefaultExperimentConfig is the default Experiment middleware config.
+	DefaultExperimentConfig = ExperimentConfig{
+		Skipper:      DefaultSkipper,
+		CookieName:   "_experiment_visitor",
+		CookieMaxAge: 365 * 24 * 60 * 60,
+		Header:       "X-Experiment",
+	}
+)
+
+// Experiment returns a middleware assigning requests to a variant of the A/B
+// experiment, evenly split between the variants. Assignments are
+// deterministic: the variant is derived from a hash of the experiment name and
+// the principal, or of a visitor ID kept in a cookie for anonymous requests.
+//
+// The variant is returned by `ExperimentVariantFromContext()`, listed in the
+// "X-Experiment" response header and added to the request log fields as
+// "experiment.<name>", so that access logs and metrics derived from them can
+// be split by variant.
+//
+// Example:
+//
+//	e.Use(middleware.Experiment("checkout-button", "control", "green"))
+//	e.GET("/checkout", func(c echo.Context) error {
+//	  return c.Render(http.StatusOK, "checkout-"+middleware.ExperimentVariantFromContext(c, "checkout-button"), nil)
+//	})
+func Experiment(name string, variants ...string) echo.MiddlewareFunc {
+	c := DefaultExperimentConfig
+	c.Name = name
+	for _, v := range variants {
+		c.Variants = append(c.Variants, ExperimentVariant{Name: v, Weight: 1})
+	}
+	return ExperimentWithConfig(c)
+}
+
+// ExperimentWithConfig returns an Experiment middleware with config.
+// See: `Experiment()`.
+func ExperimentWithConfig(config ExperimentConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultExperimentConfig.Skipper
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultExperimentConfig.CookieName
+	}
+	if config.CookieMaxAge == 0 {
+		config.CookieMaxAge = DefaultExperimentConfig.CookieMaxAge
+	}
+	if config.Header == "" {
+		config.Header = DefaultExperimentConfig.Header
+	}
+	if config.Name == "" {
+		panic("echo: experiment middleware requires a name")
+	}
+	total := 0
+	for _, v := range config.Variants {
+		if v.Weight < 0 {
+			panic("echo: experiment middleware requires non-negative weights")
+		}
+		total += v.Weight
+	}
+	if total == 0 {
+		panic("echo: experiment middleware requires variants")
+	}
+	if config.Subject == nil {
+		config.Subject = func(c echo.Context) string {
+			if p := c.Principal(); p != nil {
+				return "principal:" + p.ID
+			}
+			return "visitor:" + experimentVisitor(c, config.CookieName, config.CookieMaxAge)
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			h := fnv.New32a()
+			h.Write([]byte(config.Name + "\x00" + config.Subject(c)))
+			bucket := int(h.Sum32() % uint32(total))
+			var variant string
+			for _, v := range config.Variants {
+				if bucket < v.Weight {
+					variant = v.Name
+					break
+				}
+				bucket -= v.Weight
+			}
+
+			c.Set("experiment:"+config.Name, variant)
+			c.Response().Header().Add(config.Header, config.Name+"="+variant)
+			c.LogFields("experiment."+config.Name, variant)
+			return next(c)
+		}
+	}
+}
+
+// ExperimentVariantFromContext returns the variant of the experiment the
+// request was assigned to by the Experiment middleware, or "".
+func ExperimentVariantFromContext(c echo.Context, name string) string {
+	v, _ := c.Get("experiment:" + name).(string)
+	return v
+}
+
+// experimentVisitor returns the visitor ID of the request, setting the cookie
+// if it has none.
+func experimentVisitor(c echo.Context, cookieName string, maxAge int) string {
+	// The cookie set by an experiment isn't in the request yet
+	if id, ok := c.Get(experimentVisitorKey).(string); ok {
+		return id
+	}
+	id := ""
+	if cookie, err := c.Cookie(cookieName); err == nil && cookie.Value != "" {
+		id = cookie.Value
+	} else {
+		id = random.String(32)
+		c.SetCookie(&http.Cookie{
+			Name:     cookieName,
+			Value:    id,
+			Path:     "/",
+			MaxAge:   maxAge,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	c.Set(experimentVisitorKey, id)
+	return id
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExperiment(t *testing.T) {
+	e := echo.New()
+	e.Use(Experiment("button", "control", "green"))
+	e.Use(ExperimentWithConfig(ExperimentConfig{
+		Name:     "pricing",
+		Variants: []ExperimentVariant{{Name: "old", Weight: 0}, {Name: "new", Weight: 1}},
+	}))
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, ExperimentVariantFromContext(c, "button"))
+	})
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// New visitors get a single visitor ID shared by the experiments
+	rec := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+	cookies := rec.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		assert.Equal(t, "_experiment_visitor", cookies[0].Name)
+	}
+	variant := rec.Body.String()
+	assert.Contains(t, []string{"control", "green"}, variant)
+	assert.Equal(t, []string{"button=" + variant, "pricing=new"}, rec.Header().Values("X-Experiment"))
+
+	// Assignments are sticky
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookies[0])
+		rec := serve(req)
+		assert.Equal(t, variant, rec.Body.String())
+		assert.Empty(t, rec.Result().Cookies())
+	}
+
+	// Requests are split between the variants
+	counts := map[string]int{}
+	for i := 0; i < 200; i++ {
+		counts[serve(httptest.NewRequest(http.MethodGet, "/", nil)).Body.String()]++
+	}
+	assert.InDelta(t, 100, counts["control"], 40)
+	assert.InDelta(t, 100, counts["green"], 40)
+
+	assert.Panics(t, func() {
+		Experiment("empty")
+	})
+}
+
+func TestExperimentPrincipal(t *testing.T) {
+	mw := Experiment("button", "control", "green")
+	e := echo.New()
+	variants := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		c.SetPrincipal(&echo.Principal{ID: "jon"})
+		assert.NoError(t, mw(func(echo.Context) error { return nil })(c))
+		variant := ExperimentVariantFromContext(c, "button")
+		variants[variant] = true
+		assert.Equal(t, variant, c.LogFields()["experiment.button"])
+		assert.False(t, strings.Contains(c.Response().Header().Get(echo.HeaderSetCookie), "_experiment_visitor"))
+	}
+	assert.Len(t, variants, 1)
+}
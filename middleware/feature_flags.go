@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// FeatureFlagsConfig defines the config for FeatureFlags middleware.
+	FeatureFlagsConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Provider evaluates the flags.
+		// Required.
+		Provider FlagProvider
+
+		// Subject returns the subject flags are evaluated for.
+		// Optional. Default value returns the principal, see `Authn()`, and the
+		// tenant, see `MultiTenant()`, of the request.
+		Subject func(c echo.Context) FlagSubject
+	}
+
+	// FlagProvider evaluates feature flags, e.g. backed by a flag service.
+	FlagProvider interface {
+		// Flags returns the flags of the subject. Flags which aren't returned
+		// are disabled.
+		Flags(c echo.Context, subject FlagSubject) (echo.Flags, error)
+	}
+
+	// FlagSubject is what flags are evaluated for.
+	FlagSubject struct {
+		// PrincipalID is the ID of the principal of the request, if any.
+		PrincipalID string
+
+		// TenantID is the ID of the tenant of the request, if any.
+		TenantID string
+	}
+
+	// StaticFlagProvider is a `FlagProvider` with fixed flags, for tests and
+	// simple rollouts. Flags of the principal take precedence over those of
+	// the tenant, which take precedence over the defaults.
+	StaticFlagProvider struct {
+		// Defaults are the flags of all subjects.
+		Defaults echo.Flags
+
+		// Tenants are the flags by tenant ID.
+		Tenants map[string]echo.Flags
+
+		// Principals are the flags by principal ID.
+		Principals map[string]echo.Flags
+	}
+)
+
+var (
+	// DefaultFeatureFlagsConfig is the default FeatureFlags middleware config.
+	DefaultFeatureFlagsConfig = FeatureFlagsConfig{
+		Skipper: DefaultSkipper,
+		Subject: defaultFlagSubject,
+	}
+)
+
+// FeatureFlags returns a middleware evaluating the feature flags of requests
+// with `provider` and setting them on the context, see
+// `Context#FlagEnabled()`. It must be registered after the middleware setting
+// the principal and the tenant. If the provider fails, the error is logged and
+// all flags are disabled, so that features fall back to their stable version.
+//
+// Example:
+//
+//	e.Use(middleware.FeatureFlags(&middleware.StaticFlagProvider{
+//	  Defaults: echo.Flags{"new-checkout": false},
+//	  Tenants:  map[string]echo.Flags{"acme": {"new-checkout": true}},
+//	}))
+//	e.GET("/checkout", func(c echo.Context) error {
+//	  if c.FlagEnabled("new-checkout") {
+//	    ...
+//	  }
+//	})
+func FeatureFlags(provider FlagProvider) echo.MiddlewareFunc {
+	c := DefaultFeatureFlagsConfig
+	c.Provider = provider
+	return FeatureFlagsWithConfig(c)
+}
+
+// FeatureFlagsWithConfig returns a FeatureFlags middleware with config.
+// See: `FeatureFlags()`.
+func FeatureFlagsWithConfig(config FeatureFlagsConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultFeatureFlagsConfig.Skipper
+	}
+	if config.Subject == nil {
+		config.Subject = DefaultFeatureFlagsConfig.Subject
+	}
+	if config.Provider == nil {
+		panic("echo: feature-flags middleware requires a provider")
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			flags, err := config.Provider.Flags(c, config.Subject(c))
+			if err != nil {
+				c.Logger().Errorf("feature flags: %v", err)
+				flags = nil
+			}
+			c.SetFlags(flags)
+			return next(c)
+		}
+	}
+}
+
+// Flags implements `FlagProvider`.
+func (p *StaticFlagProvider) Flags(c echo.Context, subject FlagSubject) (echo.Flags, error) {
+	flags := echo.Flags{}
+	for name, on := range p.Defaults {
+		flags[name] = on
+	}
+	if subject.TenantID != "" {
+		for name, on := range p.Tenants[subject.TenantID] {
+			flags[name] = on
+		}
+	}
+	if subject.PrincipalID != "" {
+		for name, on := range p.Principals[subject.PrincipalID] {
+			flags[name] = on
+		}
+	}
+	return flags, nil
+}
+
+// defaultFlagSubject returns the principal and tenant of the request.
+func defaultFlagSubject(c echo.Context) FlagSubject {
+	var s FlagSubject
+	if p := c.Principal(); p != nil {
+		s.PrincipalID = p.ID
+	}
+	if t := TenantFromContext(c); t != nil {
+		s.TenantID = t.ID
+	}
+	return s
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingFlagProvider struct{}
+
+func (failingFlagProvider) Flags(echo.Context, FlagSubject) (echo.Flags, error) {
+	return nil, errors.New("flag service unavailable")
+}
+
+func TestFeatureFlags(t *testing.T) {
+	e := echo.New()
+	mw := FeatureFlags(&StaticFlagProvider{
+		Defaults:   echo.Flags{"new-checkout": false, "dark-mode": true},
+		Tenants:    map[string]echo.Flags{"acme": {"new-checkout": true}},
+		Principals: map[string]echo.Flags{"jon": {"new-checkout": false, "beta": true}},
+	})
+	run := func(principal, tenant string) echo.Context {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		if principal != "" {
+			c.SetPrincipal(&echo.Principal{ID: principal})
+		}
+		if tenant != "" {
+			c.Set("tenant", &Tenant{ID: tenant})
+		}
+		assert.NoError(t, mw(func(echo.Context) error { return nil })(c))
+		return c
+	}
+
+	c := run("", "")
+	assert.False(t, c.FlagEnabled("new-checkout"))
+	assert.True(t, c.FlagEnabled("dark-mode"))
+	assert.False(t, c.FlagEnabled("unknown"))
+
+	c = run("arya", "acme")
+	assert.True(t, c.FlagEnabled("new-checkout"))
+	assert.False(t, c.FlagEnabled("beta"))
+
+	c = run("jon", "acme")
+	assert.False(t, c.FlagEnabled("new-checkout"))
+	assert.True(t, c.FlagEnabled("beta"))
+	assert.Equal(t, echo.Flags{"new-checkout": false, "dark-mode": true, "beta": true}, c.Flags())
+
+	// Provider errors disable all flags
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.SetFlags(echo.Flags{"dark-mode": true})
+	assert.NoError(t, FeatureFlags(failingFlagProvider{})(func(echo.Context) error { return nil })(c))
+	assert.False(t, c.FlagEnabled("dark-mode"))
+
+	assert.Panics(t, func() {
+		FeatureFlags(nil)
+	})
+}
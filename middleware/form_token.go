@@ -0,0 +1,241 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/random"
+)
+
+type (
+	// FormTokenConfig defines the config for FormToken middleware.
+	FormTokenConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Store keeps the issued tokens until they are consumed.
+		// Optional. Default value `NewFormTokenMemoryStore()`.
+		Store FormTokenStore
+
+		// TokenLookup is a string in the form of "<source>:<key>" that is used
+		// to extract the token from the request.
+		// Optional. Default value "form:_form_token".
+		// Possible values:
+		// - "header:<name>"
+		// - "form:<name>"
+		// - "query:<name>"
+		TokenLookup string
+
+		// Expiration is how long an issued token can be submitted.
+		// Optional. Default value 1h.
+		Expiration time.Duration
+
+		// Scope returns the scope tokens are issued in and consumed from, so
+		// that a token is only accepted from the client it was issued to.
+		// Optional. Default value returns the ID of `Context#Principal()` or,
+		// for anonymous clients, a random ID kept in the `CookieName` cookie.
+		Scope func(c echo.Context) string
+
+		// CookieName is the name of the cookie identifying anonymous clients
+		// for the default scope.
+		// Optional. Default value "_form_scope".
+		CookieName string
+	}
+
+	// FormTokenStore stores the form tokens issued to clients.
+	FormTokenStore interface {
+		// Save stores the token of the scope until it expires.
+		Save(scope, token string, expires time.Time) error
+
+		// Consume removes the token of the scope and reports whether it was
+		// stored and not expired.
+		Consume(scope, token string) (bool, error)
+	}
+
+	// FormTokenMemoryStore is a `FormTokenStore` keeping tokens in memory, for
+	// applications served by a single instance.
+	FormTokenMemoryStore struct {
+		mu        sync.Mutex
+		tokens    map[string]time.Time
+		lastSweep time.Time
+	}
+
+	formTokenIssuer struct {
+		config FormTokenConfig
+		scope  string
+	}
+)
+
+const formTokenIssuerKey = "middleware.form_token"
+
+var (
+	// DefaultFormTokenConfig is the default FormToken middleware config.
+	DefaultFormTokenConfig = FormTokenConfig{
+		Skipper:     DefaultSkipper,
+		TokenLookup: "form:_form_token",
+		Expiration:  time.Hour,
+		CookieName:  "_form_scope",
+	}
+
+	// ErrFormTokenMissing is returned by `NewFormToken()` when the FormToken
+	// middleware isn't registered for the route.
+	ErrFormTokenMissing = errors.New("form token middleware not registered")
+)
+
+// FormToken returns a middleware preventing duplicate submissions of a form,
+// e.g. when a user double-clicks the purchase button. Forms embed a one-time
+// token created with `NewFormToken()`, which is consumed when the form is
+// submitted. Tokens are only accepted from the principal they were issued to
+// or, for anonymous clients, with the "_form_scope" cookie set by the
+// middleware.
+//
+// Unlike CSRF tokens, form tokens are valid once: for a request with an unsafe
+// method, it sends "400 - Bad Request" response if the token is missing and
+// "409 - Conflict" response if it was already submitted, expired or wasn't
+// issued. A form re-rendered after a failed submission needs a new token.
+func FormToken() echo.MiddlewareFunc {
+	return FormTokenWithConfig(DefaultFormTokenConfig)
+}
+
+// FormTokenWithConfig returns a FormToken middleware with config.
+// See: `FormToken()`.
+func FormTokenWithConfig(config FormTokenConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultFormTokenConfig.Skipper
+	}
+	if config.Store == nil {
+		config.Store = NewFormTokenMemoryStore()
+	}
+	if config.TokenLookup == "" {
+		config.TokenLookup = DefaultFormTokenConfig.TokenLookup
+	}
+	if config.Expiration == 0 {
+		config.Expiration = DefaultFormTokenConfig.Expiration
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultFormTokenConfig.CookieName
+	}
+	if config.Scope == nil {
+		config.Scope = clientScope(config.CookieName)
+	}
+
+	// Initialize
+	parts := strings.SplitN(config.TokenLookup, ":", 2)
+	source, key := parts[0], parts[1]
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			scope := config.Scope(c)
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			default:
+				var token string
+				switch source {
+				case "header":
+					token = c.Request().Header.Get(key)
+				case "query":
+					token = c.QueryParam(key)
+				default:
+					token = c.FormValue(key)
+				}
+				if token == "" {
+					return echo.NewHTTPError(http.StatusBadRequest, "missing form token")
+				}
+				ok, err := config.Store.Consume(scope, token)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return echo.NewHTTPError(http.StatusConflict, "duplicate form submission")
+				}
+			}
+
+			c.Set(formTokenIssuerKey, &formTokenIssuer{config: config, scope: scope})
+			return next(c)
+		}
+	}
+}
+
+// NewFormToken issues a one-time token for a form of the request, to be
+// submitted in the field of `FormTokenConfig.TokenLookup`. It returns
+// `ErrFormTokenMissing` if the FormToken middleware isn't registered.
+func NewFormToken(c echo.Context) (string, error) {
+	issuer, ok := c.Get(formTokenIssuerKey).(*formTokenIssuer)
+	if !ok {
+		return "", ErrFormTokenMissing
+	}
+	token := random.String(32)
+	expires := time.Now().Add(issuer.config.Expiration)
+	if err := issuer.config.Store.Save(issuer.scope, token, expires); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// NewFormTokenMemoryStore returns a `FormTokenMemoryStore`.
+func NewFormTokenMemoryStore() *FormTokenMemoryStore {
+	return &FormTokenMemoryStore{tokens: map[string]time.Time{}}
+}
+
+// Save implements `FormTokenStore`.
+func (s *FormTokenMemoryStore) Save(scope, token string, expires time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, exp := range s.tokens {
+			if now.After(exp) {
+				delete(s.tokens, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	s.tokens[scope+"\x00"+token] = expires
+	return nil
+}
+
+// Consume implements `FormTokenStore`.
+func (s *FormTokenMemoryStore) Consume(scope, token string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := scope + "\x00" + token
+	exp, ok := s.tokens[k]
+	if !ok {
+		return false, nil
+	}
+	delete(s.tokens, k)
+	return time.Now().Before(exp), nil
+}
+
+// clientScope returns a scope function returning the ID of the principal of
+// the request or, for anonymous clients, a random ID kept in the cookie, which
+// is set if missing.
+func clientScope(cookie string) func(c echo.Context) string {
+	return func(c echo.Context) string {
+		if p := c.Principal(); p != nil {
+			return "principal:" + p.ID
+		}
+		if k, err := c.Cookie(cookie); err == nil && k.Value != "" {
+			return "client:" + k.Value
+		}
+		id := random.String(32)
+		c.SetCookie(&http.Cookie{
+			Name:     cookie,
+			Value:    id,
+			Path:     "/",
+			Secure:   c.IsTLS(),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+		return "client:" + id
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormToken(t *testing.T) {
+	e := echo.New()
+	store := NewFormTokenMemoryStore()
+	mw := FormTokenWithConfig(FormTokenConfig{
+		Store: store,
+		Scope: func(c echo.Context) string { return c.Request().Header.Get("X-User") },
+	})
+	e.GET("/checkout", func(c echo.Context) error {
+		token, err := NewFormToken(c)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, token)
+	}, mw)
+	e.POST("/checkout", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	}, mw)
+	submit := func(user, token string) int {
+		form := url.Values{"_form_token": {token}}
+		req := httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		req.Header.Set("X-User", user)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+	req.Header.Set("X-User", "jon")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	token := rec.Body.String()
+	assert.Len(t, token, 32)
+
+	assert.Equal(t, http.StatusBadRequest, submit("jon", ""))
+	assert.Equal(t, http.StatusConflict, submit("ann", token), "other scope")
+	assert.Equal(t, http.StatusCreated, submit("jon", token))
+	assert.Equal(t, http.StatusConflict, submit("jon", token), "duplicate")
+
+	// Expired
+	store.Save("jon", "expired", time.Now().Add(-time.Second))
+	assert.Equal(t, http.StatusConflict, submit("jon", "expired"))
+
+	// Middleware not registered
+	_, err := NewFormToken(e.NewContext(req, rec))
+	assert.Equal(t, ErrFormTokenMissing, err)
+}
+
+func TestFormTokenDefaultScope(t *testing.T) {
+	e := echo.New()
+	mw := FormToken()
+	e.GET("/checkout", func(c echo.Context) error {
+		token, err := NewFormToken(c)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, token)
+	}, mw)
+	e.POST("/checkout", func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	}, mw)
+	submit := func(cookie *http.Cookie, token string) int {
+		form := url.Values{"_form_token": {token}}
+		req := httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(form.Encode()))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/checkout", nil))
+	token := rec.Body.String()
+	cookies := rec.Result().Cookies()
+	if !assert.Len(t, cookies, 1) {
+		return
+	}
+	assert.Equal(t, "_form_scope", cookies[0].Name)
+	assert.True(t, cookies[0].HttpOnly)
+
+	// Anonymous clients don't share tokens
+	assert.Equal(t, http.StatusConflict, submit(nil, token))
+	assert.Equal(t, http.StatusConflict, submit(&http.Cookie{Name: "_form_scope", Value: "other"}, token))
+	assert.Equal(t, http.StatusCreated, submit(cookies[0], token))
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// GeoIPConfig defines the config for GeoIP middleware.
+	GeoIPConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Resolver looks up the location of the client IPs.
+		// Required.
+		Resolver GeoIPResolver
+
+		// AllowCountries lists the countries (ISO 3166-1 alpha-2 codes, e.g.
+		// "DE") requests are allowed from. Requests from other countries, and
+		// from IPs without country, get a "403 - Forbidden" response.
+		// Optional. By default requests from all countries are allowed.
+		AllowCountries []string `yaml:"allow_countries"`
+
+		// DenyCountries lists the countries requests get a "403 - Forbidden"
+		// response from.
+		// Optional.
+		DenyCountries []string `yaml:"deny_countries"`
+
+		// ContextKey is the key the location is stored under in the context.
+		// Optional. Default value "geo".
+		ContextKey string `yaml:"context_key"`
+	}
+
+	// GeoIPResolver looks up the location of IPs, e.g. in a MaxMind database.
+	//
+	// Example adapting a `geoip2.Reader`:
+	//
+	//	middleware.GeoIPResolverFunc(func(ip net.IP) (*middleware.GeoLocation, error) {
+	//	  city, err := cityDB.City(ip)
+	//	  if err != nil {
+	//	    return nil, err
+	//	  }
+	//	  asn, err := asnDB.ASN(ip)
+	//	  if err != nil {
+	//	    return nil, err
+	//	  }
+	//	  loc := &middleware.GeoLocation{
+	//	    Country:      city.Country.IsoCode,
+	//	    ASN:          asn.AutonomousSystemNumber,
+	//	    Organization: asn.AutonomousSystemOrganization,
+	//	  }
+	//	  if len(city.Subdivisions) > 0 {
+	//	    loc.Region = city.Subdivisions[0].IsoCode
+	//	  }
+	//	  return loc, nil
+	//	})
+	GeoIPResolver interface {
+		// Lookup returns the location of the IP, or nil if it is unknown.
+		Lookup(ip net.IP) (*GeoLocation, error)
+	}
+
+	// GeoIPResolverFunc is an adapter to use functions as resolvers.
+	GeoIPResolverFunc func(ip net.IP) (*GeoLocation, error)
+
+	// GeoLocation is the location of an IP.
+	GeoLocation struct {
+		// Country is the ISO 3166-1 alpha-2 code of the country, e.g. "DE".
+		Country string `json:"country,omitempty"`
+
+		// Region is the code of the region, e.g. "BE" for Berlin.
+		Region string `json:"region,omitempty"`
+
+		// ASN is the number of the autonomous system.
+		ASN uint `json:"asn,omitempty"`
+
+		// Organization is the organization of the autonomous system.
+		Organization string `json:"organization,omitempty"`
+	}
+)
+
+var (
+	// DefaultGeoIPConfig is the default GeoIP middleware config.
+	DefaultGeoIPConfig = GeoIPConfig{
+		Skipper:    DefaultSkipper,
+		ContextKey: "geo",
+	}
+)
+
+// Lookup implements `GeoIPResolver`.
+func (fn GeoIPResolverFunc) Lookup(ip net.IP) (*GeoLocation, error) {
+	return fn(ip)
+}
+
+// GeoIP returns a middleware looking up the location of the client IP (see
+// `Context#RealIP()`) with `resolver`. The location is stored in the context,
+// see `GeoFromContext()`, and its country, region and ASN are added to the
+// request log fields as "geo_country", "geo_region" and "geo_asn". Lookup
+// errors are logged and the location is unknown.
+func GeoIP(resolver GeoIPResolver) echo.MiddlewareFunc {
+	c := DefaultGeoIPConfig
+	c.Resolver = resolver
+	return GeoIPWithConfig(c)
+}
+
+// GeoIPWithConfig returns a GeoIP middleware with config.
+// See: `GeoIP()`.
+func GeoIPWithConfig(config GeoIPConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultGeoIPConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultGeoIPConfig.ContextKey
+	}
+	if config.Resolver == nil {
+		panic("echo: geoip middleware requires a resolver")
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			var loc *GeoLocation
+			if ip := net.ParseIP(c.RealIP()); ip != nil {
+				var err error
+				if loc, err = config.Resolver.Lookup(ip); err != nil {
+					c.Logger().Errorf("geoip: %v", err)
+					loc = nil
+				}
+			}
+			country := ""
+			if loc != nil {
+				country = strings.ToUpper(loc.Country)
+				c.Set(config.ContextKey, loc)
+				c.LogFields("geo_country", loc.Country, "geo_region", loc.Region, "geo_asn", loc.ASN)
+			}
+
+			if len(config.AllowCountries) > 0 && !containsCountry(config.AllowCountries, country) {
+				return echo.ErrForbidden
+			}
+			if country != "" && containsCountry(config.DenyCountries, country) {
+				return echo.ErrForbidden
+			}
+			return next(c)
+		}
+	}
+}
+
+// GeoFromContext returns the location stored by the GeoIP middleware
+// configured with the default context key, or nil.
+func GeoFromContext(c echo.Context) *GeoLocation {
+	loc, _ := c.Get(DefaultGeoIPConfig.ContextKey).(*GeoLocation)
+	return loc
+}
+
+func containsCountry(countries []string, country string) bool {
+	if country == "" {
+		return false
+	}
+	for _, c := range countries {
+		if strings.EqualFold(c, country) {
+			return true
+		}
+	}
+	return false
+}
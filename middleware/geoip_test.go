@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoIP(t *testing.T) {
+	resolver := GeoIPResolverFunc(func(ip net.IP) (*GeoLocation, error) {
+		switch ip.String() {
+		case "1.1.1.1":
+			return &GeoLocation{Country: "DE", Region: "BE", ASN: 3320, Organization: "Telekom"}, nil
+		case "2.2.2.2":
+			return &GeoLocation{Country: "fr"}, nil
+		case "3.3.3.3":
+			return nil, errors.New("database closed")
+		}
+		return nil, nil
+	})
+	e := echo.New()
+	run := func(mw echo.MiddlewareFunc, ip string) (echo.Context, error) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		c := e.NewContext(req, httptest.NewRecorder())
+		return c, mw(func(echo.Context) error { return nil })(c)
+	}
+
+	c, err := run(GeoIP(resolver), "1.1.1.1")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &GeoLocation{Country: "DE", Region: "BE", ASN: 3320, Organization: "Telekom"}, GeoFromContext(c))
+		assert.Equal(t, "DE", c.LogFields()["geo_country"])
+		assert.Equal(t, uint(3320), c.LogFields()["geo_asn"])
+	}
+	c, err = run(GeoIP(resolver), "3.3.3.3")
+	assert.NoError(t, err)
+	assert.Nil(t, GeoFromContext(c))
+
+	// Allow and deny rules
+	allow := GeoIPWithConfig(GeoIPConfig{Resolver: resolver, AllowCountries: []string{"de", "FR"}})
+	_, err = run(allow, "1.1.1.1")
+	assert.NoError(t, err)
+	_, err = run(allow, "2.2.2.2")
+	assert.NoError(t, err)
+	_, err = run(allow, "4.4.4.4")
+	assert.Equal(t, echo.ErrForbidden, err)
+
+	deny := GeoIPWithConfig(GeoIPConfig{Resolver: resolver, DenyCountries: []string{"FR"}})
+	_, err = run(deny, "2.2.2.2")
+	assert.Equal(t, echo.ErrForbidden, err)
+	_, err = run(deny, "4.4.4.4")
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() {
+		GeoIP(nil)
+	})
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// HardenConfig defines the config for Harden middleware.
+	HardenConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// SingleHeaders lists the headers a request must not have more than once.
+		// Headers net/http moves out of the header, e.g. "Host", can't be
+		// checked.
+		// Optional. Default value `DefaultSingleHeaders`.
+		SingleHeaders []string
+
+		// MaxHeaders is the maximum number of header values of a request.
+		// Optional. Default value 100.
+		MaxHeaders int
+
+		// MaxHeaderSize is the maximum size of a header value.
+		// Optional. Default value 8KB.
+		MaxHeaderSize int
+
+		// AllowControlChars allows control characters other than horizontal tab
+		// in header values and in the decoded path.
+		// Optional. Default value false.
+		AllowControlChars bool
+
+		// ReportOnly logs the violations instead of rejecting requests, e.g. to
+		// evaluate the config on production traffic.
+		// Optional. Default value false.
+		ReportOnly bool
+	}
+)
+
+var (
+	// DefaultSingleHeaders are the headers a request must not have more than
+	// once by default, as proxies may disagree on which value to use. "Host"
+	// isn't listed: net/http moves it out of the header, rejecting HTTP/1.x
+	// requests with several Host headers itself.
+	DefaultSingleHeaders = []string{
+		echo.HeaderAuthorization,
+		echo.HeaderContentLength,
+		echo.HeaderContentType,
+		echo.HeaderOrigin,
+		"Transfer-Encoding",
+		"X-Forwarded-Host",
+		echo.HeaderXForwardedProto,
+		echo.HeaderXRealIP,
+	}
+
+	// DefaultHardenConfig is the default Harden middleware config.
+	DefaultHardenConfig = HardenConfig{
+		Skipper:       DefaultSkipper,
+		SingleHeaders: DefaultSingleHeaders,
+		MaxHeaders:    100,
+		MaxHeaderSize: 8 << 10,
+	}
+)
+
+// Harden returns a middleware rejecting requests which proxies in front of the
+// server may interpret differently, a source of request smuggling:
+//
+// - requests with both "Transfer-Encoding" and "Content-Length", several or
+// malformed "Content-Length" values or a transfer coding other than "chunked"
+// - requests with several values of the headers in `DefaultSingleHeaders`
+// - requests with too many or too large headers
+// - requests with control characters in header values or in the path
+//
+// For rejected requests, it sends "400 - Bad Request" response and closes the
+// connection.
+func Harden() echo.MiddlewareFunc {
+	return HardenWithConfig(DefaultHardenConfig)
+}
+
+// HardenWithConfig returns a Harden middleware with config.
+// See: `Harden()`.
+func HardenWithConfig(config HardenConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultHardenConfig.Skipper
+	}
+	if config.SingleHeaders == nil {
+		config.SingleHeaders = DefaultHardenConfig.SingleHeaders
+	}
+	if config.MaxHeaders == 0 {
+		config.MaxHeaders = DefaultHardenConfig.MaxHeaders
+	}
+	if config.MaxHeaderSize == 0 {
+		config.MaxHeaderSize = DefaultHardenConfig.MaxHeaderSize
+	}
+	single := make([]string, len(config.SingleHeaders))
+	for i, h := range config.SingleHeaders {
+		single[i] = http.CanonicalHeaderKey(h)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			reason := checkRequest(c.Request(), config, single)
+			if reason == "" {
+				return next(c)
+			}
+			if config.ReportOnly {
+				c.Logger().Warnf("harden: %s", reason)
+				return next(c)
+			}
+			c.Response().Header().Set(echo.HeaderConnection, "close")
+			return echo.NewHTTPError(http.StatusBadRequest, "malformed request").
+				SetInternal(fmt.Errorf("harden: %s", reason))
+		}
+	}
+}
+
+// checkRequest returns the reason to reject the request, or "".
+func checkRequest(req *http.Request, config HardenConfig, single []string) string {
+	// Message length
+	// net/http moves "Transfer-Encoding" out of the header, other servers may not
+	te := append([]string(nil), req.TransferEncoding...)
+	if v, ok := req.Header["Transfer-Encoding"]; ok {
+		te = append(te, v...)
+	}
+	cl, hasCL := req.Header[echo.HeaderContentLength]
+	if len(te) > 0 && hasCL {
+		return "both Transfer-Encoding and Content-Length"
+	}
+	for _, coding := range te {
+		if !strings.EqualFold(strings.TrimSpace(coding), "chunked") {
+			return fmt.Sprintf("unsupported transfer coding %q", coding)
+		}
+	}
+	if len(cl) > 1 {
+		return "multiple Content-Length"
+	}
+	if hasCL && !isDigits(cl[0]) {
+		return "malformed Content-Length"
+	}
+
+	// Headers
+	for _, h := range single {
+		if len(req.Header[h]) > 1 {
+			return "multiple " + h
+		}
+	}
+	n := 0
+	for name, values := range req.Header {
+		n += len(values)
+		for _, v := range values {
+			if len(v) > config.MaxHeaderSize {
+				return "header " + name + " too large"
+			}
+			if !config.AllowControlChars && hasControlChars(v) {
+				return "control characters in header " + name
+			}
+		}
+	}
+	if n > config.MaxHeaders {
+		return "too many headers"
+	}
+	if !config.AllowControlChars && hasControlChars(req.URL.Path) {
+		return "control characters in path"
+	}
+	return ""
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hasControlChars reports whether s contains ASCII control characters other
+// than horizontal tab.
+func hasControlChars(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if b := s[i]; b < 0x20 && b != '\t' || b == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/gommon/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHarden(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(req *http.Request)
+		config HardenConfig
+		reject bool
+	}{
+		{name: "valid", setup: func(req *http.Request) {
+			req.Header.Set(echo.HeaderContentLength, "0")
+			req.Header.Add("Accept", "text/html")
+			req.Header.Add("Accept", "application/json")
+		}},
+		{name: "transfer-encoding and content-length", reject: true, setup: func(req *http.Request) {
+			req.TransferEncoding = []string{"chunked"}
+			req.Header.Set(echo.HeaderContentLength, "10")
+		}},
+		{name: "transfer-encoding header and content-length", reject: true, setup: func(req *http.Request) {
+			req.Header.Set("Transfer-Encoding", "chunked")
+			req.Header.Set(echo.HeaderContentLength, "10")
+		}},
+		{name: "unsupported transfer coding", reject: true, setup: func(req *http.Request) {
+			req.Header.Set("Transfer-Encoding", "chunked, identity")
+		}},
+		{name: "multiple content-length", reject: true, setup: func(req *http.Request) {
+			req.Header.Add(echo.HeaderContentLength, "10")
+			req.Header.Add(echo.HeaderContentLength, "10")
+		}},
+		{name: "malformed content-length", reject: true, setup: func(req *http.Request) {
+			req.Header.Set(echo.HeaderContentLength, "+10")
+		}},
+		{name: "multiple forwarded host", reject: true, setup: func(req *http.Request) {
+			req.Header.Add("X-Forwarded-Host", "a.com")
+			req.Header.Add("X-Forwarded-Host", "b.com")
+		}},
+		{name: "custom single header", reject: true, config: HardenConfig{SingleHeaders: []string{"x-tenant"}}, setup: func(req *http.Request) {
+			req.Header.Add("X-Tenant", "a")
+			req.Header.Add("X-Tenant", "b")
+		}},
+		{name: "too large header", reject: true, setup: func(req *http.Request) {
+			req.Header.Set("X-Large", strings.Repeat("a", 8<<10+1))
+		}},
+		{name: "too many headers", reject: true, config: HardenConfig{MaxHeaders: 2}, setup: func(req *http.Request) {
+			req.Header.Set("X-A", "a")
+			req.Header.Set("X-B", "b")
+			req.Header.Set("X-C", "c")
+		}},
+		{name: "control characters in header", reject: true, setup: func(req *http.Request) {
+			req.Header.Set("X-A", "a\x00b")
+		}},
+		{name: "control characters in path", reject: true, setup: func(req *http.Request) {
+			req.URL.Path = "/a\nb"
+		}},
+		{name: "allowed control characters", config: HardenConfig{AllowControlChars: true}, setup: func(req *http.Request) {
+			req.URL.Path = "/a\x01b"
+		}},
+		{name: "report only", config: HardenConfig{ReportOnly: true}, setup: func(req *http.Request) {
+			req.Header.Set("X-A", "a\x00b")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			buf := new(bytes.Buffer)
+			e.Logger.SetOutput(buf)
+			e.Logger.SetLevel(log.WARN)
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			tt.setup(req)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			h := HardenWithConfig(tt.config)(func(c echo.Context) error {
+				return c.NoContent(http.StatusNoContent)
+			})
+
+			err := h(c)
+			if tt.reject {
+				he, ok := err.(*echo.HTTPError)
+				if assert.True(t, ok) {
+					assert.Equal(t, http.StatusBadRequest, he.Code)
+				}
+				assert.Equal(t, "close", rec.Header().Get(echo.HeaderConnection))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			if tt.config.ReportOnly {
+				assert.Contains(t, buf.String(), "control characters in header X-A")
+			}
+		})
+	}
+}
+
+func TestHardenConn(t *testing.T) {
+	e := echo.New()
+	e.Use(Harden())
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	s := httptest.NewServer(e)
+	defer s.Close()
+
+	send := func(raw string) int {
+		conn, err := net.Dial("tcp", s.Listener.Addr().String())
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		defer conn.Close()
+		_, err = conn.Write([]byte(raw))
+		assert.NoError(t, err)
+		res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if !assert.NoError(t, err) {
+			return 0
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+	assert.Equal(t, http.StatusNoContent, send("GET / HTTP/1.1\r\nHost: a.com\r\n\r\n"))
+	assert.Equal(t, http.StatusBadRequest, send("GET / HTTP/1.1\r\nHost: a.com\r\nX-Forwarded-Host: a.com\r\nX-Forwarded-Host: b.com\r\n\r\n"))
+	// Rejected by net/http before the middleware runs
+	assert.Equal(t, http.StatusBadRequest, send("GET / HTTP/1.1\r\nHost: a.com\r\nHost: b.com\r\n\r\n"))
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// LocaleConfig defines the config for Locale middleware.
+	LocaleConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Locales lists the supported locales, e.g. []string{"en", "fr", "de-CH"}.
+		// The first one is the default locale.
+		// Required.
+		Locales []string
+
+		// Param is the name of the locale param prefixing localized routes, e.g.
+		// "/:locale/products/:id".
+		// Optional. Default value "locale".
+		Param string
+
+		// ContextKey is the key the locale of the request is stored under.
+		// Optional. Default value "locale".
+		ContextKey string
+
+		// RedirectCode is the status code of the redirects of unprefixed paths.
+		// Optional. Default value http.StatusFound.
+		RedirectCode int
+	}
+)
+
+var (
+	// DefaultLocaleConfig is the default Locale middleware config.
+	DefaultLocaleConfig = LocaleConfig{
+		Skipper:      DefaultSkipper,
+		Param:        "locale",
+		ContextKey:   "locale",
+		RedirectCode: http.StatusFound,
+	}
+)
+
+// Locale returns a middleware for routes prefixed with a locale param, e.g.
+// "/:locale/products/:id", registered with `Echo#Use()`:
+//
+// - requests to localized routes with a supported locale get it stored in the
+// context under "locale", the others a "404 - Not Found" response
+// - `GET` and `HEAD` requests to an unprefixed path of a localized route, e.g.
+// "/products/1", are redirected to the path prefixed with the locale preferred
+// by the "Accept-Language" header, or the default locale
+func Locale(locales ...string) echo.MiddlewareFunc {
+	c := DefaultLocaleConfig
+	c.Locales = locales
+	return LocaleWithConfig(c)
+}
+
+// LocaleWithConfig returns a Locale middleware with config.
+// See: `Locale()`.
+func LocaleWithConfig(config LocaleConfig) echo.MiddlewareFunc {
+	// Defaults
+	if len(config.Locales) == 0 {
+		panic("echo: locale middleware requires locales")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultLocaleConfig.Skipper
+	}
+	if config.Param == "" {
+		config.Param = DefaultLocaleConfig.Param
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultLocaleConfig.ContextKey
+	}
+	if config.RedirectCode == 0 {
+		config.RedirectCode = DefaultLocaleConfig.RedirectCode
+	}
+	prefix := "/:" + config.Param
+	notFound := runtime.FuncForPC(reflect.ValueOf(echo.NotFoundHandler).Pointer()).Name()
+	// localized reports whether the route is a localized route
+	localized := func(r *echo.Route) bool {
+		return r != nil && r.Name != notFound &&
+			(r.Path == prefix || strings.HasPrefix(r.Path, prefix+"/"))
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			e := c.Echo()
+			if localized(e.MatchedRoute(c)) {
+				v := c.Param(config.Param)
+				for _, l := range config.Locales {
+					if strings.EqualFold(v, l) {
+						c.Set(config.ContextKey, l)
+						return next(c)
+					}
+				}
+			}
+
+			// Redirect unprefixed paths of localized routes
+			req := c.Request()
+			if req.Method == http.MethodGet || req.Method == http.MethodHead {
+				// Matched as `ServeHTTP()` would match the prefixed path
+				lr := new(http.Request)
+				*lr = *req
+				u := *req.URL
+				u.Path = "/" + config.Locales[0] + strings.TrimSuffix(u.Path, "/")
+				if u.RawPath != "" {
+					u.RawPath = "/" + config.Locales[0] + strings.TrimSuffix(u.RawPath, "/")
+				}
+				lr.URL = &u
+				if localized(e.FindRoute(lr)) {
+					locale := c.NegotiateLanguage(config.Locales...)
+					if locale == "" {
+						locale = config.Locales[0]
+					}
+					path := "/" + locale + strings.TrimSuffix(req.URL.EscapedPath(), "/")
+					if req.URL.RawQuery != "" {
+						path += "?" + req.URL.RawQuery
+					}
+					return c.Redirect(config.RedirectCode, path)
+				}
+			}
+			if localized(e.MatchedRoute(c)) {
+				return echo.ErrNotFound
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocale(t *testing.T) {
+	e := echo.New()
+	e.Use(Locale("en", "fr", "de-CH"))
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Get("locale").(string)+" "+c.Param("id"))
+	}
+	e.GET("/:locale", h)
+	e.GET("/:locale/products/:id", h)
+	e.GET("/health", func(c echo.Context) error {
+		return c.String(http.StatusOK, "ok")
+	})
+
+	tests := []struct {
+		method         string
+		path           string
+		acceptLanguage string
+		code           int
+		body           string
+		location       string
+	}{
+		{method: http.MethodGet, path: "/fr/products/1", code: http.StatusOK, body: "fr 1"},
+		{method: http.MethodGet, path: "/DE-ch/products/1", code: http.StatusOK, body: "de-CH 1"},
+		{method: http.MethodGet, path: "/en", code: http.StatusOK, body: "en "},
+		{method: http.MethodGet, path: "/xx/products/1", code: http.StatusNotFound},
+		{method: http.MethodGet, path: "/products/1?q=a", acceptLanguage: "de-CH, fr;q=0.5", code: http.StatusFound, location: "/de-CH/products/1?q=a"},
+		{method: http.MethodGet, path: "/products/1", acceptLanguage: "es", code: http.StatusFound, location: "/en/products/1"},
+		{method: http.MethodGet, path: "/", acceptLanguage: "fr", code: http.StatusFound, location: "/fr"},
+		{method: http.MethodPost, path: "/products/1", code: http.StatusNotFound},
+		{method: http.MethodGet, path: "/health", code: http.StatusOK, body: "ok"},
+		{method: http.MethodGet, path: "/missing/a/b", code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		req.Header.Set(echo.HeaderAcceptLanguage, tt.acceptLanguage)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tt.code, rec.Code, tt.path)
+		if tt.body != "" {
+			assert.Equal(t, tt.body, rec.Body.String(), tt.path)
+		}
+		assert.Equal(t, tt.location, rec.Header().Get(echo.HeaderLocation), tt.path)
+	}
+
+	assert.Panics(t, func() { Locale() })
+}
+
+func TestLocaleRouting(t *testing.T) {
+	e := echo.New()
+	e.PathMatch = echo.PathMatchRaw
+	e.Use(Locale("en", "fr"))
+	h := func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Param("name"))
+	}
+	e.GET("/:locale/files/:name", h)
+	e.Host("shop.example.com").GET("/:locale/cart", h)
+
+	tests := []struct {
+		target   string
+		code     int
+		location string
+	}{
+		{target: "/files/a%2Fb", code: http.StatusFound, location: "/en/files/a%2Fb"},
+		{target: "/en/files/a%2Fb", code: http.StatusOK},
+		{target: "http://shop.example.com/cart", code: http.StatusFound, location: "/en/cart"},
+		{target: "/cart", code: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+		assert.Equal(t, tt.code, rec.Code, tt.target)
+		assert.Equal(t, tt.location, rec.Header().Get(echo.HeaderLocation), tt.target)
+	}
+}
@@ -3,7 +3,9 @@ package middleware
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -45,6 +47,8 @@ type (
 		// - header:<NAME>
 		// - query:<NAME>
 		// - form:<NAME>
+		// - fields (Fields attached with `Context#LogFields()` as JSON members, each preceded by a comma)
+		// - field:<NAME> (Field attached with `Context#LogFields()`)
 		//
 		// Example "${remote_ip} ${status}"
 		//
@@ -58,6 +62,11 @@ type (
 		// Optional. Default value os.Stdout.
 		Output io.Writer
 
+		// Redact defines the headers, query and form parameters and members of
+		// the log fields replaced in the logs, see `DefaultRedaction`.
+		// Optional. By default nothing is redacted.
+		Redact Redaction `yaml:"redact"`
+
 		template *fasttemplate.Template
 		colorer  *color.Color
 		pool     *sync.Pool
@@ -71,7 +80,7 @@ var (
 		Format: `{"time":"${time_rfc3339_nano}","id":"${id}","remote_ip":"${remote_ip}",` +
 			`"host":"${host}","method":"${method}","uri":"${uri}","user_agent":"${user_agent}",` +
 			`"status":${status},"error":"${error}","latency":${latency},"latency_human":"${latency_human}"` +
-			`,"bytes_in":${bytes_in},"bytes_out":${bytes_out}}` + "\n",
+			`,"bytes_in":${bytes_in},"bytes_out":${bytes_out}${fields}}` + "\n",
 		CustomTimeFormat: "2006-01-02 15:04:05.00000",
 		colorer:          color.New(),
 	}
@@ -145,7 +154,7 @@ func LoggerWithConfig(config LoggerConfig) echo.MiddlewareFunc {
 				case "host":
 					return buf.WriteString(req.Host)
 				case "uri":
-					return buf.WriteString(req.RequestURI)
+					return buf.WriteString(config.Redact.URI(req.RequestURI))
 				case "method":
 					return buf.WriteString(req.Method)
 				case "path":
@@ -157,7 +166,7 @@ func LoggerWithConfig(config LoggerConfig) echo.MiddlewareFunc {
 				case "protocol":
 					return buf.WriteString(req.Proto)
 				case "referer":
-					return buf.WriteString(req.Referer())
+					return buf.WriteString(config.Redact.URI(req.Referer()))
 				case "user_agent":
 					return buf.WriteString(req.UserAgent())
 				case "status":
@@ -192,18 +201,44 @@ func LoggerWithConfig(config LoggerConfig) echo.MiddlewareFunc {
 					return buf.WriteString(cl)
 				case "bytes_out":
 					return buf.WriteString(strconv.FormatInt(res.Size, 10))
+				case "fields":
+					fields := c.LogFields()
+					keys := make([]string, 0, len(fields))
+					for k := range fields {
+						keys = append(keys, k)
+					}
+					sort.Strings(keys)
+					for _, k := range keys {
+						b, jerr := json.Marshal(fields[k])
+						if jerr != nil {
+							continue
+						}
+						b = config.Redact.field(k, b)
+						kb, _ := json.Marshal(k)
+						buf.WriteByte(',')
+						buf.Write(kb)
+						buf.WriteByte(':')
+						buf.Write(b)
+					}
 				default:
 					switch {
 					case strings.HasPrefix(tag, "header:"):
-						return buf.Write([]byte(c.Request().Header.Get(tag[7:])))
+						return buf.Write([]byte(config.Redact.Header(tag[7:], c.Request().Header.Get(tag[7:]))))
 					case strings.HasPrefix(tag, "query:"):
-						return buf.Write([]byte(c.QueryParam(tag[6:])))
+						return buf.Write([]byte(config.Redact.Param(tag[6:], c.QueryParam(tag[6:]))))
 					case strings.HasPrefix(tag, "form:"):
-						return buf.Write([]byte(c.FormValue(tag[5:])))
+						return buf.Write([]byte(config.Redact.Param(tag[5:], c.FormValue(tag[5:]))))
+					case strings.HasPrefix(tag, "field:"):
+						if v, ok := c.LogFields()[tag[6:]]; ok {
+							if config.Redact.redactsField(tag[6:]) {
+								v = config.Redact.replacement()
+							}
+							return fmt.Fprint(buf, v)
+						}
 					case strings.HasPrefix(tag, "cookie:"):
 						cookie, err := c.Cookie(tag[7:])
 						if err == nil {
-							return buf.Write([]byte(cookie.Value))
+							return buf.Write([]byte(config.Redact.Header(echo.HeaderCookie, cookie.Value)))
 						}
 					}
 				}
@@ -171,3 +171,59 @@ func TestLoggerCustomTimestamp(t *testing.T) {
 	_, err := time.Parse(customTimeFormat, loggedTime)
 	assert.Error(t, err)
 }
+
+func TestLoggerFields(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	e.Use(LoggerWithConfig(LoggerConfig{
+		Format: `{"status":${status}${fields}} ${field:user}`,
+		Output: buf,
+	}))
+	e.GET("/", func(c echo.Context) error {
+		c.LogFields("user", "jon", "attempts", 2)
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, `{"status":200,"attempts":2,"user":"jon"} jon`, buf.String())
+
+	// Default format stays valid JSON
+	buf.Reset()
+	e = echo.New()
+	e.Use(LoggerWithConfig(LoggerConfig{Output: buf}))
+	e.GET("/", func(c echo.Context) error {
+		c.LogFields("user", "jon")
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	var entry map[string]interface{}
+	if assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry)) {
+		assert.Equal(t, "jon", entry["user"])
+	}
+}
+
+func TestLoggerRedact(t *testing.T) {
+	e := echo.New()
+	buf := new(bytes.Buffer)
+	e.Use(LoggerWithConfig(LoggerConfig{
+		Format: `${uri} ${header:Authorization} ${query:token} ${cookie:session} ${field:password}${fields}`,
+		Output: buf,
+		Redact: Redaction{
+			Headers:   []string{"Authorization", "Cookie"},
+			Params:    []string{"token"},
+			JSONPaths: []string{"password", "user.email"},
+		},
+	}))
+	e.GET("/", func(c echo.Context) error {
+		c.LogFields("password", "secret", "user", echo.Map{"id": 1, "email": "jon@example.com"})
+		return c.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/?token=secret&page=1", nil)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "secret"})
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, `/?token=[REDACTED]&page=1 [REDACTED] [REDACTED] [REDACTED] [REDACTED],"password":"[REDACTED]","user":{"email":"[REDACTED]","id":1}`, buf.String())
+}
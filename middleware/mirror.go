@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// MirrorConfig defines the config for Mirror middleware.
+	MirrorConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Target is the upstream requests are mirrored to, e.g. a new version
+		// of the service.
+		// Required.
+		Target *url.URL
+
+		// Percentage is the percentage of requests mirrored, from 0 to 100.
+		// Optional. Default value 100.
+		Percentage float64
+
+		// Timeout is the timeout of mirrored requests.
+		// Optional. Default value 5s.
+		Timeout time.Duration
+
+		// MaxInFlight is the maximum number of mirrored requests in flight.
+		// Requests aren't mirrored while the target is that far behind.
+		// Optional. Default value 100.
+		MaxInFlight int
+
+		// Transport sends the mirrored requests.
+		// Optional. Default value `http.DefaultTransport`.
+		Transport http.RoundTripper
+
+		// OnError is called when a mirrored request fails.
+		// Optional.
+		OnError func(req *http.Request, err error)
+	}
+)
+
+var (
+	// DefaultMirrorConfig is the default Mirror middleware config.
+	DefaultMirrorConfig = MirrorConfig{
+		Skipper:     DefaultSkipper,
+		Percentage:  100,
+		Timeout:     5 * time.Second,
+		MaxInFlight: 100,
+	}
+)
+
+// Mirror returns a middleware mirroring requests to `target`, e.g. to test a
+// new version of a service with production traffic. Mirrored requests are
+// sent in the background, after the request is read and without delaying the
+// response, and their responses are discarded. Requests whose body exceeds
+// `Echo#BodyCaptureLimit` aren't mirrored.
+func Mirror(target *url.URL) echo.MiddlewareFunc {
+	c := DefaultMirrorConfig
+	c.Target = target
+	return MirrorWithConfig(c)
+}
+
+// MirrorWithConfig returns a Mirror middleware with config.
+// See: `Mirror()`.
+func MirrorWithConfig(config MirrorConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultMirrorConfig.Skipper
+	}
+	if config.Percentage == 0 {
+		config.Percentage = DefaultMirrorConfig.Percentage
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultMirrorConfig.Timeout
+	}
+	if config.MaxInFlight == 0 {
+		config.MaxInFlight = DefaultMirrorConfig.MaxInFlight
+	}
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+	if config.Target == nil {
+		panic("echo: mirror middleware requires a target")
+	}
+
+	inFlight := make(chan struct{}, config.MaxInFlight)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) || rand.Float64()*100 >= config.Percentage {
+				return next(c)
+			}
+			body, err := c.Body()
+			if err != nil {
+				return next(c)
+			}
+
+			select {
+			case inFlight <- struct{}{}:
+			default:
+				return next(c)
+			}
+			req := mirrorRequest(c.Request(), config.Target, body, c.RealIP())
+			go func() {
+				defer func() { <-inFlight }()
+				ctx, cancel := context.WithTimeout(context.Background(), config.Timeout)
+				defer cancel()
+				res, err := config.Transport.RoundTrip(req.WithContext(ctx))
+				if err != nil {
+					if config.OnError != nil {
+						config.OnError(req, err)
+					}
+					return
+				}
+				io.Copy(ioutil.Discard, res.Body)
+				res.Body.Close()
+			}()
+			return next(c)
+		}
+	}
+}
+
+// mirrorRequest returns a copy of the request to the target.
+func mirrorRequest(r *http.Request, target *url.URL, body []byte, realIP string) *http.Request {
+	u := *r.URL
+	u.Scheme = target.Scheme
+	u.Host = target.Host
+	u.Path = strings.TrimSuffix(target.Path, "/") + r.URL.Path
+	if r.URL.RawPath != "" {
+		u.RawPath = strings.TrimSuffix(target.EscapedPath(), "/") + r.URL.RawPath
+	}
+	req := &http.Request{
+		Method:        r.Method,
+		URL:           &u,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        r.Header.Clone(),
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Host:          target.Host,
+	}
+	if len(body) == 0 {
+		req.Body = http.NoBody
+	}
+	req.Header.Set(echo.HeaderXForwardedFor, realIP)
+	return req
+}
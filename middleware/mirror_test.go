@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMirror(t *testing.T) {
+	type mirrored struct {
+		method, uri, body, header string
+	}
+	received := make(chan mirrored, 10)
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		received <- mirrored{r.Method, r.RequestURI, string(b), r.Header.Get("X-Custom")}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer shadow.Close()
+	target, _ := url.Parse(shadow.URL + "/v2")
+
+	e := echo.New()
+	e.Use(Mirror(target))
+	e.POST("/orders", func(c echo.Context) error {
+		b, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusCreated, string(b))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders?dry=1", strings.NewReader("order"))
+	req.Header.Set("X-Custom", "value")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "order", rec.Body.String())
+
+	select {
+	case m := <-received:
+		assert.Equal(t, mirrored{http.MethodPost, "/v2/orders?dry=1", "order", "value"}, m)
+	case <-time.After(time.Second):
+		t.Fatal("request not mirrored")
+	}
+}
+
+func TestMirrorSampling(t *testing.T) {
+	var errs int
+	mw := MirrorWithConfig(MirrorConfig{
+		Target:     &url.URL{Scheme: "http", Host: "shadow.invalid"},
+		Percentage: 0.001,
+		OnError: func(*http.Request, error) {
+			errs++
+		},
+	})
+	e := echo.New()
+	for i := 0; i < 100; i++ {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+		assert.NoError(t, mw(func(c echo.Context) error {
+			return c.NoContent(http.StatusOK)
+		})(c))
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, 0, errs)
+
+	assert.Panics(t, func() {
+		Mirror(nil)
+	})
+}
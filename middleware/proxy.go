@@ -1,8 +1,12 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"io"
+	"io/ioutil"
 	"math/rand"
 	"net"
 	"net/http"
@@ -37,6 +41,21 @@ type (
 		// "/users/*/orders/*": "/user/$1/order/$2",
 		Rewrite map[string]string
 
+		// RegexRewrite defines URL path rewrite rules using regular expressions,
+		// matched in order against the path left by the `Rewrite` rules. The
+		// values captured in groups can be retrieved by index e.g. $1, $2 and so
+		// on. Only the first matching rule is applied.
+		// Examples:
+		// {Pattern: "^/api/v[0-9]+/(.*)", Replacement: "/$1"},
+		// {Pattern: "^/users/([0-9]+)/posts$", Replacement: "/posts/by/$1"},
+		RegexRewrite []ProxyRegexRewrite
+
+		// ModifyResponse modifies the response from the target before it is sent
+		// to the client, e.g. to remove headers or rewrite links. If it returns
+		// an error, "502 - Bad Gateway" response is sent.
+		// Optional.
+		ModifyResponse func(*http.Response) error
+
 		// Context key to store selected ProxyTarget into context.
 		// Optional. Default value "target".
 		ContextKey string
@@ -45,7 +64,52 @@ type (
 		// Examples: If custom TLS certificates are required.
 		Transport http.RoundTripper
 
+		// Retries is the number of times a request is retried with another
+		// target when the connection to the target fails. Only requests without
+		// a body are retried.
+		// Optional. Default value 0.
+		Retries int
+
+		// HealthCheck configures active health checking of the targets.
+		// Unhealthy targets are skipped by the balancers of this package.
+		// Optional. Default value disabled.
+		HealthCheck ProxyHealthCheck
+
 		rewriteRegex map[*regexp.Regexp]string
+		regexRewrite []*regexp.Regexp
+	}
+
+	// ProxyRegexRewrite defines a URL path rewrite rule using a regular
+	// expression.
+	ProxyRegexRewrite struct {
+		// Pattern is the regular expression matched against the path.
+		Pattern string
+
+		// Replacement is the new path, expanded as in `regexp.ReplaceAllString`.
+		Replacement string
+	}
+
+	// ProxyHealthCheck defines the config for active health checking of proxy
+	// targets. Checks run until `Context` is done.
+	ProxyHealthCheck struct {
+		// Path requested on each target. Targets responding with a 2xx or 3xx
+		// status are healthy.
+		// Required to enable health checks.
+		Path string
+
+		// Interval between checks.
+		// Optional. Default value 10s.
+		Interval time.Duration
+
+		// Timeout of a check.
+		// Optional. Default value 2s.
+		Timeout time.Duration
+
+		// Context stops the checks when done, e.g. a context canceled with
+		// `e.Server.RegisterOnShutdown(cancel)`.
+		// Optional. Default value `context.Background()`, checks run for the
+		// lifetime of the process.
+		Context context.Context
 	}
 
 	// ProxyTarget defines the upstream target.
@@ -53,6 +117,14 @@ type (
 		Name string
 		URL  *url.URL
 		Meta echo.Map
+
+		// Weight is the share of requests sent to the target by the weighted
+		// balancer, relative to the weights of the other targets. Targets with
+		// a zero weight only receive the requests selecting them explicitly.
+		// See `NewWeightedBalancer()`.
+		Weight int
+
+		down uint32
 	}
 
 	// ProxyBalancer defines an interface to implement a load balancing technique.
@@ -62,6 +134,13 @@ type (
 		Next(echo.Context) *ProxyTarget
 	}
 
+	// Balancer is a ProxyBalancer listing its targets, as required for health
+	// checks. The balancers of this package implement it.
+	Balancer interface {
+		ProxyBalancer
+		Targets() []*ProxyTarget
+	}
+
 	commonBalancer struct {
 		targets []*ProxyTarget
 		mutex   sync.RWMutex
@@ -78,6 +157,39 @@ type (
 		*commonBalancer
 		i uint32
 	}
+
+	// stickyBalancer implements a load balancing technique sending requests
+	// with the same header value to the same target.
+	stickyBalancer struct {
+		*roundRobinBalancer
+		header string
+	}
+
+	// WeightedBalancerConfig defines the config for the weighted balancer.
+	WeightedBalancerConfig struct {
+		// Header is the request header naming the target requests are sent
+		// to, e.g. for testers to reach a canary.
+		// Optional.
+		Header string
+
+		// Cookie is the name of the cookie the target of a client is kept in,
+		// so that its following requests are sent to the same target. It can
+		// also be set to select the target.
+		// Optional. By default each request is balanced independently.
+		Cookie string
+
+		// CookieMaxAge is the max age (in seconds) of the cookie.
+		// Optional. Default value 86400 (24 hours).
+		CookieMaxAge int
+	}
+
+	// weightedBalancer implements a load balancing technique sending a share
+	// of requests to each target, e.g. a canary.
+	weightedBalancer struct {
+		*commonBalancer
+		config WeightedBalancerConfig
+		random *rand.Rand
+	}
 )
 
 var (
@@ -86,6 +198,12 @@ var (
 		Skipper:    DefaultSkipper,
 		ContextKey: "target",
 	}
+
+	// DefaultProxyHealthCheck is the default proxy health check config.
+	DefaultProxyHealthCheck = ProxyHealthCheck{
+		Interval: 10 * time.Second,
+		Timeout:  2 * time.Second,
+	}
 )
 
 func proxyRaw(t *ProxyTarget, c echo.Context) http.Handler {
@@ -140,15 +258,59 @@ func NewRoundRobinBalancer(targets []*ProxyTarget) ProxyBalancer {
 	return b
 }
 
+// NewStickyBalancer returns a proxy balancer sending requests with the same
+// value of header to the same target, e.g. a session ID. Requests without the
+// header are balanced round-robin. When a target is unhealthy, only the
+// requests sent to it move to other targets.
+func NewStickyBalancer(targets []*ProxyTarget, header string) ProxyBalancer {
+	b := &stickyBalancer{roundRobinBalancer: &roundRobinBalancer{commonBalancer: new(commonBalancer)}, header: header}
+	b.targets = targets
+	return b
+}
+
+// NewWeightedBalancer returns a proxy balancer sending requests to the targets
+// in proportion to their `ProxyTarget#Weight`, e.g. 5% of the requests to a
+// canary. Requests naming a healthy target in the header or cookie of config
+// are sent to it.
+//
+// Example:
+//
+//	b := middleware.NewWeightedBalancer([]*middleware.ProxyTarget{
+//	  {Name: "stable", URL: stableURL, Weight: 95},
+//	  {Name: "canary", URL: canaryURL, Weight: 5},
+//	}, middleware.WeightedBalancerConfig{Header: "X-Backend", Cookie: "backend"})
+func NewWeightedBalancer(targets []*ProxyTarget, config WeightedBalancerConfig) ProxyBalancer {
+	if config.CookieMaxAge == 0 {
+		config.CookieMaxAge = 24 * 60 * 60
+	}
+	b := &weightedBalancer{commonBalancer: new(commonBalancer), config: config}
+	b.targets = targets
+	return b
+}
+
+// Healthy reports whether the target passed its last health check. Targets
+// are healthy unless health checks are enabled.
+func (t *ProxyTarget) Healthy() bool {
+	return atomic.LoadUint32(&t.down) == 0
+}
+
+func (t *ProxyTarget) setHealthy(healthy bool) {
+	var down uint32
+	if !healthy {
+		down = 1
+	}
+	atomic.StoreUint32(&t.down, down)
+}
+
 // AddTarget adds an upstream target to the list.
 func (b *commonBalancer) AddTarget(target *ProxyTarget) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 	for _, t := range b.targets {
 		if t.Name == target.Name {
 			return false
 		}
 	}
-	b.mutex.Lock()
-	defer b.mutex.Unlock()
 	b.targets = append(b.targets, target)
 	return true
 }
@@ -166,22 +328,202 @@ func (b *commonBalancer) RemoveTarget(name string) bool {
 	return false
 }
 
-// Next randomly returns an upstream target.
+// Targets returns a copy of the list of upstream targets.
+func (b *commonBalancer) Targets() []*ProxyTarget {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return append([]*ProxyTarget(nil), b.targets...)
+}
+
+// healthy returns the healthy upstream targets. The caller must hold the lock.
+func (b *commonBalancer) healthy() []*ProxyTarget {
+	for i, t := range b.targets {
+		if !t.Healthy() {
+			// Copy only if there are unhealthy targets
+			targets := append([]*ProxyTarget(nil), b.targets[:i]...)
+			for _, t := range b.targets[i+1:] {
+				if t.Healthy() {
+					targets = append(targets, t)
+				}
+			}
+			return targets
+		}
+	}
+	return b.targets
+}
+
+// Next randomly returns a healthy upstream target.
 func (b *randomBalancer) Next(c echo.Context) *ProxyTarget {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
 	if b.random == nil {
 		b.random = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
 	}
+	targets := b.healthy()
+	if len(targets) == 0 {
+		return nil
+	}
+	return targets[b.random.Intn(len(targets))]
+}
+
+// Next returns a healthy upstream target using round-robin technique.
+func (b *roundRobinBalancer) Next(c echo.Context) *ProxyTarget {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	targets := b.healthy()
+	if len(targets) == 0 {
+		return nil
+	}
+	return targets[(atomic.AddUint32(&b.i, 1)-1)%uint32(len(targets))]
+}
+
+// Next returns the healthy upstream target with the highest hash of the
+// header value and target name (rendezvous hashing).
+func (b *stickyBalancer) Next(c echo.Context) *ProxyTarget {
+	key := c.Request().Header.Get(b.header)
+	if key == "" {
+		return b.roundRobinBalancer.Next(c)
+	}
 	b.mutex.RLock()
 	defer b.mutex.RUnlock()
-	return b.targets[b.random.Intn(len(b.targets))]
+	var (
+		tgt *ProxyTarget
+		max uint64
+	)
+	for _, t := range b.healthy() {
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		h.Write([]byte(t.Name))
+		h.Write([]byte(t.URL.String()))
+		if sum := h.Sum64(); tgt == nil || sum > max {
+			tgt, max = t, sum
+		}
+	}
+	return tgt
 }
 
-// Next returns an upstream target using round-robin technique.
-func (b *roundRobinBalancer) Next(c echo.Context) *ProxyTarget {
-	b.i = b.i % uint32(len(b.targets))
-	t := b.targets[b.i]
-	atomic.AddUint32(&b.i, 1)
-	return t
+// Next returns the healthy upstream target named by the request, or else a
+// target picked randomly by weight.
+func (b *weightedBalancer) Next(c echo.Context) *ProxyTarget {
+	var header, cookie string
+	if b.config.Header != "" {
+		header = c.Request().Header.Get(b.config.Header)
+	}
+	if b.config.Cookie != "" {
+		if ck, err := c.Cookie(b.config.Cookie); err == nil {
+			cookie = ck.Value
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	targets := b.healthy()
+	byName := func(name string) *ProxyTarget {
+		for _, t := range targets {
+			if name != "" && t.Name == name {
+				return t
+			}
+		}
+		return nil
+	}
+	if t := byName(header); t != nil {
+		return t
+	}
+	if t := byName(cookie); t != nil {
+		return t
+	}
+
+	total := 0
+	for _, t := range targets {
+		if t.Weight > 0 {
+			total += t.Weight
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+	if b.random == nil {
+		b.random = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
+	}
+	var tgt *ProxyTarget
+	n := b.random.Intn(total)
+	for _, t := range targets {
+		if t.Weight <= 0 {
+			continue
+		}
+		if n < t.Weight {
+			tgt = t
+			break
+		}
+		n -= t.Weight
+	}
+	if b.config.Cookie != "" {
+		c.SetCookie(&http.Cookie{
+			Name:     b.config.Cookie,
+			Value:    tgt.Name,
+			Path:     "/",
+			MaxAge:   b.config.CookieMaxAge,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return tgt
+}
+
+// checkHealth requests the health check path of the targets every interval
+// until the context of the config is done.
+func checkHealth(b Balancer, config ProxyHealthCheck, transport http.RoundTripper) {
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   config.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	check := func(t *ProxyTarget) {
+		u := *t.URL
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(config.Path, "/")
+		req, err := http.NewRequestWithContext(config.Context, http.MethodGet, u.String(), nil)
+		if err != nil {
+			t.setHealthy(false)
+			return
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			if config.Context.Err() == nil {
+				t.setHealthy(false)
+			}
+			return
+		}
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+		t.setHealthy(res.StatusCode < http.StatusBadRequest)
+	}
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, t := range b.Targets() {
+			wg.Add(1)
+			go func(t *ProxyTarget) {
+				defer wg.Done()
+				check(t)
+			}(t)
+		}
+		wg.Wait()
+		select {
+		case <-ticker.C:
+		case <-config.Context.Done():
+			return
+		}
+	}
+}
+
+// isDialError reports whether err is a failure to connect to a target, in
+// which case the request didn't reach it and can be retried.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
 }
 
 // Proxy returns a Proxy middleware.
@@ -203,6 +545,17 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFunc {
 	if config.Balancer == nil {
 		panic("echo: proxy middleware requires balancer")
 	}
+	if config.HealthCheck.Path != "" {
+		if config.HealthCheck.Interval == 0 {
+			config.HealthCheck.Interval = DefaultProxyHealthCheck.Interval
+		}
+		if config.HealthCheck.Timeout == 0 {
+			config.HealthCheck.Timeout = DefaultProxyHealthCheck.Timeout
+		}
+		if config.HealthCheck.Context == nil {
+			config.HealthCheck.Context = context.Background()
+		}
+	}
 	config.rewriteRegex = map[*regexp.Regexp]string{}
 
 	// Initialize
@@ -210,6 +563,17 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFunc {
 		k = strings.Replace(k, "*", "(\\S*)", -1)
 		config.rewriteRegex[regexp.MustCompile(k)] = v
 	}
+	config.regexRewrite = make([]*regexp.Regexp, len(config.RegexRewrite))
+	for i, r := range config.RegexRewrite {
+		config.regexRewrite[i] = regexp.MustCompile(r.Pattern)
+	}
+	if config.HealthCheck.Path != "" {
+		b, ok := config.Balancer.(Balancer)
+		if !ok {
+			panic("echo: proxy middleware health check requires a balancer listing its targets")
+		}
+		go checkHealth(b, config.HealthCheck, config.Transport)
+	}
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) (err error) {
@@ -220,13 +584,24 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFunc {
 			req := c.Request()
 			res := c.Response()
 			tgt := config.Balancer.Next(c)
+			if tgt == nil {
+				return echo.ErrServiceUnavailable
+			}
 			c.Set(config.ContextKey, tgt)
 
 			// Rewrite
+			path := echo.GetPath(req)
 			for k, v := range config.rewriteRegex {
 				replacer := captureTokens(k, echo.GetPath(req))
 				if replacer != nil {
 					req.URL.Path = replacer.Replace(v)
+					path = req.URL.Path
+				}
+			}
+			for i, r := range config.regexRewrite {
+				if r.MatchString(path) {
+					req.URL.Path = r.ReplaceAllString(path, config.RegexRewrite[i].Replacement)
+					break
 				}
 			}
 
@@ -249,7 +624,19 @@ func ProxyWithConfig(config ProxyConfig) echo.MiddlewareFunc {
 				proxyRaw(tgt, c).ServeHTTP(res, req)
 			case req.Header.Get(echo.HeaderAccept) == "text/event-stream":
 			default:
-				proxyHTTP(tgt, c, config).ServeHTTP(res, req)
+				replayable := req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0
+				for retries := config.Retries; ; retries-- {
+					proxyHTTP(tgt, c, config).ServeHTTP(res, req)
+					e, ok := c.Get("_error").(*echo.HTTPError)
+					if !ok || retries <= 0 || !replayable || res.Committed || !isDialError(e.Internal) {
+						break
+					}
+					if tgt = config.Balancer.Next(c); tgt == nil {
+						break
+					}
+					c.Set("_error", nil)
+					c.Set(config.ContextKey, tgt)
+				}
 			}
 			if e, ok := c.Get("_error").(error); ok {
 				err = e
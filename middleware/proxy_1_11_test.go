@@ -3,9 +3,11 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/labstack/echo/v4"
@@ -51,3 +53,107 @@ func TestProxy_1_11(t *testing.T) {
 	assert.Equal(t, "/api/users", req.URL.Path)
 	assert.Equal(t, http.StatusBadGateway, rec.Code)
 }
+
+func TestProxyRetries(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("upstream"))
+	}))
+	defer upstream.Close()
+	down := httptest.NewServer(nil)
+	down.Close()
+	url1, _ := url.Parse(down.URL)
+	url2, _ := url.Parse(upstream.URL)
+	targets := []*ProxyTarget{{Name: "down", URL: url1}, {Name: "upstream", URL: url2}}
+
+	// Without retries
+	e := echo.New()
+	e.Use(Proxy(NewRoundRobinBalancer(targets)))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+
+	// With retries
+	e = echo.New()
+	e.Use(ProxyWithConfig(ProxyConfig{Balancer: NewRoundRobinBalancer(targets), Retries: 1}))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "upstream", rec.Body.String())
+}
+
+func TestProxyRegexRewriteAndModifyResponse(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Server", "upstream/1.0")
+		w.Header().Set(echo.HeaderLocation, "http://internal:8080/posts")
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+
+	e := echo.New()
+	e.Use(ProxyWithConfig(ProxyConfig{
+		Balancer: NewRandomBalancer([]*ProxyTarget{{Name: "upstream", URL: u}}),
+		RegexRewrite: []ProxyRegexRewrite{
+			{Pattern: "^/users/([0-9]+)/posts$", Replacement: "/posts/by/$1"},
+		},
+		ModifyResponse: func(res *http.Response) error {
+			if res.Request.URL.Path == "/fail" {
+				return errors.New("invalid response")
+			}
+			res.Header.Del("Server")
+			res.Header.Set(echo.HeaderLocation, strings.Replace(res.Header.Get(echo.HeaderLocation), "http://internal:8080", "https://example.com", 1))
+			return nil
+		},
+	}))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/42/posts", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "/posts/by/42", rec.Body.String())
+	assert.Empty(t, rec.Header().Get("Server"))
+	assert.Equal(t, "https://example.com/posts", rec.Header().Get(echo.HeaderLocation))
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users/me/posts", nil))
+	assert.Equal(t, "/users/me/posts", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fail", nil))
+	assert.Equal(t, http.StatusBadGateway, rec.Code)
+}
+
+func TestProxyRegexRewriteOrder(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+
+	e := echo.New()
+	e.Use(ProxyWithConfig(ProxyConfig{
+		Balancer: NewRandomBalancer([]*ProxyTarget{{Name: "upstream", URL: u}}),
+		Rewrite: map[string]string{
+			"/old/*": "/api/$1",
+		},
+		RegexRewrite: []ProxyRegexRewrite{
+			{Pattern: "^/api/users/([0-9]+)$", Replacement: "/users/$1"},
+			{Pattern: "^/api/(.*)$", Replacement: "/v2/$1"},
+		},
+	}))
+
+	// The first matching rule wins, every time
+	for i := 0; i < 20; i++ {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/users/42", nil))
+		assert.Equal(t, "/users/42", rec.Body.String())
+	}
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/posts", nil))
+	assert.Equal(t, "/v2/posts", rec.Body.String())
+
+	// Matched against the path left by Rewrite
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/old/users/7", nil))
+	assert.Equal(t, "/users/7", rec.Body.String())
+}
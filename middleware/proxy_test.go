@@ -1,12 +1,15 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -167,3 +170,160 @@ func TestProxyRealIPHeader(t *testing.T) {
 		assert.Equal(t, tt.extectedXRealIP, req.Header.Get(echo.HeaderXRealIP), "hasRealIPheader: %t / hasIPExtractor: %t", tt.hasRealIPheader, tt.hasIPExtractor)
 	}
 }
+
+func TestProxyHealthCheck(t *testing.T) {
+	var healthy int32 = 1
+	t1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/health" && atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "target 1")
+	}))
+	defer t1.Close()
+	t2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "target 2")
+	}))
+	defer t2.Close()
+	url1, _ := url.Parse(t1.URL)
+	url2, _ := url.Parse(t2.URL)
+	target1 := &ProxyTarget{Name: "target 1", URL: url1}
+	b := NewRoundRobinBalancer([]*ProxyTarget{target1, {Name: "target 2", URL: url2}})
+
+	e := echo.New()
+	e.Use(ProxyWithConfig(ProxyConfig{
+		Balancer:    b,
+		HealthCheck: ProxyHealthCheck{Path: "/health", Interval: 10 * time.Millisecond},
+	}))
+	atomic.StoreInt32(&healthy, 0)
+	assert.Eventually(t, func() bool { return !target1.Healthy() }, time.Second, 5*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, "target 2", rec.Body.String())
+	}
+	atomic.StoreInt32(&healthy, 1)
+	assert.Eventually(t, target1.Healthy, time.Second, 5*time.Millisecond)
+
+	// No healthy targets
+	target1.setHealthy(false)
+	b = NewRandomBalancer([]*ProxyTarget{target1})
+	e = echo.New()
+	e.Use(Proxy(b))
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	assert.Panics(t, func() {
+		ProxyWithConfig(ProxyConfig{Balancer: struct{ ProxyBalancer }{b}, HealthCheck: ProxyHealthCheck{Path: "/health"}})
+	})
+}
+
+func TestProxyHealthCheckStop(t *testing.T) {
+	var checks int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&checks, 1)
+	}))
+	defer upstream.Close()
+	u, _ := url.Parse(upstream.URL)
+	b := NewRandomBalancer([]*ProxyTarget{{Name: "upstream", URL: u}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		checkHealth(b.(Balancer), ProxyHealthCheck{Path: "/health", Interval: 5 * time.Millisecond, Timeout: time.Second, Context: ctx}, nil)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&checks) >= 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("health check still running")
+	}
+}
+
+func TestStickyBalancer(t *testing.T) {
+	var targets []*ProxyTarget
+	for i := 0; i < 5; i++ {
+		u, _ := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", 8000+i))
+		targets = append(targets, &ProxyTarget{Name: fmt.Sprintf("target %d", i), URL: u})
+	}
+	b := NewStickyBalancer(targets, "X-Session-ID")
+	e := echo.New()
+	next := func(session string) *ProxyTarget {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Session-ID", session)
+		return b.Next(e.NewContext(req, nil))
+	}
+
+	sessions := map[string]*ProxyTarget{}
+	for i := 0; i < 50; i++ {
+		s := fmt.Sprintf("session-%d", i)
+		sessions[s] = next(s)
+		assert.Equal(t, sessions[s], next(s))
+	}
+
+	// Only the sessions of an unhealthy target move
+	down := sessions["session-0"]
+	down.setHealthy(false)
+	for s, tgt := range sessions {
+		if tgt == down {
+			assert.NotEqual(t, down, next(s))
+		} else {
+			assert.Equal(t, tgt, next(s))
+		}
+	}
+
+	// Round-robin without header
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), nil)
+	assert.NotEqual(t, b.Next(c), b.Next(c))
+}
+
+func TestProxyWeightedBalancer(t *testing.T) {
+	stable := &ProxyTarget{Name: "stable", URL: &url.URL{Host: "stable"}, Weight: 90}
+	canary := &ProxyTarget{Name: "canary", URL: &url.URL{Host: "canary"}, Weight: 10}
+	dark := &ProxyTarget{Name: "dark", URL: &url.URL{Host: "dark"}}
+	b := NewWeightedBalancer([]*ProxyTarget{stable, canary, dark}, WeightedBalancerConfig{Header: "X-Backend", Cookie: "backend"})
+	e := echo.New()
+	next := func(header, cookie string) (*ProxyTarget, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set("X-Backend", header)
+		}
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "backend", Value: cookie})
+		}
+		rec := httptest.NewRecorder()
+		return b.Next(e.NewContext(req, rec)), rec
+	}
+
+	// Weights
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		tgt, rec := next("", "")
+		counts[tgt.Name]++
+		if cookies := rec.Result().Cookies(); assert.Len(t, cookies, 1) {
+			assert.Equal(t, tgt.Name, cookies[0].Value)
+		}
+	}
+	assert.InDelta(t, 900, counts["stable"], 60)
+	assert.InDelta(t, 100, counts["canary"], 60)
+	assert.Zero(t, counts["dark"])
+
+	// Sticky assignment
+	tgt, rec := next("", "canary")
+	assert.Equal(t, canary, tgt)
+	assert.Empty(t, rec.Header().Get(echo.HeaderSetCookie))
+
+	// Overrides
+	tgt, rec = next("dark", "canary")
+	assert.Equal(t, dark, tgt)
+	assert.Empty(t, rec.Header().Get(echo.HeaderSetCookie))
+
+	// Unhealthy targets are reassigned
+	canary.setHealthy(false)
+	tgt, rec = next("", "canary")
+	assert.Equal(t, stable, tgt)
+	assert.Contains(t, rec.Header().Get(echo.HeaderSetCookie), "backend=stable")
+}
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// RateLimiterConfig defines the config for RateLimiter middleware.
+	RateLimiterConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Identifier returns the identifier requests are counted by.
+		// Optional. Default value returns "principal:<id>" for requests with a
+		// principal, e.g. verified by `APIKeyAuthenticator`, and "ip:<address>"
+		// otherwise.
+		Identifier func(c echo.Context) (string, error)
+
+		// Limit returns the limit of the identifier, e.g. looked up by the
+		// plan of the customer. A limit of 0 requests doesn't limit requests.
+		// Optional. Default value returns `DefaultRateLimit`.
+		Limit func(c echo.Context, identifier string) (RateLimit, error)
+
+		// Store counts the requests of the identifiers.
+		// Optional. Default value `NewRateLimiterMemoryStore()`.
+		Store RateLimiterStore
+	}
+
+	// RateLimit is a number of requests allowed in a window of time.
+	RateLimit struct {
+		Requests int
+		Window   time.Duration
+	}
+
+	// RateLimiterStore counts requests in fixed windows.
+	RateLimiterStore interface {
+		// Take counts a request of the identifier in the current window of the
+		// limit. It returns the number of requests counted in the window,
+		// including the request, and when the window ends.
+		Take(identifier string, limit RateLimit) (count int, reset time.Time, err error)
+	}
+
+	// RateLimiterMemoryStore is a `RateLimiterStore` counting requests in
+	// memory, for applications served by a single instance.
+	RateLimiterMemoryStore struct {
+		mu        sync.Mutex
+		windows   map[string]*rateLimitWindow
+		lastSweep time.Time
+	}
+
+	rateLimitWindow struct {
+		count int
+		reset time.Time
+	}
+)
+
+var (
+	// DefaultRateLimit is the default limit of RateLimiter middleware.
+	DefaultRateLimit = RateLimit{Requests: 60, Window: time.Minute}
+
+	// DefaultRateLimiterConfig is the default RateLimiter middleware config.
+	DefaultRateLimiterConfig = RateLimiterConfig{
+		Skipper:    DefaultSkipper,
+		Identifier: principalIdentifier,
+	}
+)
+
+// RateLimiter returns a middleware limiting the requests of a principal, API
+// key or client IP to `limit`, e.g. `RateLimit{Requests: 100, Window:
+// time.Minute}`.
+//
+// The limit is reported in the "X-RateLimit-Limit", "X-RateLimit-Remaining"
+// and "X-RateLimit-Reset" (Unix time) headers. For requests over the limit, it
+// sends "429 - Too Many Requests" response with the "Retry-After" header.
+func RateLimiter(limit RateLimit) echo.MiddlewareFunc {
+	c := DefaultRateLimiterConfig
+	c.Limit = func(echo.Context, string) (RateLimit, error) {
+		return limit, nil
+	}
+	return RateLimiterWithConfig(c)
+}
+
+// RateLimiterWithConfig returns a RateLimiter middleware with config.
+// See: `RateLimiter()`.
+func RateLimiterWithConfig(config RateLimiterConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRateLimiterConfig.Skipper
+	}
+	if config.Identifier == nil {
+		config.Identifier = DefaultRateLimiterConfig.Identifier
+	}
+	if config.Limit == nil {
+		config.Limit = func(echo.Context, string) (RateLimit, error) {
+			return DefaultRateLimit, nil
+		}
+	}
+	if config.Store == nil {
+		config.Store = NewRateLimiterMemoryStore()
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			id, err := config.Identifier(c)
+			if err != nil {
+				return err
+			}
+			limit, err := config.Limit(c, id)
+			if err != nil {
+				return err
+			}
+			if limit.Requests <= 0 {
+				return next(c)
+			}
+			count, reset, err := config.Store.Take(id, limit)
+			if err != nil {
+				return err
+			}
+
+			remaining := limit.Requests - count
+			if remaining < 0 {
+				remaining = 0
+			}
+			h := c.Response().Header()
+			h.Set(echo.HeaderXRateLimitLimit, strconv.Itoa(limit.Requests))
+			h.Set(echo.HeaderXRateLimitRemaining, strconv.Itoa(remaining))
+			h.Set(echo.HeaderXRateLimitReset, strconv.FormatInt(reset.Unix(), 10))
+			if count > limit.Requests {
+				h.Set(echo.HeaderRetryAfter, strconv.Itoa(retryAfter(reset)))
+				return echo.ErrTooManyRequests
+			}
+			return next(c)
+		}
+	}
+}
+
+// NewRateLimiterMemoryStore returns a `RateLimiterMemoryStore`.
+func NewRateLimiterMemoryStore() *RateLimiterMemoryStore {
+	return &RateLimiterMemoryStore{windows: map[string]*rateLimitWindow{}}
+}
+
+// Take implements `RateLimiterStore`.
+func (s *RateLimiterMemoryStore) Take(identifier string, limit RateLimit) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for id, w := range s.windows {
+			if !now.Before(w.reset) {
+				delete(s.windows, id)
+			}
+		}
+		s.lastSweep = now
+	}
+	w, ok := s.windows[identifier]
+	if !ok || !now.Before(w.reset) {
+		w = &rateLimitWindow{reset: now.Add(limit.Window)}
+		s.windows[identifier] = w
+	}
+	w.count++
+	return w.count, w.reset, nil
+}
+
+// principalIdentifier identifies requests by principal or client IP. Unverified
+// credentials, e.g. a raw "X-API-Key" header, are ignored as clients could
+// choose a new key for every request.
+func principalIdentifier(c echo.Context) (string, error) {
+	if p := c.Principal(); p != nil {
+		return "principal:" + p.ID, nil
+	}
+	return "ip:" + c.RealIP(), nil
+}
+
+// retryAfter returns the seconds until reset, rounded up.
+func retryAfter(reset time.Time) int {
+	d := time.Until(reset)
+	if d <= 0 {
+		return 0
+	}
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	e := echo.New()
+	e.Use(AuthnWithConfig(AuthnConfig{
+		Authenticators: []Authenticator{AuthenticatorFunc(func(c echo.Context) (*echo.Principal, error) {
+			if user := c.Request().Header.Get("X-User"); user != "" {
+				return &echo.Principal{ID: user}, nil
+			}
+			return nil, ErrNoCredentials
+		})},
+		Optional: true,
+	}))
+	e.Use(RateLimiterWithConfig(RateLimiterConfig{
+		Limit: func(c echo.Context, id string) (RateLimit, error) {
+			switch id {
+			case "principal:pro":
+				return RateLimit{Requests: 3, Window: time.Hour}, nil
+			case "principal:internal":
+				return RateLimit{}, nil
+			case "principal:broken":
+				return RateLimit{}, errors.New("tier lookup failed")
+			}
+			return RateLimit{Requests: 1, Window: time.Hour}, nil
+		},
+	}))
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	request := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Tiers
+	for i := 0; i < 3; i++ {
+		rec := request("X-User", "pro")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "3", rec.Header().Get(echo.HeaderXRateLimitLimit))
+		assert.Equal(t, strconv.Itoa(2-i), rec.Header().Get(echo.HeaderXRateLimitRemaining))
+	}
+	rec := request("X-User", "pro")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get(echo.HeaderXRateLimitRemaining))
+	assert.Equal(t, "3600", rec.Header().Get(echo.HeaderRetryAfter))
+	reset, _ := strconv.ParseInt(rec.Header().Get(echo.HeaderXRateLimitReset), 10, 64)
+	assert.InDelta(t, time.Now().Add(time.Hour).Unix(), reset, 1)
+
+	// Identifiers
+	assert.Equal(t, http.StatusNoContent, request("X-User", "free").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("X-User", "free").Code)
+	assert.Equal(t, http.StatusNoContent, request("X-API-Key", "a").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("X-API-Key", "b").Code, "unverified key counted by ip")
+	assert.Equal(t, http.StatusTooManyRequests, request("", "").Code)
+
+	// Unlimited
+	rec = request("X-User", "internal")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get(echo.HeaderXRateLimitLimit))
+
+	assert.Equal(t, http.StatusInternalServerError, request("X-User", "broken").Code)
+}
+
+func TestRateLimiterMemoryStore(t *testing.T) {
+	s := NewRateLimiterMemoryStore()
+	limit := RateLimit{Requests: 1, Window: 10 * time.Millisecond}
+	count, _, _ := s.Take("a", limit)
+	assert.Equal(t, 1, count)
+	count, _, _ = s.Take("a", limit)
+	assert.Equal(t, 2, count)
+	time.Sleep(15 * time.Millisecond)
+	count, _, _ = s.Take("a", limit)
+	assert.Equal(t, 1, count, "new window")
+}
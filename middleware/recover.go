@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"fmt"
 	"runtime"
 
 	"github.com/labstack/echo/v4"
@@ -25,7 +24,15 @@ type (
 		// DisablePrintStack disables printing stack trace.
 		// Optional. Default value as false.
 		DisablePrintStack bool `yaml:"disable_print_stack"`
+
+		// PanicHandler is called with the recovered panic before it is passed to
+		// the centralized HTTPErrorHandler, e.g. to report it.
+		// Optional.
+		PanicHandler RecoverPanicHandler
 	}
+
+	// RecoverPanicHandler defines a function which is executed for a recovered panic.
+	RecoverPanicHandler func(echo.Context, *echo.PanicError)
 )
 
 var (
@@ -39,7 +46,8 @@ var (
 )
 
 // Recover returns a middleware which recovers from panics anywhere in the chain
-// and handles the control to the centralized HTTPErrorHandler.
+// and handles the control to the centralized HTTPErrorHandler. The error passed
+// to the handler is an `*echo.PanicError` holding the recovered value and stack.
 func Recover() echo.MiddlewareFunc {
 	return RecoverWithConfig(DefaultRecoverConfig)
 }
@@ -63,14 +71,14 @@ func RecoverWithConfig(config RecoverConfig) echo.MiddlewareFunc {
 
 			defer func() {
 				if r := recover(); r != nil {
-					err, ok := r.(error)
-					if !ok {
-						err = fmt.Errorf("%v", r)
-					}
 					stack := make([]byte, config.StackSize)
 					length := runtime.Stack(stack, !config.DisableStackAll)
+					err := &echo.PanicError{Value: r, Stack: stack[:length]}
 					if !config.DisablePrintStack {
-						c.Logger().Printf("[PANIC RECOVER] %v %s\n", err, stack[:length])
+						c.Logger().Printf("[PANIC RECOVER] %v %s\n", r, err.Stack)
+					}
+					if config.PanicHandler != nil {
+						config.PanicHandler(c, err)
 					}
 					c.Error(err)
 				}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"bytes"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -24,3 +25,37 @@ func TestRecover(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	assert.Contains(t, buf.String(), "PANIC RECOVER")
 }
+
+func TestRecoverPanicError(t *testing.T) {
+	e := echo.New()
+	e.Logger.SetOutput(new(bytes.Buffer))
+	var handled error
+	e.HTTPErrorHandler = func(err error, c echo.Context) {
+		handled = err
+		e.DefaultHTTPErrorHandler(err, c)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	cause := errors.New("cause")
+	var hooked *echo.PanicError
+	h := RecoverWithConfig(RecoverConfig{
+		DisablePrintStack: true,
+		PanicHandler: func(c echo.Context, err *echo.PanicError) {
+			hooked = err
+		},
+	})(func(c echo.Context) error {
+		panic(cause)
+	})
+	assert.NoError(t, h(c))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	pe, ok := handled.(*echo.PanicError)
+	if assert.True(t, ok) {
+		assert.Equal(t, hooked, pe)
+		assert.Equal(t, cause, pe.Value)
+		assert.Contains(t, string(pe.Stack), "goroutine")
+		assert.True(t, errors.Is(pe, cause))
+		assert.Equal(t, "panic: cause", pe.Error())
+	}
+}
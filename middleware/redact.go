@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Redaction defines the sensitive data replaced in logs and dumps, see
+	// `LoggerConfig.Redact` and `BodyDumpConfig.Redact`.
+	Redaction struct {
+		// Headers lists the headers redacted, case-insensitive. Redacting the
+		// "Cookie" header redacts all the cookies.
+		Headers []string `yaml:"headers"`
+
+		// Params lists the query and form parameters redacted.
+		Params []string `yaml:"params"`
+
+		// JSONPaths lists the members of JSON bodies and log fields redacted, as
+		// dot-separated keys, e.g. "password" or "card.number". "*" matches any
+		// key, and arrays are traversed, so that "users.email" redacts the
+		// email of each user.
+		JSONPaths []string `yaml:"json_paths"`
+
+		// Replacement replaces the redacted values.
+		// Optional. Default value "[REDACTED]".
+		Replacement string `yaml:"replacement"`
+	}
+)
+
+var (
+	// DefaultRedaction redacts common credentials.
+	DefaultRedaction = Redaction{
+		Headers:   []string{echo.HeaderAuthorization, echo.HeaderCookie, echo.HeaderSetCookie, "X-API-Key", "Proxy-Authorization"},
+		Params:    []string{"password", "token", "access_token", "api_key"},
+		JSONPaths: []string{"password", "token", "access_token", "refresh_token"},
+	}
+)
+
+// Enabled reports whether anything is redacted.
+func (r Redaction) Enabled() bool {
+	return len(r.Headers) > 0 || len(r.Params) > 0 || len(r.JSONPaths) > 0
+}
+
+func (r Redaction) replacement() string {
+	if r.Replacement == "" {
+		return "[REDACTED]"
+	}
+	return r.Replacement
+}
+
+// Header returns the value of the header, redacted if the header is.
+func (r Redaction) Header(name, value string) string {
+	if value == "" {
+		return value
+	}
+	for _, h := range r.Headers {
+		if strings.EqualFold(h, name) {
+			return r.replacement()
+		}
+	}
+	return value
+}
+
+// Param returns the value of the query or form parameter, redacted if the
+// parameter is.
+func (r Redaction) Param(name, value string) string {
+	if value == "" || !r.redactsParam(name) {
+		return value
+	}
+	return r.replacement()
+}
+
+func (r Redaction) redactsParam(name string) bool {
+	for _, p := range r.Params {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// URI returns the URI with the values of the redacted query parameters
+// replaced.
+func (r Redaction) URI(uri string) string {
+	i := strings.IndexByte(uri, '?')
+	if i == -1 || len(r.Params) == 0 {
+		return uri
+	}
+	pairs := strings.Split(uri[i+1:], "&")
+	for j, pair := range pairs {
+		k := pair
+		if eq := strings.IndexByte(pair, '='); eq != -1 {
+			k = pair[:eq]
+		}
+		name, err := url.QueryUnescape(k)
+		if err != nil {
+			name = k
+		}
+		if r.redactsParam(name) {
+			pairs[j] = k + "=" + r.replacement()
+		}
+	}
+	return uri[:i+1] + strings.Join(pairs, "&")
+}
+
+// JSON returns the JSON document with the members at the redacted paths
+// replaced. Other documents are returned as is.
+func (r Redaction) JSON(b []byte) []byte {
+	if len(r.JSONPaths) == 0 || len(bytes.TrimSpace(b)) == 0 {
+		return b
+	}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return b
+	}
+	for _, p := range r.JSONPaths {
+		v = r.redactPath(v, strings.Split(p, "."))
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return b
+	}
+	return out
+}
+
+// redactsField reports whether the log field is redacted as a whole.
+func (r Redaction) redactsField(key string) bool {
+	for _, p := range r.JSONPaths {
+		if p == key || p == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// field returns the JSON value of the log field with the members at the
+// redacted paths replaced.
+func (r Redaction) field(key string, b []byte) []byte {
+	if len(r.JSONPaths) == 0 {
+		return b
+	}
+	kb, _ := json.Marshal(key)
+	out := r.JSON([]byte("{" + string(kb) + ":" + string(b) + "}"))
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(out, &m); err != nil {
+		return b
+	}
+	return m[key]
+}
+
+func (r Redaction) redactPath(v interface{}, path []string) interface{} {
+	switch t := v.(type) {
+	case []interface{}:
+		for i := range t {
+			t[i] = r.redactPath(t[i], path)
+		}
+	case map[string]interface{}:
+		for k, child := range t {
+			if path[0] != "*" && path[0] != k {
+				continue
+			}
+			if len(path) == 1 {
+				t[k] = r.replacement()
+			} else {
+				t[k] = r.redactPath(child, path[1:])
+			}
+		}
+	}
+	return v
+}
+
+// Form returns the URL-encoded form with the values of the redacted
+// parameters replaced.
+func (r Redaction) Form(b []byte) []byte {
+	if len(r.Params) == 0 {
+		return b
+	}
+	return []byte(strings.TrimPrefix(r.URI("?"+string(b)), "?"))
+}
+
+// body returns the body with content type redacted.
+func (r Redaction) body(contentType string, b []byte) []byte {
+	switch {
+	case strings.HasPrefix(contentType, echo.MIMEApplicationForm):
+		return r.Form(b)
+	case strings.Contains(contentType, "json"):
+		return r.JSON(b)
+	}
+	return b
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedaction(t *testing.T) {
+	r := Redaction{
+		Headers:   []string{"Authorization"},
+		Params:    []string{"token", "card number"},
+		JSONPaths: []string{"password", "cards.number", "*.ssn"},
+	}
+	assert.True(t, r.Enabled())
+	assert.False(t, Redaction{}.Enabled())
+
+	assert.Equal(t, "[REDACTED]", r.Header("authorization", "Bearer secret"))
+	assert.Equal(t, "", r.Header("Authorization", ""))
+	assert.Equal(t, "text/plain", r.Header("Accept", "text/plain"))
+
+	assert.Equal(t, "[REDACTED]", r.Param("token", "secret"))
+	assert.Equal(t, "1", r.Param("page", "1"))
+
+	assert.Equal(t, "/login?token=[REDACTED]&page=1&card+number=[REDACTED]&flag", r.URI("/login?token=secret&page=1&card+number=4242&flag"))
+	assert.Equal(t, "/login", r.URI("/login"))
+	assert.Equal(t, "token=[REDACTED]&page=1", string(r.Form([]byte("token=secret&page=1"))))
+
+	assert.JSONEq(t, `{"name":"jon","password":"[REDACTED]","cards":[{"number":"[REDACTED]","exp":"01/30"}],"user":{"ssn":"[REDACTED]"},"id":12345678901234567890}`,
+		string(r.JSON([]byte(`{"name":"jon","password":"secret","cards":[{"number":"4242","exp":"01/30"}],"user":{"ssn":"123"},"id":12345678901234567890}`))))
+	assert.Equal(t, "not json", string(r.JSON([]byte("not json"))))
+	assert.Equal(t, `12345678901234567890`, string(r.JSON([]byte(`12345678901234567890`))))
+
+	r.Replacement = "***"
+	assert.Equal(t, "***", r.Header("Authorization", "Bearer secret"))
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/labstack/echo/v4"
 )
@@ -51,6 +52,8 @@ type (
 		// security against cross-site scripting (XSS), clickjacking and other code
 		// injection attacks resulting from execution of malicious content in the
 		// trusted web page context.
+		// The "{nonce}" placeholder is replaced by the nonce of the request,
+		// see `Context#CSPNonce()`, e.g. "script-src 'self' 'nonce-{nonce}'".
 		// Optional. Default value "".
 		ContentSecurityPolicy string `yaml:"content_security_policy"`
 
@@ -74,6 +77,10 @@ type (
 	}
 )
 
+// CSPNoncePlaceholder is replaced by the nonce of the request in
+// `SecureConfig.ContentSecurityPolicy`.
+const CSPNoncePlaceholder = "{nonce}"
+
 var (
 	// DefaultSecureConfig is the default Secure middleware config.
 	DefaultSecureConfig = SecureConfig{
@@ -100,6 +107,7 @@ func SecureWithConfig(config SecureConfig) echo.MiddlewareFunc {
 	if config.Skipper == nil {
 		config.Skipper = DefaultSecureConfig.Skipper
 	}
+	nonce := strings.Contains(config.ContentSecurityPolicy, CSPNoncePlaceholder)
 
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -130,10 +138,14 @@ func SecureWithConfig(config SecureConfig) echo.MiddlewareFunc {
 				res.Header().Set(echo.HeaderStrictTransportSecurity, fmt.Sprintf("max-age=%d%s", config.HSTSMaxAge, subdomains))
 			}
 			if config.ContentSecurityPolicy != "" {
+				csp := config.ContentSecurityPolicy
+				if nonce {
+					csp = strings.ReplaceAll(csp, CSPNoncePlaceholder, c.CSPNonce())
+				}
 				if config.CSPReportOnly {
-					res.Header().Set(echo.HeaderContentSecurityPolicyReportOnly, config.ContentSecurityPolicy)
+					res.Header().Set(echo.HeaderContentSecurityPolicyReportOnly, csp)
 				} else {
-					res.Header().Set(echo.HeaderContentSecurityPolicy, config.ContentSecurityPolicy)
+					res.Header().Set(echo.HeaderContentSecurityPolicy, csp)
 				}
 			}
 			if config.ReferrerPolicy != "" {
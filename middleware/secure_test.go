@@ -88,4 +88,13 @@ func TestSecure(t *testing.T) {
 		HSTSExcludeSubdomains: true,
 	})(h)(c)
 	assert.Equal(t, "max-age=3600; preload", rec.Header().Get(echo.HeaderStrictTransportSecurity))
+
+	// Nonce
+	rec = httptest.NewRecorder()
+	c = e.NewContext(req, rec)
+	SecureWithConfig(SecureConfig{
+		ContentSecurityPolicy: "script-src 'self' 'nonce-{nonce}'; style-src 'nonce-{nonce}'",
+	})(h)(c)
+	nonce := c.CSPNonce()
+	assert.Equal(t, "script-src 'self' 'nonce-"+nonce+"'; style-src 'nonce-"+nonce+"'", rec.Header().Get(echo.HeaderContentSecurityPolicy))
 }
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// StatsConfig defines the config for Stats middleware.
+	StatsConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// LatencySamples is the number of most recent request latencies the
+		// percentiles are computed from.
+		// Optional. Default value 1024.
+		LatencySamples int `yaml:"latency_samples"`
+	}
+
+	// Stats collects request statistics: requests in flight, total requests,
+	// counts per status code and latency percentiles. It implements `expvar.Var`
+	// so it can be published with `expvar.Publish()`.
+	Stats struct {
+		config    StatsConfig
+		inFlight  int64
+		total     uint64
+		mutex     sync.Mutex
+		statuses  map[int]uint64
+		latencies []time.Duration
+		next      int
+		filled    bool
+	}
+
+	// StatsSnapshot is a point in time copy of the collected statistics.
+	StatsSnapshot struct {
+		InFlight int64             `json:"in_flight"`
+		Total    uint64            `json:"total"`
+		Statuses map[string]uint64 `json:"statuses"`
+		Latency  StatsLatency      `json:"latency"`
+	}
+
+	// StatsLatency holds latency percentiles in nanoseconds.
+	StatsLatency struct {
+		P50 time.Duration `json:"p50"`
+		P95 time.Duration `json:"p95"`
+		P99 time.Duration `json:"p99"`
+	}
+)
+
+var (
+	// DefaultStatsConfig is the default Stats middleware config.
+	DefaultStatsConfig = StatsConfig{
+		Skipper:        DefaultSkipper,
+		LatencySamples: 1024,
+	}
+)
+
+// NewStats returns a new request statistics collector.
+//
+// Example:
+//
+//	stats := middleware.NewStats(middleware.DefaultStatsConfig)
+//	e.Use(stats.Middleware())
+//	e.GET("/stats", stats.Handler)
+//	expvar.Publish("http", stats)
+func NewStats(config StatsConfig) *Stats {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultStatsConfig.Skipper
+	}
+	if config.LatencySamples <= 0 {
+		config.LatencySamples = DefaultStatsConfig.LatencySamples
+	}
+	return &Stats{
+		config:    config,
+		statuses:  map[int]uint64{},
+		latencies: make([]time.Duration, config.LatencySamples),
+	}
+}
+
+// Middleware returns a middleware collecting statistics of the requests it
+// processes.
+func (s *Stats) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if s.config.Skipper(c) {
+				return next(c)
+			}
+
+			atomic.AddInt64(&s.inFlight, 1)
+			start := time.Now()
+			err := next(c)
+			latency := time.Since(start)
+			atomic.AddInt64(&s.inFlight, -1)
+
+			status := c.Response().Status
+			if err != nil {
+				// The error handler has not run yet, predict its status code
+				status = http.StatusInternalServerError
+				var he *echo.HTTPError
+				if errors.As(err, &he) {
+					status = he.Code
+				}
+			}
+			s.record(status, latency)
+			return err
+		}
+	}
+}
+
+func (s *Stats) record(status int, latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.total++
+	s.statuses[status]++
+	s.latencies[s.next] = latency
+	s.next++
+	if s.next == len(s.latencies) {
+		s.next = 0
+		s.filled = true
+	}
+}
+
+// Snapshot returns a copy of the collected statistics.
+func (s *Stats) Snapshot() StatsSnapshot {
+	snap := StatsSnapshot{
+		InFlight: atomic.LoadInt64(&s.inFlight),
+		Statuses: map[string]uint64{},
+	}
+	s.mutex.Lock()
+	snap.Total = s.total
+	for k, v := range s.statuses {
+		snap.Statuses[strconv.Itoa(k)] = v
+	}
+	n := s.next
+	if s.filled {
+		n = len(s.latencies)
+	}
+	samples := make([]time.Duration, n)
+	copy(samples, s.latencies[:n])
+	s.mutex.Unlock()
+
+	if n > 0 {
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		snap.Latency = StatsLatency{
+			P50: percentile(samples, 50),
+			P95: percentile(samples, 95),
+			P99: percentile(samples, 99),
+		}
+	}
+	return snap
+}
+
+// Handler is an `echo.HandlerFunc` sending the statistics as JSON.
+func (s *Stats) Handler(c echo.Context) error {
+	return c.JSON(http.StatusOK, s.Snapshot())
+}
+
+// String implements `expvar.Var` returning the statistics as JSON.
+func (s *Stats) String() string {
+	b, _ := json.Marshal(s.Snapshot())
+	return string(b)
+}
+
+// percentile returns the nearest-rank percentile `p` of sorted samples.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
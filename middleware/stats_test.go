@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	e := echo.New()
+	stats := NewStats(StatsConfig{LatencySamples: 4})
+	e.Use(stats.Middleware())
+	e.GET("/ok", func(c echo.Context) error {
+		assert.Equal(t, int64(1), stats.Snapshot().InFlight)
+		return c.String(http.StatusOK, "OK")
+	})
+	e.GET("/error", func(c echo.Context) error {
+		return errors.New("error")
+	})
+	e.GET("/stats", stats.Handler)
+
+	for _, path := range []string{"/ok", "/ok", "/missing", "/error", "/ok"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	snap := stats.Snapshot()
+	assert.Equal(t, int64(0), snap.InFlight)
+	assert.Equal(t, uint64(5), snap.Total)
+	assert.Equal(t, map[string]uint64{"200": 3, "404": 1, "500": 1}, snap.Statuses)
+	assert.True(t, snap.Latency.P50 <= snap.Latency.P95)
+	assert.True(t, snap.Latency.P95 <= snap.Latency.P99)
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	res := StatsSnapshot{}
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res)) {
+		assert.Equal(t, uint64(5), res.Total)
+	}
+
+	// expvar
+	expvar.Publish("echo_test_stats", stats)
+	assert.Contains(t, expvar.Get("echo_test_stats").String(), `"total":6`)
+}
+
+func TestStatsPercentile(t *testing.T) {
+	stats := NewStats(StatsConfig{LatencySamples: 100})
+	assert.Equal(t, StatsLatency{}, stats.Snapshot().Latency)
+	for i := 100; i > 0; i-- {
+		stats.record(http.StatusOK, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, StatsLatency{P50: 50 * time.Millisecond, P95: 95 * time.Millisecond, P99: 99 * time.Millisecond}, stats.Snapshot().Latency)
+
+	// Ring buffer keeps the most recent samples
+	for i := 0; i < 100; i++ {
+		stats.record(http.StatusOK, time.Second)
+	}
+	assert.Equal(t, time.Second, stats.Snapshot().Latency.P50)
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// MultiTenantConfig defines the config for MultiTenant middleware.
+	MultiTenantConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Lookup is a comma separated list of sources the tenant key is
+		// extracted from, tried in order.
+		// Optional. Default value "header:X-Tenant-ID".
+		// Possible values:
+		// - "subdomain": the first label of the host, e.g. "acme" for
+		//   "acme.example.com". See `BaseDomain`.
+		// - "header:<name>"
+		// - "path": the first segment of the path, e.g. "acme" for "/acme/users".
+		//   See `RewritePath`.
+		Lookup string
+
+		// BaseDomain is the domain tenant subdomains belong to, e.g.
+		// "example.com". Requests to the base domain itself carry no tenant.
+		// Optional. By default the first label of hosts with at least three
+		// labels is used.
+		BaseDomain string
+
+		// RewritePath removes the tenant path segment from the request path, so
+		// that routes don't need a tenant prefix. The middleware must then be
+		// registered with `Echo#Pre()`.
+		// Optional. Default value false.
+		RewritePath bool
+
+		// Resolver looks up the tenant of a key. It returns a nil tenant for
+		// unknown keys.
+		// Required.
+		Resolver TenantResolver
+
+		// ContextKey is the key the tenant is stored under in the context.
+		// Optional. Default value "tenant".
+		ContextKey string
+	}
+
+	// TenantResolver defines a function to look up the tenant of a key.
+	TenantResolver func(c echo.Context, key string) (*Tenant, error)
+
+	// Tenant is a tenant resolved by the MultiTenant middleware.
+	Tenant struct {
+		// ID is the tenant key extracted from the request.
+		ID string
+
+		// Name is the display name of the tenant.
+		Name string
+
+		// Data holds the application data of the tenant, e.g. its plan or
+		// database connection.
+		Data interface{}
+	}
+
+	tenantExtractor func(c echo.Context) (key string, rewrite func())
+)
+
+var (
+	// DefaultMultiTenantConfig is the default MultiTenant middleware config.
+	DefaultMultiTenantConfig = MultiTenantConfig{
+		Skipper:    DefaultSkipper,
+		Lookup:     "header:X-Tenant-ID",
+		ContextKey: "tenant",
+	}
+)
+
+// MultiTenant returns a multitenancy middleware resolving the tenant of requests
+// with `resolver`, from the "X-Tenant-ID" header. The tenant is stored in the
+// context, see `TenantFromContext()`, and added to the request log fields.
+//
+// For a missing tenant key, it sends "400 - Bad Request" response.
+// For an unknown tenant, it sends "404 - Not Found" response.
+func MultiTenant(resolver TenantResolver) echo.MiddlewareFunc {
+	c := DefaultMultiTenantConfig
+	c.Resolver = resolver
+	return MultiTenantWithConfig(c)
+}
+
+// MultiTenantWithConfig returns a MultiTenant middleware with config.
+// See: `MultiTenant()`.
+func MultiTenantWithConfig(config MultiTenantConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultMultiTenantConfig.Skipper
+	}
+	if config.Lookup == "" {
+		config.Lookup = DefaultMultiTenantConfig.Lookup
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultMultiTenantConfig.ContextKey
+	}
+	if config.Resolver == nil {
+		panic("echo: tenant middleware requires a resolver function")
+	}
+
+	// Initialize
+	var extractors []tenantExtractor
+	for _, source := range strings.Split(config.Lookup, ",") {
+		parts := strings.SplitN(strings.TrimSpace(source), ":", 2)
+		switch parts[0] {
+		case "subdomain":
+			extractors = append(extractors, tenantFromSubdomain(config.BaseDomain))
+		case "header":
+			if len(parts) != 2 {
+				panic("echo: tenant middleware lookup requires a header name: " + source)
+			}
+			extractors = append(extractors, tenantFromHeader(parts[1]))
+		case "path":
+			extractors = append(extractors, tenantFromPath(config.RewritePath))
+		default:
+			panic("echo: invalid tenant middleware lookup: " + source)
+		}
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			var (
+				key     string
+				rewrite func()
+			)
+			for _, extract := range extractors {
+				if key, rewrite = extract(c); key != "" {
+					break
+				}
+			}
+			if key == "" {
+				return echo.NewHTTPError(http.StatusBadRequest, "missing tenant")
+			}
+			tenant, err := config.Resolver(c, key)
+			if err != nil {
+				return err
+			}
+			if tenant == nil {
+				return echo.NewHTTPError(http.StatusNotFound, "unknown tenant")
+			}
+			if rewrite != nil {
+				rewrite()
+			}
+			c.Set(config.ContextKey, tenant)
+			c.LogFields("tenant", tenant.ID)
+			return next(c)
+		}
+	}
+}
+
+// TenantFromContext returns the tenant resolved by the MultiTenant middleware
+// configured with the default context key, or nil.
+func TenantFromContext(c echo.Context) *Tenant {
+	t, _ := c.Get(DefaultMultiTenantConfig.ContextKey).(*Tenant)
+	return t
+}
+
+func tenantFromSubdomain(baseDomain string) tenantExtractor {
+	baseDomain = "." + strings.ToLower(strings.Trim(baseDomain, "."))
+	return func(c echo.Context) (string, func()) {
+		host := c.Request().Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(host)
+		if net.ParseIP(host) != nil {
+			return "", nil
+		}
+		if baseDomain != "." {
+			if !strings.HasSuffix(host, baseDomain) {
+				return "", nil
+			}
+			host = strings.TrimSuffix(host, baseDomain)
+		} else if strings.Count(host, ".") < 2 {
+			return "", nil
+		}
+		return strings.SplitN(host, ".", 2)[0], nil
+	}
+}
+
+func tenantFromHeader(header string) tenantExtractor {
+	return func(c echo.Context) (string, func()) {
+		return c.Request().Header.Get(header), nil
+	}
+}
+
+func tenantFromPath(rewritePath bool) tenantExtractor {
+	return func(c echo.Context) (string, func()) {
+		req := c.Request()
+		path := strings.TrimPrefix(req.URL.Path, "/")
+		key := strings.SplitN(path, "/", 2)[0]
+		if key == "" || !rewritePath {
+			return key, nil
+		}
+		return key, func() {
+			req.URL.Path = strings.TrimPrefix(path, key)
+			if req.URL.Path == "" {
+				req.URL.Path = "/"
+			}
+			if req.URL.RawPath != "" {
+				raw := strings.TrimPrefix(req.URL.RawPath, "/")
+				req.URL.RawPath = raw[strings.IndexByte(raw+"/", '/'):]
+				if req.URL.RawPath == "" {
+					req.URL.RawPath = "/"
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiTenant(t *testing.T) {
+	tenants := map[string]*Tenant{
+		"acme":   {ID: "acme", Name: "ACME Corp"},
+		"globex": {ID: "globex", Name: "Globex"},
+	}
+	resolver := func(c echo.Context, key string) (*Tenant, error) {
+		if key == "fail" {
+			return nil, errors.New("database down")
+		}
+		return tenants[key], nil
+	}
+	handler := func(c echo.Context) error {
+		return c.String(http.StatusOK, TenantFromContext(c).Name+" "+c.Request().URL.Path)
+	}
+
+	tests := []struct {
+		name   string
+		config MultiTenantConfig
+		host   string
+		target string
+		header string
+		code   int
+		body   string
+	}{
+		{name: "header", target: "/users", header: "acme", code: http.StatusOK, body: "ACME Corp /users"},
+		{name: "missing", target: "/users", code: http.StatusBadRequest},
+		{name: "unknown", target: "/users", header: "initech", code: http.StatusNotFound},
+		{name: "resolver error", target: "/users", header: "fail", code: http.StatusInternalServerError},
+		{
+			name:   "subdomain",
+			config: MultiTenantConfig{Lookup: "subdomain"},
+			host:   "globex.example.com:8080", target: "/", code: http.StatusOK, body: "Globex /",
+		},
+		{
+			name:   "subdomain base domain",
+			config: MultiTenantConfig{Lookup: "subdomain", BaseDomain: "app.example.com"},
+			host:   "acme.app.example.com", target: "/", code: http.StatusOK, body: "ACME Corp /",
+		},
+		{
+			name:   "no subdomain",
+			config: MultiTenantConfig{Lookup: "subdomain", BaseDomain: "app.example.com"},
+			host:   "app.example.com", target: "/", code: http.StatusBadRequest,
+		},
+		{
+			name:   "path",
+			config: MultiTenantConfig{Lookup: "path"},
+			target: "/acme/users", code: http.StatusOK, body: "ACME Corp /acme/users",
+		},
+		{
+			name:   "path rewrite",
+			config: MultiTenantConfig{Lookup: "path", RewritePath: true},
+			target: "/acme/users", code: http.StatusOK, body: "ACME Corp /users",
+		},
+		{
+			name:   "fallback",
+			config: MultiTenantConfig{Lookup: "subdomain, header:X-Tenant-ID"},
+			host:   "localhost", target: "/", header: "globex", code: http.StatusOK, body: "Globex /",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			tt.config.Resolver = resolver
+			e.Pre(MultiTenantWithConfig(tt.config))
+			e.GET("/*", handler)
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			assert.Equal(t, tt.code, rec.Code)
+			if tt.body != "" {
+				assert.Equal(t, tt.body, rec.Body.String())
+			}
+		})
+	}
+
+	assert.Panics(t, func() { MultiTenant(nil) })
+	assert.Panics(t, func() { MultiTenantWithConfig(MultiTenantConfig{Lookup: "query:tenant", Resolver: resolver}) })
+}
@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	stdContext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// TraceConfig defines the config for Trace middleware.
+	TraceConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// ContextKey is the key the `*TraceContext` is stored under in the context.
+		// Optional. Default value "trace".
+		ContextKey string
+
+		// DisableB3 disables reading and injecting the B3 headers.
+		// Optional. Default value false.
+		DisableB3 bool
+
+		// DisableTraceparent disables reading and injecting the W3C Trace Context
+		// headers.
+		// Optional. Default value false.
+		DisableTraceparent bool
+	}
+
+	// TraceContext identifies the trace and span of a request. It is read from
+	// the W3C `traceparent`/`tracestate` or the B3 headers of the request, a new
+	// trace is started if there are none.
+	TraceContext struct {
+		TraceID      string // 32 hex characters
+		SpanID       string // 16 hex characters, span of the current request
+		ParentSpanID string // Span of the caller, empty for a new trace
+		Sampled      bool
+		State        string // W3C `tracestate`
+
+		b3          bool
+		traceparent bool
+	}
+
+	// traceTransport injects the trace headers into outgoing requests.
+	traceTransport struct {
+		base http.RoundTripper
+	}
+
+	traceKey struct{}
+)
+
+// Trace headers
+const (
+	HeaderTraceparent     = "Traceparent"
+	HeaderTracestate      = "Tracestate"
+	HeaderB3              = "B3"
+	HeaderB3TraceID       = "X-B3-Traceid"
+	HeaderB3SpanID        = "X-B3-Spanid"
+	HeaderB3ParentSpanID  = "X-B3-Parentspanid"
+	HeaderB3Sampled       = "X-B3-Sampled"
+	HeaderB3Flags         = "X-B3-Flags"
+	traceparentVersion    = "00"
+	traceparentSampledBit = 0x01
+)
+
+var (
+	// DefaultTraceConfig is the default Trace middleware config.
+	DefaultTraceConfig = TraceConfig{
+		Skipper:    DefaultSkipper,
+		ContextKey: "trace",
+	}
+)
+
+// Trace returns a middleware that reads the B3 and W3C trace headers and exposes
+// the `*TraceContext` in the context and in the std context of the request, see
+// `TraceFromContext()` and `TraceTransport()`.
+func Trace() echo.MiddlewareFunc {
+	return TraceWithConfig(DefaultTraceConfig)
+}
+
+// TraceWithConfig returns a Trace middleware with config.
+// See: `Trace()`.
+func TraceWithConfig(config TraceConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultTraceConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultTraceConfig.ContextKey
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			tc := &TraceContext{
+				b3:          !config.DisableB3,
+				traceparent: !config.DisableTraceparent,
+			}
+			ok := tc.traceparent && tc.readTraceparent(req.Header)
+			if !ok && tc.b3 {
+				ok = tc.readB3(req.Header)
+			}
+			if !ok {
+				tc.TraceID = randomHex(16)
+				tc.Sampled = true
+			}
+			tc.SpanID = randomHex(8)
+
+			c.Set(config.ContextKey, tc)
+			c.SetRequest(req.WithContext(stdContext.WithValue(req.Context(), traceKey{}, tc)))
+			return next(c)
+		}
+	}
+}
+
+// TraceFromContext returns the `*TraceContext` stored in the std context by the
+// Trace middleware.
+func TraceFromContext(ctx stdContext.Context) (*TraceContext, bool) {
+	tc, ok := ctx.Value(traceKey{}).(*TraceContext)
+	return tc, ok
+}
+
+// TraceTransport returns an `http.RoundTripper` injecting the trace headers of
+// the `*TraceContext` found in the std context of outgoing requests, so that
+// downstream services continue the trace. It uses `http.DefaultTransport` if
+// `base` is nil.
+//
+// Example:
+//
+//	client := &http.Client{Transport: middleware.TraceTransport(nil)}
+//	req, _ := http.NewRequestWithContext(c.Request().Context(), http.MethodGet, url, nil)
+//	res, err := client.Do(req)
+func TraceTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &traceTransport{base: base}
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tc, ok := TraceFromContext(req.Context())
+	if !ok {
+		return t.base.RoundTrip(req)
+	}
+	// A RoundTripper must not modify the request
+	r := new(http.Request)
+	*r = *req
+	r.Header = req.Header.Clone()
+	tc.Inject(r.Header)
+	return t.base.RoundTrip(r)
+}
+
+// Inject sets the headers of an outgoing request made on behalf of the current
+// span.
+func (tc *TraceContext) Inject(h http.Header) {
+	if tc.traceparent {
+		flags := "00"
+		if tc.Sampled {
+			flags = "01"
+		}
+		h.Set(HeaderTraceparent, traceparentVersion+"-"+tc.TraceID+"-"+tc.SpanID+"-"+flags)
+		if tc.State != "" {
+			h.Set(HeaderTracestate, tc.State)
+		}
+	}
+	if tc.b3 {
+		h.Set(HeaderB3TraceID, tc.TraceID)
+		h.Set(HeaderB3SpanID, randomHex(8))
+		h.Set(HeaderB3ParentSpanID, tc.SpanID)
+		sampled := "0"
+		if tc.Sampled {
+			sampled = "1"
+		}
+		h.Set(HeaderB3Sampled, sampled)
+	}
+}
+
+func (tc *TraceContext) readTraceparent(h http.Header) bool {
+	parts := strings.Split(strings.TrimSpace(h.Get(HeaderTraceparent)), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" ||
+		(parts[0] == traceparentVersion && len(parts) != 4) ||
+		!isTraceID(parts[1], 32) || !isTraceID(parts[2], 16) || !isHex(parts[3], 2) {
+		return false
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	tc.TraceID = parts[1]
+	tc.ParentSpanID = parts[2]
+	tc.Sampled = flags[0]&traceparentSampledBit != 0
+	tc.State = h.Get(HeaderTracestate)
+	return true
+}
+
+func (tc *TraceContext) readB3(h http.Header) bool {
+	var traceID, spanID, sampled string
+	if single := h.Get(HeaderB3); single != "" {
+		// {TraceId}-{SpanId}-{SamplingState}-{ParentSpanId}
+		parts := strings.Split(single, "-")
+		if len(parts) < 2 {
+			return false
+		}
+		traceID, spanID = parts[0], parts[1]
+		if len(parts) > 2 {
+			sampled = parts[2]
+		}
+	} else {
+		traceID, spanID = h.Get(HeaderB3TraceID), h.Get(HeaderB3SpanID)
+		sampled = h.Get(HeaderB3Sampled)
+		if h.Get(HeaderB3Flags) == "1" {
+			sampled = "d"
+		}
+	}
+	if len(traceID) == 16 {
+		traceID = strings.Repeat("0", 16) + traceID // 64 bit trace ID
+	}
+	traceID, spanID = strings.ToLower(traceID), strings.ToLower(spanID)
+	if !isTraceID(traceID, 32) || !isTraceID(spanID, 16) {
+		return false
+	}
+	tc.TraceID = traceID
+	tc.ParentSpanID = spanID
+	tc.Sampled = sampled != "0" && sampled != "false"
+	return true
+}
+
+// isTraceID reports whether s is a valid, i.e. non-zero, trace or span ID of
+// length n.
+func isTraceID(s string, n int) bool {
+	return isHex(s, n) && strings.Trim(s, "0") != ""
+}
+
+// isHex reports whether s is a lowercase hex string of length n.
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
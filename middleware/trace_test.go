@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrace(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  map[string]string
+		traceID string
+		parent  string
+		sampled bool
+	}{
+		{
+			name:    "traceparent",
+			header:  map[string]string{HeaderTraceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", HeaderTracestate: "congo=t61rcWkgMzE"},
+			traceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+			parent:  "00f067aa0ba902b7",
+			sampled: true,
+		},
+		{
+			name:    "b3 multi",
+			header:  map[string]string{HeaderB3TraceID: "463ac35c9f6413ad", HeaderB3SpanID: "a2fb4a1d1a96d312", HeaderB3Sampled: "0"},
+			traceID: "0000000000000000463ac35c9f6413ad",
+			parent:  "a2fb4a1d1a96d312",
+		},
+		{
+			name:    "b3 single",
+			header:  map[string]string{HeaderB3: "80f198ee56343ba864fe8b2a57d3eff7-e457b5a2e4d86bd1-1-05e3ac9a4f6e3b90"},
+			traceID: "80f198ee56343ba864fe8b2a57d3eff7",
+			parent:  "e457b5a2e4d86bd1",
+			sampled: true,
+		},
+		{
+			name:    "invalid traceparent starts a trace",
+			header:  map[string]string{HeaderTraceparent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01"},
+			sampled: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+			h := Trace()(func(c echo.Context) error {
+				tc := c.Get("trace").(*TraceContext)
+				fromStd, ok := TraceFromContext(c.Request().Context())
+				assert.True(t, ok)
+				assert.Same(t, tc, fromStd)
+				if tt.traceID != "" {
+					assert.Equal(t, tt.traceID, tc.TraceID)
+				} else {
+					assert.Len(t, tc.TraceID, 32)
+				}
+				assert.Equal(t, tt.parent, tc.ParentSpanID)
+				assert.Len(t, tc.SpanID, 16)
+				assert.Equal(t, tt.sampled, tc.Sampled)
+				return nil
+			})
+			assert.NoError(t, h(c))
+		})
+	}
+}
+
+func TestTraceTransport(t *testing.T) {
+	var got http.Header
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+	}))
+	defer upstream.Close()
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	req.Header.Set(HeaderTracestate, "congo=t61rcWkgMzE")
+	c := e.NewContext(req, httptest.NewRecorder())
+	client := &http.Client{Transport: TraceTransport(nil)}
+	var tc *TraceContext
+	h := Trace()(func(c echo.Context) error {
+		tc = c.Get("trace").(*TraceContext)
+		out, _ := http.NewRequest(http.MethodGet, upstream.URL, nil)
+		res, err := client.Do(out.WithContext(c.Request().Context()))
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	})
+	if assert.NoError(t, h(c)) {
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-"+tc.SpanID+"-01", got.Get(HeaderTraceparent))
+		assert.Equal(t, "congo=t61rcWkgMzE", got.Get(HeaderTracestate))
+		assert.Equal(t, tc.TraceID, got.Get(HeaderB3TraceID))
+		assert.Equal(t, tc.SpanID, got.Get(HeaderB3ParentSpanID))
+		assert.Len(t, got.Get(HeaderB3SpanID), 16)
+		assert.Equal(t, "1", got.Get(HeaderB3Sampled))
+	}
+}
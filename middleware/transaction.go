@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// TransactionConfig defines the config for Transaction middleware.
+	TransactionConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Transactor begins the transactions.
+		// Required.
+		Transactor Transactor
+
+		// ContextKey is the key the transaction is stored under in the context.
+		// Optional. Default value "tx".
+		ContextKey string
+	}
+
+	// Transactor begins transactions, e.g. of a database.
+	Transactor interface {
+		Begin(ctx context.Context) (Tx, error)
+	}
+
+	// TransactorFunc is an adapter to use functions as transactors.
+	TransactorFunc func(ctx context.Context) (Tx, error)
+
+	// Tx is a transaction. `*sql.Tx` implements it.
+	Tx interface {
+		Commit() error
+		Rollback() error
+	}
+
+	transactionWriter struct {
+		http.ResponseWriter
+		tx   Tx
+		c    echo.Context
+		done bool
+		err  error
+	}
+)
+
+var (
+	// DefaultTransactionConfig is the default Transaction middleware config.
+	DefaultTransactionConfig = TransactionConfig{
+		Skipper:    DefaultSkipper,
+		ContextKey: "tx",
+	}
+)
+
+// Begin implements `Transactor`.
+func (fn TransactorFunc) Begin(ctx context.Context) (Tx, error) {
+	return fn(ctx)
+}
+
+// Transaction returns a middleware running each request in a transaction,
+// stored under the key "tx" in the context. The transaction is committed when
+// the handler succeeds with a status code below 400, and rolled back when it
+// returns an error, panics or responds with an error status code. The commit
+// happens before the response is sent, so that a commit error is sent as
+// "500 - Internal Server Error" response instead.
+//
+// Example:
+//
+//	e.Use(middleware.Transaction(middleware.TransactorFunc(func(ctx context.Context) (middleware.Tx, error) {
+//	  return db.BeginTx(ctx, nil)
+//	})))
+//	e.POST("/orders", func(c echo.Context) error {
+//	  tx := c.Get("tx").(*sql.Tx)
+//	  ...
+//	})
+func Transaction(t Transactor) echo.MiddlewareFunc {
+	c := DefaultTransactionConfig
+	c.Transactor = t
+	return TransactionWithConfig(c)
+}
+
+// TransactionWithConfig returns a Transaction middleware with config.
+// See: `Transaction()`.
+func TransactionWithConfig(config TransactionConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultTransactionConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultTransactionConfig.ContextKey
+	}
+	if config.Transactor == nil {
+		panic("echo: transaction middleware requires a transactor")
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			tx, err := config.Transactor.Begin(c.Request().Context())
+			if err != nil {
+				return err
+			}
+			c.Set(config.ContextKey, tx)
+
+			res := c.Response()
+			w := &transactionWriter{ResponseWriter: res.Writer, tx: tx, c: c}
+			res.Writer = w
+			finished := false
+			defer func() {
+				res.Writer = w.ResponseWriter
+				if !finished {
+					// The handler panicked
+					w.end(false)
+				}
+			}()
+			err = next(c)
+			finished = true
+
+			if !w.done {
+				if cerr := w.end(err == nil && res.Status < http.StatusBadRequest); cerr != nil {
+					return cerr
+				}
+				return err
+			}
+			if w.err != nil {
+				// Nothing was sent, the error handler sends the response
+				res.Committed = false
+				res.Status = 0
+				res.Size = 0
+				res.Header().Del(echo.HeaderContentLength)
+				return w.err
+			}
+			return err
+		}
+	}
+}
+
+// end commits or rolls back the transaction, once.
+func (w *transactionWriter) end(commit bool) error {
+	if w.done {
+		return w.err
+	}
+	w.done = true
+	if !commit {
+		if err := w.tx.Rollback(); err != nil {
+			w.c.Logger().Errorf("transaction: rollback: %v", err)
+		}
+		return nil
+	}
+	w.err = w.tx.Commit()
+	return w.err
+}
+
+func (w *transactionWriter) WriteHeader(code int) {
+	if w.end(code < http.StatusBadRequest) != nil {
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *transactionWriter) Write(b []byte) (int, error) {
+	if !w.done {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.err != nil {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *transactionWriter) Flush() {
+	if w.err == nil {
+		w.ResponseWriter.(http.Flusher).Flush()
+	}
+}
+
+func (w *transactionWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type testTx struct {
+	committed  bool
+	rolledBack bool
+	commitErr  error
+}
+
+func (tx *testTx) Commit() error {
+	if tx.commitErr != nil {
+		return tx.commitErr
+	}
+	tx.committed = true
+	return nil
+}
+
+func (tx *testTx) Rollback() error {
+	tx.rolledBack = true
+	return nil
+}
+
+func TestTransaction(t *testing.T) {
+	var tx *testTx
+	mw := Transaction(TransactorFunc(func(ctx context.Context) (Tx, error) {
+		tx = &testTx{}
+		return tx, nil
+	}))
+	e := echo.New()
+	run := func(h echo.HandlerFunc) error {
+		c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+		return mw(func(c echo.Context) error {
+			assert.Same(t, tx, c.Get("tx"))
+			return h(c)
+		})(c)
+	}
+
+	// Commit
+	assert.NoError(t, run(func(c echo.Context) error {
+		return c.NoContent(http.StatusCreated)
+	}))
+	assert.True(t, tx.committed)
+	assert.False(t, tx.rolledBack)
+
+	// Error
+	assert.EqualError(t, run(func(c echo.Context) error {
+		return errors.New("insert failed")
+	}), "insert failed")
+	assert.False(t, tx.committed)
+	assert.True(t, tx.rolledBack)
+
+	// Error status
+	assert.NoError(t, run(func(c echo.Context) error {
+		return c.NoContent(http.StatusServiceUnavailable)
+	}))
+	assert.False(t, tx.committed)
+	assert.True(t, tx.rolledBack)
+
+	// Panic
+	assert.Panics(t, func() {
+		run(func(c echo.Context) error {
+			panic("boom")
+		})
+	})
+	assert.False(t, tx.committed)
+	assert.True(t, tx.rolledBack)
+
+	// Commit error
+	tx = &testTx{commitErr: errors.New("serialization failure")}
+	e.Use(Transaction(TransactorFunc(func(ctx context.Context) (Tx, error) {
+		return tx, nil
+	})))
+	e.POST("/", func(c echo.Context) error {
+		return c.JSON(http.StatusCreated, echo.Map{"id": 1})
+	})
+	e.POST("/empty", func(c echo.Context) error {
+		return nil
+	})
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), `"id"`)
+	assert.False(t, tx.committed)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/empty", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	// Begin error
+	mw = Transaction(TransactorFunc(func(ctx context.Context) (Tx, error) {
+		return nil, errors.New("pool exhausted")
+	}))
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+	assert.EqualError(t, mw(func(c echo.Context) error {
+		return nil
+	})(c), "pool exhausted")
+
+	assert.Panics(t, func() {
+		Transaction(nil)
+	})
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"mime"
+	"net"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// TransformConfig defines the config for Transform middleware.
+	TransformConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Transform returns the transformed response body, e.g. minified.
+		// Required.
+		Transform func(c echo.Context, body []byte) ([]byte, error)
+
+		// MediaTypes lists the media types of the responses transformed, e.g.
+		// "text/html".
+		// Optional. By default responses of all media types are transformed.
+		MediaTypes []string
+
+		// MaxSize is the maximum size (in bytes) of the buffered body. Larger
+		// bodies are streamed as is.
+		// Optional. Default value 1 MB.
+		MaxSize int `yaml:"max_size"`
+	}
+
+	transformWriter struct {
+		http.ResponseWriter
+		mediaTypes []string
+		maxSize    int
+		buf        bytes.Buffer
+		code       int
+		buffering  bool
+	}
+)
+
+var (
+	// DefaultTransformConfig is the default Transform middleware config.
+	DefaultTransformConfig = TransformConfig{
+		Skipper: DefaultSkipper,
+		MaxSize: 1 << 20,
+	}
+)
+
+// Transform returns a middleware transforming the response bodies with `fn`
+// before they are sent, e.g. to minify HTML or to redact JSON fields. Bodies
+// are buffered up to 1 MB; larger bodies, flushed responses (e.g. server-sent
+// events) and encoded responses are streamed as is. If `fn` fails, its error
+// is returned and nothing is sent.
+func Transform(fn func(c echo.Context, body []byte) ([]byte, error)) echo.MiddlewareFunc {
+	c := DefaultTransformConfig
+	c.Transform = fn
+	return TransformWithConfig(c)
+}
+
+// TransformWithConfig returns a Transform middleware with config.
+// See: `Transform()`.
+func TransformWithConfig(config TransformConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultTransformConfig.Skipper
+	}
+	if config.MaxSize == 0 {
+		config.MaxSize = DefaultTransformConfig.MaxSize
+	}
+	if config.Transform == nil {
+		panic("echo: transform middleware requires a transform function")
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			res := c.Response()
+			w := &transformWriter{ResponseWriter: res.Writer, mediaTypes: config.MediaTypes, maxSize: config.MaxSize}
+			res.Writer = w
+			err := next(c)
+			res.Writer = w.ResponseWriter
+			if !w.buffering {
+				return err
+			}
+
+			b, terr := config.Transform(c, w.buf.Bytes())
+			if terr != nil {
+				// Nothing was sent, let the error handler send the response
+				res.Committed = false
+				res.Size = 0
+				return terr
+			}
+			w.ResponseWriter.WriteHeader(w.code)
+			if _, werr := w.ResponseWriter.Write(b); werr != nil && err == nil {
+				err = werr
+			}
+			return err
+		}
+	}
+}
+
+func (w *transformWriter) WriteHeader(code int) {
+	if code != http.StatusNoContent && code != http.StatusNotModified && w.Header().Get(echo.HeaderContentEncoding) == "" && w.transforms() {
+		w.buffering = true
+		w.code = code
+		w.Header().Del(echo.HeaderContentLength)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// transforms reports whether the media type of the response is transformed.
+func (w *transformWriter) transforms() bool {
+	if len(w.mediaTypes) == 0 {
+		return true
+	}
+	mediaType, _, _ := mime.ParseMediaType(w.Header().Get(echo.HeaderContentType))
+	for _, t := range w.mediaTypes {
+		if t == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *transformWriter) Write(b []byte) (int, error) {
+	if !w.buffering {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.buf.Len()+len(b) <= w.maxSize {
+		return w.buf.Write(b)
+	}
+	if err := w.passthrough(); err != nil {
+		return 0, err
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// passthrough stops buffering, sending the buffered body as is.
+func (w *transformWriter) passthrough() error {
+	w.buffering = false
+	w.ResponseWriter.WriteHeader(w.code)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+func (w *transformWriter) Flush() {
+	if w.buffering {
+		w.passthrough()
+	}
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+func (w *transformWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return w.ResponseWriter.(http.Hijacker).Hijack()
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransform(t *testing.T) {
+	e := echo.New()
+	e.Use(TransformWithConfig(TransformConfig{
+		MediaTypes: []string{echo.MIMETextHTML},
+		MaxSize:    16,
+		Transform: func(c echo.Context, body []byte) ([]byte, error) {
+			if c.QueryParam("fail") != "" {
+				return nil, errors.New("invalid html")
+			}
+			return bytes.Join(bytes.Fields(body), []byte(" ")), nil
+		},
+	}))
+	e.GET("/", func(c echo.Context) error {
+		return c.HTML(http.StatusOK, c.QueryParam("body"))
+	})
+	e.GET("/text", func(c echo.Context) error {
+		return c.String(http.StatusOK, "a   b")
+	})
+	e.GET("/stream", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, echo.MIMETextHTML)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte("a   b"))
+		c.Response().Flush()
+		c.Response().Write([]byte("   c"))
+		return nil
+	})
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec
+	}
+
+	rec := serve("/?body=a++++b")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "a b", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentLength))
+
+	// Other media types
+	assert.Equal(t, "a   b", serve("/text").Body.String())
+
+	// Larger bodies are streamed as is
+	long := strings.Repeat("a  ", 10)
+	assert.Equal(t, long, serve("/?body="+strings.ReplaceAll(long, " ", "+")).Body.String())
+
+	// Flushed responses are streamed as is
+	assert.Equal(t, "a   b   c", serve("/stream").Body.String())
+
+	// Transform errors
+	rec = serve("/?body=a&fail=1")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Internal Server Error")
+
+	assert.Panics(t, func() {
+		Transform(nil)
+	})
+}
@@ -2,8 +2,25 @@ package middleware
 
 import (
 	"strings"
+
+	"github.com/labstack/echo/v4"
 )
 
+// routeOverride returns the value set under key on the route the request was
+// routed to, e.g. with `echo.Route#BodyLimit()`.
+func routeOverride(c echo.Context, key string) (interface{}, bool) {
+	e := c.Echo()
+	if e == nil {
+		return nil, false
+	}
+	r := e.MatchedRoute(c)
+	if r == nil {
+		return nil, false
+	}
+	v, ok := r.Meta()[key]
+	return v, ok
+}
+
 func matchScheme(domain, pattern string) bool {
 	didx := strings.Index(domain, ":")
 	pidx := strings.Index(pattern, ":")
@@ -0,0 +1,223 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// WebhookConfig defines the config for Webhook middleware.
+	WebhookConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// SignatureHeader is the request header holding the signature.
+		// Required.
+		SignatureHeader string
+
+		// SignaturePrefix is trimmed from the signature, e.g. "sha256=".
+		// Optional.
+		SignaturePrefix string
+
+		// ParseSignature extracts the signatures and, for providers sending it
+		// along, the timestamp from the signature header.
+		// Optional. Default value returns the header with SignaturePrefix trimmed.
+		ParseSignature func(header string) (signatures []string, timestamp string)
+
+		// TimestampHeader is the request header holding the Unix time the request
+		// was signed at.
+		// Optional.
+		TimestampHeader string
+
+		// SignedPayload builds the payload the signature is computed over.
+		// Optional. Default value returns the body.
+		SignedPayload func(timestamp string, body []byte) []byte
+
+		// Hash is the hash function of the HMAC.
+		// Optional. Default value sha256.New.
+		Hash func() hash.Hash
+
+		// Encoding of the signature, "hex" or "base64".
+		// Optional. Default value "hex".
+		Encoding string
+
+		// Tolerance is the maximum age of the timestamp, protecting against
+		// replayed requests.
+		// Optional. Default value 0 (timestamp not checked).
+		Tolerance time.Duration
+
+		// Secret returns the secret of the request, e.g. looked up by tenant.
+		// Required.
+		Secret WebhookSecretProvider
+	}
+
+	// WebhookSecretProvider defines a function returning the HMAC secret of a
+	// webhook request.
+	WebhookSecretProvider func(echo.Context) ([]byte, error)
+)
+
+var (
+	// DefaultWebhookConfig is the default Webhook middleware config.
+	DefaultWebhookConfig = WebhookConfig{
+		Skipper:  DefaultSkipper,
+		Hash:     sha256.New,
+		Encoding: "hex",
+	}
+
+	// GitHubWebhookConfig verifies GitHub webhooks.
+	GitHubWebhookConfig = WebhookConfig{
+		SignatureHeader: "X-Hub-Signature-256",
+		SignaturePrefix: "sha256=",
+	}
+
+	// StripeWebhookConfig verifies Stripe webhooks.
+	StripeWebhookConfig = WebhookConfig{
+		SignatureHeader: "Stripe-Signature",
+		ParseSignature:  parseStripeSignature,
+		SignedPayload: func(timestamp string, body []byte) []byte {
+			return append([]byte(timestamp+"."), body...)
+		},
+		Tolerance: 5 * time.Minute,
+	}
+
+	// SlackWebhookConfig verifies Slack requests.
+	SlackWebhookConfig = WebhookConfig{
+		SignatureHeader: "X-Slack-Signature",
+		SignaturePrefix: "v0=",
+		TimestampHeader: "X-Slack-Request-Timestamp",
+		SignedPayload: func(timestamp string, body []byte) []byte {
+			return append([]byte("v0:"+timestamp+":"), body...)
+		},
+		Tolerance: 5 * time.Minute,
+	}
+
+	// ShopifyWebhookConfig verifies Shopify webhooks.
+	ShopifyWebhookConfig = WebhookConfig{
+		SignatureHeader: "X-Shopify-Hmac-Sha256",
+		Encoding:        "base64",
+	}
+)
+
+// Webhook returns a middleware verifying the HMAC signature of webhook requests
+// against the raw body, see `echo.Context#Body()`, using the config of a
+// provider, e.g. `GitHubWebhookConfig`, and a static secret.
+//
+// For a missing signature, it sends "400 - Bad Request" response.
+// For an invalid or expired signature, it sends "401 - Unauthorized" response.
+func Webhook(provider WebhookConfig, secret []byte) echo.MiddlewareFunc {
+	provider.Secret = func(echo.Context) ([]byte, error) {
+		return secret, nil
+	}
+	return WebhookWithConfig(provider)
+}
+
+// WebhookWithConfig returns a Webhook middleware with config.
+// See: `Webhook()`.
+func WebhookWithConfig(config WebhookConfig) echo.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultWebhookConfig.Skipper
+	}
+	if config.Hash == nil {
+		config.Hash = DefaultWebhookConfig.Hash
+	}
+	if config.Encoding == "" {
+		config.Encoding = DefaultWebhookConfig.Encoding
+	}
+	if config.ParseSignature == nil {
+		prefix := config.SignaturePrefix
+		config.ParseSignature = func(header string) ([]string, string) {
+			return []string{strings.TrimPrefix(header, prefix)}, ""
+		}
+	}
+	if config.SignedPayload == nil {
+		config.SignedPayload = func(_ string, body []byte) []byte {
+			return body
+		}
+	}
+	if config.SignatureHeader == "" {
+		panic("echo: webhook middleware requires a signature header")
+	}
+	if config.Secret == nil {
+		panic("echo: webhook middleware requires a secret provider")
+	}
+	var decode func(string) ([]byte, error)
+	switch config.Encoding {
+	case "hex":
+		decode = hex.DecodeString
+	case "base64":
+		decode = base64.StdEncoding.DecodeString
+	default:
+		panic("echo: webhook middleware: unsupported signature encoding " + config.Encoding)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			header := c.Request().Header.Get(config.SignatureHeader)
+			if header == "" {
+				return echo.NewHTTPError(http.StatusBadRequest, "missing webhook signature")
+			}
+			signatures, timestamp := config.ParseSignature(header)
+			if config.TimestampHeader != "" {
+				timestamp = c.Request().Header.Get(config.TimestampHeader)
+			}
+			if config.Tolerance > 0 {
+				ts, err := strconv.ParseInt(timestamp, 10, 64)
+				if err != nil {
+					return echo.NewHTTPError(http.StatusUnauthorized, "invalid webhook timestamp").SetInternal(err)
+				}
+				if age := time.Since(time.Unix(ts, 0)); age > config.Tolerance || age < -config.Tolerance {
+					return echo.NewHTTPError(http.StatusUnauthorized, "expired webhook signature")
+				}
+			}
+
+			body, err := c.Body()
+			if err != nil {
+				return err
+			}
+			secret, err := config.Secret(c)
+			if err != nil {
+				return err
+			}
+			mac := hmac.New(config.Hash, secret)
+			mac.Write(config.SignedPayload(timestamp, body))
+			expected := mac.Sum(nil)
+			for _, s := range signatures {
+				if sig, err := decode(s); err == nil && hmac.Equal(sig, expected) {
+					return next(c)
+				}
+			}
+			return echo.NewHTTPError(http.StatusUnauthorized, "invalid webhook signature")
+		}
+	}
+}
+
+// parseStripeSignature parses a header of the form "t=<timestamp>,v1=<sig>,v1=<sig>".
+func parseStripeSignature(header string) (signatures []string, timestamp string) {
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "v1":
+			signatures = append(signatures, kv[1])
+		}
+	}
+	return
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func webhookSign(secret, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+func TestWebhook(t *testing.T) {
+	const (
+		secret = "secret"
+		body   = `{"action":"opened"}`
+	)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	tests := []struct {
+		name     string
+		provider WebhookConfig
+		header   map[string]string
+		code     int
+	}{
+		{
+			name:     "github",
+			provider: GitHubWebhookConfig,
+			header:   map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(webhookSign(secret, body))},
+		},
+		{
+			name:     "github invalid",
+			provider: GitHubWebhookConfig,
+			header:   map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(webhookSign("other", body))},
+			code:     http.StatusUnauthorized,
+		},
+		{
+			name:     "github missing",
+			provider: GitHubWebhookConfig,
+			code:     http.StatusBadRequest,
+		},
+		{
+			name:     "stripe",
+			provider: StripeWebhookConfig,
+			header:   map[string]string{"Stripe-Signature": "t=" + now + ",v1=00,v1=" + hex.EncodeToString(webhookSign(secret, now+"."+body))},
+		},
+		{
+			name:     "stripe expired",
+			provider: StripeWebhookConfig,
+			header:   map[string]string{"Stripe-Signature": "t=" + old + ",v1=" + hex.EncodeToString(webhookSign(secret, old+"."+body))},
+			code:     http.StatusUnauthorized,
+		},
+		{
+			name:     "slack",
+			provider: SlackWebhookConfig,
+			header: map[string]string{
+				"X-Slack-Signature":         "v0=" + hex.EncodeToString(webhookSign(secret, "v0:"+now+":"+body)),
+				"X-Slack-Request-Timestamp": now,
+			},
+		},
+		{
+			name:     "shopify",
+			provider: ShopifyWebhookConfig,
+			header:   map[string]string{"X-Shopify-Hmac-Sha256": base64.StdEncoding.EncodeToString(webhookSign(secret, body))},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			c := e.NewContext(req, httptest.NewRecorder())
+			h := Webhook(tt.provider, []byte(secret))(func(c echo.Context) error {
+				// Body is still readable
+				b, _ := ioutil.ReadAll(c.Request().Body)
+				assert.Equal(t, body, string(b))
+				return nil
+			})
+			err := h(c)
+			if tt.code == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, tt.code, err.(*echo.HTTPError).Code)
+			}
+		})
+	}
+}
+
+func TestWebhookWithConfig(t *testing.T) {
+	assert.Panics(t, func() {
+		WebhookWithConfig(WebhookConfig{SignatureHeader: "X-Signature"})
+	})
+	assert.Panics(t, func() {
+		WebhookWithConfig(WebhookConfig{Secret: func(echo.Context) ([]byte, error) { return nil, nil }})
+	})
+}
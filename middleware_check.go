@@ -0,0 +1,126 @@
+package echo
+
+import (
+	"sort"
+	"strings"
+)
+
+type (
+	// MiddlewareOrderRule requires middleware to run before other middleware,
+	// see `Echo#CheckMiddleware()`. Names are middleware names as listed by
+	// `Echo#RouteInfos()`, without the "WithConfig" suffix.
+	MiddlewareOrderRule struct {
+		// First is the middleware which must run first.
+		First string
+
+		// Then is the middleware which must run after First, or "*" for any
+		// other middleware.
+		Then string
+
+		// Except lists the middleware allowed to run before First when Then
+		// is "*".
+		Except []string
+
+		// Reason explains what goes wrong otherwise.
+		Reason string
+	}
+
+	// MiddlewareWarning is a violated rule of middleware order.
+	MiddlewareWarning struct {
+		// Message describes the violation.
+		Message string
+
+		// Routes lists the routes violating the rule, as "<method> <path>".
+		Routes []string
+	}
+)
+
+var (
+	// DefaultMiddlewareOrderRules are the middleware orderings known to cause
+	// problems.
+	DefaultMiddlewareOrderRules = []MiddlewareOrderRule{
+		{
+			First:  "middleware.Recover",
+			Then:   "*",
+			Except: []string{"middleware.Logger", "middleware.RequestID", "middleware.Trace"},
+			Reason: "panics in it aren't recovered",
+		},
+		{
+			First:  "middleware.Gzip",
+			Then:   "middleware.BodyDump",
+			Reason: "response bodies are dumped compressed",
+		},
+		{
+			First:  "middleware.BodyLimit",
+			Then:   "middleware.BodyDump",
+			Reason: "request bodies are read before the limit applies",
+		},
+		{First: "middleware.CORS", Then: "middleware.Authn", Reason: "preflight requests are rejected"},
+		{First: "middleware.CORS", Then: "middleware.JWT", Reason: "preflight requests are rejected"},
+		{First: "middleware.CORS", Then: "middleware.KeyAuth", Reason: "preflight requests are rejected"},
+		{First: "middleware.CORS", Then: "middleware.BasicAuth", Reason: "preflight requests are rejected"},
+	}
+)
+
+// MiddlewareChain returns the names of the middleware requests to the route go
+// through, in the order they run, or nil if there is no such route.
+func (e *Echo) MiddlewareChain(method, path string) []string {
+	for _, r := range e.RouteInfos() {
+		if r.Method == method && r.Path == path {
+			return r.Middleware
+		}
+	}
+	return nil
+}
+
+// CheckMiddleware checks the middleware chains of the routes against the
+// rules, `DefaultMiddlewareOrderRules` if none are given, e.g. to log warnings
+// at startup. Warnings are sorted by message.
+func (e *Echo) CheckMiddleware(rules ...MiddlewareOrderRule) []MiddlewareWarning {
+	if len(rules) == 0 {
+		rules = DefaultMiddlewareOrderRules
+	}
+	routes := map[string][]string{}
+	for _, r := range e.RouteInfos() {
+		chain := make([]string, len(r.Middleware))
+		for i, m := range r.Middleware {
+			chain[i] = strings.TrimSuffix(m, "WithConfig")
+		}
+		for _, rule := range rules {
+			if msg := rule.check(chain); msg != "" {
+				routes[msg] = append(routes[msg], r.Method+" "+r.Path)
+			}
+		}
+	}
+	warnings := make([]MiddlewareWarning, 0, len(routes))
+	for msg, r := range routes {
+		warnings = append(warnings, MiddlewareWarning{Message: msg, Routes: r})
+	}
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].Message < warnings[j].Message
+	})
+	return warnings
+}
+
+// check returns the violation of the rule by the chain, or "".
+func (rule MiddlewareOrderRule) check(chain []string) string {
+	first := -1
+	for i, m := range chain {
+		if m == rule.First {
+			first = i
+			break
+		}
+	}
+	if first == -1 {
+		return ""
+	}
+	for _, m := range chain[:first] {
+		if m == rule.First {
+			continue
+		}
+		if rule.Then == "*" && !containsString(rule.Except, m) || m == rule.Then {
+			return m + " runs before " + rule.First + ", " + rule.Reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package echo
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareOrderRule(t *testing.T) {
+	tests := []struct {
+		chain   []string
+		message string
+	}{
+		{chain: []string{"middleware.Logger", "middleware.Recover", "middleware.Gzip", "middleware.BodyDump"}},
+		{chain: []string{"middleware.Gzip", "middleware.Recover"},
+			message: "middleware.Gzip runs before middleware.Recover, panics in it aren't recovered"},
+		{chain: []string{"middleware.Recover", "middleware.BodyDump", "middleware.Gzip"},
+			message: "middleware.BodyDump runs before middleware.Gzip, response bodies are dumped compressed"},
+		{chain: []string{"middleware.Recover", "middleware.JWT", "middleware.CORS"},
+			message: "middleware.JWT runs before middleware.CORS, preflight requests are rejected"},
+		{chain: []string{"middleware.Authn"}},
+	}
+	for _, tt := range tests {
+		var messages []string
+		for _, rule := range DefaultMiddlewareOrderRules {
+			if msg := rule.check(tt.chain); msg != "" {
+				messages = append(messages, msg)
+			}
+		}
+		if tt.message == "" {
+			assert.Empty(t, messages, tt.chain)
+		} else {
+			assert.Equal(t, []string{tt.message}, messages, tt.chain)
+		}
+	}
+}
+
+func TestEchoCheckMiddleware(t *testing.T) {
+	e := New()
+	e.Use(testLogger())
+	e.GET("/users", listUsers, testAuth{}.Middleware)
+	e.GET("/admin", listUsers, testAuth{}.Middleware)
+	e.GET("/public", listUsers)
+
+	assert.Equal(t, []string{"v4.testLogger", "v4.testAuth.Middleware"}, e.MiddlewareChain(http.MethodGet, "/users"))
+	assert.Nil(t, e.MiddlewareChain(http.MethodPost, "/users"))
+	assert.Empty(t, e.CheckMiddleware())
+
+	warnings := e.CheckMiddleware(MiddlewareOrderRule{First: "v4.testAuth.Middleware", Then: "v4.testLogger", Reason: "requests are logged twice"})
+	assert.Equal(t, []MiddlewareWarning{{
+		Message: "v4.testLogger runs before v4.testAuth.Middleware, requests are logged twice",
+		Routes:  []string{"GET /admin", "GET /users"},
+	}}, warnings)
+}
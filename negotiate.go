@@ -0,0 +1,102 @@
+package echo
+
+import (
+	"strconv"
+	"strings"
+)
+
+type (
+	// acceptRange is a range of an "Accept" or "Accept-Language" header.
+	acceptRange struct {
+		value string
+		q     float64
+	}
+)
+
+func (c *context) Negotiate(offers ...string) string {
+	c.checkReleased()
+	c.response.AddVary(HeaderAccept)
+	return negotiate(c.request.Header.Get(HeaderAccept), offers, matchMediaRange)
+}
+
+func (c *context) NegotiateLanguage(offers ...string) string {
+	c.checkReleased()
+	c.response.AddVary(HeaderAcceptLanguage)
+	return negotiate(c.request.Header.Get(HeaderAcceptLanguage), offers, matchLanguageRange)
+}
+
+// negotiate returns the offer with the highest quality, the first one on ties.
+// `match` returns the specificity of the match of the offer by the range, or -1.
+func negotiate(header string, offers []string, match func(rng, offer string) int) string {
+	if len(offers) == 0 {
+		return ""
+	}
+	if strings.TrimSpace(header) == "" {
+		return offers[0]
+	}
+	ranges := parseAccept(header)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		// The quality of an offer is the one of its most specific range
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := match(r.value, offer); s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+func parseAccept(header string) []acceptRange {
+	ranges := []acceptRange{}
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		r := acceptRange{value: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		if r.value == "" {
+			continue
+		}
+		for _, p := range params[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				if q, err := strconv.ParseFloat(p[2:], 64); err == nil && q >= 0 && q <= 1 {
+					r.q = q
+				}
+			}
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// matchMediaRange matches media ranges such as "*/*", "text/*" and "text/html".
+func matchMediaRange(rng, offer string) int {
+	offer = strings.ToLower(offer)
+	switch {
+	case rng == offer:
+		return 2
+	case rng == "*/*":
+		return 0
+	case strings.HasSuffix(rng, "/*") && strings.HasPrefix(offer, rng[:len(rng)-1]):
+		return 1
+	}
+	return -1
+}
+
+// matchLanguageRange matches language ranges with the basic filtering of
+// RFC 4647, e.g. "en" matches "en" and "en-US".
+func matchLanguageRange(rng, offer string) int {
+	offer = strings.ToLower(offer)
+	switch {
+	case rng == offer:
+		return 2
+	case rng == "*":
+		return 0
+	case strings.HasPrefix(offer, rng+"-"):
+		return 1
+	}
+	return -1
+}
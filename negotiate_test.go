@@ -0,0 +1,60 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_Negotiate(t *testing.T) {
+	e := New()
+	offers := []string{MIMEApplicationJSON, MIMEApplicationXML, MIMETextHTML}
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", MIMEApplicationJSON},
+		{"text/html", MIMETextHTML},
+		{"application/xml;q=0.9, text/html;q=0.8", MIMEApplicationXML},
+		{"text/*, application/json;q=0.5", MIMETextHTML},
+		{"*/*;q=0.1, application/xml", MIMEApplicationXML},
+		{"*/*", MIMEApplicationJSON},
+		{"text/html;q=0, */*", MIMEApplicationJSON},
+		{"image/png", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAccept, tt.accept)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assert.Equal(t, tt.expected, c.Negotiate(offers...), tt.accept)
+		c.Negotiate(offers...)
+		assert.Equal(t, []string{HeaderAccept}, rec.Header().Values(HeaderVary))
+	}
+}
+
+func TestContext_NegotiateLanguage(t *testing.T) {
+	e := New()
+	offers := []string{"en-US", "fr", "de-DE"}
+	tests := []struct {
+		accept   string
+		expected string
+	}{
+		{"", "en-US"},
+		{"fr-CH, fr;q=0.9, en;q=0.8", "fr"},
+		{"de", "de-DE"},
+		{"EN-us", "en-US"},
+		{"es, *;q=0.5", "en-US"},
+		{"es", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(HeaderAcceptLanguage, tt.accept)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		assert.Equal(t, tt.expected, c.NegotiateLanguage(offers...), tt.accept)
+		assert.Equal(t, []string{HeaderAcceptLanguage}, rec.Header().Values(HeaderVary))
+	}
+}
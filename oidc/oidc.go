@@ -0,0 +1,497 @@
+/*
+Package oidc implements the OpenID Connect authorization code flow with PKCE
+for Echo web applications.
+
+A `Provider` serves the login, callback and logout handlers, verifies ID tokens
+against the keys published by the identity provider and stores the signed in
+user in a session. The session is available to `middleware.Authn()` through
+`Provider#Authenticator()`.
+
+Example:
+
+	p, err := oidc.New(oidc.Config{
+	  Issuer:       "https://accounts.example.com",
+	  ClientID:     "my-app",
+	  ClientSecret: os.Getenv("OIDC_CLIENT_SECRET"),
+	  RedirectURL:  "https://app.example.com/auth/callback",
+	  CookieSecret: []byte(os.Getenv("COOKIE_SECRET")),
+	})
+	if err != nil {
+	  log.Fatal(err)
+	}
+	p.Register(e)
+	e.GET("/account", account, p.RequireLogin())
+*/
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type (
+	// Config defines the config for an OpenID Connect provider.
+	Config struct {
+		// Issuer is the issuer URL of the identity provider. Endpoints are
+		// discovered from "<Issuer>/.well-known/openid-configuration" unless
+		// set explicitly.
+		// Required.
+		Issuer string
+
+		// ClientID is the client ID registered with the identity provider.
+		// Required.
+		ClientID string
+
+		// ClientSecret is the client secret registered with the identity
+		// provider. Public clients rely on PKCE only.
+		// Optional.
+		ClientSecret string
+
+		// RedirectURL is the absolute URL of the callback handler.
+		// Required.
+		RedirectURL string
+
+		// Scopes requested from the identity provider.
+		// Optional. Default value ["openid", "profile", "email"].
+		Scopes []string
+
+		// AuthURL, TokenURL and JWKSURL are the endpoints of the identity
+		// provider.
+		// Optional. Default values are discovered.
+		AuthURL  string
+		TokenURL string
+		JWKSURL  string
+
+		// LoginPath, CallbackPath and LogoutPath are the paths of the
+		// handlers registered by `Provider#Register()`.
+		// Optional. Default values "/auth/login", "/auth/callback" and
+		// "/auth/logout".
+		LoginPath    string
+		CallbackPath string
+		LogoutPath   string
+
+		// Sessions stores the signed in user.
+		// Optional. Default value is a `CookieStore` signed with `CookieSecret`.
+		Sessions SessionStore
+
+		// CookieSecret is the key cookies are signed with.
+		// Required.
+		CookieSecret []byte
+
+		// PreviousCookieSecrets are former values of CookieSecret, still
+		// accepted while the secret is rotated.
+		// Optional.
+		PreviousCookieSecrets [][]byte
+
+		// KeysTTL is how long the keys of the identity provider are cached.
+		// Unknown key IDs trigger a refresh regardless.
+		// Optional. Default value 1h.
+		KeysTTL time.Duration
+
+		// Client is the HTTP client used to call the identity provider.
+		// Optional. Default value `http.DefaultClient`.
+		Client *http.Client
+
+		// OnLogin is called after a user signed in, before the redirect to the
+		// page the login was started from. It can reject the user by returning
+		// an error or enrich the session.
+		// Optional.
+		OnLogin func(c echo.Context, s *Session) error
+	}
+
+	// Provider serves the OpenID Connect flow of an identity provider.
+	Provider struct {
+		config Config
+		keys   *keySet
+
+		mu        sync.Mutex
+		endpoints *endpoints
+	}
+
+	// Tokens are the tokens returned by the token endpoint.
+	Tokens struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		RefreshToken string `json:"refresh_token"`
+		IDToken      string `json:"id_token"`
+		ExpiresIn    int64  `json:"expires_in"`
+	}
+
+	endpoints struct {
+		AuthURL  string `json:"authorization_endpoint"`
+		TokenURL string `json:"token_endpoint"`
+		JWKSURL  string `json:"jwks_uri"`
+	}
+
+	// flowState is kept in a cookie between the login and callback handlers.
+	flowState struct {
+		State    string `json:"s"`
+		Nonce    string `json:"n"`
+		Verifier string `json:"v"`
+		ReturnTo string `json:"r"`
+	}
+)
+
+const (
+	stateCookie = "oidc_state"
+	stateMaxAge = 10 * time.Minute
+)
+
+var (
+	// DefaultConfig is the default OpenID Connect config.
+	DefaultConfig = Config{
+		Scopes:       []string{"openid", "profile", "email"},
+		LoginPath:    "/auth/login",
+		CallbackPath: "/auth/callback",
+		LogoutPath:   "/auth/logout",
+		KeysTTL:      time.Hour,
+	}
+
+	// ErrInvalidState is returned by the callback handler for a missing,
+	// expired or forged state.
+	ErrInvalidState = echo.NewHTTPError(http.StatusBadRequest, "invalid oidc state")
+)
+
+// New returns a provider with config.
+func New(config Config) (*Provider, error) {
+	// Defaults
+	if config.Issuer == "" || config.ClientID == "" || config.RedirectURL == "" {
+		return nil, errors.New("oidc: issuer, client id and redirect url are required")
+	}
+	if len(config.CookieSecret) == 0 {
+		return nil, errors.New("oidc: cookie secret is required")
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = DefaultConfig.Scopes
+	}
+	if config.LoginPath == "" {
+		config.LoginPath = DefaultConfig.LoginPath
+	}
+	if config.CallbackPath == "" {
+		config.CallbackPath = DefaultConfig.CallbackPath
+	}
+	if config.LogoutPath == "" {
+		config.LogoutPath = DefaultConfig.LogoutPath
+	}
+	if config.KeysTTL == 0 {
+		config.KeysTTL = DefaultConfig.KeysTTL
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	if config.Sessions == nil {
+		config.Sessions = &CookieStore{
+			Secret:          config.CookieSecret,
+			PreviousSecrets: config.PreviousCookieSecrets,
+			Secure:          strings.HasPrefix(config.RedirectURL, "https:"),
+		}
+	}
+	config.Issuer = strings.TrimSuffix(config.Issuer, "/")
+
+	p := &Provider{config: config}
+	p.keys = &keySet{client: config.Client, ttl: config.KeysTTL, url: func() (string, error) {
+		ep, err := p.discover()
+		if err != nil {
+			return "", err
+		}
+		return ep.JWKSURL, nil
+	}}
+	if config.AuthURL != "" && config.TokenURL != "" && config.JWKSURL != "" {
+		p.endpoints = &endpoints{AuthURL: config.AuthURL, TokenURL: config.TokenURL, JWKSURL: config.JWKSURL}
+	}
+	return p, nil
+}
+
+// Register registers the login, callback and logout handlers.
+func (p *Provider) Register(e *echo.Echo) {
+	e.GET(p.config.LoginPath, p.Login)
+	e.GET(p.config.CallbackPath, p.Callback)
+	e.POST(p.config.LogoutPath, p.Logout)
+}
+
+// Login redirects to the identity provider to sign in. The user is sent back
+// to the path in the "return_to" query param afterwards.
+func (p *Provider) Login(c echo.Context) error {
+	ep, err := p.discover()
+	if err != nil {
+		return err
+	}
+	fs := flowState{
+		State:    randomString(),
+		Nonce:    randomString(),
+		Verifier: randomString(),
+		ReturnTo: safeReturnTo(c.QueryParam("return_to")),
+	}
+	b, _ := json.Marshal(fs)
+	c.SetCookie(&http.Cookie{
+		Name:     stateCookie,
+		Value:    sign(p.config.CookieSecret, stateCookie, b),
+		Path:     p.config.CallbackPath,
+		MaxAge:   int(stateMaxAge / time.Second),
+		Secure:   strings.HasPrefix(p.config.RedirectURL, "https:"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	challenge := sha256.Sum256([]byte(fs.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {fs.State},
+		"nonce":                 {fs.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(ep.AuthURL, "?") {
+		sep = "&"
+	}
+	return c.Redirect(http.StatusFound, ep.AuthURL+sep+q.Encode())
+}
+
+// Callback completes the sign in: it exchanges the authorization code for
+// tokens, verifies the ID token and saves the session.
+func (p *Provider) Callback(c echo.Context) error {
+	if e := c.QueryParam("error"); e != "" {
+		return echo.NewHTTPError(http.StatusUnauthorized, "login failed").
+			SetInternal(fmt.Errorf("oidc: %s: %s", e, c.QueryParam("error_description")))
+	}
+
+	cookie, err := c.Cookie(stateCookie)
+	if err != nil {
+		return ErrInvalidState
+	}
+	c.SetCookie(&http.Cookie{Name: stateCookie, Path: p.config.CallbackPath, MaxAge: -1})
+	b, _, ok := verifyAny(p.config.CookieSecret, p.config.PreviousCookieSecrets, stateCookie, cookie.Value)
+	if !ok {
+		return ErrInvalidState
+	}
+	var fs flowState
+	if err := json.Unmarshal(b, &fs); err != nil || fs.State == "" || fs.State != c.QueryParam("state") {
+		return ErrInvalidState
+	}
+
+	tokens, err := p.Exchange(c, c.QueryParam("code"), fs.Verifier)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "login failed").SetInternal(err)
+	}
+	claims, err := p.Verify(tokens.IDToken)
+	if err != nil {
+		return echo.NewHTTPError(http.StatusUnauthorized, "login failed").SetInternal(err)
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != fs.Nonce {
+		return echo.NewHTTPError(http.StatusUnauthorized, "login failed").
+			SetInternal(errors.New("oidc: invalid nonce"))
+	}
+
+	s := &Session{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		IDToken:      tokens.IDToken,
+		Claims:       claims,
+	}
+	s.Subject, _ = claims["sub"].(string)
+	if tokens.ExpiresIn > 0 {
+		s.Expiry = time.Now().Add(time.Duration(tokens.ExpiresIn) * time.Second)
+	}
+	if p.config.OnLogin != nil {
+		if err := p.config.OnLogin(c, s); err != nil {
+			return err
+		}
+	}
+	if err := p.config.Sessions.Save(c, s); err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusFound, fs.ReturnTo)
+}
+
+// Logout clears the session and redirects to "/".
+func (p *Provider) Logout(c echo.Context) error {
+	if err := p.config.Sessions.Clear(c); err != nil {
+		return err
+	}
+	return c.Redirect(http.StatusFound, "/")
+}
+
+// Exchange exchanges an authorization code for tokens at the token endpoint.
+func (p *Provider) Exchange(c echo.Context, code, verifier string) (*Tokens, error) {
+	ep, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"client_id":     {p.config.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, ep.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(c.Request().Context())
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	req.Header.Set(echo.HeaderAccept, echo.MIMEApplicationJSON)
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+	res, err := p.config.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: token endpoint returned status=%d", res.StatusCode)
+	}
+	t := new(Tokens)
+	if err := json.NewDecoder(res.Body).Decode(t); err != nil {
+		return nil, err
+	}
+	if t.IDToken == "" {
+		return nil, errors.New("oidc: token response has no id_token")
+	}
+	return t, nil
+}
+
+// Verify verifies the signature, issuer, audience and expiry of an ID token
+// and returns its claims.
+func (p *Provider) Verify(idToken string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("oidc: unexpected signing method=%v", t.Header["alg"])
+		}
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.get(kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !claims.VerifyIssuer(p.config.Issuer, true) {
+		return nil, errors.New("oidc: invalid issuer")
+	}
+	if !verifyAudience(claims["aud"], p.config.ClientID) {
+		return nil, errors.New("oidc: invalid audience")
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, errors.New("oidc: id token has no expiry")
+	}
+	return claims, nil
+}
+
+// Authenticator returns an authenticator for `middleware.Authn()` whose
+// principal is the signed in user.
+func (p *Provider) Authenticator() middleware.Authenticator {
+	return middleware.AuthenticatorFunc(func(c echo.Context) (*echo.Principal, error) {
+		s, err := p.config.Sessions.Load(c)
+		if err != nil {
+			return nil, err
+		}
+		if s == nil || s.Subject == "" || s.Expired() {
+			return nil, middleware.ErrNoCredentials
+		}
+		return s.Principal(), nil
+	})
+}
+
+// RequireLogin returns a middleware redirecting users who aren't signed in to
+// the login handler, and back to the requested page afterwards. The signed in
+// user is available with `Context#Principal()`.
+func (p *Provider) RequireLogin() echo.MiddlewareFunc {
+	authn := middleware.Authn(p.Authenticator())
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		h := authn(next)
+		return func(c echo.Context) error {
+			err := h(c)
+			if he, ok := err.(*echo.HTTPError); ok && he.Code == http.StatusUnauthorized && c.Request().Method == http.MethodGet {
+				c.Response().Header().Del(echo.HeaderWWWAuthenticate)
+				return c.Redirect(http.StatusFound, p.config.LoginPath+"?"+url.Values{"return_to": {c.Request().URL.RequestURI()}}.Encode())
+			}
+			return err
+		}
+	}
+}
+
+// discover returns the endpoints of the identity provider, fetching its
+// discovery document on first use.
+func (p *Provider) discover() (*endpoints, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.endpoints != nil {
+		return p.endpoints, nil
+	}
+	res, err := p.config.Client.Get(p.config.Issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oidc: discovery returned status=%d", res.StatusCode)
+	}
+	ep := new(endpoints)
+	if err := json.NewDecoder(res.Body).Decode(ep); err != nil {
+		return nil, err
+	}
+	if p.config.AuthURL != "" {
+		ep.AuthURL = p.config.AuthURL
+	}
+	if p.config.TokenURL != "" {
+		ep.TokenURL = p.config.TokenURL
+	}
+	if p.config.JWKSURL != "" {
+		ep.JWKSURL = p.config.JWKSURL
+	}
+	if ep.AuthURL == "" || ep.TokenURL == "" || ep.JWKSURL == "" {
+		return nil, errors.New("oidc: incomplete discovery document")
+	}
+	p.endpoints = ep
+	return ep, nil
+}
+
+func verifyAudience(aud interface{}, clientID string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// safeReturnTo only allows local paths, preventing open redirects.
+func safeReturnTo(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") {
+		return "/"
+	}
+	return path
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
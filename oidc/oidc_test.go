@@ -0,0 +1,311 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type testIdP struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	claims   jwt.MapClaims
+	codes    map[string]url.Values
+	jwksHits int
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	idp := &testIdP{key: key, codes: map[string]url.Values{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.jwksHits++
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "k1",
+			"kty": "RSA",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		auth, ok := idp.codes[r.PostForm.Get("code")]
+		id, secret, _ := r.BasicAuth()
+		if !ok || id != "app" || secret != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		// PKCE
+		sum := sha256Sum(r.PostForm.Get("code_verifier"))
+		if sum != auth.Get("code_challenge") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":   idp.URL,
+			"aud":   "app",
+			"sub":   "jon",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": auth.Get("nonce"),
+			"scope": "users:read",
+		}
+		for k, v := range idp.claims {
+			claims[k] = v
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "at",
+			"token_type":   "Bearer",
+			"id_token":     idp.sign(claims),
+			"expires_in":   3600,
+		})
+	})
+	idp.Server = httptest.NewServer(mux)
+	return idp
+}
+
+func (idp *testIdP) sign(claims jwt.MapClaims) string {
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = "k1"
+	s, _ := t.SignedString(idp.key)
+	return s
+}
+
+// authorize simulates the user signing in, returning the callback URL.
+func (idp *testIdP) authorize(t *testing.T, location string) string {
+	u, err := url.Parse(location)
+	assert.NoError(t, err)
+	q := u.Query()
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	idp.codes["code"] = q
+	return q.Get("redirect_uri") + "?" + url.Values{"code": {"code"}, "state": {q.Get("state")}}.Encode()
+}
+
+func sha256Sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func newTestApp(t *testing.T, idp *testIdP) (*echo.Echo, *Provider) {
+	p, err := New(Config{
+		Issuer:       idp.URL,
+		ClientID:     "app",
+		ClientSecret: "secret",
+		RedirectURL:  "http://app.test/auth/callback",
+		CookieSecret: []byte("cookie-secret"),
+	})
+	assert.NoError(t, err)
+	e := echo.New()
+	p.Register(e)
+	e.GET("/account", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Principal().ID)
+	}, p.RequireLogin())
+	return e, p
+}
+
+func serve(e *echo.Echo, method, target string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestProvider(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.Close()
+	e, _ := newTestApp(t, idp)
+
+	// Not signed in
+	rec := serve(e, http.MethodGet, "/account?tab=1", nil)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/auth/login?return_to=%2Faccount%3Ftab%3D1", rec.Header().Get(echo.HeaderLocation))
+
+	// Login
+	rec = serve(e, http.MethodGet, rec.Header().Get(echo.HeaderLocation), nil)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	location := rec.Header().Get(echo.HeaderLocation)
+	assert.True(t, strings.HasPrefix(location, idp.URL+"/authorize?"))
+	state := rec.Result().Cookies()
+
+	// Callback
+	callback := idp.authorize(t, location)
+	rec = serve(e, http.MethodGet, callback, state)
+	if !assert.Equal(t, http.StatusFound, rec.Code, rec.Body.String()) {
+		return
+	}
+	assert.Equal(t, "/account?tab=1", rec.Header().Get(echo.HeaderLocation))
+	var session []*http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "oidc_session" {
+			session = append(session, c)
+		}
+	}
+	assert.Len(t, session, 1)
+
+	// Signed in
+	rec = serve(e, http.MethodGet, "/account", session)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "jon", rec.Body.String())
+
+	// Replayed callback
+	rec = serve(e, http.MethodGet, callback, nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Logout
+	rec = serve(e, http.MethodPost, "/auth/logout", session)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, -1, rec.Result().Cookies()[0].MaxAge)
+}
+
+func TestProviderCallbackErrors(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.Close()
+	e, _ := newTestApp(t, idp)
+
+	login := func() (string, []*http.Cookie) {
+		rec := serve(e, http.MethodGet, "/auth/login?return_to=//evil.com", nil)
+		return idp.authorize(t, rec.Header().Get(echo.HeaderLocation)), rec.Result().Cookies()
+	}
+
+	// Forged state
+	callback, cookies := login()
+	rec := serve(e, http.MethodGet, strings.Replace(callback, "state=", "state=x", 1), cookies)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Tampered state cookie
+	callback, cookies = login()
+	cookies[0].Value = "x" + cookies[0].Value
+	rec = serve(e, http.MethodGet, callback, cookies)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Wrong audience
+	idp.claims = jwt.MapClaims{"aud": "other"}
+	callback, cookies = login()
+	rec = serve(e, http.MethodGet, callback, cookies)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Open redirect
+	idp.claims = nil
+	callback, cookies = login()
+	rec = serve(e, http.MethodGet, callback, cookies)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/", rec.Header().Get(echo.HeaderLocation))
+
+	// Identity provider error
+	rec = serve(e, http.MethodGet, "/auth/callback?error=access_denied", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestProviderForgedSession(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.Close()
+	e, p := newTestApp(t, idp)
+
+	// State cookie replayed as a session
+	rec := serve(e, http.MethodGet, "/auth/login", nil)
+	state := rec.Result().Cookies()[0]
+	rec = serve(e, http.MethodGet, "/account", []*http.Cookie{{Name: "oidc_session", Value: state.Value}})
+	assert.Equal(t, http.StatusFound, rec.Code)
+
+	// Session without subject
+	rec = httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	assert.NoError(t, p.config.Sessions.Save(c, &Session{}))
+	rec = serve(e, http.MethodGet, "/account", rec.Result().Cookies())
+	assert.Equal(t, http.StatusFound, rec.Code)
+}
+
+func TestProviderVerify(t *testing.T) {
+	idp := newTestIdP(t)
+	defer idp.Close()
+	_, p := newTestApp(t, idp)
+
+	valid := jwt.MapClaims{"iss": idp.URL, "aud": []interface{}{"other", "app"}, "sub": "jon", "exp": time.Now().Add(time.Hour).Unix()}
+	claims, err := p.Verify(idp.sign(valid))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "jon", claims["sub"])
+	}
+	_, err = p.Verify(idp.sign(valid))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, idp.jwksHits, "keys are cached")
+
+	expired := jwt.MapClaims{"iss": idp.URL, "aud": "app", "exp": time.Now().Add(-time.Hour).Unix()}
+	_, err = p.Verify(idp.sign(expired))
+	assert.Error(t, err)
+
+	issuer := jwt.MapClaims{"iss": "https://evil.com", "aud": "app", "exp": time.Now().Add(time.Hour).Unix()}
+	_, err = p.Verify(idp.sign(issuer))
+	assert.Error(t, err)
+
+	hs, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, valid).SignedString([]byte("secret"))
+	_, err = p.Verify(hs)
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{Issuer: "https://accounts.example.com"})
+	assert.Error(t, err)
+	_, err = New(Config{Issuer: "https://accounts.example.com", ClientID: "app", RedirectURL: "https://app.example.com/cb"})
+	assert.Error(t, err)
+}
+
+func TestCookieStoreRotation(t *testing.T) {
+	e := echo.New()
+	old := &CookieStore{Secret: []byte("old-secret")}
+	rec := httptest.NewRecorder()
+	assert.NoError(t, old.Save(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec), &Session{Subject: "jon"}))
+	cookie := rec.Result().Cookies()[0]
+
+	store := &CookieStore{Secret: []byte("new-secret"), PreviousSecrets: [][]byte{[]byte("old-secret")}}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	s, err := store.Load(e.NewContext(req, rec))
+	if assert.NoError(t, err) && assert.NotNil(t, s) {
+		assert.Equal(t, "jon", s.Subject)
+	}
+
+	// Re-signed with the new secret
+	cookies := rec.Result().Cookies()
+	if assert.Len(t, cookies, 1) {
+		store.PreviousSecrets = nil
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.AddCookie(cookies[0])
+		rec = httptest.NewRecorder()
+		s, _ = store.Load(e.NewContext(req, rec))
+		if assert.NotNil(t, s) {
+			assert.Equal(t, "jon", s.Subject)
+		}
+		assert.Empty(t, rec.Result().Cookies(), "not re-signed")
+	}
+
+	// Unknown secret
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	s, _ = store.Load(e.NewContext(req, httptest.NewRecorder()))
+	assert.Nil(t, s)
+}
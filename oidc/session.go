@@ -0,0 +1,190 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Session is the signed in user.
+	Session struct {
+		Subject      string                 `json:"sub"`
+		Claims       map[string]interface{} `json:"claims,omitempty"`
+		AccessToken  string                 `json:"at,omitempty"`
+		RefreshToken string                 `json:"rt,omitempty"`
+		IDToken      string                 `json:"it,omitempty"`
+		Expiry       time.Time              `json:"exp,omitempty"`
+	}
+
+	// SessionStore stores the sessions of signed in users.
+	SessionStore interface {
+		// Load returns the session of the request, or nil if there is none.
+		Load(c echo.Context) (*Session, error)
+
+		// Save stores the session and associates it with the client.
+		Save(c echo.Context, s *Session) error
+
+		// Clear removes the session of the request.
+		Clear(c echo.Context) error
+	}
+
+	// CookieStore is a `SessionStore` keeping sessions in a signed cookie.
+	// Browsers limit cookies to about 4KB, set `KeepTokens` only for identity
+	// providers issuing short tokens.
+	CookieStore struct {
+		// Secret is the key cookies are signed with.
+		// Required.
+		Secret []byte
+
+		// PreviousSecrets are former values of Secret, still accepted so that
+		// rotating the secret doesn't sign users out. Sessions signed with a
+		// previous secret are re-signed with Secret when loaded.
+		// Optional.
+		PreviousSecrets [][]byte
+
+		// Name of the cookie.
+		// Optional. Default value "oidc_session".
+		Name string
+
+		// MaxAge of the cookie in seconds.
+		// Optional. Default value 0 (browser session).
+		MaxAge int
+
+		// Secure marks the cookie as secure.
+		// Optional. Default value false.
+		Secure bool
+
+		// KeepTokens stores the access, refresh and ID tokens in the cookie.
+		// Optional. Default value false.
+		KeepTokens bool
+	}
+)
+
+// Expired reports whether the access token of the session has expired.
+func (s *Session) Expired() bool {
+	return !s.Expiry.IsZero() && time.Now().After(s.Expiry)
+}
+
+// Principal returns the principal of the signed in user.
+func (s *Session) Principal() *echo.Principal {
+	p := &echo.Principal{ID: s.Subject, Method: "oidc", Claims: s.Claims}
+	if scope, ok := s.Claims["scope"].(string); ok {
+		p.Scopes = strings.Fields(scope)
+	}
+	return p
+}
+
+// Load implements `SessionStore`.
+func (s *CookieStore) Load(c echo.Context) (*Session, error) {
+	cookie, err := c.Cookie(s.name())
+	if err != nil {
+		return nil, nil
+	}
+	b, stale, ok := verifyAny(s.Secret, s.PreviousSecrets, s.name(), cookie.Value)
+	if !ok {
+		return nil, nil
+	}
+	sess := new(Session)
+	if err := json.Unmarshal(b, sess); err != nil {
+		return nil, nil
+	}
+	if stale {
+		c.SetCookie(s.cookie(sign(s.Secret, s.name(), b), s.MaxAge))
+	}
+	return sess, nil
+}
+
+// Save implements `SessionStore`.
+func (s *CookieStore) Save(c echo.Context, sess *Session) error {
+	if !s.KeepTokens {
+		cp := *sess
+		cp.AccessToken, cp.RefreshToken, cp.IDToken = "", "", ""
+		sess = &cp
+	}
+	b, err := json.Marshal(sess)
+	if err != nil {
+		return err
+	}
+	c.SetCookie(s.cookie(sign(s.Secret, s.name(), b), s.MaxAge))
+	return nil
+}
+
+// Clear implements `SessionStore`.
+func (s *CookieStore) Clear(c echo.Context) error {
+	c.SetCookie(s.cookie("", -1))
+	return nil
+}
+
+func (s *CookieStore) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     s.name(),
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   s.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (s *CookieStore) name() string {
+	if s.Name == "" {
+		return "oidc_session"
+	}
+	return s.Name
+}
+
+// sign returns the payload and its HMAC-SHA256 signature, base64 encoded. The
+// signature binds the payload to the cookie name, so that a cookie can't be
+// replayed as another, e.g. the state of a login as a session.
+func sign(secret []byte, name string, payload []byte) string {
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(cookieMAC(secret, name, payload))
+}
+
+func cookieMAC(secret []byte, name string, payload []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// verify returns the payload of a value created by `sign()` if the signature
+// is valid.
+func verify(secret []byte, name, value string) ([]byte, bool) {
+	i := strings.LastIndexByte(value, '.')
+	if i == -1 {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(value[:i])
+	if err != nil {
+		return nil, false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(value[i+1:])
+	if err != nil {
+		return nil, false
+	}
+	return payload, hmac.Equal(sig, cookieMAC(secret, name, payload))
+}
+
+// verifyAny returns the payload of a value signed with the secret or one of the
+// previous secrets, and whether it was signed with a previous secret.
+func verifyAny(secret []byte, previous [][]byte, name, value string) (payload []byte, stale, ok bool) {
+	if payload, ok = verify(secret, name, value); ok {
+		return payload, false, true
+	}
+	for _, secret := range previous {
+		if payload, ok = verify(secret, name, value); ok {
+			return payload, true, true
+		}
+	}
+	return nil, false, false
+}
@@ -0,0 +1,467 @@
+/*
+Package openapi generates an OpenAPI 3 document from the routes registered on an
+Echo instance.
+
+Routes are documented by attaching an `Operation` with `Describe()`, or with the
+summary, description, tags and deprecation set on the route, e.g. with
+`echo.Route#Summary()`. Request and response types are inspected via
+reflection: fields tagged with `param` and `query` become parameters, the
+remaining fields form the JSON request body and `validate` rules are mapped to
+schema constraints.
+
+Example:
+
+	e := echo.New()
+	openapi.Describe(e.POST("/users", createUser), openapi.Operation{
+	  Summary:   "Create a user",
+	  Tags:      []string{"users"},
+	  Request:   CreateUserRequest{},
+	  Responses: map[int]interface{}{http.StatusCreated: User{}},
+	})
+	openapi.Register(e, openapi.Config{
+	  Info:   openapi.Info{Title: "Users API", Version: "1.0.0"},
+	  UIPath: "/docs",
+	})
+*/
+package openapi
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Config defines the config for the OpenAPI document and its routes.
+	Config struct {
+		// Info describes the API.
+		// Required.
+		Info Info
+
+		// Servers lists the base URLs of the API.
+		// Optional.
+		Servers []Server
+
+		// Path the document is served at.
+		// Optional. Default value "/openapi.json".
+		Path string
+
+		// UIPath is the path of a Swagger UI page rendering the document.
+		// Optional. Default value "" (disabled).
+		UIPath string
+
+		// Skipper defines a function to leave a route out of the document.
+		// Optional. By default the document routes and routes registered with
+		// `echo.NotFoundHandler` are left out.
+		Skipper func(*echo.Route) bool
+	}
+
+	// Operation documents a route. It is attached to a route with `Describe()`.
+	Operation struct {
+		// ID is the operation id.
+		// Optional. Default value is the route name.
+		ID          string
+		Summary     string
+		Description string
+		Tags        []string
+		Deprecated  bool
+
+		// Request is a value of the type the handler binds the request into.
+		Request interface{}
+
+		// Responses maps status codes to a value of the response body type, or nil
+		// for responses without a body.
+		Responses map[int]interface{}
+	}
+
+	// Document is an OpenAPI 3 document.
+	Document struct {
+		OpenAPI    string              `json:"openapi"`
+		Info       Info                `json:"info"`
+		Servers    []Server            `json:"servers,omitempty"`
+		Paths      map[string]PathItem `json:"paths"`
+		Components *Components         `json:"components,omitempty"`
+	}
+
+	// Info is the OpenAPI info object.
+	Info struct {
+		Title       string `json:"title"`
+		Description string `json:"description,omitempty"`
+		Version     string `json:"version"`
+	}
+
+	// Server is the OpenAPI server object.
+	Server struct {
+		URL         string `json:"url"`
+		Description string `json:"description,omitempty"`
+	}
+
+	// PathItem maps lowercase HTTP methods to operations.
+	PathItem map[string]*OperationObject
+
+	// OperationObject is the OpenAPI operation object.
+	OperationObject struct {
+		OperationID string               `json:"operationId,omitempty"`
+		Summary     string               `json:"summary,omitempty"`
+		Description string               `json:"description,omitempty"`
+		Tags        []string             `json:"tags,omitempty"`
+		Deprecated  bool                 `json:"deprecated,omitempty"`
+		Parameters  []*Parameter         `json:"parameters,omitempty"`
+		RequestBody *RequestBody         `json:"requestBody,omitempty"`
+		Responses   map[string]*Response `json:"responses"`
+	}
+
+	// Parameter is the OpenAPI parameter object.
+	Parameter struct {
+		Name     string  `json:"name"`
+		In       string  `json:"in"`
+		Required bool    `json:"required,omitempty"`
+		Schema   *Schema `json:"schema"`
+	}
+
+	// RequestBody is the OpenAPI request body object.
+	RequestBody struct {
+		Required bool                 `json:"required,omitempty"`
+		Content  map[string]MediaType `json:"content"`
+	}
+
+	// Response is the OpenAPI response object.
+	Response struct {
+		Description string               `json:"description"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+
+	// MediaType is the OpenAPI media type object.
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	// Components holds the schemas referenced from the document.
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas,omitempty"`
+	}
+)
+
+const (
+	// Version is the OpenAPI specification version of generated documents.
+	Version = "3.0.3"
+
+	// MetaKey is the route meta key the operation is stored under.
+	MetaKey = "openapi"
+)
+
+var (
+	// DefaultConfig is the default OpenAPI config.
+	DefaultConfig = Config{
+		Path: "/openapi.json",
+	}
+)
+
+// Describe attaches the operation to the route and returns the route.
+func Describe(r *echo.Route, op Operation) *echo.Route {
+	return r.SetMeta(MetaKey, op)
+}
+
+// Generate builds the document for the routes registered on `e`.
+func Generate(e *echo.Echo, config Config) *Document {
+	config = withDefaults(config)
+	g := newSchemaGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    config.Info,
+		Servers: config.Servers,
+		Paths:   map[string]PathItem{},
+	}
+
+	routes := e.Routes()
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path == routes[j].Path {
+			return routes[i].Method < routes[j].Method
+		}
+		return routes[i].Path < routes[j].Path
+	})
+	ids := map[string]bool{}
+	for _, r := range routes {
+		if config.Skipper(r) || !isOperationMethod(r.Method) {
+			continue
+		}
+		op, _ := r.Meta()[MetaKey].(Operation)
+		op = withRouteDocs(r, op)
+		path, pnames := convertPath(r.Path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		o := g.operation(r, op, pnames)
+		if ids[o.OperationID] {
+			// Routes sharing a handler, made unique by method and path
+			id := o.OperationID + "_" + routeID(r)
+			for n := 2; ids[id]; n++ {
+				id = fmt.Sprintf("%s_%s_%d", o.OperationID, routeID(r), n)
+			}
+			o.OperationID = id
+		}
+		ids[o.OperationID] = true
+		item[strings.ToLower(r.Method)] = o
+	}
+
+	if len(g.schemas) > 0 {
+		doc.Components = &Components{Schemas: g.schemas}
+	}
+	return doc
+}
+
+// Register registers a route serving the document at `Config.Path` and,
+// if configured, a Swagger UI page at `Config.UIPath`. The document is generated
+// on each request so that routes added later are included.
+func Register(e *echo.Echo, config Config) {
+	config = withDefaults(config)
+	e.GET(config.Path, func(c echo.Context) error {
+		return c.JSON(http.StatusOK, Generate(e, config))
+	})
+	if config.UIPath != "" {
+		page := fmt.Sprintf(swaggerUI, html.EscapeString(config.Info.Title), config.Path)
+		e.GET(config.UIPath, func(c echo.Context) error {
+			return c.HTML(http.StatusOK, page)
+		})
+	}
+}
+
+func withDefaults(config Config) Config {
+	if config.Path == "" {
+		config.Path = DefaultConfig.Path
+	}
+	if config.Skipper == nil {
+		notFound := runtime.FuncForPC(reflect.ValueOf(echo.NotFoundHandler).Pointer()).Name()
+		path, uiPath := config.Path, config.UIPath
+		config.Skipper = func(r *echo.Route) bool {
+			return r.Name == notFound || r.Path == path || (uiPath != "" && r.Path == uiPath)
+		}
+	}
+	return config
+}
+
+// withRouteDocs fills the summary, description and tags the operation leaves
+// empty from the route, see `echo.Route#Summary()`.
+func withRouteDocs(r *echo.Route, op Operation) Operation {
+	meta := r.Meta()
+	if op.Summary == "" {
+		op.Summary, _ = meta[echo.RouteMetaSummary].(string)
+	}
+	if op.Description == "" {
+		op.Description, _ = meta[echo.RouteMetaDescription].(string)
+	}
+	if len(op.Tags) == 0 {
+		op.Tags, _ = meta[echo.RouteMetaTags].([]string)
+	}
+	if deprecated, _ := meta[echo.RouteMetaDeprecated].(bool); deprecated {
+		op.Deprecated = true
+	}
+	return op
+}
+
+func (g *schemaGenerator) operation(r *echo.Route, op Operation, pnames []string) *OperationObject {
+	o := &OperationObject{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Deprecated:  op.Deprecated,
+		Responses:   map[string]*Response{},
+	}
+	if o.OperationID == "" {
+		o.OperationID = operationID(r)
+	}
+
+	// Parameters
+	declared := map[string]bool{}
+	if op.Request != nil {
+		t := reflect.TypeOf(op.Request)
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() == reflect.Struct {
+			o.Parameters = g.parameters(t)
+			for _, p := range o.Parameters {
+				if p.In == "path" {
+					declared[p.Name] = true
+				}
+			}
+			if hasBody(r.Method) {
+				if body := g.body(t); body != nil {
+					o.RequestBody = &RequestBody{
+						Required: true,
+						Content:  map[string]MediaType{echo.MIMEApplicationJSON: {Schema: body}},
+					}
+				}
+			}
+		}
+	}
+	var path []*Parameter
+	for _, name := range pnames {
+		if !declared[name] {
+			path = append(path, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	o.Parameters = append(path, o.Parameters...)
+
+	// Responses
+	codes := make([]int, 0, len(op.Responses))
+	for code := range op.Responses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		res := &Response{Description: http.StatusText(code)}
+		if v := op.Responses[code]; v != nil {
+			res.Content = map[string]MediaType{echo.MIMEApplicationJSON: {Schema: g.schema(reflect.TypeOf(v))}}
+		}
+		o.Responses[strconv.Itoa(code)] = res
+	}
+	if len(o.Responses) == 0 {
+		o.Responses["default"] = &Response{Description: "Default response"}
+	}
+	return o
+}
+
+// parameters returns the path and query parameters bound into struct type `t`.
+func (g *schemaGenerator) parameters(t reflect.Type) (params []*Parameter) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		for _, in := range [...]string{"param", "query"} {
+			name := f.Tag.Get(in)
+			if name == "" {
+				continue
+			}
+			p := &Parameter{Name: name, In: in, Schema: g.inline(f.Type)}
+			if in == "param" {
+				p.In = "path"
+				p.Required = true
+			}
+			if applyValidate(p.Schema, f.Tag.Get("validate")) {
+				p.Required = true
+			}
+			params = append(params, p)
+		}
+	}
+	return
+}
+
+// body returns the request body schema for struct type `t`, leaving out fields
+// bound from path and query parameters.
+func (g *schemaGenerator) body(t reflect.Type) *Schema {
+	isParam := func(f reflect.StructField) bool {
+		return f.Tag.Get("json") == "" && (f.Tag.Get("param") != "" || f.Tag.Get("query") != "")
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if isParam(t.Field(i)) {
+			s := g.object(t, isParam)
+			if len(s.Properties) == 0 {
+				return nil
+			}
+			return s
+		}
+	}
+	return g.schema(t)
+}
+
+// convertPath converts an Echo route path to an OpenAPI path template, returning
+// the parameter names.
+func convertPath(path string) (string, []string) {
+	var (
+		b      strings.Builder
+		pnames []string
+	)
+	for i, l := 0, len(path); i < l; i++ {
+		switch path[i] {
+		case ':':
+			j := i + 1
+			for ; i < l && path[i] != '/'; i++ {
+			}
+			pnames = append(pnames, path[j:i])
+			b.WriteString("{" + path[j:i] + "}")
+			if i < l {
+				b.WriteByte(path[i])
+			}
+		case '*':
+			pnames = append(pnames, "*")
+			b.WriteString("{*}")
+		default:
+			b.WriteByte(path[i])
+		}
+	}
+	if b.Len() == 0 {
+		return "/", pnames
+	}
+	return b.String(), pnames
+}
+
+func operationID(r *echo.Route) string {
+	name := r.Name
+	if i := strings.LastIndexByte(name, '/'); i != -1 {
+		name = name[i+1:]
+	}
+	if strings.Contains(name, ".func") {
+		// Anonymous handler, derive from method and path instead
+		return routeID(r)
+	}
+	if i := strings.IndexByte(name, '.'); i != -1 {
+		name = name[i+1:] // Package
+	}
+	return strings.NewReplacer("(*", "", ")", "", ".", "", "-fm", "").Replace(name)
+}
+
+// routeID returns an ID of the route derived from its method and path, e.g.
+// "get_users_id" for "GET /users/:id".
+func routeID(r *echo.Route) string {
+	return strings.ToLower(r.Method) + strings.NewReplacer("/", "_", ":", "", "*", "any").Replace(r.Path)
+}
+
+// isOperationMethod reports whether the method can be described by a path item,
+// unlike e.g. CONNECT or PROPFIND registered by `Echo#Any()`.
+func isOperationMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodPost, http.MethodDelete,
+		http.MethodOptions, http.MethodHead, http.MethodPatch, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func hasBody(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>%s</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@3/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@3/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function() {
+      SwaggerUIBundle({url: %q, dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	user struct {
+		ID    int      `json:"id"`
+		Name  string   `json:"name" validate:"required,min=2,max=64"`
+		Email string   `json:"email,omitempty" validate:"email"`
+		Role  string   `json:"role" validate:"oneof=admin user"`
+		Tags  []string `json:"tags"`
+	}
+
+	updateUserRequest struct {
+		ID     int    `param:"id"`
+		Notify bool   `query:"notify"`
+		Name   string `json:"name" validate:"required"`
+	}
+
+	listUsersRequest struct {
+		Page    int `query:"page" validate:"min=1"`
+		PerPage int `query:"per_page" validate:"max=100"`
+	}
+)
+
+func getUser(c echo.Context) error {
+	return nil
+}
+
+func TestGenerate(t *testing.T) {
+	e := echo.New()
+	Describe(e.GET("/users", func(c echo.Context) error { return nil }), Operation{
+		Summary:   "List users",
+		Tags:      []string{"users"},
+		Request:   listUsersRequest{},
+		Responses: map[int]interface{}{http.StatusOK: []user{}},
+	})
+	Describe(e.GET("/users/:id", getUser), Operation{
+		Responses: map[int]interface{}{http.StatusOK: user{}, http.StatusNotFound: nil},
+	})
+	Describe(e.PUT("/users/:id", func(c echo.Context) error { return nil }), Operation{
+		ID:         "updateUser",
+		Deprecated: true,
+		Request:    &updateUserRequest{},
+	})
+	e.POST("/users", func(c echo.Context) error { return nil })
+	e.Group("/admin", func(next echo.HandlerFunc) echo.HandlerFunc { return next })
+
+	doc := Generate(e, Config{Info: Info{Title: "API", Version: "1.0.0"}})
+	assert.Equal(t, Version, doc.OpenAPI)
+	assert.Equal(t, "API", doc.Info.Title)
+	assert.Len(t, doc.Paths, 2)
+
+	// List
+	op := doc.Paths["/users"]["get"]
+	if assert.NotNil(t, op) {
+		assert.Equal(t, "List users", op.Summary)
+		assert.Equal(t, []string{"users"}, op.Tags)
+		assert.Equal(t, "get_users", op.OperationID)
+		if assert.Len(t, op.Parameters, 2) {
+			assert.Equal(t, "page", op.Parameters[0].Name)
+			assert.Equal(t, "query", op.Parameters[0].In)
+			assert.Equal(t, float64(1), *op.Parameters[0].Schema.Minimum)
+			assert.Equal(t, float64(100), *op.Parameters[1].Schema.Maximum)
+		}
+		assert.Nil(t, op.RequestBody)
+		items := op.Responses["200"].Content[echo.MIMEApplicationJSON].Schema
+		assert.Equal(t, "array", items.Type)
+		assert.Equal(t, "#/components/schemas/user", items.Items.Ref)
+	}
+
+	// Get
+	op = doc.Paths["/users/{id}"]["get"]
+	if assert.NotNil(t, op) {
+		assert.Equal(t, "getUser", op.OperationID)
+		if assert.Len(t, op.Parameters, 1) {
+			assert.Equal(t, &Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string"}}, op.Parameters[0])
+		}
+		assert.Equal(t, "Not Found", op.Responses["404"].Description)
+		assert.Nil(t, op.Responses["404"].Content)
+	}
+
+	// Update
+	op = doc.Paths["/users/{id}"]["put"]
+	if assert.NotNil(t, op) {
+		assert.Equal(t, "updateUser", op.OperationID)
+		assert.True(t, op.Deprecated)
+		if assert.Len(t, op.Parameters, 2) {
+			assert.Equal(t, "path", op.Parameters[0].In)
+			assert.Equal(t, "integer", op.Parameters[0].Schema.Type)
+			assert.Equal(t, "notify", op.Parameters[1].Name)
+		}
+		body := op.RequestBody.Content[echo.MIMEApplicationJSON].Schema
+		assert.Equal(t, []string{"name"}, body.Required)
+		assert.Len(t, body.Properties, 1)
+		assert.NotNil(t, op.Responses["default"])
+	}
+
+	// Undocumented
+	op = doc.Paths["/users"]["post"]
+	if assert.NotNil(t, op) {
+		assert.Empty(t, op.Parameters)
+		assert.Nil(t, op.RequestBody)
+	}
+
+	// Components
+	s := doc.Components.Schemas["user"]
+	if assert.NotNil(t, s) {
+		assert.Equal(t, []string{"name"}, s.Required)
+		assert.Equal(t, 2, *s.Properties["name"].MinLength)
+		assert.Equal(t, 64, *s.Properties["name"].MaxLength)
+		assert.Equal(t, "email", s.Properties["email"].Format)
+		assert.Equal(t, []interface{}{"admin", "user"}, s.Properties["role"].Enum)
+		assert.Equal(t, "array", s.Properties["tags"].Type)
+		assert.Equal(t, "int64", s.Properties["id"].Format)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	e := echo.New()
+	Register(e, Config{Info: Info{Title: "API", Version: "1.0.0"}, UIPath: "/docs"})
+	e.GET("/ping", func(c echo.Context) error { return nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	doc := new(Document)
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), doc)) {
+		assert.Len(t, doc.Paths, 1)
+		assert.NotNil(t, doc.Paths["/ping"]["get"])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `url: "/openapi.json"`)
+}
+
+func TestGenerateRouteDocs(t *testing.T) {
+	e := echo.New()
+	h := func(c echo.Context) error { return nil }
+	e.GET("/users", h).Summary("List users").Description("Paginated.").Tags("users")
+	Describe(e.DELETE("/users/:id", h), Operation{Summary: "Delete a user", Tags: []string{"admin"}}).
+		Summary("ignored").Tags("users").Deprecated()
+
+	doc := Generate(e, Config{})
+	op := doc.Paths["/users"]["get"]
+	assert.Equal(t, "List users", op.Summary)
+	assert.Equal(t, "Paginated.", op.Description)
+	assert.Equal(t, []string{"users"}, op.Tags)
+	assert.False(t, op.Deprecated)
+
+	op = doc.Paths["/users/{id}"]["delete"]
+	assert.Equal(t, "Delete a user", op.Summary)
+	assert.Equal(t, []string{"admin"}, op.Tags)
+	assert.True(t, op.Deprecated)
+}
+
+func TestGenerateOperationIDs(t *testing.T) {
+	e := echo.New()
+	e.GET("/users/:id", getUser)
+	e.GET("/v2/users/:id", getUser)
+	e.Any("/ping", func(c echo.Context) error { return nil })
+
+	doc := Generate(e, Config{})
+	assert.Equal(t, "getUser", doc.Paths["/users/{id}"]["get"].OperationID)
+	assert.Equal(t, "getUser_get_v2_users_id", doc.Paths["/v2/users/{id}"]["get"].OperationID)
+	ping := doc.Paths["/ping"]
+	assert.Len(t, ping, 8)
+	assert.NotContains(t, ping, "connect")
+	assert.NotContains(t, ping, "propfind")
+	assert.NotContains(t, ping, "report")
+	assert.Equal(t, "trace_ping", ping["trace"].OperationID)
+}
+
+func TestConvertPath(t *testing.T) {
+	path, pnames := convertPath("/users/:id/files/*")
+	assert.Equal(t, "/users/{id}/files/{*}", path)
+	assert.Equal(t, []string{"id", "*"}, pnames)
+
+	path, pnames = convertPath("")
+	assert.Equal(t, "/", path)
+	assert.Empty(t, pnames)
+}
@@ -0,0 +1,242 @@
+package openapi
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Schema is an OpenAPI schema object.
+	Schema struct {
+		Ref                  string             `json:"$ref,omitempty"`
+		Type                 string             `json:"type,omitempty"`
+		Format               string             `json:"format,omitempty"`
+		Description          string             `json:"description,omitempty"`
+		Properties           map[string]*Schema `json:"properties,omitempty"`
+		AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+		Items                *Schema            `json:"items,omitempty"`
+		Required             []string           `json:"required,omitempty"`
+		Enum                 []interface{}      `json:"enum,omitempty"`
+		Minimum              *float64           `json:"minimum,omitempty"`
+		Maximum              *float64           `json:"maximum,omitempty"`
+		MinLength            *int               `json:"minLength,omitempty"`
+		MaxLength            *int               `json:"maxLength,omitempty"`
+		MinItems             *int               `json:"minItems,omitempty"`
+		MaxItems             *int               `json:"maxItems,omitempty"`
+		Nullable             bool               `json:"nullable,omitempty"`
+	}
+
+	// schemaGenerator builds schemas from Go types, collecting named struct types
+	// into the document components.
+	schemaGenerator struct {
+		schemas map[string]*Schema
+	}
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{schemas: map[string]*Schema{}}
+}
+
+// schema returns the schema for type `t`. Named struct types are added to the
+// components and referenced.
+func (g *schemaGenerator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Struct && t.Name() != "" && t != timeType {
+		name := t.Name()
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = &Schema{} // Placeholder for recursive types
+			g.schemas[name] = g.object(t, nil)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return g.inline(t)
+}
+
+func (g *schemaGenerator) inline(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	if reflect.PtrTo(t).Implements(textMarshalerType) {
+		return &Schema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.object(t, nil)
+	}
+	return &Schema{}
+}
+
+// object returns an object schema for struct type `t`. Fields for which `skip`
+// returns true are left out.
+func (g *schemaGenerator) object(t reflect.Type, skip func(reflect.StructField) bool) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	g.fields(s, t, skip)
+	return s
+}
+
+func (g *schemaGenerator) fields(s *Schema, t reflect.Type, skip func(reflect.StructField) bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous { // Unexported
+			continue
+		}
+		if skip != nil && skip(f) {
+			continue
+		}
+		name := jsonName(f)
+		if name == "-" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && f.Tag.Get("json") == "" && ft.Kind() == reflect.Struct {
+			g.fields(s, ft, skip) // Embedded fields are promoted
+			continue
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		fs := g.schema(f.Type)
+		if f.Type.Kind() == reflect.Ptr && fs.Ref == "" {
+			fs.Nullable = true
+		}
+		if applyValidate(fs, f.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+		if d := f.Tag.Get("description"); d != "" && fs.Ref == "" {
+			fs.Description = d
+		}
+		s.Properties[name] = fs
+	}
+}
+
+// jsonName returns the JSON property name of a struct field.
+func jsonName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" {
+		name = f.Name
+	}
+	return name
+}
+
+// applyValidate maps the rules of a `validate` struct tag (as used by
+// github.com/go-playground/validator) to schema constraints. It reports
+// whether the field is required.
+func applyValidate(s *Schema, tag string) (required bool) {
+	if tag == "" || tag == "-" {
+		return
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg := rule, ""
+		if i := strings.IndexByte(rule, '='); i != -1 {
+			name, arg = rule[:i], rule[i+1:]
+		}
+		switch name {
+		case "required":
+			required = true
+		case "omitempty":
+		case "min", "gte":
+			setBound(s, arg, true)
+		case "max", "lte":
+			setBound(s, arg, false)
+		case "len":
+			setBound(s, arg, true)
+			setBound(s, arg, false)
+		case "oneof":
+			for _, v := range strings.Fields(arg) {
+				s.Enum = append(s.Enum, enumValue(s.Type, v))
+			}
+		case "email":
+			s.Format = "email"
+		case "url", "uri":
+			s.Format = "uri"
+		case "uuid", "uuid4":
+			s.Format = "uuid"
+		case "ip", "ipv4":
+			s.Format = "ipv4"
+		case "ipv6":
+			s.Format = "ipv6"
+		case "datetime":
+			s.Format = "date-time"
+		}
+	}
+	return
+}
+
+func setBound(s *Schema, arg string, lower bool) {
+	switch s.Type {
+	case "integer", "number":
+		v, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return
+		}
+		if lower {
+			s.Minimum = &v
+		} else {
+			s.Maximum = &v
+		}
+	case "string", "array":
+		v, err := strconv.Atoi(arg)
+		if err != nil {
+			return
+		}
+		switch {
+		case s.Type == "string" && lower:
+			s.MinLength = &v
+		case s.Type == "string":
+			s.MaxLength = &v
+		case lower:
+			s.MinItems = &v
+		default:
+			s.MaxItems = &v
+		}
+	}
+}
+
+func enumValue(typ, v string) interface{} {
+	switch typ {
+	case "integer":
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return v
+}
@@ -0,0 +1,63 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	node struct {
+		Value    string  `json:"value"`
+		Children []*node `json:"children"`
+	}
+
+	base struct {
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	article struct {
+		base
+		Title  string         `json:"title" description:"Article title"`
+		Body   *string        `json:"body"`
+		Meta   map[string]int `json:"meta"`
+		Data   []byte         `json:"data"`
+		Score  float32        `json:"score" validate:"gte=0,lte=1"`
+		Level  int8           `json:"level" validate:"oneof=1 2 3"`
+		Secret string         `json:"-"`
+		Any    interface{}    `json:"any"`
+		hidden string
+	}
+)
+
+func TestSchemaRecursive(t *testing.T) {
+	g := newSchemaGenerator()
+	s := g.schema(reflect.TypeOf(&node{}))
+	assert.Equal(t, "#/components/schemas/node", s.Ref)
+	n := g.schemas["node"]
+	if assert.NotNil(t, n) {
+		assert.Equal(t, "#/components/schemas/node", n.Properties["children"].Items.Ref)
+	}
+}
+
+func TestSchemaFields(t *testing.T) {
+	g := newSchemaGenerator()
+	g.schema(reflect.TypeOf(article{}))
+	s := g.schemas["article"]
+	if !assert.NotNil(t, s) {
+		return
+	}
+	assert.Len(t, s.Properties, 8)
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, s.Properties["created_at"])
+	assert.Equal(t, "Article title", s.Properties["title"].Description)
+	assert.True(t, s.Properties["body"].Nullable)
+	assert.Equal(t, "integer", s.Properties["meta"].AdditionalProperties.Type)
+	assert.Equal(t, "byte", s.Properties["data"].Format)
+	assert.Equal(t, float64(0), *s.Properties["score"].Minimum)
+	assert.Equal(t, float64(1), *s.Properties["score"].Maximum)
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, s.Properties["level"].Enum)
+	assert.Equal(t, &Schema{}, s.Properties["any"])
+	assert.Empty(t, s.Required)
+}
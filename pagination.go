@@ -0,0 +1,166 @@
+package echo
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Pagination is the pagination of a list endpoint, bound from the query
+	// params with `Context#BindPagination()`.
+	Pagination struct {
+		// Page is the requested page, starting at 1.
+		Page int `json:"page"`
+
+		// PerPage is the number of items per page.
+		PerPage int `json:"per_page"`
+
+		// Sort lists the fields to sort by, in order.
+		Sort []SortField `json:"sort,omitempty"`
+	}
+
+	// SortField is a field to sort by. In the "sort" query param, fields are
+	// separated by commas and descending fields prefixed with "-", e.g.
+	// "-created_at,name".
+	SortField struct {
+		Field string `json:"field"`
+		Desc  bool   `json:"desc,omitempty"`
+	}
+
+	// PaginationLimits defines the defaults and bounds of a `Pagination`.
+	PaginationLimits struct {
+		// DefaultPerPage is the number of items per page without "per_page".
+		// Optional. Default value 20.
+		DefaultPerPage int
+
+		// MaxPerPage is the maximum of "per_page".
+		// Optional. Default value 100.
+		MaxPerPage int
+
+		// Sortable lists the fields allowed in "sort".
+		// Optional. Default value nil (sorting disabled).
+		Sortable []string
+
+		// DefaultSort is the value of "sort" when missing, e.g. "-created_at".
+		// Optional.
+		DefaultSort string
+	}
+)
+
+// DefaultPaginationLimits are the default pagination limits.
+var DefaultPaginationLimits = PaginationLimits{
+	DefaultPerPage: 20,
+	MaxPerPage:     100,
+}
+
+func (c *context) BindPagination(limits PaginationLimits) (*Pagination, error) {
+	c.checkReleased()
+	// Defaults
+	if limits.DefaultPerPage == 0 {
+		limits.DefaultPerPage = DefaultPaginationLimits.DefaultPerPage
+	}
+	if limits.MaxPerPage == 0 {
+		limits.MaxPerPage = DefaultPaginationLimits.MaxPerPage
+	}
+
+	p := &Pagination{Page: 1, PerPage: limits.DefaultPerPage}
+	if v := c.QueryParam("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, NewHTTPError(http.StatusBadRequest, "page must be a positive integer")
+		}
+		p.Page = n
+	}
+	if v := c.QueryParam("per_page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > limits.MaxPerPage {
+			return nil, NewHTTPError(http.StatusBadRequest,
+				fmt.Sprintf("per_page must be an integer between 1 and %d", limits.MaxPerPage))
+		}
+		p.PerPage = n
+	}
+	sort := c.QueryParam("sort")
+	if sort == "" {
+		sort = limits.DefaultSort
+	}
+	if sort == "" {
+		return p, nil
+	}
+	for _, f := range strings.Split(sort, ",") {
+		field := SortField{Field: strings.TrimSpace(f)}
+		if strings.HasPrefix(field.Field, "-") {
+			field.Field, field.Desc = field.Field[1:], true
+		}
+		if !containsString(limits.Sortable, field.Field) {
+			return nil, NewHTTPError(http.StatusBadRequest, fmt.Sprintf("cannot sort by %q", field.Field))
+		}
+		p.Sort = append(p.Sort, field)
+	}
+	return p, nil
+}
+
+// Offset returns the number of items before the page.
+func (p *Pagination) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+// TotalPages returns the number of pages of `total` items.
+func (p *Pagination) TotalPages(total int) int {
+	return (total + p.PerPage - 1) / p.PerPage
+}
+
+// Meta returns the pagination fields of a response listing `total` items, e.g.
+// for the meta of `middleware.Envelope()`.
+func (p *Pagination) Meta(total int) Map {
+	return Map{
+		"page":        p.Page,
+		"per_page":    p.PerPage,
+		"total":       total,
+		"total_pages": p.TotalPages(total),
+	}
+}
+
+// SetHeaders sets the "X-Total-Count" header and the "Link" header with the
+// first, prev, next and last pages of a response listing `total` items. The
+// links keep the other query params of the request.
+func (p *Pagination) SetHeaders(c Context, total int) {
+	h := c.Response().Header()
+	h.Set(HeaderXTotalCount, strconv.Itoa(total))
+	last := p.TotalPages(total)
+	if last < 1 {
+		last = 1
+	}
+	links := []string{p.link(c, 1, "first")}
+	if p.Page > 1 {
+		prev := p.Page - 1
+		if prev > last {
+			prev = last
+		}
+		links = append(links, p.link(c, prev, "prev"))
+	}
+	if p.Page < last {
+		links = append(links, p.link(c, p.Page+1, "next"))
+	}
+	links = append(links, p.link(c, last, "last"))
+	h.Set(HeaderLink, strings.Join(links, ", "))
+}
+
+func (p *Pagination) link(c Context, page int, rel string) string {
+	u := *c.Request().URL
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(p.PerPage))
+	u.RawQuery = q.Encode()
+	return fmt.Sprintf("<%s>; rel=%q", u.RequestURI(), rel)
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_BindPagination(t *testing.T) {
+	e := New()
+	limits := PaginationLimits{MaxPerPage: 50, Sortable: []string{"name", "created_at"}, DefaultSort: "-created_at"}
+	tests := []struct {
+		query      string
+		pagination *Pagination
+		message    string
+	}{
+		{"", &Pagination{Page: 1, PerPage: 20, Sort: []SortField{{Field: "created_at", Desc: true}}}, ""},
+		{"page=3&per_page=50&sort=name,-created_at", &Pagination{Page: 3, PerPage: 50, Sort: []SortField{{Field: "name"}, {Field: "created_at", Desc: true}}}, ""},
+		{"page=0", nil, "page must be a positive integer"},
+		{"page=x", nil, "page must be a positive integer"},
+		{"per_page=51", nil, "per_page must be an integer between 1 and 50"},
+		{"sort=password", nil, `cannot sort by "password"`},
+	}
+	for _, tt := range tests {
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/users?"+tt.query, nil), httptest.NewRecorder())
+		p, err := c.BindPagination(limits)
+		if tt.message != "" {
+			assert.Equal(t, NewHTTPError(http.StatusBadRequest, tt.message), err, tt.query)
+			continue
+		}
+		assert.NoError(t, err, tt.query)
+		assert.Equal(t, tt.pagination, p, tt.query)
+	}
+}
+
+func TestPagination(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/users?q=jon&page=2&per_page=10", nil), rec)
+	p, err := c.BindPagination(DefaultPaginationLimits)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 10, p.Offset())
+	assert.Equal(t, 3, p.TotalPages(25))
+	assert.Equal(t, Map{"page": 2, "per_page": 10, "total": 25, "total_pages": 3}, p.Meta(25))
+
+	p.SetHeaders(c, 25)
+	assert.Equal(t, "25", rec.Header().Get(HeaderXTotalCount))
+	assert.Equal(t, `</users?page=1&per_page=10&q=jon>; rel="first", `+
+		`</users?page=1&per_page=10&q=jon>; rel="prev", `+
+		`</users?page=3&per_page=10&q=jon>; rel="next", `+
+		`</users?page=3&per_page=10&q=jon>; rel="last"`, rec.Header().Get(HeaderLink))
+
+	// Empty list
+	p.Page = 1
+	p.SetHeaders(c, 0)
+	assert.Equal(t, `</users?page=1&per_page=10&q=jon>; rel="first", </users?page=1&per_page=10&q=jon>; rel="last"`, rec.Header().Get(HeaderLink))
+}
@@ -0,0 +1,32 @@
+package echo
+
+type (
+	// Principal is the authenticated identity of a request, set by
+	// `middleware.Authn()` and returned by `Context#Principal()`.
+	Principal struct {
+		// ID identifies the principal, e.g. a user ID or a certificate subject.
+		ID string `json:"id"`
+
+		// Method is the authentication method, e.g. "jwt", "api_key" or "mtls".
+		Method string `json:"method"`
+
+		// Scopes lists the permissions granted to the principal.
+		Scopes []string `json:"scopes,omitempty"`
+
+		// Claims holds the attributes of the principal, e.g. the claims of a JWT.
+		Claims Map `json:"claims,omitempty"`
+	}
+)
+
+// HasScope reports whether the principal was granted the scope.
+func (p *Principal) HasScope(scope string) bool {
+	if p == nil {
+		return false
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
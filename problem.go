@@ -0,0 +1,133 @@
+package echo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+type (
+	// Problem is an RFC 7807 problem details object. It implements `error`, so
+	// handlers can return it directly when `Echo#ProblemHTTPErrorHandler` is the
+	// registered error handler.
+	// See: https://tools.ietf.org/html/rfc7807
+	Problem struct {
+		Type     string `json:"type,omitempty"`
+		Title    string `json:"title,omitempty"`
+		Status   int    `json:"status,omitempty"`
+		Detail   string `json:"detail,omitempty"`
+		Instance string `json:"instance,omitempty"`
+
+		// Extensions are additional members serialized alongside the standard ones.
+		Extensions Map `json:"-"`
+	}
+)
+
+const (
+	// MIMEApplicationProblemJSON is the media type of problem details documents.
+	MIMEApplicationProblemJSON = "application/problem+json"
+
+	problemTypeBlank = "about:blank"
+)
+
+// NewProblem creates a new Problem for the status code with the default title.
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{
+		Type:   problemTypeBlank,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Error makes it compatible with `error` interface.
+func (p *Problem) Error() string {
+	return fmt.Sprintf("status=%d, title=%s, detail=%s", p.Status, p.Title, p.Detail)
+}
+
+// MarshalJSON implements `json.Marshaler` merging the extension members.
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem // Avoid recursion
+	b, err := json.Marshal((*problem)(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return b, err
+	}
+	m := Map{}
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	if err = json.Unmarshal(b, &m); err != nil { // Standard members take precedence
+		return nil, err
+	}
+	return json.Marshal(m)
+}
+
+// ProblemHTTPErrorHandler is an HTTP error handler sending errors as RFC 7807
+// problem details with `Content-Type: application/problem+json`. It can be used
+// in place of `Echo#DefaultHTTPErrorHandler`:
+//
+//	e.HTTPErrorHandler = e.ProblemHTTPErrorHandler
+//
+// A `*Problem` in the error chain is sent as is. An `*HTTPError` is converted
+// using its code and message; non-string messages are sent as the "errors"
+// extension member. Any other error results in "500 - Internal Server Error".
+func (e *Echo) ProblemHTTPErrorHandler(err error, c Context) {
+	p := problemFromError(err)
+	if p.Type == "" {
+		p.Type = problemTypeBlank
+	}
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = c.Request().URL.Path
+	}
+	if e.Debug {
+		p.Detail = err.Error()
+	}
+
+	// Send response
+	if !c.Response().Committed {
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(p.Status)
+		} else {
+			c.Response().Header().Set(HeaderContentType, MIMEApplicationProblemJSON)
+			err = c.JSON(p.Status, p)
+		}
+		if err != nil {
+			e.Logger.Error(err)
+		}
+	}
+}
+
+func problemFromError(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		cp := *p // Don't modify errors shared between requests
+		return &cp
+	}
+	var he *HTTPError
+	if !errors.As(err, &he) {
+		return &Problem{}
+	}
+	if herr, ok := he.Internal.(*HTTPError); ok {
+		he = herr
+	}
+	p = &Problem{Status: he.Code}
+	switch m := he.Message.(type) {
+	case string:
+		if m != http.StatusText(he.Code) {
+			p.Detail = m
+		}
+	case nil:
+	case error:
+		p.Detail = m.Error()
+	default:
+		p.Extensions = Map{"errors": m}
+	}
+	return p
+}
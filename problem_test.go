@@ -0,0 +1,74 @@
+package echo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblemMarshalJSON(t *testing.T) {
+	p := NewProblem(http.StatusForbidden, "not enough credit")
+	p.Type = "https://example.com/probs/out-of-credit"
+	p.Extensions = Map{"balance": 30, "status": 200}
+	b, err := json.Marshal(p)
+	if assert.NoError(t, err) {
+		assert.JSONEq(t, `{"type":"https://example.com/probs/out-of-credit","title":"Forbidden","status":403,"detail":"not enough credit","balance":30}`, string(b))
+	}
+	assert.Equal(t, "status=403, title=Forbidden, detail=not enough credit", p.Error())
+}
+
+func TestProblemHTTPErrorHandler(t *testing.T) {
+	e := New()
+	e.HTTPErrorHandler = e.ProblemHTTPErrorHandler
+	e.GET("/problem", func(c Context) error {
+		return fmt.Errorf("wrapped: %w", &Problem{Type: "https://example.com/probs/test", Status: http.StatusConflict, Detail: "conflict"})
+	})
+	e.GET("/http", func(c Context) error {
+		return NewHTTPError(http.StatusBadRequest, "invalid id")
+	})
+	e.GET("/map", func(c Context) error {
+		return NewHTTPError(http.StatusUnprocessableEntity, Map{"name": "required"})
+	})
+	e.GET("/error", func(c Context) error {
+		return errors.New("error")
+	})
+
+	testCases := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/problem", http.StatusConflict, `{"type":"https://example.com/probs/test","title":"Conflict","status":409,"detail":"conflict","instance":"/problem"}`},
+		{"/http", http.StatusBadRequest, `{"type":"about:blank","title":"Bad Request","status":400,"detail":"invalid id","instance":"/http"}`},
+		{"/map", http.StatusUnprocessableEntity, `{"type":"about:blank","title":"Unprocessable Entity","status":422,"instance":"/map","errors":{"name":"required"}}`},
+		{"/error", http.StatusInternalServerError, `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/error"}`},
+		{"/missing", http.StatusNotFound, `{"type":"about:blank","title":"Not Found","status":404,"instance":"/missing"}`},
+	}
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tc.status, rec.Code, tc.path)
+		assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(HeaderContentType), tc.path)
+		assert.JSONEq(t, tc.body, rec.Body.String(), tc.path)
+	}
+
+	// HEAD
+	req := httptest.NewRequest(http.MethodHead, "/missing", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	// Debug
+	e.Debug = true
+	req = httptest.NewRequest(http.MethodGet, "/error", nil)
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Contains(t, rec.Body.String(), `"detail": "error"`)
+}
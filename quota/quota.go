@@ -0,0 +1,428 @@
+/*
+Package quota enforces long-term request quotas, e.g. 10000 requests per month
+for the free plan. Unlike `middleware.RateLimiter()`, which smooths bursts over
+short windows, quotas are counted per calendar day or month (UTC), or over a
+rolling window such as the last 24 hours, and persisted in a store shared by the
+instances of the application.
+
+Example:
+
+	q, err := quota.New(e, quota.Config{
+	  Limits: func(c echo.Context, key string) ([]quota.Limit, error) {
+	    plan, err := plans.Lookup(key)
+	    if err != nil {
+	      return nil, err
+	    }
+	    return []quota.Limit{{Period: quota.Daily, Requests: plan.Daily}, {Window: 30 * 24 * time.Hour, Requests: plan.Monthly}}, nil
+	  },
+	  Store: redisStore,
+	})
+	if err != nil {
+	  e.Logger.Fatal(err)
+	}
+	api.Use(q.Middleware())
+*/
+package quota
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type (
+	// Config defines the config for quotas.
+	Config struct {
+		// Skipper defines a function to skip middleware.
+		Skipper middleware.Skipper
+
+		// Key returns the key quotas are counted by.
+		// Optional. Default value returns "principal:<id>" for requests with a
+		// principal, e.g. verified by `APIKeyAuthenticator`, and "ip:<address>"
+		// otherwise.
+		Key func(c echo.Context) (string, error)
+
+		// Limits returns the limits of the key, e.g. looked up by the plan of
+		// the customer. A key without limits isn't limited.
+		// Required.
+		Limits func(c echo.Context, key string) ([]Limit, error)
+
+		// Store persists the counters.
+		// Optional. Default value `NewMemoryStore()`.
+		Store Store
+	}
+
+	// Period is the period of a quota.
+	Period int
+
+	// Limit is a number of requests allowed per period.
+	Limit struct {
+		Period Period
+
+		// Window makes the limit apply to any window of this duration ending
+		// now, e.g. the last 24 hours, instead of the calendar period. Usage
+		// over a rolling window is estimated from the counts of the current and
+		// previous windows, weighing the previous one by its overlap.
+		Window time.Duration
+
+		Requests int64
+	}
+
+	// Usage is the usage of a quota in the current period. The reset of a
+	// rolling window is the end of the current window.
+	Usage struct {
+		Limit     Limit
+		Used      int64
+		Remaining int64
+		Reset     time.Time
+	}
+
+	// Store persists request counters.
+	Store interface {
+		// Get returns the value of the counter, 0 if it doesn't exist.
+		Get(counter string) (int64, error)
+
+		// Increment atomically increments the counter and returns its new
+		// value. The counter may be removed after it expires.
+		Increment(counter string, expires time.Time) (int64, error)
+
+		// Decrement atomically decrements the counter, undoing the increment of
+		// a rejected request.
+		Decrement(counter string) error
+	}
+
+	// Quota enforces request quotas.
+	Quota struct {
+		config Config
+	}
+
+	// MemoryStore is a `Store` keeping counters in memory, for tests and
+	// applications served by a single instance.
+	MemoryStore struct {
+		mu       sync.Mutex
+		counters map[string]*counter
+	}
+
+	// FileStore is a `MemoryStore` saved to a JSON file by `FileStore#Flush()`,
+	// so that counters survive restarts of a single instance.
+	FileStore struct {
+		*MemoryStore
+		path string
+	}
+
+	counter struct {
+		Value   int64     `json:"value"`
+		Expires time.Time `json:"expires"`
+	}
+)
+
+// Periods
+const (
+	Daily Period = iota
+	Monthly
+)
+
+// Headers
+const (
+	HeaderLimit     = "X-Quota-Limit"
+	HeaderRemaining = "X-Quota-Remaining"
+	HeaderReset     = "X-Quota-Reset"
+)
+
+// New returns a `Quota` with config. If the store has a `Flush() error`
+// method, like `FileStore`, it is flushed when the servers of `e` shut down.
+func New(e *echo.Echo, config Config) (*Quota, error) {
+	// Defaults
+	if config.Limits == nil {
+		return nil, errors.New("quota: limits are required")
+	}
+	if config.Skipper == nil {
+		config.Skipper = middleware.DefaultSkipper
+	}
+	if config.Key == nil {
+		config.Key = defaultKey
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryStore()
+	}
+
+	if f, ok := config.Store.(interface{ Flush() error }); ok && e != nil {
+		flush := func() {
+			if err := f.Flush(); err != nil {
+				e.Logger.Errorf("quota: flush: %v", err)
+			}
+		}
+		e.Server.RegisterOnShutdown(flush)
+		e.TLSServer.RegisterOnShutdown(flush)
+	}
+	return &Quota{config: config}, nil
+}
+
+// Middleware returns a middleware counting requests against the quotas of
+// their key. The quota with the fewest remaining requests is reported in the
+// "X-Quota-Limit", "X-Quota-Remaining" and "X-Quota-Reset" (Unix time)
+// headers. When a quota is exhausted, it sends "429 - Too Many Requests"
+// response with the "Retry-After" header. Rejected requests aren't counted.
+func (q *Quota) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if q.config.Skipper(c) {
+				return next(c)
+			}
+
+			key, err := q.config.Key(c)
+			if err != nil {
+				return err
+			}
+			limits, err := q.config.Limits(c, key)
+			if err != nil {
+				return err
+			}
+			if len(limits) == 0 {
+				return next(c)
+			}
+			// Counted first, so that concurrent requests can't exceed the
+			// limits, and undone when rejected
+			now := time.Now().UTC()
+			usages := make([]Usage, len(limits))
+			var counters []string
+			undo := func() {
+				for _, name := range counters {
+					if err := q.config.Store.Decrement(name); err != nil {
+						c.Logger().Errorf("quota: decrement: %v", err)
+					}
+				}
+			}
+			for i, l := range limits {
+				name, reset, expires := l.counter(key, now)
+				n, err := q.config.Store.Increment(name, expires)
+				if err != nil {
+					undo()
+					return err
+				}
+				counters = append(counters, name)
+				used, err := q.used(key, l, now, n)
+				if err != nil {
+					undo()
+					return err
+				}
+				usages[i] = newUsage(l, used, reset)
+				if used > l.Requests {
+					undo()
+					h := c.Response().Header()
+					h.Set(HeaderLimit, strconv.FormatInt(l.Requests, 10))
+					h.Set(HeaderRemaining, "0")
+					h.Set(HeaderReset, strconv.FormatInt(reset.Unix(), 10))
+					h.Set(echo.HeaderRetryAfter, strconv.FormatInt(int64(reset.Sub(now)/time.Second)+1, 10))
+					return echo.NewHTTPError(http.StatusTooManyRequests, "quota exceeded")
+				}
+			}
+			tightest := usages[0]
+			for _, u := range usages[1:] {
+				if u.Remaining < tightest.Remaining {
+					tightest = u
+				}
+			}
+
+			h := c.Response().Header()
+			h.Set(HeaderLimit, strconv.FormatInt(tightest.Limit.Requests, 10))
+			h.Set(HeaderRemaining, strconv.FormatInt(tightest.Remaining, 10))
+			h.Set(HeaderReset, strconv.FormatInt(tightest.Reset.Unix(), 10))
+			return next(c)
+		}
+	}
+}
+
+// Usage returns the usage of the quotas of the key in the current periods,
+// e.g. for an account dashboard.
+func (q *Quota) Usage(c echo.Context, key string) ([]Usage, error) {
+	limits, err := q.config.Limits(c, key)
+	if err != nil {
+		return nil, err
+	}
+	return q.usage(key, limits, time.Now().UTC())
+}
+
+func (q *Quota) usage(key string, limits []Limit, now time.Time) ([]Usage, error) {
+	usages := make([]Usage, len(limits))
+	for i, l := range limits {
+		name, reset, _ := l.counter(key, now)
+		n, err := q.config.Store.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		used, err := q.used(key, l, now, n)
+		if err != nil {
+			return nil, err
+		}
+		usages[i] = newUsage(l, used, reset)
+	}
+	return usages, nil
+}
+
+// used returns the usage of the limit given the count `n` of its current
+// counter, adding the weighted count of the previous window of a rolling limit.
+func (q *Quota) used(key string, l Limit, now time.Time, n int64) (int64, error) {
+	if l.Window <= 0 {
+		return n, nil
+	}
+	name, reset, _ := l.counter(key, now.Add(-l.Window))
+	prev, err := q.config.Store.Get(name)
+	if err != nil {
+		return 0, err
+	}
+	overlap := float64(reset.Add(l.Window).Sub(now)) / float64(l.Window)
+	return n + int64(float64(prev)*overlap), nil
+}
+
+func newUsage(l Limit, used int64, reset time.Time) Usage {
+	remaining := l.Requests - used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Usage{Limit: l, Used: used, Remaining: remaining, Reset: reset}
+}
+
+// counter returns the name of the counter of the limit for the key at `t`, the
+// end of its period and when the counter expires. Counters of rolling windows
+// outlive their window, to be weighed in the next one.
+func (l Limit) counter(key string, t time.Time) (name string, reset, expires time.Time) {
+	if l.Window <= 0 {
+		reset = l.Period.end(t)
+		return counterName(key, l.Period, t), reset, reset
+	}
+	start := t.Truncate(l.Window)
+	name = key + ":rolling:" + strconv.FormatInt(int64(l.Window/time.Second), 10) + "s:" + strconv.FormatInt(start.Unix(), 10)
+	reset = start.Add(l.Window)
+	return name, reset, reset.Add(l.Window)
+}
+
+// String returns "daily" or "monthly".
+func (p Period) String() string {
+	if p == Monthly {
+		return "monthly"
+	}
+	return "daily"
+}
+
+// end returns the end of the period containing `t`, in UTC.
+func (p Period) end(t time.Time) time.Time {
+	y, m, d := t.Date()
+	if p == Monthly {
+		return time.Date(y, m+1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// counterName returns the name of the counter of the key in the period
+// containing `t`, e.g. "key:abc:monthly:2006-01".
+func counterName(key string, p Period, t time.Time) string {
+	if p == Monthly {
+		return key + ":monthly:" + t.Format("2006-01")
+	}
+	return key + ":daily:" + t.Format("2006-01-02")
+}
+
+// NewMemoryStore returns a `MemoryStore`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: map[string]*counter{}}
+}
+
+// Get implements `Store`.
+func (s *MemoryStore) Get(name string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.counters[name]; ok && time.Now().Before(c.Expires) {
+		return c.Value, nil
+	}
+	return 0, nil
+}
+
+// Increment implements `Store`.
+func (s *MemoryStore) Increment(name string, expires time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.counters[name]
+	if !ok || !time.Now().Before(c.Expires) {
+		c = &counter{Expires: expires}
+		s.counters[name] = c
+	}
+	c.Value++
+	return c.Value, nil
+}
+
+// Decrement implements `Store`.
+func (s *MemoryStore) Decrement(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.counters[name]; ok && c.Value > 0 {
+		c.Value--
+	}
+	return nil
+}
+
+// NewFileStore returns a `FileStore` saved to the file at path, loading the
+// counters saved previously.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &s.counters); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Flush saves the counters which haven't expired to the file.
+func (s *FileStore) Flush() error {
+	s.mu.Lock()
+	now := time.Now()
+	for name, c := range s.counters {
+		if !now.Before(c.Expires) {
+			delete(s.counters, name)
+		}
+	}
+	b, err := json.Marshal(s.counters)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// defaultKey identifies requests by principal or client IP. Unverified
+// credentials, e.g. a raw "X-API-Key" header, are ignored as clients could
+// choose a new key for every request.
+func defaultKey(c echo.Context) (string, error) {
+	if p := c.Principal(); p != nil {
+		return "principal:" + p.ID, nil
+	}
+	return "ip:" + c.RealIP(), nil
+}
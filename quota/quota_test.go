@@ -0,0 +1,185 @@
+package quota
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuota(t *testing.T) {
+	e := echo.New()
+	q, err := New(e, Config{
+		Limits: func(c echo.Context, key string) ([]Limit, error) {
+			if key == "principal:internal" {
+				return nil, nil
+			}
+			return []Limit{{Period: Daily, Requests: 2}, {Period: Monthly, Requests: 3}}, nil
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	e.Use(middleware.Authn(&middleware.APIKeyAuthenticator{
+		Validate: func(c echo.Context, key string) (*echo.Principal, error) {
+			return &echo.Principal{ID: key}, nil
+		},
+	}))
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, q.Middleware())
+	request := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	now := time.Now().UTC()
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i >= 0; i-- {
+		rec := request("abc")
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get(HeaderLimit))
+		assert.Equal(t, strconv.Itoa(i), rec.Header().Get(HeaderRemaining))
+		assert.Equal(t, strconv.FormatInt(tomorrow.Unix(), 10), rec.Header().Get(HeaderReset))
+	}
+	rec := request("abc")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get(HeaderRemaining))
+	assert.NotEmpty(t, rec.Header().Get(echo.HeaderRetryAfter))
+
+	usages, err := q.Usage(nil, "principal:abc")
+	if assert.NoError(t, err) && assert.Len(t, usages, 2) {
+		assert.Equal(t, int64(2), usages[0].Used, "rejected request not counted")
+		assert.Equal(t, int64(1), usages[1].Remaining)
+		assert.Equal(t, Monthly, usages[1].Limit.Period)
+	}
+
+	assert.Equal(t, http.StatusNoContent, request("def").Code)
+	rec = request("internal")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderLimit))
+
+	_, err = New(e, Config{})
+	assert.Error(t, err)
+}
+
+func TestQuotaConcurrent(t *testing.T) {
+	e := echo.New()
+	q, _ := New(e, Config{
+		Key: func(c echo.Context) (string, error) { return "k", nil },
+		Limits: func(c echo.Context, key string) ([]Limit, error) {
+			return []Limit{{Period: Monthly, Requests: 100}, {Period: Daily, Requests: 10}}, nil
+		},
+	})
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	}, q.Middleware())
+
+	var wg sync.WaitGroup
+	var accepted int32
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code == http.StatusNoContent {
+				atomic.AddInt32(&accepted, 1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(10), accepted)
+
+	// Rejected requests are undone in every counter
+	usages, err := q.Usage(nil, "k")
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(10), usages[0].Used)
+		assert.Equal(t, int64(10), usages[1].Used)
+	}
+}
+
+func TestRollingWindow(t *testing.T) {
+	q, _ := New(nil, Config{Limits: func(c echo.Context, key string) ([]Limit, error) { return nil, nil }})
+	l := Limit{Window: 24 * time.Hour, Requests: 100}
+	now := time.Date(2020, time.December, 31, 6, 0, 0, 0, time.UTC)
+
+	name, reset, expires := l.counter("k", now)
+	assert.Equal(t, "k:rolling:86400s:1609372800", name)
+	assert.Equal(t, time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), reset)
+	assert.Equal(t, reset.Add(24*time.Hour), expires)
+
+	prev, _, _ := l.counter("k", now.Add(-l.Window))
+	for i := 0; i < 40; i++ {
+		q.config.Store.Increment(prev, time.Now().Add(time.Hour))
+	}
+	// Three quarters of the previous window overlap the last 24 hours
+	used, err := q.used("k", l, now, 5)
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(35), used)
+	}
+	used, _ = q.used("k", Limit{Period: Daily, Requests: 100}, now, 5)
+	assert.Equal(t, int64(5), used)
+}
+
+func TestDefaultKey(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "forged")
+	c := e.NewContext(req, httptest.NewRecorder())
+	key, _ := defaultKey(c)
+	assert.Equal(t, "ip:192.0.2.1", key)
+
+	c.SetPrincipal(&echo.Principal{ID: "abc"})
+	key, _ = defaultKey(c)
+	assert.Equal(t, "principal:abc", key)
+}
+
+func TestPeriod(t *testing.T) {
+	now := time.Date(2020, time.December, 31, 23, 59, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), Daily.end(now))
+	assert.Equal(t, time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), Monthly.end(now))
+	assert.Equal(t, "k:daily:2020-12-31", counterName("k", Daily, now))
+	assert.Equal(t, "k:monthly:2020-12", counterName("k", Monthly, now))
+	assert.Equal(t, "monthly", Monthly.String())
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quota")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "quota.json")
+
+	s, err := NewFileStore(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	s.Increment("a", time.Now().Add(time.Hour))
+	s.Increment("a", time.Now().Add(time.Hour))
+	s.Increment("a", time.Now().Add(time.Hour))
+	s.Decrement("a")
+	s.Increment("expired", time.Now().Add(-time.Second))
+	assert.NoError(t, s.Flush())
+
+	s, err = NewFileStore(path)
+	if assert.NoError(t, err) {
+		n, _ := s.Get("a")
+		assert.Equal(t, int64(2), n)
+		assert.NotContains(t, s.counters, "expired")
+	}
+}
@@ -0,0 +1,302 @@
+/*
+Package recorder captures sampled requests in production and replays them
+against the in-process handler chain, to reproduce bugs locally.
+
+Example:
+
+	// Production
+	rec := recorder.New(recorder.Config{
+	  Rate:  0.01,
+	  Store: recorder.NewWriterStore(f),
+	})
+	e.Use(rec.Middleware())
+
+	// Locally
+	recordings, err := recorder.Load(f)
+	for _, r := range recordings {
+	  res := recorder.Replay(e, r)
+	  ...
+	}
+*/
+package recorder
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Config defines the config for a recorder.
+	Config struct {
+		// Skipper defines a function to skip recording a request.
+		// Optional.
+		Skipper func(c echo.Context) bool
+
+		// Rate is the fraction of requests recorded, between 0 and 1.
+		// Optional. Default value 1.
+		Rate float64
+
+		// Store saves the recordings.
+		// Optional. Default value is a ring of 100 recordings.
+		Store Store
+
+		// MaxBodySize is the maximum size of the recorded request bodies. Larger
+		// bodies are truncated.
+		// Optional. Default value 1MB.
+		MaxBodySize int64
+
+		// RedactHeaders lists the headers replaced by "[REDACTED]".
+		// Optional. Default value `DefaultRedactHeaders`.
+		RedactHeaders []string
+	}
+
+	// Recorder records requests.
+	Recorder struct {
+		config Config
+	}
+
+	// Recording is a recorded request.
+	Recording struct {
+		Time       time.Time         `json:"time"`
+		Method     string            `json:"method"`
+		URI        string            `json:"uri"`
+		Host       string            `json:"host"`
+		RemoteAddr string            `json:"remote_addr"`
+		Header     http.Header       `json:"header"`
+		Body       []byte            `json:"body,omitempty"`
+		Truncated  bool              `json:"truncated,omitempty"`
+		Route      string            `json:"route"`
+		Params     map[string]string `json:"params,omitempty"`
+		Status     int               `json:"status"`
+		Error      string            `json:"error,omitempty"`
+	}
+
+	// Store saves recordings.
+	Store interface {
+		Save(r *Recording) error
+	}
+
+	// Ring keeps the last recordings in memory.
+	Ring struct {
+		mu         sync.Mutex
+		recordings []*Recording
+		next       int
+		full       bool
+	}
+
+	// WriterStore writes recordings as JSON lines, see `Load()`.
+	WriterStore struct {
+		mu sync.Mutex
+		w  io.Writer
+	}
+
+	// replayBody replays the recorded part of a body before the rest of it.
+	replayBody struct {
+		io.Reader
+		io.Closer
+	}
+)
+
+// RedactedValue replaces the values of redacted headers.
+const RedactedValue = "[REDACTED]"
+
+var (
+	// DefaultRedactHeaders are the headers redacted by default.
+	DefaultRedactHeaders = []string{
+		echo.HeaderAuthorization,
+		echo.HeaderCookie,
+		echo.HeaderSetCookie,
+		"Proxy-Authorization",
+		"X-API-Key",
+	}
+
+	// DefaultConfig is the default recorder config.
+	DefaultConfig = Config{
+		Rate:          1,
+		MaxBodySize:   1 << 20,
+		RedactHeaders: DefaultRedactHeaders,
+	}
+)
+
+// New returns a recorder with config.
+func New(config Config) *Recorder {
+	// Defaults
+	if config.Rate == 0 {
+		config.Rate = DefaultConfig.Rate
+	}
+	if config.Store == nil {
+		config.Store = NewRing(100)
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = DefaultConfig.MaxBodySize
+	}
+	if config.RedactHeaders == nil {
+		config.RedactHeaders = DefaultConfig.RedactHeaders
+	}
+	return &Recorder{config: config}
+}
+
+// Store returns the store of the recorder.
+func (r *Recorder) Store() Store {
+	return r.config.Store
+}
+
+// Middleware returns a middleware recording the sampled requests once they are
+// handled. Errors saving recordings are logged.
+func (r *Recorder) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if r.config.Skipper != nil && r.config.Skipper(c) {
+				return next(c)
+			}
+			if r.config.Rate < 1 && rand.Float64() >= r.config.Rate {
+				return next(c)
+			}
+
+			req := c.Request()
+			rec := &Recording{
+				Time:       time.Now(),
+				Method:     req.Method,
+				URI:        req.RequestURI,
+				Host:       req.Host,
+				RemoteAddr: req.RemoteAddr,
+				Header:     req.Header.Clone(),
+			}
+			if rec.URI == "" {
+				rec.URI = req.URL.RequestURI()
+			}
+			for _, h := range r.config.RedactHeaders {
+				if _, ok := rec.Header[http.CanonicalHeaderKey(h)]; ok {
+					rec.Header.Set(h, RedactedValue)
+				}
+			}
+			if req.Body != nil && req.Body != http.NoBody {
+				body, err := ioutil.ReadAll(io.LimitReader(req.Body, r.config.MaxBodySize+1))
+				if err != nil {
+					return err
+				}
+				if int64(len(body)) > r.config.MaxBodySize {
+					rec.Truncated = true
+				}
+				req.Body = replayBody{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+				if rec.Truncated {
+					body = body[:r.config.MaxBodySize]
+				}
+				rec.Body = body
+			}
+
+			err := next(c)
+
+			rec.Route = c.Path()
+			if names := c.ParamNames(); len(names) > 0 {
+				rec.Params = make(map[string]string, len(names))
+				for _, name := range names {
+					rec.Params[name] = c.Param(name)
+				}
+			}
+			rec.Status = c.Response().Status
+			if err != nil {
+				rec.Error = err.Error()
+				if he, ok := err.(*echo.HTTPError); ok {
+					rec.Status = he.Code
+				} else if !c.Response().Committed {
+					rec.Status = http.StatusInternalServerError
+				}
+			}
+			if serr := r.config.Store.Save(rec); serr != nil {
+				c.Logger().Errorf("recorder: %v", serr)
+			}
+			return err
+		}
+	}
+}
+
+// Replay serves the recorded request with `e` and returns the response.
+// Redacted headers are sent as recorded, so they should be set again before,
+// e.g. with a valid token.
+func Replay(e *echo.Echo, r *Recording) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(r.Method, r.URI, bytes.NewReader(r.Body))
+	req.Host = r.Host
+	if r.RemoteAddr != "" {
+		req.RemoteAddr = r.RemoteAddr
+	}
+	req.Header = r.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	res := httptest.NewRecorder()
+	e.ServeHTTP(res, req)
+	return res
+}
+
+// Load reads the recordings written by a `WriterStore`.
+func Load(r io.Reader) ([]*Recording, error) {
+	recordings := []*Recording{}
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64<<20)
+	for s.Scan() {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		rec := new(Recording)
+		if err := json.Unmarshal(s.Bytes(), rec); err != nil {
+			return nil, err
+		}
+		recordings = append(recordings, rec)
+	}
+	return recordings, s.Err()
+}
+
+// NewRing returns a ring keeping the last `size` recordings.
+func NewRing(size int) *Ring {
+	return &Ring{recordings: make([]*Recording, size)}
+}
+
+// Save implements `Store#Save()`.
+func (r *Ring) Save(rec *Recording) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recordings[r.next] = rec
+	r.next = (r.next + 1) % len(r.recordings)
+	if r.next == 0 {
+		r.full = true
+	}
+	return nil
+}
+
+// Recordings returns the recordings of the ring, oldest first.
+func (r *Ring) Recordings() []*Recording {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]*Recording(nil), r.recordings[:r.next]...)
+	}
+	return append(append([]*Recording(nil), r.recordings[r.next:]...), r.recordings[:r.next]...)
+}
+
+// NewWriterStore returns a store writing recordings to `w`.
+func NewWriterStore(w io.Writer) *WriterStore {
+	return &WriterStore{w: w}
+}
+
+// Save implements `Store#Save()`.
+func (s *WriterStore) Save(rec *Recording) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,82 @@
+package recorder
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	buf := new(bytes.Buffer)
+	r := New(Config{Store: NewWriterStore(buf), MaxBodySize: 4})
+	e := echo.New()
+	e.Use(r.Middleware())
+	e.POST("/users/:id", func(c echo.Context) error {
+		b, _ := ioutil.ReadAll(c.Request().Body)
+		if string(b) == "fail" {
+			return echo.NewHTTPError(http.StatusConflict, "conflict")
+		}
+		return c.String(http.StatusOK, c.Param("id")+":"+string(b)+":"+c.Request().Header.Get(echo.HeaderAuthorization))
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/users/1?a=b", strings.NewReader("hello"))
+	req.Header.Set(echo.HeaderAuthorization, "Bearer secret")
+	req.Header.Set("X-Trace", "t")
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "1:hello:Bearer secret", rec.Body.String(), "body is not consumed")
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/users/2", strings.NewReader("fail")))
+
+	recordings, err := Load(bytes.NewReader(buf.Bytes()))
+	if !assert.NoError(t, err) || !assert.Len(t, recordings, 2) {
+		return
+	}
+	r1 := recordings[0]
+	assert.Equal(t, http.MethodPost, r1.Method)
+	assert.Equal(t, "/users/1?a=b", r1.URI)
+	assert.Equal(t, "/users/:id", r1.Route)
+	assert.Equal(t, map[string]string{"id": "1"}, r1.Params)
+	assert.Equal(t, []byte("hell"), r1.Body)
+	assert.True(t, r1.Truncated)
+	assert.Equal(t, RedactedValue, r1.Header.Get(echo.HeaderAuthorization))
+	assert.Equal(t, "t", r1.Header.Get("X-Trace"))
+	assert.Equal(t, http.StatusOK, r1.Status)
+	r2 := recordings[1]
+	assert.Equal(t, http.StatusConflict, r2.Status)
+	assert.Equal(t, "code=409, message=conflict", r2.Error)
+
+	// Replay
+	r1.Header.Set(echo.HeaderAuthorization, "Bearer local")
+	res := Replay(e, r1)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "1:hell:Bearer local", res.Body.String())
+	assert.Equal(t, http.StatusConflict, Replay(e, r2).Code)
+}
+
+func TestRecorderRate(t *testing.T) {
+	ring := NewRing(10)
+	e := echo.New()
+	e.Use(New(Config{Store: ring, Rate: 0.000001}).Middleware())
+	e.GET("/", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+	for i := 0; i < 10; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+	assert.Empty(t, ring.Recordings())
+}
+
+func TestRing(t *testing.T) {
+	ring := NewRing(2)
+	assert.Empty(t, ring.Recordings())
+	for _, uri := range []string{"/1", "/2", "/3"} {
+		ring.Save(&Recording{URI: uri})
+	}
+	assert.Equal(t, []*Recording{{URI: "/2"}, {URI: "/3"}}, ring.Recordings())
+}
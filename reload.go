@@ -0,0 +1,112 @@
+package echo
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+type (
+	// Reloadable is a component whose settings are updated at runtime by
+	// `Echo#Reload()`, e.g. the logger level or the allowed CORS origins.
+	// Reload must be safe to call while requests are served.
+	Reloadable interface {
+		Reload(config interface{}) error
+	}
+
+	// ReloadValidator is implemented by reloadable components validating the
+	// config before any component is reloaded, so that an invalid config is
+	// rejected as a whole.
+	ReloadValidator interface {
+		ValidateReload(config interface{}) error
+	}
+
+	// ReloadableFunc is an adapter to use functions as reloadable components.
+	ReloadableFunc func(config interface{}) error
+
+	// reloader holds the reloadable components of an Echo instance.
+	reloader struct {
+		mu         sync.Mutex
+		names      []string
+		components []Reloadable
+	}
+)
+
+// Reload implements `Reloadable`.
+func (fn ReloadableFunc) Reload(config interface{}) error {
+	return fn(config)
+}
+
+// OnReload registers a reloadable component, reloaded in the order of
+// registration.
+func (e *Echo) OnReload(name string, r Reloadable) {
+	e.reloader.mu.Lock()
+	defer e.reloader.mu.Unlock()
+	e.reloader.names = append(e.reloader.names, name)
+	e.reloader.components = append(e.reloader.components, r)
+}
+
+// Reload applies the config to the registered components without restarting
+// the servers, e.g. a config file parsed again. The config is validated by the
+// components implementing `ReloadValidator` first. Reloads don't run
+// concurrently. If a component fails, the following ones are still reloaded
+// and the first error is returned.
+func (e *Echo) Reload(config interface{}) error {
+	r := &e.reloader
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, c := range r.components {
+		if v, ok := c.(ReloadValidator); ok {
+			if err := v.ValidateReload(config); err != nil {
+				return fmt.Errorf("echo: reload %s: %v", r.names[i], err)
+			}
+		}
+	}
+	var first error
+	for i, c := range r.components {
+		if err := c.Reload(config); err != nil && first == nil {
+			first = fmt.Errorf("echo: reload %s: %v", r.names[i], err)
+		}
+	}
+	return first
+}
+
+// ReloadOnSignal reloads the config returned by `load` whenever the process
+// receives one of the signals, SIGHUP by default. Errors are logged and the
+// previous settings are kept. The returned function stops handling the
+// signals.
+func (e *Echo) ReloadOnSignal(load func() (interface{}, error), signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				config, err := load()
+				if err == nil {
+					err = e.Reload(config)
+				}
+				if err != nil {
+					e.Logger.Errorf("echo: reload: %v", err)
+					continue
+				}
+				e.Logger.Info("echo: config reloaded")
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
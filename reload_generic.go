@@ -0,0 +1,58 @@
+//go:build go1.18
+// +build go1.18
+
+package echo
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Setting is a value read by the middleware and handlers and updated
+// atomically on `Echo#Reload()`.
+//
+// Example:
+//
+//	origins := echo.NewSetting(cfg.AllowOrigins)
+//	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{AllowOriginsFunc: origins.Get}))
+//	e.OnReload("cors", echo.ReloadFunc(func(cfg *AppConfig) error {
+//	  origins.Set(cfg.AllowOrigins)
+//	  return nil
+//	}))
+type Setting[T any] struct {
+	v atomic.Value
+}
+
+type settingValue[T any] struct {
+	v T
+}
+
+// NewSetting returns a setting with the initial value.
+func NewSetting[T any](initial T) *Setting[T] {
+	s := new(Setting[T])
+	s.Set(initial)
+	return s
+}
+
+// Get returns the value of the setting.
+func (s *Setting[T]) Get() T {
+	return s.v.Load().(settingValue[T]).v
+}
+
+// Set sets the value of the setting.
+func (s *Setting[T]) Set(v T) {
+	s.v.Store(settingValue[T]{v})
+}
+
+// ReloadFunc returns a reloadable component calling `fn` with the config,
+// which must be of type T.
+func ReloadFunc[T any](fn func(config T) error) ReloadableFunc {
+	return func(config interface{}) error {
+		c, ok := config.(T)
+		if !ok {
+			var zero T
+			return fmt.Errorf("config is %T, not %T", config, zero)
+		}
+		return fn(c)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build go1.18
+// +build go1.18
+
+package echo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetting(t *testing.T) {
+	type config struct {
+		Maintenance bool
+	}
+	e := New()
+	maintenance := NewSetting(false)
+	e.OnReload("maintenance", ReloadFunc(func(c *config) error {
+		maintenance.Set(c.Maintenance)
+		return nil
+	}))
+	assert.False(t, maintenance.Get())
+
+	assert.NoError(t, e.Reload(&config{Maintenance: true}))
+	assert.True(t, maintenance.Get())
+	assert.EqualError(t, e.Reload(config{}), "echo: reload maintenance: config is echo.config, not *echo.config")
+
+	var origins = NewSetting[[]string](nil)
+	assert.Nil(t, origins.Get())
+}
@@ -0,0 +1,77 @@
+package echo
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validatedComponent struct {
+	level string
+}
+
+func (c *validatedComponent) ValidateReload(config interface{}) error {
+	if config.(map[string]string)["level"] == "" {
+		return errors.New("missing level")
+	}
+	return nil
+}
+
+func (c *validatedComponent) Reload(config interface{}) error {
+	c.level = config.(map[string]string)["level"]
+	return nil
+}
+
+func TestEchoReload(t *testing.T) {
+	e := New()
+	var origins []string
+	e.OnReload("cors", ReloadableFunc(func(config interface{}) error {
+		origins = []string{config.(map[string]string)["origin"]}
+		return nil
+	}))
+	log := new(validatedComponent)
+	e.OnReload("log", log)
+
+	assert.NoError(t, e.Reload(map[string]string{"origin": "https://a.com", "level": "debug"}))
+	assert.Equal(t, []string{"https://a.com"}, origins)
+	assert.Equal(t, "debug", log.level)
+
+	// Invalid config rejected as a whole
+	err := e.Reload(map[string]string{"origin": "https://b.com"})
+	assert.EqualError(t, err, "echo: reload log: missing level")
+	assert.Equal(t, []string{"https://a.com"}, origins)
+
+	// Failing component
+	e.OnReload("broken", ReloadableFunc(func(interface{}) error {
+		return errors.New("boom")
+	}))
+	err = e.Reload(map[string]string{"origin": "https://c.com", "level": "info"})
+	assert.EqualError(t, err, "echo: reload broken: boom")
+	assert.Equal(t, "info", log.level)
+}
+
+func TestEchoReloadOnSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP can't be sent on windows")
+	}
+	e := New()
+	var reloads int32
+	e.OnReload("counter", ReloadableFunc(func(interface{}) error {
+		atomic.AddInt32(&reloads, 1)
+		return nil
+	}))
+	stop := e.ReloadOnSignal(func() (interface{}, error) {
+		return nil, nil
+	})
+	defer stop()
+
+	p, _ := os.FindProcess(os.Getpid())
+	assert.NoError(t, p.Signal(syscall.SIGHUP))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&reloads) == 1 }, time.Second, time.Millisecond)
+}
@@ -0,0 +1,394 @@
+/*
+Package rememberme implements persistent logins with the selector:validator
+token pattern. The cookie holds a selector looking the token up in a store and
+a validator of which only a hash is stored, so that a leaked store doesn't leak
+usable tokens. The validator is rotated when the token signs the user in
+again, see `RememberMe#Login()`: a request presenting a known selector with a
+stale validator means the token was stolen and used, and all the tokens of the
+user are revoked. The previous validator stays valid for a grace period, for
+the concurrent requests a browser sends with the same cookie.
+
+Example:
+
+	r, err := rememberme.New(rememberme.Config{
+	  Store:  rememberme.NewMemoryStore(),
+	  Secure: true,
+	})
+	if err != nil {
+	  e.Logger.Fatal(err)
+	}
+	e.POST("/login", func(c echo.Context) error {
+	  ...
+	  if c.FormValue("remember") == "on" {
+	    if err := r.Issue(c, user.ID); err != nil {
+	      return err
+	    }
+	  }
+	  ...
+	})
+	e.GET("/account", account, middleware.Authn(sessionAuthenticator, r.Authenticator()))
+
+Where the session is set up, e.g. by a middleware of the pages, the token signs
+the user in again once the session has expired:
+
+	if sess.Values["user"] == nil {
+	  if userID, err := r.Login(c); err == nil {
+	    sess.Values["user"] = userID
+	    ...
+	  }
+	}
+*/
+package rememberme
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+type (
+	// Config defines the config for persistent logins.
+	Config struct {
+		// Store stores the tokens.
+		// Required.
+		Store Store
+
+		// CookieName is the name of the cookie holding the token.
+		// Optional. Default value "remember_me".
+		CookieName string
+
+		// CookiePath is the path of the cookie.
+		// Optional. Default value "/".
+		CookiePath string
+
+		// MaxAge is how long a token is valid. Using a token doesn't extend it.
+		// Optional. Default value 30 days.
+		MaxAge time.Duration
+
+		// Grace is how long the previous validator of a rotated token stays
+		// valid, for concurrent requests sent with the same cookie.
+		// Optional. Default value 1 minute.
+		Grace time.Duration
+
+		// Secure marks the cookie as secure.
+		// Optional. Default value false.
+		Secure bool
+
+		// OnTheft is called when a stolen token is detected, after the tokens
+		// of the user were revoked, e.g. to alert the user.
+		// Optional.
+		OnTheft func(c echo.Context, userID string)
+	}
+
+	// Token is a persistent login token.
+	Token struct {
+		// Selector looks the token up.
+		Selector string `json:"selector"`
+
+		// ValidatorHash is the SHA-256 hash of the validator.
+		ValidatorHash []byte `json:"validator_hash"`
+
+		// PreviousValidatorHash is the hash of the validator before the last
+		// rotation.
+		PreviousValidatorHash []byte `json:"previous_validator_hash,omitempty"`
+
+		// Rotated is when the validator was last rotated.
+		Rotated time.Time `json:"rotated,omitempty"`
+
+		// UserID is the user the token signs in.
+		UserID string `json:"user_id"`
+
+		// Expires is when the token expires.
+		Expires time.Time `json:"expires"`
+	}
+
+	// Store stores persistent login tokens.
+	Store interface {
+		// Save creates or replaces the token with the selector of `t`.
+		Save(t *Token) error
+
+		// Find returns the token with the selector, or nil if there is none.
+		Find(selector string) (*Token, error)
+
+		// Delete removes the token with the selector.
+		Delete(selector string) error
+
+		// DeleteUser removes the tokens of the user.
+		DeleteUser(userID string) error
+	}
+
+	// RememberMe issues and validates persistent login tokens.
+	RememberMe struct {
+		config Config
+		mu     sync.Mutex
+	}
+
+	// MemoryStore is a `Store` keeping tokens in memory, for tests and
+	// applications served by a single instance.
+	MemoryStore struct {
+		mu     sync.Mutex
+		tokens map[string]Token
+	}
+)
+
+var (
+	// DefaultConfig is the default persistent login config.
+	DefaultConfig = Config{
+		CookieName: "remember_me",
+		CookiePath: "/",
+		MaxAge:     30 * 24 * time.Hour,
+		Grace:      time.Minute,
+	}
+
+	// ErrNoToken is returned when the request has no valid token.
+	ErrNoToken = errors.New("rememberme: no token")
+
+	// ErrTheft is returned when the token of the request was stolen.
+	ErrTheft = errors.New("rememberme: token theft detected")
+)
+
+// New returns a `RememberMe` with config.
+func New(config Config) (*RememberMe, error) {
+	// Defaults
+	if config.Store == nil {
+		return nil, errors.New("rememberme: store is required")
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultConfig.CookieName
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = DefaultConfig.CookiePath
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = DefaultConfig.MaxAge
+	}
+	if config.Grace == 0 {
+		config.Grace = DefaultConfig.Grace
+	}
+	return &RememberMe{config: config}, nil
+}
+
+// Issue creates a token for the user and sets the cookie holding it.
+func (r *RememberMe) Issue(c echo.Context, userID string) error {
+	t := &Token{
+		Selector: randomString(12),
+		UserID:   userID,
+		Expires:  time.Now().Add(r.config.MaxAge),
+	}
+	return r.save(c, t)
+}
+
+// Validate returns the user of the token of the request, without rotating its
+// validator. It returns `ErrNoToken` if the request has no valid token and
+// `ErrTheft` if the token was stolen, in which case the tokens of the user are
+// revoked. The cookie is cleared on error.
+func (r *RememberMe) Validate(c echo.Context) (string, error) {
+	t, _, err := r.validate(c)
+	if err != nil {
+		return "", err
+	}
+	return t.UserID, nil
+}
+
+// Login returns the user of the token of the request, like `Validate()`, and
+// rotates its validator. Call it when the token signs the user in and a
+// session is set up, so that the token isn't used again until the session
+// expires. A request with the previous validator of a token rotated less than
+// `Config.Grace` ago signs the user in without rotating it again.
+//
+// Rotations are serialized within the process only: instances sharing a store
+// must route the requests of a user to the same instance, or keep the grace
+// period longer than the time between their reads and writes of a token.
+func (r *RememberMe) Login(c echo.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, current, err := r.validate(c)
+	if err != nil {
+		return "", err
+	}
+	if current {
+		if err := r.save(c, t); err != nil {
+			return "", err
+		}
+	}
+	return t.UserID, nil
+}
+
+// validate returns the token of the request and whether its current validator,
+// rather than the previous one within the grace period, was presented.
+func (r *RememberMe) validate(c echo.Context) (*Token, bool, error) {
+	cookie, err := c.Cookie(r.config.CookieName)
+	if err != nil {
+		return nil, false, ErrNoToken
+	}
+	i := strings.IndexByte(cookie.Value, ':')
+	if i == -1 {
+		r.clear(c)
+		return nil, false, ErrNoToken
+	}
+	selector, validator := cookie.Value[:i], cookie.Value[i+1:]
+	t, err := r.config.Store.Find(selector)
+	if err != nil {
+		return nil, false, err
+	}
+	if t == nil {
+		r.clear(c)
+		return nil, false, ErrNoToken
+	}
+	if time.Now().After(t.Expires) {
+		r.clear(c)
+		if err := r.config.Store.Delete(selector); err != nil {
+			return nil, false, err
+		}
+		return nil, false, ErrNoToken
+	}
+	hash := sha256.Sum256([]byte(validator))
+	if subtle.ConstantTimeCompare(hash[:], t.ValidatorHash) == 1 {
+		return t, true, nil
+	}
+	if subtle.ConstantTimeCompare(hash[:], t.PreviousValidatorHash) == 1 &&
+		time.Since(t.Rotated) < r.config.Grace {
+		return t, false, nil
+	}
+	r.clear(c)
+	if err := r.config.Store.DeleteUser(t.UserID); err != nil {
+		return nil, false, err
+	}
+	if r.config.OnTheft != nil {
+		r.config.OnTheft(c, t.UserID)
+	}
+	return nil, false, ErrTheft
+}
+
+// Forget revokes the token of the request and clears the cookie, e.g. when
+// the user signs out.
+func (r *RememberMe) Forget(c echo.Context) error {
+	r.clear(c)
+	cookie, err := c.Cookie(r.config.CookieName)
+	if err != nil {
+		return nil
+	}
+	if i := strings.IndexByte(cookie.Value, ':'); i != -1 {
+		return r.config.Store.Delete(cookie.Value[:i])
+	}
+	return nil
+}
+
+// ForgetUser revokes the tokens of the user, e.g. when the password changes.
+func (r *RememberMe) ForgetUser(userID string) error {
+	return r.config.Store.DeleteUser(userID)
+}
+
+// Authenticator returns an authenticator for `middleware.Authn()` whose
+// principal is the user of the token, with method "remember_me". Place it
+// after the authenticator of the session so that tokens are only used when the
+// session has expired. It doesn't rotate the validator, see `Login()`.
+func (r *RememberMe) Authenticator() middleware.Authenticator {
+	return middleware.AuthenticatorFunc(func(c echo.Context) (*echo.Principal, error) {
+		userID, err := r.Validate(c)
+		switch err {
+		case nil:
+			return &echo.Principal{ID: userID, Method: "remember_me"}, nil
+		case ErrNoToken:
+			return nil, middleware.ErrNoCredentials
+		case ErrTheft:
+			return nil, middleware.ErrInvalidCredentials
+		}
+		return nil, err
+	})
+}
+
+// save sets a new validator on the token, keeping the current one as the
+// previous validator, stores it and sets the cookie.
+func (r *RememberMe) save(c echo.Context, t *Token) error {
+	validator := randomString(32)
+	hash := sha256.Sum256([]byte(validator))
+	cp := *t
+	if t.ValidatorHash != nil {
+		cp.PreviousValidatorHash = t.ValidatorHash
+		cp.Rotated = time.Now()
+	}
+	cp.ValidatorHash = hash[:]
+	if err := r.config.Store.Save(&cp); err != nil {
+		return err
+	}
+	c.SetCookie(r.cookie(t.Selector+":"+validator, int(time.Until(t.Expires)/time.Second)))
+	return nil
+}
+
+func (r *RememberMe) clear(c echo.Context) {
+	c.SetCookie(r.cookie("", -1))
+}
+
+func (r *RememberMe) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     r.config.CookieName,
+		Value:    value,
+		Path:     r.config.CookiePath,
+		MaxAge:   maxAge,
+		Secure:   r.config.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// NewMemoryStore returns a `MemoryStore`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tokens: map[string]Token{}}
+}
+
+// Save implements `Store`.
+func (s *MemoryStore) Save(t *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[t.Selector] = *t
+	return nil
+}
+
+// Find implements `Store`.
+func (s *MemoryStore) Find(selector string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[selector]
+	if !ok {
+		return nil, nil
+	}
+	return &t, nil
+}
+
+// Delete implements `Store`.
+func (s *MemoryStore) Delete(selector string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.tokens, selector)
+	return nil
+}
+
+// DeleteUser implements `Store`.
+func (s *MemoryStore) DeleteUser(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for selector, t := range s.tokens {
+		if t.UserID == userID {
+			delete(s.tokens, selector)
+		}
+	}
+	return nil
+}
+
+func randomString(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,185 @@
+package rememberme
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func request(e *echo.Echo, cookie *http.Cookie) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	return e.NewContext(req, rec), rec
+}
+
+func cookie(rec *httptest.ResponseRecorder) *http.Cookie {
+	cookies := rec.Result().Cookies()
+	if len(cookies) == 0 {
+		return nil
+	}
+	return cookies[len(cookies)-1]
+}
+
+func TestRememberMe(t *testing.T) {
+	e := echo.New()
+	store := NewMemoryStore()
+	var stolen string
+	r, err := New(Config{Store: store, OnTheft: func(c echo.Context, userID string) {
+		stolen = userID
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	c, rec := request(e, nil)
+	assert.NoError(t, r.Issue(c, "jon"))
+	first := cookie(rec)
+	assert.Equal(t, "remember_me", first.Name)
+	assert.True(t, first.HttpOnly)
+	assert.InDelta(t, int(DefaultConfig.MaxAge/time.Second), first.MaxAge, 1)
+	c, _ = request(e, nil)
+	assert.NoError(t, r.Issue(c, "jon"), "second device")
+	assert.Len(t, store.tokens, 2)
+
+	// Validated without rotation
+	c, rec = request(e, first)
+	userID, err := r.Validate(c)
+	assert.NoError(t, err)
+	assert.Equal(t, "jon", userID)
+	assert.Nil(t, cookie(rec))
+
+	// Validator rotated
+	c, rec = request(e, first)
+	userID, err = r.Login(c)
+	assert.NoError(t, err)
+	assert.Equal(t, "jon", userID)
+	second := cookie(rec)
+	assert.NotEqual(t, first.Value, second.Value)
+
+	// Previous validator within the grace period
+	c, rec = request(e, first)
+	userID, err = r.Login(c)
+	assert.NoError(t, err)
+	assert.Equal(t, "jon", userID)
+	assert.Nil(t, cookie(rec), "not rotated again")
+	assert.Empty(t, stolen)
+
+	// Stolen token, presented after the grace period
+	for _, t := range store.tokens {
+		t.Rotated = time.Now().Add(-2 * DefaultConfig.Grace)
+		store.Save(&t)
+	}
+	c, rec = request(e, first)
+	_, err = r.Validate(c)
+	assert.Equal(t, ErrTheft, err)
+	assert.Equal(t, "jon", stolen)
+	assert.Empty(t, store.tokens, "all tokens of the user revoked")
+	assert.Equal(t, -1, cookie(rec).MaxAge)
+	c, _ = request(e, second)
+	_, err = r.Validate(c)
+	assert.Equal(t, ErrNoToken, err)
+
+	// Expired and malformed
+	c, rec = request(e, nil)
+	r.Issue(c, "ann")
+	for _, t := range store.tokens {
+		t.Expires = time.Now().Add(-time.Second)
+		store.Save(&t)
+	}
+	c, _ = request(e, cookie(rec))
+	_, err = r.Validate(c)
+	assert.Equal(t, ErrNoToken, err)
+	assert.Empty(t, store.tokens)
+	c, _ = request(e, &http.Cookie{Name: "remember_me", Value: "malformed"})
+	_, err = r.Validate(c)
+	assert.Equal(t, ErrNoToken, err)
+
+	// Forget
+	c, rec = request(e, nil)
+	r.Issue(c, "ann")
+	c, _ = request(e, cookie(rec))
+	assert.NoError(t, r.Forget(c))
+	assert.Empty(t, store.tokens)
+
+	_, err = New(Config{})
+	assert.Error(t, err)
+}
+
+func TestRememberMeAuthenticator(t *testing.T) {
+	e := echo.New()
+	r, _ := New(Config{Store: NewMemoryStore()})
+	c, rec := request(e, nil)
+	r.Issue(c, "jon")
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, c.Principal().ID+" "+c.Principal().Method)
+	}, middleware.Authn(r.Authenticator()))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie(rec))
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, "jon remember_me", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestRememberMeConcurrent(t *testing.T) {
+	e := echo.New()
+	store := NewMemoryStore()
+	theft := false
+	r, _ := New(Config{Store: store, OnTheft: func(c echo.Context, userID string) {
+		theft = true
+	}})
+	c, rec := request(e, nil)
+	r.Issue(c, "jon")
+	first := cookie(rec)
+	e.GET("/", func(c echo.Context) error {
+		userID, err := r.Login(c)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, userID)
+	}, middleware.Authn(r.Authenticator()))
+
+	recs := make([]*httptest.ResponseRecorder, 2)
+	var wg sync.WaitGroup
+	for i := range recs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.AddCookie(first)
+			recs[i] = httptest.NewRecorder()
+			e.ServeHTTP(recs[i], req)
+		}(i)
+	}
+	wg.Wait()
+
+	var rotated *http.Cookie
+	for _, rec := range recs {
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "jon", rec.Body.String())
+		if c := cookie(rec); c != nil {
+			assert.Nil(t, rotated, "rotated once")
+			rotated = c
+		}
+	}
+	assert.False(t, theft)
+	assert.Len(t, store.tokens, 1)
+	if assert.NotNil(t, rotated) {
+		c, _ = request(e, rotated)
+		_, err := r.Validate(c)
+		assert.NoError(t, err)
+	}
+}
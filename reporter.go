@@ -0,0 +1,179 @@
+package echo
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type (
+	// ErrorReporter reports errors to a crash reporting service. It is invoked
+	// with the errors resulting in a 5xx response, including the panics recovered
+	// by `middleware.Recover()`, before they are passed to the HTTPErrorHandler.
+	// An error is reported at most once per request. The stack is only set for
+	// `*PanicError`s.
+	ErrorReporter interface {
+		Report(c Context, err error, stack []byte)
+	}
+
+	// ErrorReport is an error reported to `BatchErrorReporter` along with the
+	// request it occurred in.
+	ErrorReport struct {
+		Time      time.Time
+		Method    string
+		URI       string
+		Route     string
+		RemoteIP  string
+		RequestID string
+		Error     error
+		Stack     []byte
+	}
+
+	// BatchErrorReporterConfig defines the config for BatchErrorReporter.
+	BatchErrorReporterConfig struct {
+		// Flush sends a batch of reports, e.g. to a crash reporting service.
+		// Optional. Default value discards the reports.
+		Flush func([]ErrorReport)
+
+		// Size is the number of reports triggering a flush.
+		// Optional. Default value 100.
+		Size int
+
+		// Interval between flushes of pending reports.
+		// Optional. Default value 5s.
+		Interval time.Duration
+	}
+
+	// BatchErrorReporter is an ErrorReporter collecting reports and flushing them
+	// in batches from a background goroutine.
+	BatchErrorReporter struct {
+		config  BatchErrorReporterConfig
+		mu      sync.Mutex
+		flushMu sync.Mutex
+		batch   []ErrorReport
+		done    chan struct{}
+		once    sync.Once
+	}
+
+	// NopErrorReporter is an ErrorReporter discarding the reports.
+	NopErrorReporter struct{}
+)
+
+const errorReportedKey = "_echo_error_reported"
+
+var (
+	// DefaultBatchErrorReporterConfig is the default BatchErrorReporter config.
+	DefaultBatchErrorReporterConfig = BatchErrorReporterConfig{
+		Flush:    func([]ErrorReport) {},
+		Size:     100,
+		Interval: 5 * time.Second,
+	}
+)
+
+// Report implements `ErrorReporter#Report()`.
+func (NopErrorReporter) Report(Context, error, []byte) {}
+
+// NewBatchErrorReporter returns a BatchErrorReporter with config. It must be
+// closed to flush the pending reports and stop the background goroutine.
+func NewBatchErrorReporter(config BatchErrorReporterConfig) *BatchErrorReporter {
+	if config.Flush == nil {
+		config.Flush = DefaultBatchErrorReporterConfig.Flush
+	}
+	if config.Size <= 0 {
+		config.Size = DefaultBatchErrorReporterConfig.Size
+	}
+	if config.Interval <= 0 {
+		config.Interval = DefaultBatchErrorReporterConfig.Interval
+	}
+	r := &BatchErrorReporter{config: config, done: make(chan struct{})}
+	go r.run()
+	return r
+}
+
+// Report implements `ErrorReporter#Report()`.
+func (r *BatchErrorReporter) Report(c Context, err error, stack []byte) {
+	report := ErrorReport{
+		Time:  time.Now(),
+		Route: c.Path(),
+		Error: err,
+		Stack: stack,
+	}
+	if req := c.Request(); req != nil {
+		report.Method = req.Method
+		report.URI = req.RequestURI
+		report.RemoteIP = c.RealIP()
+		report.RequestID = req.Header.Get(HeaderXRequestID)
+	}
+	if report.RequestID == "" && c.Response() != nil {
+		report.RequestID = c.Response().Header().Get(HeaderXRequestID)
+	}
+
+	r.mu.Lock()
+	r.batch = append(r.batch, report)
+	full := len(r.batch) >= r.config.Size
+	r.mu.Unlock()
+	if full {
+		go r.Flush()
+	}
+}
+
+// Flush sends the pending reports in batches of at most `Size` reports.
+func (r *BatchErrorReporter) Flush() {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	batch := r.batch
+	r.batch = nil
+	r.mu.Unlock()
+	for len(batch) > 0 {
+		n := len(batch)
+		if n > r.config.Size {
+			n = r.config.Size
+		}
+		r.config.Flush(batch[:n:n])
+		batch = batch[n:]
+	}
+}
+
+// Close flushes the pending reports and stops the background goroutine.
+func (r *BatchErrorReporter) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+	})
+	r.Flush()
+	return nil
+}
+
+func (r *BatchErrorReporter) run() {
+	ticker := time.NewTicker(r.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.Flush()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// reportError passes err to the ErrorReporter, if any, unless it does not
+// result in a 5xx response or an error was already reported for the request.
+func (e *Echo) reportError(err error, c Context) {
+	if e.ErrorReporter == nil || c.Get(errorReportedKey) != nil {
+		return
+	}
+	var he *HTTPError
+	if errors.As(err, &he) && he.Code < http.StatusInternalServerError {
+		return
+	}
+	c.Set(errorReportedKey, true)
+	var stack []byte
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		stack = pe.Stack
+	}
+	e.ErrorReporter.Report(c, err, stack)
+}
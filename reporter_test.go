@@ -0,0 +1,92 @@
+package echo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	testify "github.com/stretchr/testify/assert"
+)
+
+type testErrorReporter struct {
+	errs   []error
+	stacks [][]byte
+}
+
+func (r *testErrorReporter) Report(c Context, err error, stack []byte) {
+	r.errs = append(r.errs, err)
+	r.stacks = append(r.stacks, stack)
+}
+
+func TestEchoErrorReporter(t *testing.T) {
+	e := New()
+	r := new(testErrorReporter)
+	e.ErrorReporter = r
+	errFailed := errors.New("failed")
+	panicErr := &PanicError{Value: "boom", Stack: []byte("stack")}
+	e.GET("/error", func(c Context) error {
+		return errFailed
+	})
+	e.GET("/panic", func(c Context) error {
+		c.Error(panicErr) // As done by middleware.Recover()
+		return nil
+	})
+	e.GET("/twice", func(c Context) error {
+		c.Error(errFailed)
+		return errFailed
+	})
+	e.GET("/bad", func(c Context) error {
+		return ErrBadRequest
+	})
+
+	for _, path := range []string{"/error", "/panic", "/twice", "/bad", "/missing"} {
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	testify.Equal(t, []error{errFailed, panicErr, errFailed}, r.errs)
+	testify.Equal(t, [][]byte{nil, []byte("stack"), nil}, r.stacks)
+}
+
+func TestBatchErrorReporter(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]ErrorReport
+	)
+	r := NewBatchErrorReporter(BatchErrorReporterConfig{
+		Size:     2,
+		Interval: time.Hour,
+		Flush: func(batch []ErrorReport) {
+			mu.Lock()
+			defer mu.Unlock()
+			batches = append(batches, batch)
+		},
+	})
+	e := New()
+	e.ErrorReporter = r
+	e.GET("/users/:id", func(c Context) error {
+		return errors.New("failed")
+	})
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set(HeaderXRequestID, "abc")
+	for i := 0; i < 3; i++ {
+		e.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	testify.NoError(t, r.Close())
+
+	mu.Lock()
+	defer mu.Unlock()
+	var reports []ErrorReport
+	for _, b := range batches {
+		testify.LessOrEqual(t, len(b), 2)
+		reports = append(reports, b...)
+	}
+	if testify.Len(t, reports, 3) {
+		testify.Equal(t, http.MethodGet, reports[0].Method)
+		testify.Equal(t, "/users/1", reports[0].URI)
+		testify.Equal(t, "/users/:id", reports[0].Route)
+		testify.Equal(t, "abc", reports[0].RequestID)
+		testify.EqualError(t, reports[0].Error, "failed")
+	}
+}
@@ -0,0 +1,66 @@
+package echo
+
+import "fmt"
+
+// RequestGuardMode controls the detection of middleware changing the method or
+// path of requests after routing, see `Echo#RequestGuard`. Such changes don't
+// reroute the request, which is a common source of confusing bugs, e.g.
+// `middleware.Rewrite()` registered with `Echo#Use()` instead of `Echo#Pre()`.
+type RequestGuardMode uint8
+
+// Request guard modes
+const (
+	// RequestGuardOff doesn't check requests.
+	RequestGuardOff RequestGuardMode = iota
+
+	// RequestGuardLog logs an error naming the middleware which changed the
+	// request.
+	RequestGuardLog
+
+	// RequestGuardPanic panics naming the middleware which changed the
+	// request, e.g. in tests.
+	RequestGuardPanic
+)
+
+// applyGuardedMiddleware chains the middleware running after routing, guarded as
+// configured by `Echo#RequestGuard`.
+func (e *Echo) applyGuardedMiddleware(h HandlerFunc, middleware []MiddlewareFunc, names []string) HandlerFunc {
+	if e.RequestGuard == RequestGuardOff || len(middleware) == 0 {
+		return applyMiddleware(h, middleware...)
+	}
+	if names == nil {
+		names = middlewareNames(middleware)
+	}
+	var method, path string
+	for i := len(middleware) - 1; i >= 0; i-- {
+		next, name := h, names[i]
+		h = middleware[i](func(c Context) error {
+			e.guardRequest(c, name, &method, &path)
+			return next(c)
+		})
+	}
+	return func(c Context) error {
+		method, path = c.Request().Method, c.Request().URL.Path
+		return h(c)
+	}
+}
+
+// guardRequest reports the middleware `name` when the method or path of the
+// request differs from the method and path it was called with.
+func (e *Echo) guardRequest(c Context, name string, method, path *string) {
+	r := c.Request()
+	var msg string
+	switch {
+	case r.Method != *method:
+		msg = fmt.Sprintf("echo: middleware %s changed the request method from %s to %s after routing", name, *method, r.Method)
+	case r.URL.Path != *path:
+		msg = fmt.Sprintf("echo: middleware %s changed the request path from %q to %q after routing", name, *path, r.URL.Path)
+	default:
+		return
+	}
+	*method, *path = r.Method, r.URL.Path
+	if e.RequestGuard == RequestGuardPanic {
+		panic(msg)
+	}
+	e.Logger.Error(msg)
+}
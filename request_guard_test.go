@@ -0,0 +1,63 @@
+package echo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rewriteToAdmin(next HandlerFunc) HandlerFunc {
+	return func(c Context) error {
+		c.Request().URL.Path = "/admin"
+		return next(c)
+	}
+}
+
+func overrideMethod(next HandlerFunc) HandlerFunc {
+	return func(c Context) error {
+		c.Request().Method = http.MethodDelete
+		return next(c)
+	}
+}
+
+func TestEchoRequestGuard(t *testing.T) {
+	e := New()
+	e.RequestGuard = RequestGuardPanic
+	e.Use(testLogger())
+	e.GET("/", listUsers)
+	e.GET("/users", listUsers, rewriteToAdmin)
+	g := e.Group("/api", overrideMethod)
+	g.GET("/users", listUsers)
+
+	code, _ := request(http.MethodGet, "/", e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.PanicsWithValue(t, `echo: middleware v4.rewriteToAdmin changed the request path from "/users" to "/admin" after routing`, func() {
+		request(http.MethodGet, "/users", e)
+	})
+	assert.PanicsWithValue(t, `echo: middleware v4.overrideMethod changed the request method from GET to DELETE after routing`, func() {
+		request(http.MethodGet, "/api/users", e)
+	})
+
+	// Global middleware, logged
+	e = New()
+	buf := new(bytes.Buffer)
+	e.Logger.SetOutput(buf)
+	e.RequestGuard = RequestGuardLog
+	e.Use(rewriteToAdmin)
+	e.GET("/users", listUsers)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, buf.String(), `middleware v4.rewriteToAdmin changed the request path from \"/users\" to \"/admin\"`)
+
+	// Pre middleware may change requests
+	e = New()
+	e.RequestGuard = RequestGuardPanic
+	e.Pre(rewriteToAdmin)
+	e.GET("/admin", listUsers, testLogger())
+	code, _ = request(http.MethodGet, "/users", e)
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"net"
 	"net/http"
+	"strings"
 )
 
 type (
@@ -59,11 +60,60 @@ func (r *Response) WriteHeader(code int) {
 	for _, fn := range r.beforeFuncs {
 		fn()
 	}
+	if r.Header().Get(HeaderTrailer) != "" {
+		// Trailers are sent after a chunked body
+		r.Header().Del(HeaderContentLength)
+	}
 	r.Status = code
 	r.Writer.WriteHeader(code)
 	r.Committed = true
 }
 
+// AddVary adds the request headers to the "Vary" header, unless they are
+// already listed or it is "*", so that shared caches don't serve a variant
+// negotiated with these headers to other clients.
+func (r *Response) AddVary(headers ...string) {
+	h := r.Header()
+	for _, name := range headers {
+		listed := false
+		for _, v := range h.Values(HeaderVary) {
+			for _, field := range strings.Split(v, ",") {
+				field = strings.TrimSpace(field)
+				if field == "*" || strings.EqualFold(field, name) {
+					listed = true
+				}
+			}
+		}
+		if !listed {
+			h.Add(HeaderVary, name)
+		}
+	}
+}
+
+// DeclareTrailer declares the trailers set with `SetTrailer()` by adding them to
+// the "Trailer" header. It must be called before the response is committed.
+func (r *Response) DeclareTrailer(names ...string) {
+	for _, name := range names {
+		r.Header().Add(HeaderTrailer, http.CanonicalHeaderKey(name))
+	}
+}
+
+// SetTrailer sets a trailer sent after the response body, e.g. a checksum of
+// a streamed download. Trailers not declared with `DeclareTrailer()` are sent
+// as well, see `http.TrailerPrefix`.
+func (r *Response) SetTrailer(name, value string) {
+	name = http.CanonicalHeaderKey(name)
+	for _, v := range r.Header()[HeaderTrailer] {
+		for _, declared := range strings.Split(v, ",") {
+			if http.CanonicalHeaderKey(strings.TrimSpace(declared)) == name {
+				r.Header().Set(name, value)
+				return
+			}
+		}
+	}
+	r.Header().Set(http.TrailerPrefix+name, value)
+}
+
 // Write writes the data to the connection as part of an HTTP reply.
 func (r *Response) Write(b []byte) (n int, err error) {
 	if !r.Committed {
@@ -84,6 +134,12 @@ func (r *Response) Write(b []byte) (n int, err error) {
 // buffered data to the client.
 // See [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
 func (r *Response) Flush() {
+	if !r.Committed {
+		if r.Status == 0 {
+			r.Status = http.StatusOK
+		}
+		r.WriteHeader(r.Status)
+	}
 	r.Writer.(http.Flusher).Flush()
 }
 
@@ -0,0 +1,8 @@
+//go:build go1.19
+// +build go1.19
+
+package echo
+
+// informationalSupported reports whether `http.ResponseWriter#WriteHeader()`
+// can send 1xx interim responses before the final response.
+const informationalSupported = true
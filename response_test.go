@@ -51,3 +51,49 @@ func TestResponse_Flush(t *testing.T) {
 	res.Flush()
 	assert.True(t, rec.Flushed)
 }
+
+func TestResponse_Flush_Commits(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	res := &Response{echo: e, Writer: rec}
+	called := false
+	res.Before(func() {
+		called = true
+	})
+
+	res.Flush()
+	assert.True(t, res.Committed)
+	assert.True(t, called)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestResponse_Trailer(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	res := &Response{echo: e, Writer: rec}
+
+	res.DeclareTrailer("x-checksum")
+	res.Header().Set(HeaderContentLength, "4")
+	res.Write([]byte("test"))
+	res.SetTrailer("X-Checksum", "abc")
+	res.SetTrailer("X-Undeclared", "def")
+
+	result := rec.Result()
+	assert.Empty(t, result.Header.Get(HeaderContentLength))
+	assert.Equal(t, "abc", result.Trailer.Get("X-Checksum"))
+	assert.Equal(t, "def", result.Trailer.Get("X-Undeclared"))
+}
+
+func TestResponse_AddVary(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	res := &Response{echo: e, Writer: rec}
+
+	res.Header().Set(HeaderVary, "Origin, Accept")
+	res.AddVary(HeaderAcceptEncoding, "origin", HeaderAcceptEncoding, HeaderAccept)
+	assert.Equal(t, []string{"Origin, Accept", HeaderAcceptEncoding}, res.Header().Values(HeaderVary))
+
+	res.Header().Set(HeaderVary, "*")
+	res.AddVary(HeaderAcceptLanguage)
+	assert.Equal(t, []string{"*"}, res.Header().Values(HeaderVary))
+}
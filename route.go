@@ -0,0 +1,162 @@
+package echo
+
+import (
+	stdContext "context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/labstack/gommon/bytes"
+)
+
+type (
+	// limitedBody fails reads past the body limit of a route.
+	limitedBody struct {
+		io.ReadCloser
+		limit int64
+		read  int64
+	}
+)
+
+// Route meta keys of the limits enforced for a route
+const (
+	// RouteMetaBodyLimit is the key of the body limit in bytes (int64) set with
+	// `Route#BodyLimit()`.
+	RouteMetaBodyLimit = "echo.body_limit"
+
+	// RouteMetaTimeout is the key of the timeout (time.Duration) set with
+	// `Route#Timeout()`.
+	RouteMetaTimeout = "echo.timeout"
+
+	// RouteMetaScopes is the key of the scopes ([]string) required for a route
+	// set with `Route#RequireScopes()`.
+	RouteMetaScopes = "echo.scopes"
+)
+
+// Route meta keys of the documentation of a route, see `Echo#RouteInfos()` and
+// package openapi
+const (
+	// RouteMetaSummary is the key of the summary (string) set with
+	// `Route#Summary()`.
+	RouteMetaSummary = "echo.summary"
+
+	// RouteMetaDescription is the key of the description (string) set with
+	// `Route#Description()`.
+	RouteMetaDescription = "echo.description"
+
+	// RouteMetaTags is the key of the tags ([]string) set with `Route#Tags()`.
+	RouteMetaTags = "echo.tags"
+
+	// RouteMetaDeprecated is the key of the deprecation (bool) set with
+	// `Route#Deprecated()`.
+	RouteMetaDeprecated = "echo.deprecated"
+)
+
+// BodyLimit sets the maximum allowed size of the request body of the route,
+// e.g. "100M", overriding the limit of `middleware.BodyLimit()`. Requests with
+// a larger body get a "413 - Request Entity Too Large" response.
+// It panics if the limit can't be parsed.
+func (r *Route) BodyLimit(limit string) *Route {
+	n, err := bytes.Parse(limit)
+	if err != nil {
+		panic(fmt.Errorf("echo: invalid body-limit=%s", limit))
+	}
+	return r.SetMeta(RouteMetaBodyLimit, n)
+}
+
+// Timeout sets the timeout after which the std context of the requests of the
+// route is canceled, overriding the timeout of `middleware.Deadline()`. Handler
+// errors caused by the timeout result in a "503 - Service Unavailable" response.
+func (r *Route) Timeout(d time.Duration) *Route {
+	return r.SetMeta(RouteMetaTimeout, d)
+}
+
+// RequireScopes sets the scopes the principal of requests to the route must
+// have been granted. They are enforced by `middleware.Authorize()`.
+func (r *Route) RequireScopes(scopes ...string) *Route {
+	return r.SetMeta(RouteMetaScopes, scopes)
+}
+
+// Summary sets a short summary of what the route does, e.g. "Create a user".
+func (r *Route) Summary(summary string) *Route {
+	return r.SetMeta(RouteMetaSummary, summary)
+}
+
+// Description sets a description of the route, which may be Markdown.
+func (r *Route) Description(description string) *Route {
+	return r.SetMeta(RouteMetaDescription, description)
+}
+
+// Tags adds tags grouping the route with related routes, e.g. "users".
+func (r *Route) Tags(tags ...string) *Route {
+	prev, _ := r.Meta()[RouteMetaTags].([]string)
+	return r.SetMeta(RouteMetaTags, append(append([]string(nil), prev...), tags...))
+}
+
+// Deprecated marks the route as deprecated.
+func (r *Route) Deprecated() *Route {
+	return r.SetMeta(RouteMetaDeprecated, true)
+}
+
+// MatchedRoute returns the route the request of the context was routed to, by
+// the router of its host like `ServeHTTP()`, or nil if there is none, e.g. in
+// middleware registered with `Pre()`.
+func (e *Echo) MatchedRoute(c Context) *Route {
+	req := c.Request()
+	if c.Path() == "" || req == nil {
+		return nil
+	}
+	return e.findRouter(req.Host).snapshot().routes[req.Method+c.Path()]
+}
+
+// FindRoute returns the route `ServeHTTP()` routes the request to, matching its
+// path as configured by `Echo#PathMatch` with the router of its host, or nil if
+// there is none. Middleware registered with `Pre()` isn't run, so rewrites of
+// the path aren't taken into account.
+func (e *Echo) FindRoute(req *http.Request) *Route {
+	c := e.pool.Get().(*context)
+	defer e.pool.Put(c)
+	c.Reset(req, nil)
+	e.findRouter(req.Host).Find(req.Method, e.matchPath(req), c)
+	return e.MatchedRoute(c)
+}
+
+// serveRoute calls `h` enforcing the limits set on route `r`.
+func serveRoute(r *Route, c Context, h HandlerFunc) error {
+	meta := r.Meta()
+	if meta == nil {
+		return h(c)
+	}
+	req := c.Request()
+	if limit, ok := meta[RouteMetaBodyLimit].(int64); ok {
+		if req.ContentLength > limit {
+			return ErrStatusRequestEntityTooLarge
+		}
+		if req.Body != nil {
+			req.Body = &limitedBody{ReadCloser: req.Body, limit: limit}
+		}
+	}
+	d, ok := meta[RouteMetaTimeout].(time.Duration)
+	if !ok || d <= 0 {
+		return h(c)
+	}
+	ctx, cancel := stdContext.WithTimeout(req.Context(), d)
+	defer cancel()
+	c.SetRequest(req.WithContext(ctx))
+	err := h(c)
+	if err != nil && errors.Is(err, stdContext.DeadlineExceeded) && ctx.Err() == stdContext.DeadlineExceeded {
+		return ErrServiceUnavailable.WithInternal(err)
+	}
+	return err
+}
+
+func (b *limitedBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.read > b.limit {
+		return n, ErrStatusRequestEntityTooLarge
+	}
+	return
+}
@@ -0,0 +1,148 @@
+package echo
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+type (
+	// RouteInfo describes a route and the middleware requests to it go
+	// through, see `Echo#RouteInfos()`.
+	RouteInfo struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Name   string `json:"name"`
+
+		// Middleware lists the names of the middleware in the order they run:
+		// the middleware registered with `Echo#Pre()` and `Echo#Use()`, then
+		// the group and route-level middleware.
+		Middleware []string `json:"middleware"`
+
+		// Summary, Description, Tags and Deprecated document the route, see
+		// `Route#Summary()`.
+		Summary     string   `json:"summary,omitempty"`
+		Description string   `json:"description,omitempty"`
+		Tags        []string `json:"tags,omitempty"`
+		Deprecated  bool     `json:"deprecated,omitempty"`
+	}
+)
+
+// RouteInfos returns the registered routes sorted by path and method, with the
+// names of their middleware.
+func (e *Echo) RouteInfos() []RouteInfo {
+	global := append(middlewareNames(e.premiddleware), middlewareNames(e.middleware)...)
+	routes := e.Routes()
+	infos := make([]RouteInfo, len(routes))
+	for i, r := range routes {
+		m := append(append([]string(nil), global...), r.middleware...)
+		infos[i] = RouteInfo{Method: r.Method, Path: r.Path, Name: r.Name, Middleware: m}
+		meta := r.Meta()
+		infos[i].Summary, _ = meta[RouteMetaSummary].(string)
+		infos[i].Description, _ = meta[RouteMetaDescription].(string)
+		infos[i].Tags, _ = meta[RouteMetaTags].([]string)
+		infos[i].Deprecated, _ = meta[RouteMetaDeprecated].(bool)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
+}
+
+// PrintRoutes writes a table of the routes and their middleware to `w`, e.g.
+// for startup diagnostics. A summary column, with the tags and deprecation of
+// the routes, is added when routes are documented.
+func (e *Echo) PrintRoutes(w io.Writer) error {
+	infos := e.RouteInfos()
+	documented := false
+	for _, r := range infos {
+		if r.Summary != "" || len(r.Tags) > 0 || r.Deprecated {
+			documented = true
+			break
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	header := "METHOD\tPATH\tNAME\tMIDDLEWARE"
+	if documented {
+		header += "\tSUMMARY"
+	}
+	io.WriteString(tw, header+"\n")
+	for _, r := range infos {
+		line := r.Method + "\t" + r.Path + "\t" + r.Name + "\t" + strings.Join(r.Middleware, ", ")
+		if documented {
+			line += "\t" + r.summary()
+		}
+		io.WriteString(tw, line+"\n")
+	}
+	return tw.Flush()
+}
+
+// summary returns the summary of the route followed by its tags and
+// deprecation, e.g. "Delete a user [users] (deprecated)".
+func (r RouteInfo) summary() string {
+	s := r.Summary
+	if len(r.Tags) > 0 {
+		s += " [" + strings.Join(r.Tags, ", ") + "]"
+	}
+	if r.Deprecated {
+		s += " (deprecated)"
+	}
+	return strings.TrimSpace(s)
+}
+
+// PrintRoutesJSON writes the routes and their middleware to `w` as a JSON
+// array of `RouteInfo`, e.g. for ops tooling.
+func (e *Echo) PrintRoutesJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e.RouteInfos())
+}
+
+// middlewareNames returns the names of the middleware.
+func middlewareNames(middleware []MiddlewareFunc) []string {
+	names := make([]string, len(middleware))
+	for i, m := range middleware {
+		names[i] = middlewareName(m)
+	}
+	return names
+}
+
+// middlewareName returns the name of the function creating the middleware,
+// e.g. "middleware.GzipWithConfig" for the middleware returned by
+// `middleware.Gzip()`.
+func middlewareName(m MiddlewareFunc) string {
+	name := runtime.FuncForPC(reflect.ValueOf(m).Pointer()).Name()
+	if i := strings.LastIndexByte(name, '/'); i != -1 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	for {
+		i := strings.LastIndexByte(name, '.')
+		if i == -1 || !isClosureName(name[i+1:]) {
+			return name
+		}
+		name = name[:i]
+	}
+}
+
+// isClosureName reports whether s names an anonymous function, e.g. "func1"
+// or "2".
+func isClosureName(s string) bool {
+	s = strings.TrimPrefix(s, "func")
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
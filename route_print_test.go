@@ -0,0 +1,92 @@
+package echo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testLogger() MiddlewareFunc {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			return next(c)
+		}
+	}
+}
+
+type testAuth struct{}
+
+func (testAuth) Middleware(next HandlerFunc) HandlerFunc {
+	return next
+}
+
+func listUsers(c Context) error {
+	return c.NoContent(http.StatusOK)
+}
+
+func TestEchoPrintRoutes(t *testing.T) {
+	e := New()
+	e.Pre(testLogger())
+	e.Use(testLogger())
+	g := e.Group("/api")
+	g.GET("/users", listUsers, testAuth{}.Middleware)
+	e.POST("/login", listUsers)
+
+	infos := e.RouteInfos()
+	if assert.Len(t, infos, 2) {
+		assert.Equal(t, RouteInfo{
+			Method:     http.MethodGet,
+			Path:       "/api/users",
+			Name:       "github.com/labstack/echo/v4.listUsers",
+			Middleware: []string{"v4.testLogger", "v4.testLogger", "v4.testAuth.Middleware"},
+		}, infos[0])
+		assert.Equal(t, []string{"v4.testLogger", "v4.testLogger"}, infos[1].Middleware)
+	}
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, e.PrintRoutes(buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 3) {
+		assert.Equal(t, []string{"METHOD", "PATH", "NAME", "MIDDLEWARE"}, strings.Fields(lines[0]))
+		assert.Equal(t, "GET     /api/users  github.com/labstack/echo/v4.listUsers  v4.testLogger, v4.testLogger, v4.testAuth.Middleware", lines[1])
+	}
+
+	buf.Reset()
+	assert.NoError(t, e.PrintRoutesJSON(buf))
+	var decoded []RouteInfo
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, infos, decoded)
+
+	// Replaced route
+	e.OverrideRoutes = true
+	g.GET("/users", listUsers)
+	assert.Equal(t, []string{"v4.testLogger", "v4.testLogger"}, e.RouteInfos()[0].Middleware)
+}
+
+func TestEchoPrintRoutesDocs(t *testing.T) {
+	e := New()
+	e.GET("/users", listUsers).Summary("List users").Tags("users", "public").Description("Paginated.")
+	e.DELETE("/users/:id", listUsers).Tags("users").Deprecated()
+	e.POST("/login", listUsers)
+
+	infos := e.RouteInfos()
+	if assert.Len(t, infos, 3) {
+		assert.Equal(t, "List users", infos[1].Summary)
+		assert.Equal(t, "Paginated.", infos[1].Description)
+		assert.Equal(t, []string{"users", "public"}, infos[1].Tags)
+		assert.True(t, infos[2].Deprecated)
+	}
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, e.PrintRoutes(buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 4) {
+		assert.Equal(t, []string{"METHOD", "PATH", "NAME", "MIDDLEWARE", "SUMMARY"}, strings.Fields(lines[0]))
+		assert.True(t, strings.HasSuffix(lines[2], "List users [users, public]"), lines[2])
+		assert.True(t, strings.HasSuffix(lines[3], "[users] (deprecated)"), lines[3])
+	}
+}
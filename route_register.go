@@ -0,0 +1,70 @@
+package echo
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// echoDir is the directory of the package, to tell the frames of its functions
+// from the frames of the code registering routes.
+var echoDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// checkRoute records where the route is registered. It panics reporting both
+// call sites if the method and path were registered before, unless
+// `Echo#OverrideRoutes` is set to let routes replace the previous route, e.g.
+// for plugin systems. Routes registered with `NotFoundHandler`, e.g. the
+// catch-all routes of groups, may always be overridden.
+func (e *Echo) checkRoute(host string, r *Route) {
+	site := registrationSite()
+	key := host + " " + r.Method + " " + routePattern(r.Path)
+	v, loaded := e.routeSites.LoadOrStore(key, &routeSite{name: r.Name, site: site})
+	if !loaded {
+		return
+	}
+	prev := v.(*routeSite)
+	notFound := handlerName(NotFoundHandler)
+	if !e.OverrideRoutes && prev.name != notFound && r.Name != notFound {
+		panic(fmt.Sprintf("echo: route %s %s registered at %s is registered again at %s", r.Method, r.Path, prev.site, site))
+	}
+	e.routeSites.Store(key, &routeSite{name: r.Name, site: site})
+}
+
+// routeSite is where a route was registered.
+type routeSite struct {
+	name string
+	site string
+}
+
+// routePattern returns the path with the names of its params left out, since
+// "/users/:id" and "/users/:name" are the same route.
+func routePattern(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") {
+			segments[i] = ":"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// registrationSite returns the "<file>:<line>" of the first caller outside of
+// the package.
+func registrationSite() string {
+	pc := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(3, pc)])
+	for {
+		f, more := frames.Next()
+		if filepath.Dir(f.File) != echoDir || strings.HasSuffix(f.File, "_test.go") {
+			return f.File + ":" + strconv.Itoa(f.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
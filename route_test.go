@@ -0,0 +1,146 @@
+package echo
+
+import (
+	stdContext "context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteBodyLimit(t *testing.T) {
+	e := New()
+	r := e.POST("/upload", func(c Context) error {
+		b, err := ioutil.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, string(b))
+	}).BodyLimit("4B")
+	assert.Equal(t, int64(4), r.Meta()[RouteMetaBodyLimit])
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("abcd")))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "abcd", rec.Body.String())
+
+	// Content length
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("abcde")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	// Content read
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("abcde"))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	assert.Panics(t, func() {
+		e.POST("/invalid", func(c Context) error { return nil }).BodyLimit("4X")
+	})
+}
+
+func TestRouteTimeout(t *testing.T) {
+	e := New()
+	e.GET("/report", func(c Context) error {
+		deadline, ok := c.Deadline()
+		assert.True(t, ok)
+		assert.WithinDuration(t, time.Now(), deadline, 20*time.Millisecond)
+		<-c.Request().Context().Done()
+		return c.Request().Context().Err()
+	}).Timeout(10 * time.Millisecond)
+	e.GET("/canceled", func(c Context) error {
+		return stdContext.Canceled
+	}).Timeout(time.Minute)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/canceled", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestEchoMatchedRoute(t *testing.T) {
+	e := New()
+	var matched *Route
+	r := e.GET("/users/:id", func(c Context) error {
+		matched = c.Echo().MatchedRoute(c)
+		return nil
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	assert.Same(t, r, matched)
+	assert.Nil(t, e.MatchedRoute(e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), nil)))
+}
+
+func TestEchoDuplicateRoute(t *testing.T) {
+	e := New()
+	e.GET("/users/:id", listUsers)
+	e.POST("/users/:id", listUsers)
+	defer func() {
+		msg, _ := recover().(string)
+		assert.Regexp(t, `^echo: route GET /users/:name registered at .*route_test.go:\d+ is registered again at .*route_test.go:\d+$`, msg)
+	}()
+	e.GET("/users/:name", listUsers)
+}
+
+func TestEchoOverrideRoutes(t *testing.T) {
+	e := New()
+	e.Group("/api", testLogger())
+	e.Group("/api", testLogger())
+	e.GET("/api/*", listUsers)
+
+	e.OverrideRoutes = true
+	e.GET("/users", listUsers)
+	e.GET("/users", func(c Context) error {
+		return c.NoContent(http.StatusAccepted)
+	})
+	code, _ := request(http.MethodGet, "/users", e)
+	assert.Equal(t, http.StatusAccepted, code)
+}
+
+func TestEchoMatchedRouteHost(t *testing.T) {
+	e := New()
+	var matched *Route
+	h := func(c Context) error {
+		matched = c.Echo().MatchedRoute(c)
+		return nil
+	}
+	r := e.GET("/users", h)
+	hr := e.Host("api.example.com").GET("/users", h).Timeout(time.Second)
+	assert.Len(t, e.Routes(), 2)
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users", nil))
+	assert.Same(t, r, matched)
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Host = "api.example.com"
+	e.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Same(t, hr, matched)
+}
+
+func TestEchoFindRoute(t *testing.T) {
+	e := New()
+	h := func(c Context) error { return nil }
+	static := e.GET("/files/a/b", h)
+	param := e.GET("/files/:name", h)
+	host := e.Host("api.example.com").GET("/files/:name", h)
+
+	req := httptest.NewRequest(http.MethodGet, "/files/a%2Fb", nil)
+	assert.Same(t, param, e.FindRoute(req))
+	e.PathMatch = PathMatchDecoded
+	assert.Same(t, static, e.FindRoute(req))
+
+	assert.Nil(t, e.FindRoute(httptest.NewRequest(http.MethodGet, "/files/a/b/c", nil)))
+
+	e.PathMatch = PathMatchAuto
+	req.Host = "api.example.com"
+	assert.Same(t, host, e.FindRoute(req))
+	assert.Nil(t, e.FindRoute(httptest.NewRequest(http.MethodPost, "/files/a", nil)))
+}
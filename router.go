@@ -3,15 +3,31 @@ package echo
 import (
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 type (
 	// Router is the registry of all registered routes for an `Echo` instance for
 	// request matching and URL path parameter parsing.
+	//
+	// Routes are added to a mutable tree, while requests are matched against an
+	// immutable copy of it swapped atomically when routes change, so that `Find`
+	// needs no locking even when routes are added while serving.
 	Router struct {
-		tree   *node
-		routes map[string]*Route
+		tree   *node             // Guarded by mu
+		routes map[string]*Route // Guarded by mu
 		echo   *Echo
+		mu     sync.Mutex
+		live   bool         // Whether a snapshot was published, guarded by mu
+		snap   atomic.Value // *routerSnapshot
+	}
+
+	// routerSnapshot is a read-only copy of the tree and routes of a router.
+	routerSnapshot struct {
+		tree     *node
+		routes   map[string]*Route
+		maxParam int
 	}
 	node struct {
 		kind          kind
@@ -59,6 +75,51 @@ func NewRouter(e *Echo) *Router {
 
 // Add registers a new route for method and path with matching handler.
 func (r *Router) Add(method, path string, h HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(method, path, h)
+	if r.live {
+		s := r.snapshot()
+		r.publish(&routerSnapshot{tree: r.tree.clone(nil), routes: s.routes})
+	}
+}
+
+// addRoute records the route for `Echo#Routes()`, `Echo#Reverse()` and
+// `Echo#MatchedRoute()`.
+func (r *Router) addRoute(key string, route *Route) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes[key] = route
+	if r.live {
+		s := r.snapshot()
+		r.publish(&routerSnapshot{tree: s.tree, routes: copyRoutes(r.routes)})
+	}
+}
+
+// snapshot returns the routing table requests are matched against, publishing
+// it on first use. Routes added later are published as they are added.
+func (r *Router) snapshot() *routerSnapshot {
+	if s, ok := r.snap.Load().(*routerSnapshot); ok {
+		return s
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.snap.Load().(*routerSnapshot); ok {
+		return s
+	}
+	s := &routerSnapshot{tree: r.tree.clone(nil), routes: copyRoutes(r.routes)}
+	r.publish(s)
+	r.live = true
+	return s
+}
+
+// publish swaps the snapshot. The caller must hold the lock.
+func (r *Router) publish(s *routerSnapshot) {
+	s.maxParam = s.tree.maxParam()
+	r.snap.Store(s)
+}
+
+func (r *Router) add(method, path string, h HandlerFunc) {
 	// Validate path
 	if path == "" {
 		path = "/"
@@ -98,10 +159,7 @@ func (r *Router) Add(method, path string, h HandlerFunc) {
 
 func (r *Router) insert(method, path string, h HandlerFunc, t kind, ppath string, pnames []string) {
 	// Adjust max param
-	l := len(pnames)
-	if *r.echo.maxParam < l {
-		*r.echo.maxParam = l
-	}
+	r.echo.reserveMaxParam(len(pnames))
 
 	cn := r.tree // Current node as root
 	if cn == nil {
@@ -203,6 +261,38 @@ func newNode(t kind, pre string, p *node, c children, mh *methodHandler, ppath s
 	}
 }
 
+// clone returns a deep copy of the node and its children.
+func (n *node) clone(parent *node) *node {
+	c := *n
+	c.parent = parent
+	mh := *n.methodHandler
+	c.methodHandler = &mh
+	c.children = make(children, len(n.children))
+	for i, child := range n.children {
+		c.children[i] = child.clone(&c)
+	}
+	return &c
+}
+
+// maxParam returns the maximum number of params of the node and its children.
+func (n *node) maxParam() int {
+	max := len(n.pnames)
+	for _, c := range n.children {
+		if m := c.maxParam(); m > max {
+			max = m
+		}
+	}
+	return max
+}
+
+func copyRoutes(routes map[string]*Route) map[string]*Route {
+	m := make(map[string]*Route, len(routes))
+	for k, v := range routes {
+		m[k] = v
+	}
+	return m
+}
+
 func (n *node) addChild(c *node) {
 	n.children = append(n.children, c)
 }
@@ -310,7 +400,9 @@ func (n *node) checkMethodNotAllowed() HandlerFunc {
 func (r *Router) Find(method, path string, c Context) {
 	ctx := c.(*context)
 	ctx.path = path
-	cn := r.tree // Current node as root
+	s := r.snapshot()
+	ctx.reserveParams(s.maxParam)
+	cn := s.tree // Current node as root
 
 	var (
 		search  = path
@@ -447,10 +539,13 @@ func (r *Router) Find(method, path string, c Context) {
 				if np == nil {
 					break // no further parent nodes in tree, abort
 				}
-				var str strings.Builder
-				str.WriteString(nn.prefix)
-				str.WriteString(search)
-				search = str.String()
+				// Prepend the prefix, slicing the path rather than allocating
+				// when the prefix precedes the search in it
+				if i := len(path) - len(search) - len(nn.prefix); i >= 0 && path[i:i+len(nn.prefix)] == nn.prefix && path[i+len(nn.prefix):] == search {
+					search = path[i:]
+				} else {
+					search = nn.prefix + search
+				}
 				nn = np
 			}
 			if cn != nil { // use the found "any" route and update path
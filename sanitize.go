@@ -0,0 +1,167 @@
+package echo
+
+import (
+	"html"
+	"io"
+	"net/url"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+type (
+	// Sanitizer removes the markup which isn't safe to render from untrusted
+	// HTML, e.g. user comments, see `Context#SafeHTML()`.
+	Sanitizer interface {
+		Sanitize(html string) string
+	}
+
+	// SanitizerFunc is an adapter to use functions as sanitizers, e.g. the
+	// `Sanitize()` method of a bluemonday policy.
+	SanitizerFunc func(html string) string
+
+	// HTMLPolicy is a `Sanitizer` keeping the allowed elements and attributes
+	// only. The text of other elements is kept, escaped, except for the
+	// elements whose content is never text, e.g. "script" and "style".
+	HTMLPolicy struct {
+		// Elements maps the allowed elements to their allowed attributes.
+		Elements map[string][]string
+
+		// URLSchemes lists the schemes allowed in the "href", "src" and "cite"
+		// attributes. Relative URLs are always allowed.
+		URLSchemes []string
+
+		// LinkRel is set as the "rel" attribute of links, e.g. "nofollow" so
+		// that user content doesn't pass ranking to spammers.
+		LinkRel string
+	}
+)
+
+var (
+	// DefaultHTMLPolicy allows the formatting, lists, quotes, code, headings,
+	// tables, links and images of user content. Links get the rel "nofollow
+	// ugc noopener".
+	DefaultHTMLPolicy = &HTMLPolicy{
+		Elements: map[string][]string{
+			"a": {"href", "title"}, "img": {"src", "alt", "title", "width", "height"},
+			"p": nil, "br": nil, "hr": nil, "span": nil, "div": nil,
+			"b": nil, "strong": nil, "i": nil, "em": nil, "u": nil, "s": nil, "del": nil, "ins": nil,
+			"sub": nil, "sup": nil, "small": nil, "mark": nil, "abbr": {"title"},
+			"ul": nil, "ol": {"start"}, "li": nil, "dl": nil, "dt": nil, "dd": nil,
+			"blockquote": {"cite"}, "q": {"cite"}, "code": nil, "pre": nil, "kbd": nil,
+			"h1": nil, "h2": nil, "h3": nil, "h4": nil, "h5": nil, "h6": nil,
+			"table": nil, "thead": nil, "tbody": nil, "tfoot": nil, "tr": nil,
+			"th": {"colspan", "rowspan", "align"}, "td": {"colspan", "rowspan", "align"},
+		},
+		URLSchemes: []string{"http", "https", "mailto"},
+		LinkRel:    "nofollow ugc noopener",
+	}
+
+	// rawTextElements are the elements whose content is dropped along with
+	// them.
+	rawTextElements = map[string]bool{
+		"script": true, "style": true, "iframe": true, "noscript": true,
+		"object": true, "embed": true, "template": true, "textarea": true,
+		"title": true, "xmp": true, "noembed": true, "noframes": true,
+	}
+
+	// voidElements are the elements without end tag.
+	voidElements = map[string]bool{
+		"area": true, "base": true, "br": true, "col": true, "embed": true,
+		"hr": true, "img": true, "input": true, "link": true, "meta": true,
+		"source": true, "track": true, "wbr": true,
+	}
+)
+
+// Sanitize implements `Sanitizer`.
+func (fn SanitizerFunc) Sanitize(html string) string {
+	return fn(html)
+}
+
+// Sanitize implements `Sanitizer`. The output is well-formed: end tags without
+// start tag are dropped and unclosed elements are closed.
+func (p *HTMLPolicy) Sanitize(s string) string {
+	b := new(strings.Builder)
+	z := xhtml.NewTokenizer(strings.NewReader(s))
+	var open []string
+	skip := ""
+	for {
+		tt := z.Next()
+		if tt == xhtml.ErrorToken {
+			if z.Err() != io.EOF {
+				return ""
+			}
+			break
+		}
+		t := z.Token()
+		if skip != "" {
+			if tt == xhtml.EndTagToken && t.Data == skip {
+				skip = ""
+			}
+			continue
+		}
+		switch tt {
+		case xhtml.TextToken:
+			b.WriteString(html.EscapeString(t.Data))
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if rawTextElements[t.Data] {
+				if tt == xhtml.StartTagToken {
+					skip = t.Data
+				}
+				continue
+			}
+			attrs, ok := p.Elements[t.Data]
+			if !ok {
+				continue
+			}
+			b.WriteString("<" + t.Data)
+			for _, a := range t.Attr {
+				if a.Namespace != "" || !containsString(attrs, a.Key) ||
+					(a.Key == "href" || a.Key == "src" || a.Key == "cite") && !p.allowedURL(a.Val) ||
+					a.Key == "rel" && t.Data == "a" && p.LinkRel != "" {
+					continue
+				}
+				b.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+			}
+			if t.Data == "a" && p.LinkRel != "" {
+				b.WriteString(` rel="` + html.EscapeString(p.LinkRel) + `"`)
+			}
+			b.WriteString(">")
+			if !voidElements[t.Data] {
+				open = append(open, t.Data)
+			}
+		case xhtml.EndTagToken:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != t.Data {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					b.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i] + ">")
+	}
+	return b.String()
+}
+
+// allowedURL reports whether the URL is relative or has an allowed scheme.
+func (p *HTMLPolicy) allowedURL(s string) bool {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" {
+		return true
+	}
+	for _, scheme := range p.URLSchemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return true
+		}
+	}
+	return false
+}
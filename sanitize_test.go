@@ -0,0 +1,49 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTMLPolicySanitize(t *testing.T) {
+	tests := []struct {
+		in, out string
+	}{
+		{`<p>Hello <b>world</b></p>`, `<p>Hello <b>world</b></p>`},
+		{`<p onclick="alert(1)" class="x">Hi</p>`, `<p>Hi</p>`},
+		{`<script>alert(1)</script>ok`, `ok`},
+		{`<style>body{}</style><iframe src="x"></iframe>ok`, `ok`},
+		{`<a href="javascript:alert(1)" rel="me">x</a>`, `<a rel="nofollow ugc noopener">x</a>`},
+		{`<a href="https://echo.labstack.com" title="Echo">x</a>`, `<a href="https://echo.labstack.com" title="Echo" rel="nofollow ugc noopener">x</a>`},
+		{`<a href="/docs">x</a>`, `<a href="/docs" rel="nofollow ugc noopener">x</a>`},
+		{`<img src="data:image/png;base64,AAAA" alt="x"><img src="/a.png" onerror="x">`, `<img alt="x"><img src="/a.png">`},
+		{`<unknown>text &amp; &lt;b&gt;</unknown>`, `text &amp; &lt;b&gt;`},
+		{`<b><i>unclosed`, `<b><i>unclosed</i></b>`},
+		{`</b>stray<b>x</i></b>`, `stray<b>x</b>`},
+		{`<!-- comment --><br/>`, `<br>`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.out, DefaultHTMLPolicy.Sanitize(tt.in), tt.in)
+	}
+}
+
+func TestContextSafeHTML(t *testing.T) {
+	e := New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if assert.NoError(t, c.SafeHTML(http.StatusOK, `<p>Hi<script>alert(1)</script></p>`)) {
+		assert.Equal(t, MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal(t, `<p>Hi</p>`, rec.Body.String())
+	}
+
+	e.Sanitizer = SanitizerFunc(strings.ToUpper)
+	rec = httptest.NewRecorder()
+	c = e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if assert.NoError(t, c.SafeHTML(http.StatusOK, `<p>hi</p>`)) {
+		assert.Equal(t, `<P>HI</P>`, rec.Body.String())
+	}
+}
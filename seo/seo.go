@@ -0,0 +1,298 @@
+/*
+Package seo serves robots.txt and sitemap.xml files. The robots.txt policy is
+declared with `Robots`, and the sitemap lists the GET routes opting in with
+their meta, plus the URLs of sources, e.g. the pages of a database.
+
+Example:
+
+	e.GET("/robots.txt", seo.RobotsHandler(&seo.Robots{
+	  Groups: []seo.RobotsGroup{
+	    {UserAgents: []string{"*"}, Disallow: []string{"/admin/"}},
+	  },
+	  Sitemaps: []string{"/sitemap.xml"},
+	}))
+	e.GET("/sitemap.xml", seo.SitemapHandler(e, seo.SitemapConfig{
+	  Sources: []seo.URLSource{seo.URLSourceFunc(func(c echo.Context, add func(seo.URL) error) error {
+	    for _, p := range posts {
+	      if err := add(seo.URL{Loc: "/blog/" + p.Slug, LastMod: p.Updated}); err != nil {
+	        return err
+	      }
+	    }
+	    return nil
+	  })},
+	}))
+	e.GET("/about", about).SetMeta(seo.MetaSitemap, true).SetMeta(seo.MetaPriority, 0.8)
+*/
+package seo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Robots is a robots.txt policy.
+	Robots struct {
+		// Groups are the rules of user agents.
+		Groups []RobotsGroup
+
+		// Sitemaps are the URLs of sitemaps. Paths, e.g. "/sitemap.xml", are
+		// resolved against the scheme and host of the request.
+		Sitemaps []string
+
+		// MaxAge is how long clients and caches may cache the file.
+		// Optional. Default value 1 day.
+		MaxAge time.Duration
+	}
+
+	// RobotsGroup is a group of robots.txt rules.
+	RobotsGroup struct {
+		// UserAgents are the user agents the rules apply to, e.g. "*" or
+		// "Googlebot".
+		UserAgents []string
+
+		// Allow lists the allowed path prefixes.
+		Allow []string
+
+		// Disallow lists the disallowed path prefixes, e.g. "/admin/". An
+		// empty string allows all paths.
+		Disallow []string
+
+		// CrawlDelay is the delay between requests. It is ignored by some
+		// crawlers.
+		CrawlDelay time.Duration
+	}
+
+	// SitemapConfig defines the config for sitemap.xml.
+	SitemapConfig struct {
+		// BaseURL is prepended to the paths of the URLs, e.g.
+		// "https://example.com".
+		// Optional. By default the scheme and host of the request are used.
+		BaseURL string
+
+		// Sources add URLs to the sitemap, e.g. for routes with path params.
+		// Optional.
+		Sources []URLSource
+
+		// MaxAge is how long clients and caches may cache the sitemap.
+		// Optional. Default value 1 hour.
+		MaxAge time.Duration
+	}
+
+	// URL is a URL of a sitemap.
+	URL struct {
+		// Loc is the URL or path of the page, e.g. "/blog/hello".
+		Loc string
+
+		// LastMod is when the page last changed.
+		// Optional.
+		LastMod time.Time
+
+		// ChangeFreq is how often the page changes, e.g. "daily".
+		// Optional.
+		ChangeFreq string
+
+		// Priority is the priority of the page relative to the other pages
+		// of the site, between 0 and 1.
+		// Optional.
+		Priority float64
+	}
+
+	// URLSource adds URLs to a sitemap. `add` returns an error when the
+	// sitemap is full.
+	URLSource interface {
+		URLs(c echo.Context, add func(URL) error) error
+	}
+
+	// URLSourceFunc is an adapter to use functions as URL sources.
+	URLSourceFunc func(c echo.Context, add func(URL) error) error
+
+	xmlURLSet struct {
+		XMLName xml.Name `xml:"urlset"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		URLs    []xmlURL `xml:"url"`
+	}
+
+	xmlURL struct {
+		Loc        string `xml:"loc"`
+		LastMod    string `xml:"lastmod,omitempty"`
+		ChangeFreq string `xml:"changefreq,omitempty"`
+		Priority   string `xml:"priority,omitempty"`
+	}
+)
+
+// Route meta keys
+const (
+	// MetaSitemap is the route meta key (bool) adding a GET route without
+	// path params to the sitemap.
+	MetaSitemap = "sitemap"
+
+	// MetaLastMod is the route meta key of when the page last changed
+	// (time.Time).
+	MetaLastMod = "sitemap.lastmod"
+
+	// MetaChangeFreq is the route meta key of how often the page changes
+	// (string), e.g. "weekly".
+	MetaChangeFreq = "sitemap.changefreq"
+
+	// MetaPriority is the route meta key of the priority of the page
+	// (float64).
+	MetaPriority = "sitemap.priority"
+)
+
+// MaxURLs is the maximum number of URLs of a sitemap.
+const MaxURLs = 50000
+
+// ErrSitemapFull is returned by `add` when the sitemap has `MaxURLs` URLs.
+var ErrSitemapFull = fmt.Errorf("seo: sitemap has more than %d URLs", MaxURLs)
+
+// URLs implements `URLSource`.
+func (fn URLSourceFunc) URLs(c echo.Context, add func(URL) error) error {
+	return fn(c, add)
+}
+
+// String returns the robots.txt file.
+func (r *Robots) String() string {
+	return r.text("")
+}
+
+func (r *Robots) text(base string) string {
+	b := new(strings.Builder)
+	for i, g := range r.Groups {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		for _, ua := range g.UserAgents {
+			fmt.Fprintf(b, "User-agent: %s\n", ua)
+		}
+		for _, p := range g.Allow {
+			fmt.Fprintf(b, "Allow: %s\n", p)
+		}
+		for _, p := range g.Disallow {
+			fmt.Fprintf(b, "Disallow: %s\n", p)
+		}
+		if g.CrawlDelay > 0 {
+			fmt.Fprintf(b, "Crawl-delay: %d\n", int(g.CrawlDelay/time.Second))
+		}
+	}
+	if len(r.Sitemaps) > 0 && len(r.Groups) > 0 {
+		b.WriteString("\n")
+	}
+	for _, s := range r.Sitemaps {
+		fmt.Fprintf(b, "Sitemap: %s\n", absoluteURL(base, s))
+	}
+	return b.String()
+}
+
+// RobotsHandler returns a handler serving the robots.txt file of the policy.
+func RobotsHandler(r *Robots) echo.HandlerFunc {
+	maxAge := r.MaxAge
+	if maxAge == 0 {
+		maxAge = 24 * time.Hour
+	}
+	return func(c echo.Context) error {
+		setCacheControl(c, maxAge)
+		return c.Blob(http.StatusOK, echo.MIMETextPlainCharsetUTF8, []byte(r.text(baseURL(c))))
+	}
+}
+
+// SitemapHandler returns a handler serving the sitemap.xml file of the GET
+// routes of `e` with the `MetaSitemap` meta and the URLs of the sources.
+func SitemapHandler(e *echo.Echo, config SitemapConfig) echo.HandlerFunc {
+	// Defaults
+	if config.MaxAge == 0 {
+		config.MaxAge = time.Hour
+	}
+	return func(c echo.Context) error {
+		urls, err := Sitemap(c, e, config)
+		if err != nil {
+			return err
+		}
+		base := config.BaseURL
+		if base == "" {
+			base = baseURL(c)
+		}
+		set := xmlURLSet{XMLNS: "http://www.sitemaps.org/schemas/sitemap/0.9"}
+		for _, u := range urls {
+			x := xmlURL{Loc: absoluteURL(base, u.Loc), ChangeFreq: u.ChangeFreq}
+			if !u.LastMod.IsZero() {
+				x.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+			}
+			if u.Priority > 0 {
+				x.Priority = strconv.FormatFloat(u.Priority, 'f', 1, 64)
+			}
+			set.URLs = append(set.URLs, x)
+		}
+		b := new(bytes.Buffer)
+		b.WriteString(xml.Header)
+		if err := xml.NewEncoder(b).Encode(set); err != nil {
+			return err
+		}
+		setCacheControl(c, config.MaxAge)
+		return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, b.Bytes())
+	}
+}
+
+// Sitemap returns the URLs of the sitemap of `e` with config: the GET routes
+// with the `MetaSitemap` meta, sorted by path, followed by the URLs of the
+// sources. It returns `ErrSitemapFull` for more than `MaxURLs` URLs.
+func Sitemap(c echo.Context, e *echo.Echo, config SitemapConfig) ([]URL, error) {
+	var urls []URL
+	add := func(u URL) error {
+		if len(urls) == MaxURLs {
+			return ErrSitemapFull
+		}
+		urls = append(urls, u)
+		return nil
+	}
+
+	routes := e.Routes()
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Path < routes[j].Path
+	})
+	for _, r := range routes {
+		meta := r.Meta()
+		if r.Method != http.MethodGet || meta[MetaSitemap] != true ||
+			strings.ContainsAny(r.Path, ":*") {
+			continue
+		}
+		u := URL{Loc: r.Path}
+		u.LastMod, _ = meta[MetaLastMod].(time.Time)
+		u.ChangeFreq, _ = meta[MetaChangeFreq].(string)
+		u.Priority, _ = meta[MetaPriority].(float64)
+		if err := add(u); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range config.Sources {
+		if err := s.URLs(c, add); err != nil {
+			return nil, err
+		}
+	}
+	return urls, nil
+}
+
+// baseURL returns the scheme and host of the request.
+func baseURL(c echo.Context) string {
+	return c.Scheme() + "://" + c.Request().Host
+}
+
+// absoluteURL resolves a path against base.
+func absoluteURL(base, loc string) string {
+	if base == "" || !strings.HasPrefix(loc, "/") {
+		return loc
+	}
+	return strings.TrimSuffix(base, "/") + loc
+}
+
+func setCacheControl(c echo.Context, maxAge time.Duration) {
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(maxAge/time.Second)))
+}
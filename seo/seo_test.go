@@ -0,0 +1,86 @@
+package seo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRobotsHandler(t *testing.T) {
+	e := echo.New()
+	e.GET("/robots.txt", RobotsHandler(&Robots{
+		Groups: []RobotsGroup{
+			{UserAgents: []string{"*"}, Disallow: []string{"/admin/"}},
+			{UserAgents: []string{"Bingbot"}, Allow: []string{"/"}, CrawlDelay: 5 * time.Second},
+		},
+		Sitemaps: []string{"/sitemap.xml", "https://cdn.example.com/sitemap.xml"},
+	}))
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/robots.txt", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMETextPlainCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "public, max-age=86400", rec.Header().Get(echo.HeaderCacheControl))
+	assert.Equal(t, `User-agent: *
+Disallow: /admin/
+
+User-agent: Bingbot
+Allow: /
+Crawl-delay: 5
+
+Sitemap: http://example.com/sitemap.xml
+Sitemap: https://cdn.example.com/sitemap.xml
+`, rec.Body.String())
+}
+
+func TestSitemapHandler(t *testing.T) {
+	e := echo.New()
+	h := func(c echo.Context) error { return nil }
+	e.GET("/", h).SetMeta(MetaSitemap, true).SetMeta(MetaPriority, 1.0)
+	e.GET("/about", h).SetMeta(MetaSitemap, true).
+		SetMeta(MetaChangeFreq, "monthly").
+		SetMeta(MetaLastMod, time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC))
+	e.GET("/blog/:slug", h).SetMeta(MetaSitemap, true)
+	e.POST("/contact", h).SetMeta(MetaSitemap, true)
+	e.GET("/admin", h)
+	e.GET("/sitemap.xml", SitemapHandler(e, SitemapConfig{
+		BaseURL: "https://example.com",
+		Sources: []URLSource{URLSourceFunc(func(c echo.Context, add func(URL) error) error {
+			return add(URL{Loc: "/blog/a&b", LastMod: time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)})
+		})},
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, echo.MIMEApplicationXMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+	assert.Equal(t, "public, max-age=3600", rec.Header().Get(echo.HeaderCacheControl))
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`+
+		`<url><loc>https://example.com/</loc><priority>1.0</priority></url>`+
+		`<url><loc>https://example.com/about</loc><lastmod>2020-05-01T00:00:00Z</lastmod><changefreq>monthly</changefreq></url>`+
+		`<url><loc>https://example.com/blog/a&amp;b</loc><lastmod>2020-06-01T12:00:00Z</lastmod></url>`+
+		`</urlset>`, rec.Body.String())
+}
+
+func TestSitemapFull(t *testing.T) {
+	e := echo.New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	_, err := Sitemap(c, e, SitemapConfig{
+		Sources: []URLSource{URLSourceFunc(func(c echo.Context, add func(URL) error) error {
+			for i := 0; ; i++ {
+				if err := add(URL{Loc: "/"}); err != nil {
+					return err
+				}
+			}
+		})},
+	})
+	assert.True(t, errors.Is(err, ErrSitemapFull))
+}
@@ -0,0 +1,250 @@
+//go:build go1.21
+// +build go1.21
+
+package echo
+
+import (
+	stdContext "context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/gommon/log"
+)
+
+type (
+	// SlogLogger is a `Logger` backed by a `log/slog` handler. Levels are mapped
+	// to the slog levels, the fields of the `*j` methods become record attributes
+	// and the prefix, if any, is added as the "prefix" attribute.
+	SlogLogger struct {
+		handler slog.Handler
+		mu      sync.RWMutex
+		prefix  string
+		level   log.Lvl
+		output  io.Writer
+	}
+
+	// slogWriter writes every line as an info record, so that writers obtained
+	// with `Output()` (e.g. the startup messages) flow through the handler.
+	slogWriter struct {
+		l *SlogLogger
+	}
+)
+
+// NewSlogLogger returns a `Logger` writing records to handler `h`.
+func NewSlogLogger(h slog.Handler) *SlogLogger {
+	l := &SlogLogger{handler: h}
+	l.output = slogWriter{l}
+	return l
+}
+
+// UseSlog sets `Echo#Logger` and `Echo#StdLogger` to write records to handler
+// `h`, so that framework logging (startup, recovered panics, server and TLS
+// errors) flows through it. The banner has no structured form and is hidden.
+func (e *Echo) UseSlog(h slog.Handler) {
+	e.Logger = NewSlogLogger(h)
+	e.StdLogger = slog.NewLogLogger(h, slog.LevelError)
+	e.HideBanner = true
+}
+
+// Handler returns the slog handler.
+func (l *SlogLogger) Handler() slog.Handler {
+	return l.handler
+}
+
+// Output returns a writer logging each write as an info record, unless set with
+// `SetOutput()`.
+func (l *SlogLogger) Output() io.Writer {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.output
+}
+
+// SetOutput sets the writer returned by `Output()`. Records are always written
+// to the handler.
+func (l *SlogLogger) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.output = w
+}
+
+func (l *SlogLogger) Prefix() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.prefix
+}
+
+func (l *SlogLogger) SetPrefix(p string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prefix = p
+}
+
+// Level returns the minimum level logged, in addition to the filtering done by
+// the handler.
+func (l *SlogLogger) Level() log.Lvl {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.level
+}
+
+func (l *SlogLogger) SetLevel(v log.Lvl) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.level = v
+}
+
+// SetHeader is a no-op, the record format is defined by the handler.
+func (l *SlogLogger) SetHeader(h string) {}
+
+func (l *SlogLogger) Print(i ...interface{}) {
+	l.log(0, fmt.Sprint(i...), nil)
+}
+
+func (l *SlogLogger) Printf(format string, args ...interface{}) {
+	l.log(0, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *SlogLogger) Printj(j log.JSON) {
+	l.log(0, "", j)
+}
+
+func (l *SlogLogger) Debug(i ...interface{}) {
+	l.log(log.DEBUG, fmt.Sprint(i...), nil)
+}
+
+func (l *SlogLogger) Debugf(format string, args ...interface{}) {
+	l.log(log.DEBUG, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *SlogLogger) Debugj(j log.JSON) {
+	l.log(log.DEBUG, "", j)
+}
+
+func (l *SlogLogger) Info(i ...interface{}) {
+	l.log(log.INFO, fmt.Sprint(i...), nil)
+}
+
+func (l *SlogLogger) Infof(format string, args ...interface{}) {
+	l.log(log.INFO, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *SlogLogger) Infoj(j log.JSON) {
+	l.log(log.INFO, "", j)
+}
+
+func (l *SlogLogger) Warn(i ...interface{}) {
+	l.log(log.WARN, fmt.Sprint(i...), nil)
+}
+
+func (l *SlogLogger) Warnf(format string, args ...interface{}) {
+	l.log(log.WARN, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *SlogLogger) Warnj(j log.JSON) {
+	l.log(log.WARN, "", j)
+}
+
+func (l *SlogLogger) Error(i ...interface{}) {
+	l.log(log.ERROR, fmt.Sprint(i...), nil)
+}
+
+func (l *SlogLogger) Errorf(format string, args ...interface{}) {
+	l.log(log.ERROR, fmt.Sprintf(format, args...), nil)
+}
+
+func (l *SlogLogger) Errorj(j log.JSON) {
+	l.log(log.ERROR, "", j)
+}
+
+func (l *SlogLogger) Fatal(i ...interface{}) {
+	l.log(slogFatalLevel, fmt.Sprint(i...), nil)
+	os.Exit(1)
+}
+
+func (l *SlogLogger) Fatalf(format string, args ...interface{}) {
+	l.log(slogFatalLevel, fmt.Sprintf(format, args...), nil)
+	os.Exit(1)
+}
+
+func (l *SlogLogger) Fatalj(j log.JSON) {
+	l.log(slogFatalLevel, "", j)
+	os.Exit(1)
+}
+
+func (l *SlogLogger) Panic(i ...interface{}) {
+	msg := fmt.Sprint(i...)
+	l.log(slogPanicLevel, msg, nil)
+	panic(msg)
+}
+
+func (l *SlogLogger) Panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	l.log(slogPanicLevel, msg, nil)
+	panic(msg)
+}
+
+func (l *SlogLogger) Panicj(j log.JSON) {
+	l.log(slogPanicLevel, "", j)
+	panic(j)
+}
+
+const (
+	slogPanicLevel = log.OFF + 1 + iota
+	slogFatalLevel
+)
+
+// slogLevel maps a `log.Lvl` to the `slog.Level` records are written with.
+// Level 0 is used by the `Print*` methods.
+func slogLevel(v log.Lvl) slog.Level {
+	switch v {
+	case log.DEBUG:
+		return slog.LevelDebug
+	case 0, log.INFO:
+		return slog.LevelInfo
+	case log.WARN:
+		return slog.LevelWarn
+	}
+	return slog.LevelError
+}
+
+func (l *SlogLogger) log(v log.Lvl, msg string, j log.JSON) {
+	if v != 0 && v < l.Level() {
+		return
+	}
+	level := slogLevel(v)
+	ctx := stdContext.Background()
+	if !l.handler.Enabled(ctx, level) {
+		return
+	}
+	if m, ok := j["message"].(string); ok && msg == "" {
+		msg = m
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:]) // Skip Callers, log and the logging method
+	r := slog.NewRecord(time.Now(), level, msg, pcs[0])
+	if p := l.Prefix(); p != "" {
+		r.AddAttrs(slog.String("prefix", p))
+	}
+	for k, v := range j {
+		if k == "message" && msg == v {
+			continue
+		}
+		r.AddAttrs(slog.Any(k, v))
+	}
+	l.handler.Handle(ctx, r)
+}
+
+func (w slogWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			w.l.log(0, line, nil)
+		}
+	}
+	return len(p), nil
+}
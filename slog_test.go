@@ -0,0 +1,108 @@
+//go:build go1.21
+// +build go1.21
+
+package echo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/gommon/log"
+	testify "github.com/stretchr/testify/assert"
+)
+
+func slogEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := map[string]interface{}{}
+		testify.NoError(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	buf.Reset()
+	return entries
+}
+
+func TestSlogLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	l := NewSlogLogger(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug, AddSource: true}))
+
+	l.Debugf("debug %d", 1)
+	l.Warn("warn")
+	l.Errorj(log.JSON{"message": "failed", "user": "jon"})
+	entries := slogEntries(t, buf)
+	if testify.Len(t, entries, 3) {
+		testify.Equal(t, "DEBUG", entries[0]["level"])
+		testify.Equal(t, "debug 1", entries[0]["msg"])
+		testify.Equal(t, "WARN", entries[1]["level"])
+		testify.Equal(t, "ERROR", entries[2]["level"])
+		testify.Equal(t, "failed", entries[2]["msg"])
+		testify.Equal(t, "jon", entries[2]["user"])
+		testify.NotContains(t, entries[2], "message")
+		source := entries[0]["source"].(map[string]interface{})
+		testify.True(t, strings.HasSuffix(source["file"].(string), "slog_test.go"))
+	}
+
+	// Level
+	l.SetLevel(log.WARN)
+	testify.Equal(t, log.WARN, l.Level())
+	l.Info("info")
+	l.Print("print")
+	entries = slogEntries(t, buf)
+	if testify.Len(t, entries, 1) {
+		testify.Equal(t, "print", entries[0]["msg"])
+	}
+
+	// Prefix
+	l.SetPrefix("api")
+	testify.Equal(t, "api", l.Prefix())
+	l.Error("error")
+	testify.Equal(t, "api", slogEntries(t, buf)[0]["prefix"])
+	l.SetPrefix("")
+
+	// Output
+	fmt.Fprintf(l.Output(), "started\n\n")
+	entries = slogEntries(t, buf)
+	if testify.Len(t, entries, 1) {
+		testify.Equal(t, "INFO", entries[0]["level"])
+		testify.Equal(t, "started", entries[0]["msg"])
+	}
+
+	testify.Panics(t, func() { l.Panic("panic") })
+	testify.Equal(t, "panic", slogEntries(t, buf)[0]["msg"])
+}
+
+func TestEchoUseSlog(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := New()
+	e.UseSlog(slog.NewJSONHandler(buf, nil))
+	testify.True(t, e.HideBanner)
+
+	e.StdLogger.Print("tls: handshake error")
+	entries := slogEntries(t, buf)
+	if testify.Len(t, entries, 1) {
+		testify.Equal(t, "ERROR", entries[0]["level"])
+		testify.Equal(t, "tls: handshake error", entries[0]["msg"])
+	}
+
+	e.GET("/users/:id", func(c Context) error {
+		c.LogFields("user", "jon")
+		c.Logger().Warn("slow")
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	entries = slogEntries(t, buf)
+	if testify.Len(t, entries, 1) {
+		testify.Equal(t, "slow", entries[0]["msg"])
+		testify.Equal(t, "/users/:id", entries[0]["route"])
+		testify.Equal(t, "jon", entries[0]["user"])
+	}
+}
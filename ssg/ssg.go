@@ -0,0 +1,190 @@
+/*
+Package ssg pre-renders the GET routes of an Echo instance to static files, e.g.
+to publish docs or marketing pages at build time. Handlers run in-process, with
+the middleware of the instance, and their responses are written to an output
+directory.
+
+Routes with path params are rendered once per value set of `Config.Params`, and
+skipped without value sets.
+
+Example:
+
+	e := echo.New()
+	e.GET("/", home)
+	e.GET("/docs/:page", docs)
+	pages, err := ssg.Generate(e, ssg.Config{
+	  Output: "public",
+	  Params: map[string][]map[string]string{
+	    "/docs/:page": {{"page": "intro"}, {"page": "install"}},
+	  },
+	})
+*/
+package ssg
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+type (
+	// Config defines the config for a generation.
+	Config struct {
+		// Output is the directory the pages are written to.
+		// Required.
+		Output string
+
+		// Params maps route paths, e.g. "/docs/:page", to the param value sets
+		// the route is rendered with. The value of a wildcard is set for "*".
+		// Optional.
+		Params map[string][]map[string]string
+
+		// Paths lists extra request paths to render, e.g. "/docs/a/b" for a
+		// wildcard route.
+		// Optional.
+		Paths []string
+
+		// Skipper defines a function to leave a route out.
+		// Optional. By default routes registered with `echo.NotFoundHandler`
+		// are left out.
+		Skipper func(*echo.Route) bool
+
+		// Request is called to prepare the requests, e.g. to set the host.
+		// Optional.
+		Request func(req *http.Request)
+	}
+
+	// Page is a rendered page.
+	Page struct {
+		// Path is the request path.
+		Path string
+
+		// File is the path of the written file, relative to the output directory.
+		File string
+
+		// Route is the route the page was rendered from, or nil for extra paths.
+		Route *echo.Route
+	}
+)
+
+// Generate renders the GET routes of `e` and the extra paths with config, and
+// returns the written pages. Responses other than "200 - OK" are errors.
+//
+// Paths ending in "/" or with a last segment without extension are written to
+// an "index.html" file, e.g. "/docs/intro" to "docs/intro/index.html", other
+// paths as is, e.g. "/feed.xml" to "feed.xml".
+func Generate(e *echo.Echo, config Config) ([]*Page, error) {
+	if config.Output == "" {
+		panic("echo: ssg requires output")
+	}
+	// Defaults
+	if config.Skipper == nil {
+		notFound := runtime.FuncForPC(reflect.ValueOf(echo.NotFoundHandler).Pointer()).Name()
+		config.Skipper = func(r *echo.Route) bool {
+			return r.Name == notFound
+		}
+	}
+
+	routes := e.Routes()
+	sort.Slice(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+	pages := []*Page{}
+	for _, r := range routes {
+		if r.Method != http.MethodGet || config.Skipper(r) {
+			continue
+		}
+		if !hasParams(r.Path) {
+			pages = append(pages, &Page{Path: r.Path, Route: r})
+			continue
+		}
+		for _, params := range config.Params[r.Path] {
+			p, err := expand(r.Path, params)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, &Page{Path: p, Route: r})
+		}
+	}
+	for _, p := range config.Paths {
+		pages = append(pages, &Page{Path: p})
+	}
+
+	for _, p := range pages {
+		if err := render(e, config, p); err != nil {
+			return nil, err
+		}
+	}
+	return pages, nil
+}
+
+// render serves the request of the page and writes the response.
+func render(e *echo.Echo, config Config, p *Page) error {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.URL.Path = p.Path
+	req.RequestURI = req.URL.RequestURI()
+	if config.Request != nil {
+		config.Request(req)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		return fmt.Errorf("ssg: GET %s: unexpected status %d", p.Path, rec.Code)
+	}
+
+	p.File = fileName(p.Path)
+	name := filepath.Join(config.Output, filepath.FromSlash(p.File))
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(name, rec.Body.Bytes(), 0644)
+}
+
+// fileName returns the file name of the request path.
+func fileName(p string) string {
+	if strings.HasSuffix(p, "/") || path.Ext(p) == "" {
+		return path.Join(strings.TrimPrefix(path.Clean("/"+p), "/"), "index.html")
+	}
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+func hasParams(p string) bool {
+	return strings.ContainsAny(p, ":*")
+}
+
+// expand replaces the params of the route path with their values.
+func expand(routePath string, params map[string]string) (string, error) {
+	b := new(strings.Builder)
+	for i, l := 0, len(routePath); i < l; i++ {
+		switch routePath[i] {
+		case ':':
+			j := i + 1
+			for ; j < l && routePath[j] != '/'; j++ {
+			}
+			name := routePath[i+1 : j]
+			v, ok := params[name]
+			if !ok {
+				return "", fmt.Errorf("ssg: %s: missing value of param %s", routePath, name)
+			}
+			b.WriteString(v)
+			i = j - 1
+		case '*':
+			v, ok := params["*"]
+			if !ok {
+				return "", fmt.Errorf("ssg: %s: missing value of param *", routePath)
+			}
+			b.WriteString(v)
+		default:
+			b.WriteByte(routePath[i])
+		}
+	}
+	return b.String(), nil
+}
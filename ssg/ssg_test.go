@@ -0,0 +1,93 @@
+package ssg
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ssg")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Response().Header().Set("X-Generated", "true")
+			return next(c)
+		}
+	})
+	e.GET("/", func(c echo.Context) error {
+		return c.HTML(http.StatusOK, "home "+c.Request().Host)
+	})
+	e.GET("/docs/:page", func(c echo.Context) error {
+		return c.HTML(http.StatusOK, "docs "+c.Param("page"))
+	})
+	e.GET("/files/*", func(c echo.Context) error {
+		return c.String(http.StatusOK, "file "+c.Param("*"))
+	})
+	e.GET("/feed.xml", func(c echo.Context) error {
+		return c.XMLBlob(http.StatusOK, []byte("<feed/>"))
+	})
+	e.POST("/contact", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/blog/:id", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	pages, err := Generate(e, Config{
+		Output: dir,
+		Params: map[string][]map[string]string{
+			"/docs/:page": {{"page": "intro"}, {"page": "install"}},
+		},
+		Paths: []string{"/files/a/b.txt"},
+		Request: func(req *http.Request) {
+			req.Host = "example.com"
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	files := map[string]string{}
+	for _, p := range pages {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(p.File)))
+		assert.NoError(t, err)
+		files[p.File] = string(b)
+	}
+	assert.Equal(t, map[string]string{
+		"index.html":              "home example.com",
+		"docs/intro/index.html":   "docs intro",
+		"docs/install/index.html": "docs install",
+		"feed.xml":                xml.Header + "<feed/>",
+		"files/a/b.txt":           "file a/b.txt",
+	}, files)
+
+	// Missing param value
+	_, err = Generate(e, Config{
+		Output: dir,
+		Params: map[string][]map[string]string{"/docs/:page": {{"id": "intro"}}},
+	})
+	assert.EqualError(t, err, "ssg: /docs/:page: missing value of param page")
+
+	// Unexpected status
+	_, err = Generate(e, Config{Output: dir, Paths: []string{"/missing"}})
+	assert.EqualError(t, err, "ssg: GET /missing: unexpected status 404")
+}
+
+func TestFileName(t *testing.T) {
+	assert.Equal(t, "index.html", fileName("/"))
+	assert.Equal(t, "docs/index.html", fileName("/docs/"))
+	assert.Equal(t, "docs/intro/index.html", fileName("/docs/intro"))
+	assert.Equal(t, "robots.txt", fileName("/robots.txt"))
+	assert.Equal(t, "etc/hosts.txt", fileName("/../etc/hosts.txt"))
+}
@@ -0,0 +1,94 @@
+//go:build go1.18
+// +build go1.18
+
+package echo
+
+import (
+	"fmt"
+)
+
+// Key is a typed, namespaced key of the context store. Namespacing the keys of
+// middleware avoids collisions with keys set by other middleware and handlers.
+//
+// Example:
+//
+//	var userKey = echo.NewKey[*User]("auth", "user")
+//
+//	userKey.Set(c, u)
+//	u, ok := userKey.Get(c)
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a key stored as "<namespace>:<name>".
+func NewKey[T any](namespace, name string) Key[T] {
+	return Key[T]{name: namespace + ":" + name}
+}
+
+// String returns the name the value is stored under with `Context#Set()`.
+func (k Key[T]) String() string {
+	return k.name
+}
+
+// Get returns the value stored under the key, and false if there is no value
+// of type T.
+func (k Key[T]) Get(c Context) (T, bool) {
+	return Get[T](c, k.name)
+}
+
+// MustGet returns the value stored under the key. It panics if there is no
+// value of type T.
+func (k Key[T]) MustGet(c Context) T {
+	return MustGet[T](c, k.name)
+}
+
+// Set stores the value under the key.
+func (k Key[T]) Set(c Context, v T) {
+	c.Set(k.name, v)
+}
+
+// Get returns the value stored in the context under `key` (see
+// `Context#Get()`), and false if there is no value of type T.
+func Get[T any](c Context, key string) (T, bool) {
+	v, ok := c.Get(key).(T)
+	return v, ok
+}
+
+// MustGet returns the value stored in the context under `key`. It panics if
+// there is no value of type T, e.g. when a middleware setting it isn't
+// registered.
+func MustGet[T any](c Context, key string) T {
+	raw := c.Get(key)
+	v, ok := raw.(T)
+	if !ok {
+		var zero T
+		if raw == nil {
+			panic(fmt.Sprintf("echo: no value for context key %q of type %T", key, zero))
+		}
+		panic(fmt.Sprintf("echo: context key %q holds %T, not %T", key, raw, zero))
+	}
+	return v
+}
+
+// Memoize is like `Context#Memoize()` for values of type T. It panics if the
+// value memoized for the key isn't of type T.
+//
+// Example:
+//
+//	user, err := echo.Memoize(c, "user", func() (*User, error) {
+//	  return users.Find(c.Principal().ID)
+//	})
+func Memoize[T any](c Context, key string, fn func() (T, error)) (T, error) {
+	v, err := c.Memoize(key, func() (interface{}, error) {
+		return fn()
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	t, ok := v.(T)
+	if !ok && v != nil {
+		panic(fmt.Sprintf("echo: memoized key %q holds %T, not %T", key, v, t))
+	}
+	return t, nil
+}
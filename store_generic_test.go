@@ -0,0 +1,86 @@
+//go:build go1.18
+// +build go1.18
+
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	e := New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Set("user", &user{1, "Jon Snow"})
+	c.Set("count", 2)
+
+	u, ok := Get[*user](c, "user")
+	if assert.True(t, ok) {
+		assert.Equal(t, "Jon Snow", u.Name)
+	}
+	_, ok = Get[string](c, "count")
+	assert.False(t, ok)
+	_, ok = Get[int](c, "missing")
+	assert.False(t, ok)
+
+	assert.Equal(t, 2, MustGet[int](c, "count"))
+	assert.PanicsWithValue(t, `echo: context key "count" holds int, not string`, func() {
+		MustGet[string](c, "count")
+	})
+	assert.PanicsWithValue(t, `echo: no value for context key "missing" of type *echo.user`, func() {
+		MustGet[*user](c, "missing")
+	})
+}
+
+func TestKey(t *testing.T) {
+	e := New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	authUser := NewKey[*user]("auth", "user")
+	adminUser := NewKey[*user]("admin", "user")
+
+	assert.Equal(t, "auth:user", authUser.String())
+	authUser.Set(c, &user{1, "Jon Snow"})
+	adminUser.Set(c, &user{2, "Arya"})
+	u, ok := authUser.Get(c)
+	if assert.True(t, ok) {
+		assert.Equal(t, 1, u.ID)
+	}
+	assert.Equal(t, 2, adminUser.MustGet(c).ID)
+	assert.Equal(t, u, c.Get("auth:user"))
+
+	// Concurrent access
+	count := NewKey[int]("test", "count")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			count.Set(c, i)
+			count.Get(c)
+		}(i)
+	}
+	wg.Wait()
+	_, ok = count.Get(c)
+	assert.True(t, ok)
+}
+
+func TestMemoize(t *testing.T) {
+	e := New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	find := func() (*user, error) {
+		return &user{1, "Jon Snow"}, nil
+	}
+
+	u, err := Memoize(c, "user", find)
+	if assert.NoError(t, err) {
+		v, _ := Memoize(c, "user", find)
+		assert.Same(t, u, v)
+	}
+	assert.Panics(t, func() {
+		Memoize(c, "user", func() (int, error) { return 1, nil })
+	})
+}
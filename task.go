@@ -0,0 +1,329 @@
+package echo
+
+import (
+	stdContext "context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// TaskFunc defines a function run by a background task. The context is
+	// canceled when the server shuts down.
+	TaskFunc func(ctx stdContext.Context) error
+
+	// task is a background task run on a schedule while the server is running.
+	task struct {
+		next func(time.Time) time.Time
+		fn   TaskFunc
+	}
+
+	// scheduler runs the tasks of an Echo instance.
+	scheduler struct {
+		mu         sync.Mutex
+		tasks      []*task
+		ctx        stdContext.Context
+		cancelFunc stdContext.CancelFunc
+		wg         sync.WaitGroup
+		stopped    bool
+	}
+
+	// jobPool runs the functions deferred with `Context#DeferAfterResponse()`.
+	jobPool struct {
+		mu         sync.Mutex
+		sem        chan struct{}
+		ctx        stdContext.Context
+		cancelFunc stdContext.CancelFunc
+		wg         sync.WaitGroup
+	}
+
+	// cronSchedule is a parsed cron expression, each field a bit set.
+	cronSchedule struct {
+		minute, hour, dom, month, dow uint64
+		domStar, dowStar              bool
+		loc                           *time.Location
+	}
+)
+
+const defaultDeferredWorkers = 64
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// AddTask adds a background task running `fn` every `interval` while the server
+// is running. Tasks start with the server and their context is canceled on
+// `Close()` and `Shutdown()`, the latter waiting for running tasks to return.
+// Errors and panics are logged.
+func (e *Echo) AddTask(interval time.Duration, fn TaskFunc) {
+	if interval <= 0 {
+		panic("echo: task interval must be positive")
+	}
+	e.scheduler.add(e, &task{
+		next: func(t time.Time) time.Time { return t.Add(interval) },
+		fn:   fn,
+	})
+}
+
+// AddCronTask adds a background task running `fn` on the schedule given by a
+// cron expression in local time, see `AddTask()`. The expression has five
+// fields: minute, hour, day of month, month and day of week, each one `*`, a
+// value, a range `a-b` or a list `a,b`, optionally with a step `/n`. The
+// descriptors `@yearly`, `@monthly`, `@weekly`, `@daily` and `@hourly` are
+// supported as well.
+//
+// Example: "*/15 9-17 * * 1-5" runs every 15 minutes during office hours.
+func (e *Echo) AddCronTask(spec string, fn TaskFunc) error {
+	s, err := parseCron(spec, time.Local)
+	if err != nil {
+		return err
+	}
+	if s.next(time.Now()).IsZero() {
+		return fmt.Errorf("echo: cron expression %q never matches", spec)
+	}
+	e.scheduler.add(e, &task{next: s.next, fn: fn})
+	return nil
+}
+
+func (s *scheduler) add(e *Echo, t *task) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = append(s.tasks, t)
+	if s.ctx != nil && !s.stopped {
+		s.wg.Add(1)
+		go s.run(e, t)
+	}
+}
+
+// start starts the tasks, unless already started.
+func (s *scheduler) start(e *Echo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx != nil && !s.stopped {
+		return
+	}
+	s.ctx, s.cancelFunc = stdContext.WithCancel(stdContext.Background())
+	s.stopped = false
+	for _, t := range s.tasks {
+		s.wg.Add(1)
+		go s.run(e, t)
+	}
+}
+
+// cancel cancels the context of the tasks.
+func (s *scheduler) cancel() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctx != nil && !s.stopped {
+		s.stopped = true
+		s.cancelFunc()
+	}
+}
+
+// wait waits for the tasks to return, or for `ctx` to be done.
+func (s *scheduler) wait(ctx stdContext.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *scheduler) run(e *Echo, t *task) {
+	defer s.wg.Done()
+	ctx := s.ctx
+	for {
+		now := time.Now()
+		next := t.next(now)
+		if next.IsZero() {
+			return
+		}
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := runTask(ctx, t.fn); err != nil && ctx.Err() == nil {
+			e.Logger.Error(err)
+		}
+	}
+}
+
+func runTask(ctx stdContext.Context, fn TaskFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("echo: task panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// submit runs the functions on the pool, blocking while all workers are busy.
+func (p *jobPool) submit(e *Echo, fns []TaskFunc) {
+	p.mu.Lock()
+	if p.sem == nil {
+		workers := e.DeferredWorkers
+		if workers <= 0 {
+			workers = defaultDeferredWorkers
+		}
+		p.sem = make(chan struct{}, workers)
+		p.ctx, p.cancelFunc = stdContext.WithCancel(stdContext.Background())
+	}
+	sem, ctx := p.sem, p.ctx
+	p.wg.Add(len(fns))
+	p.mu.Unlock()
+
+	for _, fn := range fns {
+		sem <- struct{}{}
+		go func(fn TaskFunc) {
+			defer func() {
+				<-sem
+				p.wg.Done()
+			}()
+			if err := runTask(ctx, fn); err != nil {
+				e.Logger.Error(err)
+			}
+		}(fn)
+	}
+}
+
+// cancel cancels the context of the running functions.
+func (p *jobPool) cancel() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancelFunc != nil {
+		p.cancelFunc()
+		p.sem = nil // Start over with a new context
+	}
+}
+
+// wait waits for the running functions to return, or for `ctx` to be done, in
+// which case their context is canceled.
+func (p *jobPool) wait(ctx stdContext.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
+
+func parseCron(spec string, loc *time.Location) (*cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := cronDescriptors[spec]; ok {
+		spec = d
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("echo: cron expression %q must have 5 fields", spec)
+	}
+	s := &cronSchedule{loc: loc}
+	var err error
+	bounds := [5][2]uint{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	sets := [5]*uint64{&s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	for i, f := range fields {
+		if *sets[i], err = parseCronField(f, bounds[i][0], bounds[i][1]); err != nil {
+			return nil, fmt.Errorf("echo: cron expression %q: %v", spec, err)
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1 // 7 is Sunday as well
+	}
+	s.domStar = fields[2] == "*"
+	s.dowStar = fields[4] == "*"
+	return s, nil
+}
+
+func parseCronField(field string, min, max uint) (set uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := uint(1)
+		if i := strings.IndexByte(part, '/'); i != -1 {
+			n, err := strconv.ParseUint(part[i+1:], 10, 8)
+			if err != nil || n == 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			step, part = uint(n), part[:i]
+		}
+		lo, hi := min, max
+		if part != "*" {
+			r := strings.SplitN(part, "-", 2)
+			n, err := strconv.ParseUint(r[0], 10, 8)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = uint(n), uint(n)
+			if len(r) == 2 {
+				if n, err = strconv.ParseUint(r[1], 10, 8); err != nil {
+					return 0, fmt.Errorf("invalid value %q", part)
+				}
+				hi = uint(n)
+			} else if step > 1 {
+				hi = max // "a/n" runs from a to the end of the range
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, errors.New("value out of range in " + strconv.Quote(part))
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return
+}
+
+// next returns the first time after t matching the schedule, or the zero time
+// if there is none within a few years.
+func (s *cronSchedule) next(t time.Time) time.Time {
+	t = t.In(s.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		y, m, d := t.Date()
+		switch {
+		case s.month&(1<<uint(m)) == 0:
+			t = time.Date(y, m+1, 1, 0, 0, 0, 0, s.loc)
+		case !s.dayMatches(t):
+			t = time.Date(y, m, d+1, 0, 0, 0, 0, s.loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(y, m, d, t.Hour()+1, 0, 0, 0, s.loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches reports whether the day of t matches. As in cron, if both the day
+// of month and the day of week are restricted, either one has to match.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
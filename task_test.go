@@ -0,0 +1,167 @@
+package echo
+
+import (
+	stdContext "context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/http2"
+)
+
+func TestEchoAddTask(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	e.Logger.SetOutput(ioutil.Discard)
+	var runs int32
+	canceled := make(chan struct{})
+	e.AddTask(10*time.Millisecond, func(ctx stdContext.Context) error {
+		if atomic.AddInt32(&runs, 1) == 1 {
+			<-ctx.Done() // Long running, canceled on shutdown
+			close(canceled)
+		}
+		return nil
+	})
+	e.AddTask(10*time.Millisecond, func(ctx stdContext.Context) error {
+		panic("task") // Logged, the server keeps running
+	})
+	assert.Panics(t, func() {
+		e.AddTask(0, func(stdContext.Context) error { return nil })
+	})
+
+	// Not started before the server
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&runs))
+
+	errCh := make(chan error)
+	go func() {
+		errCh <- e.Start(":0")
+	}()
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, e.Shutdown(ctx))
+	<-canceled
+	assert.EqualError(t, <-errCh, "http: Server closed")
+}
+
+func TestEchoAddTaskListenerError(t *testing.T) {
+	e := New()
+	e.HideBanner = true
+	var runs int32
+	e.AddTask(time.Millisecond, func(ctx stdContext.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	// Not started when the server can't listen
+	assert.Error(t, e.Start("invalid address"))
+	assert.Error(t, e.StartH2CServer("invalid address", &http2.Server{}))
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&runs))
+}
+
+func TestEchoAddCronTask(t *testing.T) {
+	e := New()
+	fn := func(stdContext.Context) error { return errors.New("task") }
+	assert.NoError(t, e.AddCronTask("*/5 * * * *", fn))
+	assert.NoError(t, e.AddCronTask("@daily", fn))
+	assert.Error(t, e.AddCronTask("* * * *", fn))
+	assert.Error(t, e.AddCronTask("60 * * * *", fn))
+	assert.Error(t, e.AddCronTask("*/0 * * * *", fn))
+	assert.Error(t, e.AddCronTask("0 0 30 2 *", fn))
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	from := time.Date(2020, time.June, 15, 10, 7, 30, 0, time.UTC) // Monday
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2020, time.June, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2020, time.June, 15, 10, 15, 0, 0, time.UTC)},
+		{"5 * * * *", time.Date(2020, time.June, 15, 11, 5, 0, 0, time.UTC)},
+		{"0 9-17 * * 1-5", time.Date(2020, time.June, 15, 11, 0, 0, 0, time.UTC)},
+		{"30 8 * * 0", time.Date(2020, time.June, 21, 8, 30, 0, 0, time.UTC)},
+		{"30 8 * * 7", time.Date(2020, time.June, 21, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,20 * 3", time.Date(2020, time.June, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2020, time.June, 15, 11, 0, 0, 0, time.UTC)},
+		{"10/20 * * * *", time.Date(2020, time.June, 15, 10, 10, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := parseCron(tt.spec, time.UTC)
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.expected, s.next(from))
+			}
+		})
+	}
+}
+
+func TestContextDeferAfterResponse(t *testing.T) {
+	e := New()
+	e.Logger.SetOutput(ioutil.Discard)
+	var (
+		committed int32
+		runs      int32
+	)
+	release := make(chan struct{})
+	e.GET("/", func(c Context) error {
+		c.DeferAfterResponse(func(ctx stdContext.Context) error {
+			<-release
+			atomic.StoreInt32(&committed, 1)
+			atomic.AddInt32(&runs, 1)
+			return nil
+		})
+		c.DeferAfterResponse(func(ctx stdContext.Context) error {
+			atomic.AddInt32(&runs, 1)
+			return errors.New("logged")
+		})
+		return c.String(http.StatusOK, "OK")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "OK", rec.Body.String())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&committed))
+
+	// Shutdown waits for the deferred functions
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, e.Shutdown(ctx))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+}
+
+func TestContextDeferAfterResponseShutdownTimeout(t *testing.T) {
+	e := New()
+	e.DeferredWorkers = 1
+	canceled := make(chan struct{})
+	e.GET("/", func(c Context) error {
+		c.DeferAfterResponse(func(ctx stdContext.Context) error {
+			<-ctx.Done()
+			close(canceled)
+			return nil
+		})
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, stdContext.DeadlineExceeded, e.Shutdown(ctx))
+	<-canceled
+}
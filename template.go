@@ -0,0 +1,68 @@
+package echo
+
+// RenderDecoratorFunc decorates the data of the template `name` before it is
+// rendered, see `Echo#RenderDecorator()`.
+type RenderDecoratorFunc func(c Context, name string, data interface{}) interface{}
+
+// RenderDecorator adds decorators run, in order, on the data of every
+// `Context#Render()` call, to merge data shared by all views, e.g. navigation
+// menus and feature flags, instead of every handler building it.
+//
+// Example:
+//
+//	e.RenderDecorator(func(c echo.Context, name string, data interface{}) interface{} {
+//	  if m, ok := data.(echo.Map); ok {
+//	    m["Nav"] = nav
+//	  }
+//	  return data
+//	})
+func (e *Echo) RenderDecorator(decorators ...RenderDecoratorFunc) {
+	e.renderDecorators = append(e.renderDecorators, decorators...)
+}
+
+// TemplateContext returns the built-in template variables of the request of
+// the context, which `Renderer` implementations get as the last argument of
+// `Render()`:
+//
+// - "Context" is the context
+//
+// - "CSRF" is the token of `middleware.CSRF()`, stored under "csrf"
+//
+// - "Principal" is the principal of the request, see `Context#Principal()`
+//
+// - "Locale" is the locale of `middleware.Locale()`, stored under "locale"
+func TemplateContext(c Context) Map {
+	csrf, _ := c.Get("csrf").(string)
+	locale, _ := c.Get("locale").(string)
+	return Map{
+		"Context":   c,
+		"CSRF":      csrf,
+		"Principal": c.Principal(),
+		"Locale":    locale,
+	}
+}
+
+// WithTemplateContext returns the template data merged with the built-in
+// variables of `TemplateContext()`, for renderers exposing them to templates,
+// e.g. `{{.CSRF}}`. Only nil and map data are merged, the keys of the data
+// taking precedence; other data is returned as is.
+func WithTemplateContext(c Context, data interface{}) interface{} {
+	if c == nil {
+		return data
+	}
+	var m map[string]interface{}
+	switch d := data.(type) {
+	case nil:
+	case Map:
+		m = d
+	case map[string]interface{}:
+		m = d
+	default:
+		return data
+	}
+	merged := TemplateContext(c)
+	for k, v := range m {
+		merged[k] = v
+	}
+	return merged
+}
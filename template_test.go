@@ -0,0 +1,51 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithTemplateContext(t *testing.T) {
+	e := New()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Set("csrf", "token")
+	c.Set("locale", "de")
+	c.SetPrincipal(&Principal{ID: "jon"})
+
+	assert.Equal(t, Map{"Context": c, "CSRF": "token", "Principal": &Principal{ID: "jon"}, "Locale": "de"}, WithTemplateContext(c, nil))
+	data := Map{"Title": "Home", "Locale": "en"}
+	merged := WithTemplateContext(c, data).(Map)
+	assert.Equal(t, "Home", merged["Title"])
+	assert.Equal(t, "en", merged["Locale"])
+	assert.Equal(t, "token", merged["CSRF"])
+	assert.Len(t, data, 2)
+
+	type page struct{ Title string }
+	assert.Equal(t, page{"Home"}, WithTemplateContext(c, page{"Home"}))
+	assert.Equal(t, "x", WithTemplateContext(nil, "x"))
+}
+
+func TestEchoRenderDecorator(t *testing.T) {
+	e := New()
+	e.Renderer = &Template{
+		templates: template.Must(template.New("page").Parse("{{.Nav}} {{.Title}} {{.Flag}}")),
+	}
+	e.RenderDecorator(func(c Context, name string, data interface{}) interface{} {
+		m := data.(Map)
+		m["Nav"] = "home|about"
+		m["Flag"] = name
+		return m
+	}, func(c Context, name string, data interface{}) interface{} {
+		data.(Map)["Flag"] = data.(Map)["Flag"].(string) + "!"
+		return data
+	})
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodGet, "/", nil), rec)
+	if assert.NoError(t, c.Render(http.StatusOK, "page", Map{"Title": "Home"})) {
+		assert.Equal(t, "home|about Home page!", rec.Body.String())
+	}
+}
@@ -0,0 +1,758 @@
+package echo
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type (
+	// tomlParser parses a TOML document into a tree of `map[string]interface{}`
+	// tables. Values are strings, int64, float64, bool, time.Time,
+	// []interface{} arrays and []map[string]interface{} arrays of tables.
+	tomlParser struct {
+		src     string
+		pos     int
+		root    map[string]interface{}
+		current map[string]interface{}
+		defined map[string]bool
+	}
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// unmarshalTOML decodes the TOML document `data` into the value pointed to by
+// `i`. Struct fields are matched against the `toml` tag or, without it, the
+// field name (case insensitive).
+func unmarshalTOML(data []byte, i interface{}) error {
+	rv := reflect.ValueOf(i)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("toml: decode target must be a non-nil pointer, got %T", i)
+	}
+	p := &tomlParser{src: string(data), root: map[string]interface{}{}, defined: map[string]bool{}}
+	p.current = p.root
+	if err := p.parse(); err != nil {
+		return err
+	}
+	return decodeTOML("", p.root, rv)
+}
+
+func (p *tomlParser) errorf(format string, args ...interface{}) error {
+	line := strings.Count(p.src[:p.pos], "\n") + 1
+	return fmt.Errorf("toml: line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) hasPrefix(s string) bool {
+	return strings.HasPrefix(p.src[p.pos:], s)
+}
+
+// skipSpace skips spaces and tabs.
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipBlank skips whitespace, newlines and comments.
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\r', '\n':
+			p.pos++
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	for !p.eof() && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+}
+
+// endLine expects the rest of the line to be blank or a comment.
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	if p.peek() == '#' {
+		p.skipComment()
+	}
+	if p.hasPrefix("\r\n") {
+		p.pos += 2
+		return nil
+	}
+	if p.eof() || p.peek() == '\n' {
+		p.pos++
+		return nil
+	}
+	return p.errorf("unexpected %q after value", p.peek())
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil
+		}
+		var err error
+		if p.peek() == '[' {
+			err = p.parseTable()
+		} else {
+			err = p.parseKeyValue(p.current)
+		}
+		if err != nil {
+			return err
+		}
+		if err = p.endLine(); err != nil {
+			return err
+		}
+	}
+}
+
+func (p *tomlParser) parseTable() error {
+	array := p.hasPrefix("[[")
+	if array {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	p.skipSpace()
+	key, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if array {
+		if !p.hasPrefix("]]") {
+			return p.errorf("expected ']]' after array of tables %q", strings.Join(key, "."))
+		}
+		p.pos += 2
+	} else {
+		if p.peek() != ']' {
+			return p.errorf("expected ']' after table %q", strings.Join(key, "."))
+		}
+		p.pos++
+	}
+
+	parent, err := p.descend(p.root, key[:len(key)-1])
+	if err != nil {
+		return err
+	}
+	name := key[len(key)-1]
+	if array {
+		t := map[string]interface{}{}
+		switch v := parent[name].(type) {
+		case nil:
+			parent[name] = []map[string]interface{}{t}
+		case []map[string]interface{}:
+			parent[name] = append(v, t)
+		default:
+			return p.errorf("key %q is already defined", strings.Join(key, "."))
+		}
+		p.current = t
+		return nil
+	}
+	path := strings.Join(key, "\x00")
+	if p.defined[path] {
+		return p.errorf("table %q is already defined", strings.Join(key, "."))
+	}
+	p.defined[path] = true
+	t, err := p.descend(parent, key[len(key)-1:])
+	if err != nil {
+		return err
+	}
+	p.current = t
+	return nil
+}
+
+// descend returns the table at `key` relative to `t`, creating missing tables.
+// The last table of an array of tables is used.
+func (p *tomlParser) descend(t map[string]interface{}, key []string) (map[string]interface{}, error) {
+	for _, k := range key {
+		switch v := t[k].(type) {
+		case nil:
+			sub := map[string]interface{}{}
+			t[k] = sub
+			t = sub
+		case map[string]interface{}:
+			t = v
+		case []map[string]interface{}:
+			t = v[len(v)-1]
+		default:
+			return nil, p.errorf("key %q is not a table", k)
+		}
+	}
+	return t, nil
+}
+
+func (p *tomlParser) parseKeyValue(t map[string]interface{}) error {
+	key, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	p.skipSpace()
+	if p.peek() != '=' {
+		return p.errorf("expected '=' after key %q", strings.Join(key, "."))
+	}
+	p.pos++
+	p.skipSpace()
+	v, err := p.parseValue()
+	if err != nil {
+		return err
+	}
+	if t, err = p.descend(t, key[:len(key)-1]); err != nil {
+		return err
+	}
+	name := key[len(key)-1]
+	if _, ok := t[name]; ok {
+		return p.errorf("key %q is already defined", strings.Join(key, "."))
+	}
+	t[name] = v
+	return nil
+}
+
+// parseKey parses a dotted key of bare and quoted parts.
+func (p *tomlParser) parseKey() (key []string, err error) {
+	for {
+		var k string
+		switch c := p.peek(); {
+		case c == '"':
+			k, err = p.parseBasicString()
+		case c == '\'':
+			k, err = p.parseLiteralString()
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.src[p.pos]) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("invalid key character %q", c)
+			}
+			k = p.src[start:p.pos]
+		}
+		if err != nil {
+			return
+		}
+		key = append(key, k)
+		p.skipSpace()
+		if p.peek() != '.' {
+			return
+		}
+		p.pos++
+		p.skipSpace()
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '"':
+		if p.hasPrefix(`"""`) {
+			return p.parseMultilineString(`"""`)
+		}
+		return p.parseBasicString()
+	case c == '\'':
+		if p.hasPrefix("'''") {
+			return p.parseMultilineString("'''")
+		}
+		return p.parseLiteralString()
+	case c == '[':
+		return p.parseArray()
+	case c == '{':
+		return p.parseInlineTable()
+	case p.hasPrefix("true"):
+		p.pos += 4
+		return true, nil
+	case p.hasPrefix("false"):
+		p.pos += 5
+		return false, nil
+	case c == 0 || c == '\n' || c == '\r' || c == '#':
+		return nil, p.errorf("missing value")
+	}
+	return p.parseScalar()
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++ // "
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("unterminated string")
+		}
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return b.String(), nil
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++ // '
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end == -1 || p.src[p.pos+end] != '\'' {
+		return "", p.errorf("unterminated string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// parseMultilineString parses a multi-line basic or literal string, depending
+// on the delimiter.
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	p.pos += 3
+	// A newline immediately following the opening delimiter is trimmed
+	if p.hasPrefix("\r\n") {
+		p.pos += 2
+	} else if p.peek() == '\n' {
+		p.pos++
+	}
+	var b strings.Builder
+	for {
+		if p.eof() {
+			return "", p.errorf("unterminated multi-line string")
+		}
+		if p.hasPrefix(delim) {
+			// Up to two quotes are allowed right before the closing delimiter
+			for n := 0; n < 2 && p.hasPrefix(delim+delim[:1]); n++ {
+				b.WriteByte(delim[0])
+				p.pos++
+			}
+			p.pos += 3
+			return b.String(), nil
+		}
+		c := p.src[p.pos]
+		if c == '\\' && delim == `"""` {
+			// Line ending backslash trims the following whitespace
+			rest := strings.TrimLeft(p.src[p.pos+1:], " \t")
+			if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+				p.pos = len(p.src) - len(strings.TrimLeft(rest, " \t\r\n"))
+				continue
+			}
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteByte(c)
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	p.pos++ // \
+	if p.eof() {
+		return p.errorf("unterminated string")
+	}
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 't':
+		b.WriteByte('\t')
+	case 'n':
+		b.WriteByte('\n')
+	case 'f':
+		b.WriteByte('\f')
+	case 'r':
+		b.WriteByte('\r')
+	case '"':
+		b.WriteByte('"')
+	case '\\':
+		b.WriteByte('\\')
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.src) {
+			return p.errorf("invalid unicode escape")
+		}
+		r, err := strconv.ParseUint(p.src[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("invalid unicode escape %q", p.src[p.pos:p.pos+n])
+		}
+		b.WriteRune(rune(r))
+		p.pos += n
+	default:
+		return p.errorf("invalid escape sequence \\%c", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseArray() ([]interface{}, error) {
+	p.pos++ // [
+	a := []interface{}{}
+	for {
+		p.skipBlank()
+		if p.peek() == ']' {
+			p.pos++
+			return a, nil
+		}
+		v, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return a, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]interface{}, error) {
+	p.pos++ // {
+	t := map[string]interface{}{}
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return t, nil
+	}
+	for {
+		if err := p.parseKeyValue(t); err != nil {
+			return nil, err
+		}
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+			p.skipSpace()
+		case '}':
+			p.pos++
+			return t, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
+
+// parseScalar parses numbers and date-times.
+func (p *tomlParser) parseScalar() (interface{}, error) {
+	start := p.pos
+	p.scanToken()
+	// Date and time may be separated by a space
+	if p.pos-start == 10 && p.src[start+4] == '-' && p.hasPrefix(" ") &&
+		p.pos+1 < len(p.src) && p.src[p.pos+1] >= '0' && p.src[p.pos+1] <= '9' {
+		p.pos++
+		p.scanToken()
+	}
+	s := p.src[start:p.pos]
+
+	if t, ok := parseTOMLTime(s); ok {
+		return t, nil
+	}
+	switch s {
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	case "nan", "+nan", "-nan":
+		return math.NaN(), nil
+	}
+	if strings.HasPrefix(s, "_") || strings.HasSuffix(s, "_") || strings.Contains(s, "__") {
+		return nil, p.errorf("invalid number %q", s)
+	}
+	n := strings.Replace(s, "_", "", -1)
+	if len(n) > 2 && n[0] == '0' {
+		base := 0
+		switch n[1] {
+		case 'x':
+			base = 16
+		case 'o':
+			base = 8
+		case 'b':
+			base = 2
+		}
+		if base != 0 {
+			v, err := strconv.ParseInt(n[2:], base, 64)
+			if err != nil {
+				return nil, p.errorf("invalid integer %q", s)
+			}
+			return v, nil
+		}
+	}
+	if strings.ContainsAny(n, ".eE") {
+		v, err := strconv.ParseFloat(n, 64)
+		if err != nil {
+			return nil, p.errorf("invalid float %q", s)
+		}
+		return v, nil
+	}
+	v, err := strconv.ParseInt(n, 10, 64)
+	if err != nil {
+		return nil, p.errorf("invalid value %q", s)
+	}
+	return v, nil
+}
+
+func (p *tomlParser) scanToken() {
+	for !p.eof() {
+		switch c := p.src[p.pos]; {
+		case isBareKeyChar(c), c == '.', c == '+', c == ':':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+var tomlTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+	"15:04:05.999999999",
+}
+
+// parseTOMLTime parses offset and local date-times, dates and times. Local
+// values are in the local time zone.
+func parseTOMLTime(s string) (time.Time, bool) {
+	if len(s) < 8 || (s[4] != '-' && s[2] != ':') {
+		return time.Time{}, false
+	}
+	s = strings.ToUpper(strings.Replace(s, " ", "T", 1))
+	for i, layout := range tomlTimeLayouts {
+		var (
+			t   time.Time
+			err error
+		)
+		if i == 0 {
+			t, err = time.Parse(layout, s)
+		} else {
+			t, err = time.ParseInLocation(layout, s, time.Local)
+		}
+		if err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// decodeTOML assigns the parsed value `v` to `rv`. `key` is the dotted key of
+// the value, used in error messages.
+func decodeTOML(key string, v interface{}, rv reflect.Value) error {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return decodeTOML(key, v, rv.Elem())
+	}
+	mismatch := func() error {
+		if key == "" {
+			return fmt.Errorf("toml: cannot decode %s into %s", tomlTypeName(v), rv.Type())
+		}
+		return fmt.Errorf("toml: cannot decode %s into %s for key %q", tomlTypeName(v), rv.Type(), key)
+	}
+
+	switch {
+	case rv.Type() == timeType:
+		t, ok := v.(time.Time)
+		if !ok {
+			return mismatch()
+		}
+		rv.Set(reflect.ValueOf(t))
+		return nil
+	case rv.Type() == durationType:
+		if s, ok := v.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("toml: invalid duration for key %q: %v", key, err)
+			}
+			rv.SetInt(int64(d))
+			return nil
+		}
+	case rv.CanAddr() && reflect.PtrTo(rv.Type()).Implements(textUnmarshalerType):
+		if s, ok := v.(string); ok {
+			return rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return mismatch()
+		}
+		rv.Set(reflect.ValueOf(v))
+	case reflect.Struct:
+		t, ok := v.(map[string]interface{})
+		if !ok {
+			return mismatch()
+		}
+		return decodeTOMLStruct(key, t, rv)
+	case reflect.Map:
+		t, ok := v.(map[string]interface{})
+		if !ok || rv.Type().Key().Kind() != reflect.String {
+			return mismatch()
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.MakeMap(rv.Type()))
+		}
+		for k, item := range t {
+			elem := reflect.New(rv.Type().Elem()).Elem()
+			if err := decodeTOML(joinTOMLKey(key, k), item, elem); err != nil {
+				return err
+			}
+			rv.SetMapIndex(reflect.ValueOf(k).Convert(rv.Type().Key()), elem)
+		}
+	case reflect.Slice, reflect.Array:
+		items := reflect.ValueOf(v)
+		if items.Kind() != reflect.Slice {
+			return mismatch()
+		}
+		n := items.Len()
+		if rv.Kind() == reflect.Slice {
+			rv.Set(reflect.MakeSlice(rv.Type(), n, n))
+		} else if n > rv.Len() {
+			return mismatch()
+		}
+		for i := 0; i < n; i++ {
+			if err := decodeTOML(fmt.Sprintf("%s[%d]", key, i), items.Index(i).Interface(), rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			return mismatch()
+		}
+		rv.SetString(s)
+	case reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return mismatch()
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := v.(int64)
+		if !ok || rv.OverflowInt(i) {
+			return mismatch()
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i, ok := v.(int64)
+		if !ok || i < 0 || rv.OverflowUint(uint64(i)) {
+			return mismatch()
+		}
+		rv.SetUint(uint64(i))
+	case reflect.Float32, reflect.Float64:
+		switch f := v.(type) {
+		case float64:
+			rv.SetFloat(f)
+		case int64:
+			rv.SetFloat(float64(f))
+		default:
+			return mismatch()
+		}
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+func decodeTOMLStruct(key string, t map[string]interface{}, rv reflect.Value) error {
+	typ := rv.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := strings.Split(f.Tag.Get("toml"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			// Embedded struct fields are promoted
+			if err := decodeTOMLStruct(key, t, rv.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if f.PkgPath != "" { // Unexported
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		v, ok := t[name]
+		if !ok {
+			for k, item := range t {
+				if strings.EqualFold(k, name) {
+					name, v, ok = k, item, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		if err := decodeTOML(joinTOMLKey(key, name), v, rv.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func joinTOMLKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func tomlTypeName(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case int64:
+		return "integer"
+	case float64:
+		return "float"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "date-time"
+	case []interface{}, []map[string]interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "table"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,183 @@
+package echo
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalTOML(t *testing.T) {
+	type (
+		server struct {
+			Name string
+			IP   net.IP `toml:"ip"`
+		}
+		base struct {
+			Title string `toml:"title"`
+		}
+		config struct {
+			base
+			Owner struct {
+				Name string    `toml:"name"`
+				DOB  time.Time `toml:"dob"`
+			} `toml:"owner"`
+			Database struct {
+				Ports   []uint16      `toml:"ports"`
+				Enabled bool          `toml:"enabled"`
+				Limit   float64       `toml:"limit"`
+				Timeout time.Duration `toml:"timeout"`
+				Data    [][]interface{}
+			} `toml:"database"`
+			Servers  map[string]*server `toml:"servers"`
+			Products []struct {
+				Name  string `toml:"name"`
+				SKU   int64  `toml:"sku"`
+				Color string `toml:"color"`
+			} `toml:"products"`
+			Point   map[string]int `toml:"point"`
+			Strings struct {
+				Escaped   string
+				Literal   string
+				Multiline string
+				Trimmed   string
+				Raw       string
+			}
+			Numbers struct {
+				Hex, Oct, Bin, Underscore int
+				Exp, Inf                  float64
+			}
+			Ignored string `toml:"-"`
+		}
+	)
+
+	doc := `
+# This is a TOML document
+title = "TOML Example"
+point = { x = 1, y = 2 }
+
+[owner]
+name = "Tom Preston-Werner"
+dob = 1979-05-27T07:32:00-08:00
+
+[database]
+enabled = true
+ports = [ 8000, 8001, 8002 ]
+data = [ ["delta", "phi"], [3.14] ]
+limit = 5 # Integer into float
+timeout = "5s"
+
+[servers]
+
+  [servers.alpha]
+  ip = "10.0.0.1"
+  name = "alpha"
+
+  [servers."beta"]
+  ip = "10.0.0.2"
+
+[[products]]
+name = "Hammer"
+sku = 738594937
+
+[[products]]  # empty table within the array
+
+[[products]]
+name = "Nail"
+sku = 284758393
+color = "gray"
+
+[strings]
+escaped = "tab\tquote\" \u00e9"
+literal = 'C:\Users\nodejs'
+multiline = """
+Roses are red
+Violets are blue"""
+trimmed = """\
+  The quick brown \
+  fox."""
+raw = '''
+I [dw]on't need \d{2} apples'''
+
+[numbers]
+hex = 0xDEAD_BEEF
+oct = 0o755
+bin = 0b1101
+underscore = 1_000
+exp = -5e+22
+inf = inf
+ignored = "x"
+`
+	c := new(config)
+	c.Ignored = "keep"
+	if assert.NoError(t, unmarshalTOML([]byte(doc), c)) {
+		assert.Equal(t, "TOML Example", c.Title)
+		assert.Equal(t, "Tom Preston-Werner", c.Owner.Name)
+		assert.True(t, c.Owner.DOB.Equal(time.Date(1979, 5, 27, 15, 32, 0, 0, time.UTC)))
+		assert.Equal(t, []uint16{8000, 8001, 8002}, c.Database.Ports)
+		assert.True(t, c.Database.Enabled)
+		assert.Equal(t, 5.0, c.Database.Limit)
+		assert.Equal(t, 5*time.Second, c.Database.Timeout)
+		assert.Equal(t, [][]interface{}{{"delta", "phi"}, {3.14}}, c.Database.Data)
+		if assert.Len(t, c.Servers, 2) {
+			assert.Equal(t, &server{Name: "alpha", IP: net.ParseIP("10.0.0.1")}, c.Servers["alpha"])
+			assert.Equal(t, net.ParseIP("10.0.0.2"), c.Servers["beta"].IP)
+		}
+		if assert.Len(t, c.Products, 3) {
+			assert.Equal(t, "Hammer", c.Products[0].Name)
+			assert.Empty(t, c.Products[1].Name)
+			assert.Equal(t, "gray", c.Products[2].Color)
+		}
+		assert.Equal(t, "tab\tquote\" é", c.Strings.Escaped)
+		assert.Equal(t, `C:\Users\nodejs`, c.Strings.Literal)
+		assert.Equal(t, "Roses are red\nViolets are blue", c.Strings.Multiline)
+		assert.Equal(t, "The quick brown fox.", c.Strings.Trimmed)
+		assert.Equal(t, `I [dw]on't need \d{2} apples`, c.Strings.Raw)
+		assert.Equal(t, 0xDEADBEEF, c.Numbers.Hex)
+		assert.Equal(t, 0755, c.Numbers.Oct)
+		assert.Equal(t, 13, c.Numbers.Bin)
+		assert.Equal(t, 1000, c.Numbers.Underscore)
+		assert.Equal(t, -5e+22, c.Numbers.Exp)
+		assert.True(t, math.IsInf(c.Numbers.Inf, 1))
+		assert.Equal(t, "keep", c.Ignored)
+		assert.Equal(t, map[string]int{"x": 1, "y": 2}, c.Point)
+	}
+
+	// Generic
+	m := map[string]interface{}{}
+	if assert.NoError(t, unmarshalTOML([]byte("a.b = 1\n[c]\nd = [true]\n"), &m)) {
+		assert.Equal(t, map[string]interface{}{
+			"a": map[string]interface{}{"b": int64(1)},
+			"c": map[string]interface{}{"d": []interface{}{true}},
+		}, m)
+	}
+}
+
+func TestUnmarshalTOMLErrors(t *testing.T) {
+	tests := []struct {
+		doc string
+		err string
+	}{
+		{"a = 1\na = 2", `toml: line 2: key "a" is already defined`},
+		{"[a]\n[a]", `toml: line 2: table "a" is already defined`},
+		{"a = 1\n[[a]]", `toml: line 2: key "a" is already defined`},
+		{"a = 1 2", `toml: line 1: unexpected '2' after value`},
+		{"a = \"b", `toml: line 1: unterminated string`},
+		{"a = 1__0", `toml: line 1: invalid number "1__0"`},
+		{"a =", `toml: line 1: missing value`},
+		{"a = [1 2]", `toml: line 1: expected ',' or ']' in array`},
+		{"= 1", `toml: line 1: invalid key character '='`},
+		{"a = \"\\q\"", `toml: line 1: invalid escape sequence \q`},
+		{"n = 1.5", `toml: cannot decode float into int for key "n"`},
+		{"id = 300", `toml: cannot decode integer into uint8 for key "id"`},
+	}
+	for _, tt := range tests {
+		var v struct {
+			ID uint8
+			N  int
+		}
+		assert.EqualError(t, unmarshalTOML([]byte(tt.doc), &v), tt.err, tt.doc)
+	}
+}
@@ -0,0 +1,158 @@
+package echo
+
+import (
+	"strings"
+)
+
+type (
+	// UserAgent is a parsed "User-Agent" header, see `Context#UserAgent()`.
+	// Parsing is heuristic, detecting the common browsers, operating systems
+	// and bots.
+	UserAgent struct {
+		// Raw is the header value.
+		Raw string `json:"raw"`
+
+		// Browser is the browser, e.g. "Chrome", "Firefox" or "Safari".
+		Browser string `json:"browser,omitempty"`
+
+		// BrowserVersion is the version of the browser, e.g. "120.0.6099.71".
+		BrowserVersion string `json:"browser_version,omitempty"`
+
+		// OS is the operating system, e.g. "Windows", "macOS", "iOS" or
+		// "Android".
+		OS string `json:"os,omitempty"`
+
+		// OSVersion is the version of the operating system, e.g. "17.1".
+		OSVersion string `json:"os_version,omitempty"`
+
+		// Device is the class of the device.
+		Device DeviceClass `json:"device"`
+
+		// Bot reports whether the client is a bot, e.g. a crawler or a script.
+		Bot bool `json:"bot"`
+	}
+
+	// DeviceClass is the class of a device.
+	DeviceClass string
+)
+
+// Device classes
+const (
+	DeviceDesktop DeviceClass = "desktop"
+	DeviceMobile  DeviceClass = "mobile"
+	DeviceTablet  DeviceClass = "tablet"
+	DeviceBot     DeviceClass = "bot"
+	DeviceUnknown DeviceClass = "unknown"
+)
+
+var (
+	// botTokens are lower case substrings of the user agents of bots.
+	botTokens = []string{
+		"bot", "crawl", "spider", "slurp", "mediapartners", "facebookexternalhit",
+		"headlesschrome", "lighthouse", "curl/", "wget/", "python-requests",
+		"python-urllib", "go-http-client", "java/", "okhttp", "axios/", "libwww",
+		"httpclient", "scrapy", "phantomjs",
+	}
+
+	// browsers are the tokens of the browsers, most specific first, since
+	// user agents list the browsers they are compatible with.
+	browsers = []struct {
+		token, name string
+	}{
+		{"Edg/", "Edge"},
+		{"EdgA/", "Edge"},
+		{"EdgiOS/", "Edge"},
+		{"OPR/", "Opera"},
+		{"SamsungBrowser/", "Samsung Internet"},
+		{"YaBrowser/", "Yandex"},
+		{"FxiOS/", "Firefox"},
+		{"Firefox/", "Firefox"},
+		{"CriOS/", "Chrome"},
+		{"Chrome/", "Chrome"},
+		{"Version/", "Safari"},
+		{"MSIE ", "Internet Explorer"},
+		{"Trident/", "Internet Explorer"},
+	}
+)
+
+// ParseUserAgent parses the value of a "User-Agent" header.
+func ParseUserAgent(ua string) *UserAgent {
+	u := &UserAgent{Raw: ua, Device: DeviceUnknown}
+	if ua == "" {
+		return u
+	}
+	lower := strings.ToLower(ua)
+	for _, t := range botTokens {
+		if strings.Contains(lower, t) {
+			u.Bot = true
+			break
+		}
+	}
+
+	for _, b := range browsers {
+		if i := strings.Index(ua, b.token); i != -1 {
+			u.Browser = b.name
+			u.BrowserVersion = versionAt(ua, i+len(b.token))
+			if j := strings.Index(ua, "rv:"); b.token == "Trident/" && j != -1 {
+				u.BrowserVersion = versionAt(ua, j+3)
+			}
+			break
+		}
+	}
+	if u.Browser == "Safari" && !strings.Contains(ua, "Safari/") {
+		u.Browser, u.BrowserVersion = "", ""
+	}
+
+	switch {
+	case strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPad") || strings.Contains(ua, "iPod"):
+		u.OS = "iOS"
+		if i := strings.Index(ua, "OS "); i != -1 {
+			u.OSVersion = strings.Replace(versionAt(ua, i+3), "_", ".", -1)
+		}
+	case strings.Contains(ua, "Android"):
+		u.OS = "Android"
+		u.OSVersion = versionAt(ua, strings.Index(ua, "Android")+len("Android "))
+	case strings.Contains(ua, "Windows"):
+		u.OS = "Windows"
+		if i := strings.Index(ua, "Windows NT "); i != -1 {
+			u.OSVersion = versionAt(ua, i+len("Windows NT "))
+		}
+	case strings.Contains(ua, "Mac OS X"):
+		u.OS = "macOS"
+		u.OSVersion = strings.Replace(versionAt(ua, strings.Index(ua, "Mac OS X")+len("Mac OS X ")), "_", ".", -1)
+	case strings.Contains(ua, "CrOS"):
+		u.OS = "Chrome OS"
+	case strings.Contains(ua, "Linux"):
+		u.OS = "Linux"
+	}
+
+	switch {
+	case u.Bot:
+		u.Device = DeviceBot
+	case strings.Contains(ua, "iPad") || strings.Contains(ua, "Tablet") ||
+		(u.OS == "Android" && !strings.Contains(ua, "Mobile")):
+		u.Device = DeviceTablet
+	case strings.Contains(ua, "Mobi") || u.OS == "iOS" || u.OS == "Android":
+		u.Device = DeviceMobile
+	case u.OS != "":
+		u.Device = DeviceDesktop
+	}
+	return u
+}
+
+// versionAt returns the version starting at index i of the user agent, e.g.
+// "120.0.1" or "10_15_7".
+func versionAt(ua string, i int) string {
+	if i < 0 || i > len(ua) {
+		return ""
+	}
+	j := i
+	for j < len(ua) {
+		c := ua[j]
+		if (c < '0' || c > '9') && c != '.' && c != '_' {
+			break
+		}
+		j++
+	}
+	return strings.TrimRight(ua[i:j], "._")
+}
@@ -0,0 +1,54 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		ua                                  string
+		browser, browserVersion, os, osVers string
+		device                              DeviceClass
+		bot                                 bool
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", "Chrome", "120.0.0.0", "Windows", "10.0", DeviceDesktop, false},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.61", "Edge", "120.0.2210.61", "Windows", "10.0", DeviceDesktop, false},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15", "Safari", "17.1", "macOS", "10.15.7", DeviceDesktop, false},
+		{"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0", "Firefox", "120.0", "Linux", "", DeviceDesktop, false},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1", "Safari", "17.1", "iOS", "17.1", DeviceMobile, false},
+		{"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/119.0.6045.169 Mobile/15E148 Safari/604.1", "Chrome", "119.0.6045.169", "iOS", "16.6", DeviceTablet, false},
+		{"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.43 Mobile Safari/537.36", "Chrome", "120.0.6099.43", "Android", "14", DeviceMobile, false},
+		{"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", "Chrome", "120.0.0.0", "Android", "13", DeviceTablet, false},
+		{"Mozilla/5.0 (Windows NT 6.1; Trident/7.0; rv:11.0) like Gecko", "Internet Explorer", "11.0", "Windows", "6.1", DeviceDesktop, false},
+		{"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", "", "", "", "", DeviceBot, true},
+		{"curl/8.4.0", "", "", "", "", DeviceBot, true},
+		{"", "", "", "", "", DeviceUnknown, false},
+	}
+	for _, tt := range tests {
+		u := ParseUserAgent(tt.ua)
+		assert.Equal(t, tt.ua, u.Raw)
+		assert.Equal(t, tt.browser, u.Browser, tt.ua)
+		assert.Equal(t, tt.browserVersion, u.BrowserVersion, tt.ua)
+		assert.Equal(t, tt.os, u.OS, tt.ua)
+		assert.Equal(t, tt.osVers, u.OSVersion, tt.ua)
+		assert.Equal(t, tt.device, u.Device, tt.ua)
+		assert.Equal(t, tt.bot, u.Bot, tt.ua)
+	}
+}
+
+func TestContextUserAgent(t *testing.T) {
+	e := New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("User-Agent", "curl/8.4.0")
+	c := e.NewContext(req, httptest.NewRecorder())
+	ua := c.UserAgent()
+	assert.True(t, ua.Bot)
+	assert.Same(t, ua, c.UserAgent())
+
+	c.Reset(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	assert.False(t, c.UserAgent().Bot)
+}
@@ -0,0 +1,118 @@
+package echo
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+type (
+	// SecurityTxt is a security.txt file (RFC 9116), telling security
+	// researchers how to report vulnerabilities.
+	SecurityTxt struct {
+		// Contact lists the URIs to report vulnerabilities to, e.g.
+		// "mailto:security@example.com".
+		// Required.
+		Contact []string
+
+		// Expires is when the file becomes stale.
+		// Required.
+		Expires time.Time
+
+		// Encryption is the URI of the key to encrypt reports with.
+		Encryption string
+
+		// Acknowledgments is the URI of the page thanking researchers.
+		Acknowledgments string
+
+		// PreferredLanguages lists the languages of reports, e.g. "en".
+		PreferredLanguages []string
+
+		// Canonical is the URI the file is served at.
+		Canonical string
+
+		// Policy is the URI of the vulnerability disclosure policy.
+		Policy string
+
+		// Hiring is the URI of the security job openings.
+		Hiring string
+	}
+)
+
+// Favicon registers a route serving the favicon.ico file, cached by clients
+// for a day.
+func (e *Echo) Favicon(file string) *Route {
+	return e.GET("/favicon.ico", func(c Context) error {
+		c.Response().Header().Set(HeaderCacheControl, "public, max-age=86400")
+		return c.File(file)
+	})
+}
+
+// FaviconFS registers a route serving the favicon.ico file `name` of a
+// filesystem, e.g. an embedded filesystem wrapped with `http.FS()`.
+func (e *Echo) FaviconFS(fsys http.FileSystem, name string) *Route {
+	return e.GET("/favicon.ico", func(c Context) error {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return ErrNotFound
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(HeaderCacheControl, "public, max-age=86400")
+		http.ServeContent(c.Response(), c.Request(), fi.Name(), fi.ModTime(), f)
+		return nil
+	})
+}
+
+// WellKnown registers a GET route for the well-known URI `name` (RFC 8615),
+// e.g. "security.txt" for "/.well-known/security.txt".
+//
+// Example serving the Android asset links:
+//
+//	e.WellKnown("assetlinks.json", func(c echo.Context) error {
+//	  return c.JSON(http.StatusOK, assetLinks)
+//	})
+func (e *Echo) WellKnown(name string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.GET(path.Join("/.well-known", name), h, m...)
+}
+
+// SecurityTxtHandler returns a handler serving the security.txt file, see
+// `Echo#WellKnown()`.
+func SecurityTxtHandler(s SecurityTxt) HandlerFunc {
+	b := new(strings.Builder)
+	field := func(name, val string) {
+		if val != "" {
+			fmt.Fprintf(b, "%s: %s\n", name, val)
+		}
+	}
+	for _, c := range s.Contact {
+		field("Contact", c)
+	}
+	if !s.Expires.IsZero() {
+		field("Expires", s.Expires.UTC().Format(time.RFC3339))
+	}
+	field("Encryption", s.Encryption)
+	field("Acknowledgments", s.Acknowledgments)
+	field("Preferred-Languages", strings.Join(s.PreferredLanguages, ", "))
+	field("Canonical", s.Canonical)
+	field("Policy", s.Policy)
+	field("Hiring", s.Hiring)
+	body := []byte(b.String())
+	return func(c Context) error {
+		return c.Blob(http.StatusOK, MIMETextPlainCharsetUTF8, body)
+	}
+}
+
+// ChangePasswordHandler returns a handler redirecting to the change password
+// page at url, for the "change-password" well-known URI password managers
+// link to, see `Echo#WellKnown()`.
+func ChangePasswordHandler(url string) HandlerFunc {
+	return func(c Context) error {
+		return c.Redirect(http.StatusFound, url)
+	}
+}
@@ -0,0 +1,56 @@
+package echo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEchoFavicon(t *testing.T) {
+	e := New()
+	e.Favicon("_fixture/favicon.ico")
+	code, body := request(http.MethodGet, "/favicon.ico", e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, body)
+
+	e = New()
+	e.FaviconFS(http.Dir("_fixture"), "favicon.ico")
+	req := httptest.NewRequest(http.MethodGet, "/favicon.ico", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "public, max-age=86400", rec.Header().Get(HeaderCacheControl))
+	assert.NotEmpty(t, rec.Header().Get(HeaderLastModified))
+
+	e = New()
+	e.FaviconFS(http.Dir("_fixture"), "missing.ico")
+	code, _ = request(http.MethodGet, "/favicon.ico", e)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestEchoWellKnown(t *testing.T) {
+	e := New()
+	e.WellKnown("security.txt", SecurityTxtHandler(SecurityTxt{
+		Contact:            []string{"mailto:security@example.com", "https://example.com/security"},
+		Expires:            time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		PreferredLanguages: []string{"en", "de"},
+	}))
+	e.WellKnown("change-password", ChangePasswordHandler("/account/password"))
+
+	code, body := request(http.MethodGet, "/.well-known/security.txt", e)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, `Contact: mailto:security@example.com
+Contact: https://example.com/security
+Expires: 2030-01-01T00:00:00Z
+Preferred-Languages: en, de
+`, body)
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/change-password", nil)
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/account/password", rec.Header().Get(HeaderLocation))
+}
@@ -0,0 +1,10 @@
+package echo
+
+type (
+	// YAMLCodec encodes and decodes YAML for `Context#YAML()` and the binding of
+	// YAML request bodies, see package yaml for a gopkg.in/yaml.v2 adapter.
+	YAMLCodec interface {
+		Marshal(v interface{}) ([]byte, error)
+		Unmarshal(data []byte, v interface{}) error
+	}
+)
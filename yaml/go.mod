@@ -0,0 +1,11 @@
+module github.com/labstack/echo/v4/yaml
+
+go 1.14
+
+require (
+	github.com/labstack/echo/v4 v4.1.16
+	github.com/stretchr/testify v1.4.0
+	gopkg.in/yaml.v2 v2.2.2
+)
+
+replace github.com/labstack/echo/v4 => ../
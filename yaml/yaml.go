@@ -0,0 +1,37 @@
+/*
+Package yaml adapts gopkg.in/yaml.v2 as the YAML codec of `Context#YAML()` and
+of the binding of YAML request bodies. It is a module of its own so that only
+applications using it depend on a YAML library:
+
+	go get github.com/labstack/echo/v4/yaml
+
+Example:
+
+	e.YAML = yaml.Codec()
+	e.GET("/config", func(c echo.Context) error {
+	  return c.YAML(http.StatusOK, config)
+	})
+*/
+package yaml
+
+import (
+	"github.com/labstack/echo/v4"
+	yamlv2 "gopkg.in/yaml.v2"
+)
+
+type codec struct{}
+
+// Codec returns a YAML codec encoding and decoding with gopkg.in/yaml.v2.
+func Codec() echo.YAMLCodec {
+	return codec{}
+}
+
+// Marshal implements `echo.YAMLCodec`.
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return yamlv2.Marshal(v)
+}
+
+// Unmarshal implements `echo.YAMLCodec`.
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return yamlv2.Unmarshal(data, v)
+}
@@ -0,0 +1,41 @@
+package yaml
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID   int    `yaml:"id"`
+	Name string `yaml:"name"`
+}
+
+func TestCodec(t *testing.T) {
+	e := echo.New()
+	e.YAML = Codec()
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("id: 1\nname: Jon Snow\n"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationYAML)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	u := new(user)
+	if assert.NoError(t, c.Bind(u)) {
+		assert.Equal(t, &user{1, "Jon Snow"}, u)
+	}
+	if assert.NoError(t, c.YAML(http.StatusOK, u)) {
+		assert.Equal(t, echo.MIMEApplicationYAMLCharsetUTF8, rec.Header().Get(echo.HeaderContentType))
+		assert.Equal(t, "id: 1\nname: Jon Snow\n", rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("id: [1"))
+	req.Header.Set(echo.HeaderContentType, echo.MIMETextYAML)
+	err := e.NewContext(req, httptest.NewRecorder()).Bind(u)
+	if assert.IsType(t, new(echo.HTTPError), err) {
+		assert.Equal(t, http.StatusBadRequest, err.(*echo.HTTPError).Code)
+	}
+}
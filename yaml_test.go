@@ -0,0 +1,15 @@
+package echo
+
+import "encoding/json"
+
+// jsonYAMLCodec is a `YAMLCodec` for tests, as JSON documents are YAML
+// documents.
+type jsonYAMLCodec struct{}
+
+func (jsonYAMLCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonYAMLCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}